@@ -54,6 +54,12 @@ const docTemplate = `{
                         "name": "longitude",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Elevation in metres",
+                        "name": "elevation",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -224,7 +230,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/peripheral/hrm/{hrmid}": {
+        "/api/v1/peripheral/hrm/{hrm_id}": {
             "put": {
                 "consumes": [
                     "application/json"
@@ -285,7 +291,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Type of HRM reading (avg/normal)",
+                        "description": "Type of HRM reading (avg/normal/samples)",
                         "name": "type",
                         "in": "query",
                         "required": true
@@ -318,6 +324,40 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "/api/v1/peripheral/pause": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peripheral"
+                ],
+                "summary": "Pause or resume a bound peripheral",
+                "operationId": "pause-peripheral",
+                "parameters": [
+                    {
+                        "description": "Pause Peripheral Data",
+                        "name": "pauseData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httphandler.PausePeripheralData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "success message: peripheral paused or resumed"
+                    },
+                    "400": {
+                        "description": "error message: invalid request with details"
+                    }
+                }
+            }
         }
     },
     "definitions": {
@@ -366,6 +406,19 @@ const docTemplate = `{
                 }
             }
         },
+        "httphandler.PausePeripheralData": {
+            "type": "object",
+            "properties": {
+                "paused": {
+                    "description": "Whether the live publishing is paused",
+                    "type": "boolean"
+                },
+                "workout_id": {
+                    "description": "WorkoutID of the workout paused or resumed",
+                    "type": "string"
+                }
+            }
+        },
         "httphandler.UnbindPeripheralData": {
             "type": "object",
             "properties": {
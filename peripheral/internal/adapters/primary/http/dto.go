@@ -13,6 +13,8 @@ type LastLocation struct {
 	Latitude float64 `json:"latitude"`
 	// Longitude of the Player
 	Longitude float64 `json:"longitude"`
+	// Elevation of the Player in metres, left out when it is not known
+	Elevation *float64 `json:"elevation,omitempty"`
 	// Time of location
 	TimeOfLocation time.Time `json:"time_of_location"`
 }
@@ -32,6 +34,20 @@ type AverageHeartRate struct {
 	AverageHeartRate uint8 `json:"heart_rate"`
 }
 
+type HeartRateSample struct {
+	// Heart rate reading
+	HeartRate int `json:"heart_rate"`
+	// Time of reading
+	TimeOfReading time.Time `json:"time_of_reading"`
+}
+
+type HeartRateSamples struct {
+	// WorkoutID the readings were recorded for
+	WorkoutID uuid.UUID `json:"workout_id"`
+	// Heart rate readings in the order they were received
+	Samples []HeartRateSample `json:"samples"`
+}
+
 type BindPeripheralData struct {
 	// PlayerID
 	PlayerID uuid.UUID `json:"player_id"`
@@ -47,6 +63,13 @@ type BindPeripheralData struct {
 	SendLiveLocationToTrailManager bool `json:"send_live_location_to_trail_manager"`
 }
 
+type PausePeripheralData struct {
+	// WorkoutID of the workout paused or resumed
+	WorkoutID uuid.UUID `json:"workout_id"`
+	// Whether the live publishing is paused
+	Paused bool `json:"paused"`
+}
+
 type UnbindPeripheralData struct {
 	// WorkoutID for the workout to be stopped
 	WorkoutID uuid.UUID `json:"workout_id"`
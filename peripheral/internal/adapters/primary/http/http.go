@@ -44,6 +44,7 @@ func (handler *HTTPHandler) InitRouter() {
 
 	router.POST("/peripheral", handler.BindPeripheralToData)
 	router.PUT("/peripheral", handler.UnbindPeripheralToData)
+	router.PUT("/peripheral/pause", handler.PausePeripheralData)
 
 	// HRM
 	router.POST("/peripheral/hrm", handler.connectHRM)
@@ -161,7 +162,7 @@ func (h *HTTPHandler) BindPeripheralToData(ctx *gin.Context) {
 		return
 	}
 
-	err := h.svc.BindPeripheral(bindDataInstance.PlayerID, bindDataInstance.WorkoutID, bindDataInstance.HRMId, bindDataInstance.HRMConnect, bindDataInstance.SendLiveLocationToTrailManager)
+	err := h.svc.BindPeripheral(bindDataInstance.PlayerID, bindDataInstance.WorkoutID, bindDataInstance.HRMId, bindDataInstance.TrailOfWorkout, bindDataInstance.HRMConnect, bindDataInstance.SendLiveLocationToTrailManager)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"error": "failed to bind workout",
@@ -230,6 +231,43 @@ func (h *HTTPHandler) UnbindPeripheralToData(ctx *gin.Context) {
 		"message": "peripheral unbound from workout"})
 }
 
+// PausePeripheralData pauses or resumes the live publishing of the peripheral bound to a workout.
+//
+//	@Summary	Pause or resume a bound peripheral
+//	@Tags		peripheral
+//	@ID			pause-peripheral
+//	@Accept		json
+//	@Produce	json
+//	@Param		pauseData	body	PausePeripheralData	true	"Pause Peripheral Data"
+//	@Success	200			"success message: peripheral paused or resumed"
+//	@Failure	400			"error message: invalid request with details"
+//	@Router		/api/v1/peripheral/pause [put]
+func (h *HTTPHandler) PausePeripheralData(ctx *gin.Context) {
+	var req PausePeripheralData
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request parameters",
+		})
+		return
+	}
+
+	if err := h.svc.SetPausedStatus(req.WorkoutID, req.Paused); err != nil {
+		log.Debug("peripheral: failed to set paused status", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "failed to pause peripheral",
+		})
+		return
+	}
+
+	if req.Paused {
+		log.Info("peripheral paused", zap.Any("workout_id", req.WorkoutID))
+		ctx.JSON(http.StatusOK, gin.H{"message": "peripheral paused"})
+		return
+	}
+	log.Info("peripheral resumed", zap.Any("workout_id", req.WorkoutID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "peripheral resumed"})
+}
+
 // getHRMReading retrieves Heart Rate Monitor (HRM) reading data.
 //
 //	@Summary	Get average heart rate
@@ -238,7 +276,7 @@ func (h *HTTPHandler) UnbindPeripheralToData(ctx *gin.Context) {
 //	@Accept		json
 //	@Produce	json
 //	@Param		workout_id	path		string				true	"Workout ID"	format(uuid)
-//	@Param		type		query		string				true	"Type of HRM reading (avg/normal)"
+//	@Param		type		query		string				true	"Type of HRM reading (avg/normal/samples)"
 //	@Success	200			{object}	LastHR				"HRM reading data"
 //	@Failure	400			{object}	map[string]string	"status: error, message: Invalid request"
 //	@Failure	500			{object}	map[string]string	"status: error, message: Reading from device failure"
@@ -291,6 +329,23 @@ func (h *HTTPHandler) getHRMReading(ctx *gin.Context) {
 			return
 		}
 		ctx.JSON(http.StatusOK, gin.H{"reading": tLoc})
+	} else if hrType == "samples" {
+		_, samples, err := h.svc.GetHRMSamples(wId)
+		if err != nil {
+			log.Debug("peripheral: failed to read from device failure ", zap.Error(err))
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "reading from hrm failed",
+			})
+			return
+		}
+		hrSamples := HeartRateSamples{
+			WorkoutID: wId,
+			Samples:   make([]HeartRateSample, len(samples)),
+		}
+		for i, sample := range samples {
+			hrSamples.Samples[i] = HeartRateSample{HeartRate: sample.HRate, TimeOfReading: sample.HRateTime}
+		}
+		ctx.JSON(http.StatusOK, hrSamples)
 	} else {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"error": "reading from device failure, invalid argument of type",
@@ -424,6 +479,7 @@ func (h *HTTPHandler) SetGeoStatus(ctx *gin.Context) {
 //	@Param		geo_id		path	string	true	"Workout ID"	format(uuid)
 //	@Param		latitude	query	string	true	"Latitude value"
 //	@Param		longitude	query	string	true	"Longitude value"
+//	@Param		elevation	query	string	false	"Elevation in metres"
 //	@Success	200			" message, geo reading set and location sent"
 //	@Failure	400			"error message with details"
 //	@Router		/api/v1/geo/:geo_id [put]
@@ -459,15 +515,30 @@ func (h *HTTPHandler) SetGeoReading(ctx *gin.Context) {
 		return
 	}
 
+	// not every device reports the elevation
+	if elevation := ctx.Query("elevation"); elevation != "" {
+		felevation, elevationFloatErr := strconv.ParseFloat(elevation, 64)
+		if elevationFloatErr != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "error set geo status from device"})
+			return
+		}
+		tempLastLoc.Elevation = &felevation
+	}
+
 	tempLastLoc.Latitude = flatitude
 	tempLastLoc.Longitude = flongitude
-	err = h.svc.SetGeoLocation(wId, flongitude, flatitude)
+	err = h.svc.SetGeoLocation(wId, flongitude, flatitude, tempLastLoc.Elevation)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "error set geo status from device"})
 		return
 	}
+	// the location of a paused workout is kept on the device only
+	if paused, _ := h.svc.GetPausedStatus(wId); paused {
+		ctx.JSON(http.StatusOK, gin.H{"message": "geo reading set, workout paused"})
+		return
+	}
 	log.Info("peripheral: sending location to queue now")
-	go h.svc.SendLastLocation(tempLastLoc.WorkoutID, tempLastLoc.Latitude, tempLastLoc.Longitude, tempLastLoc.TimeOfLocation)
+	go h.svc.SendLastLocation(tempLastLoc.WorkoutID, tempLastLoc.Latitude, tempLastLoc.Longitude, tempLastLoc.Elevation, tempLastLoc.TimeOfLocation)
 	ctx.JSON(http.StatusOK, gin.H{"message": "geo reading set and location sent"})
 }
 
@@ -519,7 +590,10 @@ func (h *HTTPHandler) StartBackgroundMockReading(ctx context.Context, ctx1 conte
 					h.cancelF()
 				}
 
-				if res {
+				// nothing is published while the workout is paused
+				if paused, _ := h.svc.GetPausedStatus(wId); res && paused {
+					log.Debug("Peripheral: workout paused, not publishing to queues")
+				} else if res {
 
 					log.Debug("Peripheral: Start sending data to queues...")
 					if startLat <= latitudeEnd {
@@ -544,8 +618,8 @@ func (h *HTTPHandler) StartBackgroundMockReading(ctx context.Context, ctx1 conte
 					tLoc.TimeOfLocation = time.Now()
 					tLoc.WorkoutID = wId
 
-					go h.svc.SendLastLocation(tLoc.WorkoutID, tLoc.Latitude, tLoc.Longitude, tLoc.TimeOfLocation)
-					err2 := h.svc.SetGeoLocation(wId, tLoc.Longitude, tLoc.Latitude)
+					go h.svc.SendLastLocation(tLoc.WorkoutID, tLoc.Latitude, tLoc.Longitude, nil, tLoc.TimeOfLocation)
+					err2 := h.svc.SetGeoLocation(wId, tLoc.Longitude, tLoc.Latitude, nil)
 					if err2 != nil {
 						log.Debug("Peripheral: error sending location", zap.Error(err2))
 					}
@@ -9,10 +9,14 @@ import (
 type LastLocation struct {
 	// WorkoutID for which the Shelter Availability is there or not
 	WorkoutID uuid.UUID `json:"workout_id"`
+	// Trail of the workout, used by the zone to check the player is on it
+	TrailID uuid.UUID `json:"trail_id"`
 	// Latitude of the Player
 	Latitude float64 `json:"latitude"`
 	// Longitude of the Player
 	Longitude float64 `json:"longitude"`
+	// Elevation of the Player in metres, left out when the device does not report it
+	Elevation *float64 `json:"elevation,omitempty"`
 	// Time of location
 	TimeOfLocation time.Time `json:"time_of_location"`
 }
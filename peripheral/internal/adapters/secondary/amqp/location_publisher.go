@@ -89,12 +89,14 @@ func (handler *RabbitMQHandler) Close() {
 	}
 }
 
-func (handler *RabbitMQHandler) SendLastLocation(wId uuid.UUID, latitude float64, longitude float64, time time.Time, toTrail bool) error {
+func (handler *RabbitMQHandler) SendLastLocation(wId uuid.UUID, tId uuid.UUID, latitude float64, longitude float64, elevation *float64, time time.Time, toTrail bool) error {
 	// location := handler.peripheralService.GetGeoLocation(wId)
 	var location LastLocation
 	location.WorkoutID = wId
+	location.TrailID = tId
 	location.Latitude = latitude
 	location.Longitude = longitude
+	location.Elevation = elevation
 	location.TimeOfLocation = time
 
 	body, err := json.Marshal(location)
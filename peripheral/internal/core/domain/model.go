@@ -13,6 +13,13 @@ type HRMData struct {
 	HRMStatus    bool
 	HRateCount   int
 	AverageHRate int
+	Samples      []HRSample
+}
+
+// HRSample is a single heart rate reading kept for the bound workout
+type HRSample struct {
+	HRate     int
+	HRateTime time.Time
 }
 
 type GeoData struct {
@@ -20,17 +27,22 @@ type GeoData struct {
 	GeoStatus    bool
 	Longitude    float64
 	Latitude     float64
+	// elevation in metres, nil when the device does not report it
+	Elevation *float64
 }
 
 type Peripheral struct {
 	PlayerId   uuid.UUID
 	WorkoutId  uuid.UUID
+	TrailId    uuid.UUID
 	HRMId      uuid.UUID
 	HRMDev     HRMData
 	GeoDev     GeoData
 	CreatedAt  time.Time
 	LiveStatus bool
 	ToShelter  bool
+	// Paused while the bound workout is paused, nothing is published nor averaged
+	Paused bool
 }
 
 func (p *Peripheral) GetAverageHRate() (uuid.UUID, time.Time, int) {
@@ -43,19 +55,29 @@ func (p *Peripheral) GetHRate() (uuid.UUID, time.Time, int) {
 
 func (p *Peripheral) SetHRate(reading int) {
 	if p.HRMDev.HRMStatus {
-		p.HRMDev.AverageHRate = (p.HRMDev.AverageHRate*p.HRMDev.HRateCount + reading) * 1.0 / (1 + p.HRMDev.HRateCount)
-		p.HRMDev.HRateCount += 1
 		p.HRMDev.HRate = reading
 		p.HRMDev.HRateTime = time.Now()
+		// readings of a paused workout are shown but not kept
+		if p.Paused {
+			return
+		}
+		p.HRMDev.AverageHRate = (p.HRMDev.AverageHRate*p.HRMDev.HRateCount + reading) * 1.0 / (1 + p.HRMDev.HRateCount)
+		p.HRMDev.HRateCount += 1
+		p.HRMDev.Samples = append(p.HRMDev.Samples, HRSample{HRate: reading, HRateTime: p.HRMDev.HRateTime})
 	}
 }
 
-// function for getting the reading of longitude and lattide
-func (p *Peripheral) SetLocation(longitude float64, latitude float64) {
+func (p *Peripheral) GetHRateSamples() (uuid.UUID, []HRSample) {
+	return p.HRMId, p.HRMDev.Samples
+}
+
+// function for getting the reading of longitude, lattide and elevation
+func (p *Peripheral) SetLocation(longitude float64, latitude float64, elevation *float64) {
 	if p.GeoDev.GeoStatus {
 		p.GeoDev.LocationTime = time.Now()
 		p.GeoDev.Longitude = longitude
 		p.GeoDev.Latitude = latitude
+		p.GeoDev.Elevation = elevation
 	}
 }
 
@@ -21,20 +21,23 @@ var (
 type PeripheralService interface {
 	CreatePeripheral(pId uuid.UUID, hId uuid.UUID) error
 	CheckStatusByHRMId(hId uuid.UUID) bool
-	BindPeripheral(pId uuid.UUID, wId uuid.UUID, hId uuid.UUID, connected bool, sendToTrail bool) error
+	BindPeripheral(pId uuid.UUID, wId uuid.UUID, hId uuid.UUID, tId uuid.UUID, connected bool, sendToTrail bool) error
 	DisconnectPeripheral(wId uuid.UUID) error
 	GetHRMAvgReading(hId uuid.UUID) (uuid.UUID, time.Time, int, error)
 	GetHRMReading(hId uuid.UUID) (uuid.UUID, time.Time, int, error)
+	GetHRMSamples(wId uuid.UUID) (uuid.UUID, []domain.HRSample, error)
 	SetHeartRateReading(hId uuid.UUID, reading int) error
 	GetHRMDevStatus(wId uuid.UUID) (bool, error)
 	SetHRMDevStatusByHRMId(hId uuid.UUID, code bool) error
 	SetHRMDevStatus(wId uuid.UUID, code bool) error
-	SetGeoLocation(wId uuid.UUID, longitude float64, latitude float64) error
+	SetGeoLocation(wId uuid.UUID, longitude float64, latitude float64, elevation *float64) error
 	GetGeoDevStatus(wId uuid.UUID) (bool, error)
 	SetGeoDevStatus(wId uuid.UUID, code bool) error
 	GetGeoLocation(wId uuid.UUID) (time.Time, float64, float64, uuid.UUID, error)
 	GetLiveStatus(wId uuid.UUID) (bool, error)
 	SetLiveStatus(wId uuid.UUID, code bool) error
+	GetPausedStatus(wId uuid.UUID) (bool, error)
+	SetPausedStatus(wId uuid.UUID, paused bool) error
 }
 
 type PeripheralRepository interface {
@@ -49,7 +52,7 @@ type PeripheralRepository interface {
 }
 
 type RabbitMQHandler interface {
-	SendLastLocation(wId uuid.UUID, latitude float64, longitude float64, time time.Time, toTrail bool) error
+	SendLastLocation(wId uuid.UUID, tId uuid.UUID, latitude float64, longitude float64, elevation *float64, time time.Time, toTrail bool) error
 }
 
 type ZoneClient interface {
@@ -44,7 +44,7 @@ func (s *PeripheralService) CheckStatusByHRMId(hId uuid.UUID) bool {
 	}
 }
 
-func (s *PeripheralService) BindPeripheral(pId uuid.UUID, wId uuid.UUID, hId uuid.UUID, connected bool, toShelter bool) error {
+func (s *PeripheralService) BindPeripheral(pId uuid.UUID, wId uuid.UUID, hId uuid.UUID, tId uuid.UUID, connected bool, toShelter bool) error {
 
 	pInstance, err := s.repo.GetByHRMId(hId)
 	if err != nil {
@@ -54,10 +54,17 @@ func (s *PeripheralService) BindPeripheral(pId uuid.UUID, wId uuid.UUID, hId uui
 		pInstance, _ = s.repo.GetByHRMId(hId)
 	}
 
+	// Heart rate samples are kept per workout
+	if pInstance.WorkoutId != wId {
+		pInstance.HRMDev.Samples = nil
+	}
+
 	pInstance.PlayerId = pId
 	pInstance.WorkoutId = wId
+	pInstance.TrailId = tId
 	pInstance.HRMDev.HRMStatus = connected
 	pInstance.ToShelter = toShelter
+	pInstance.Paused = false
 	s.repo.Update(pInstance)
 	log.Debug("peripheral binding success", zap.Any("created", true))
 	return nil
@@ -89,6 +96,15 @@ func (s *PeripheralService) GetHRMReading(wId uuid.UUID) (uuid.UUID, time.Time,
 	return pInstance.HRMId, pInstance.HRMDev.HRateTime, pInstance.HRMDev.HRate, nil
 }
 
+func (s *PeripheralService) GetHRMSamples(wId uuid.UUID) (uuid.UUID, []domain.HRSample, error) {
+	pInstance, err := s.repo.GetByWorkoutId(wId)
+	if err != nil {
+		return uuid.Nil, nil, ports.ErrorPeripheralNotFound
+	}
+	hId, samples := pInstance.GetHRateSamples()
+	return hId, samples, nil
+}
+
 func (s *PeripheralService) SetHeartRateReading(hId uuid.UUID, reading int) error {
 	pInstance, err := s.repo.GetByHRMId(hId)
 	if err != nil {
@@ -144,12 +160,12 @@ func (s *PeripheralService) SetHRMDevStatus(wId uuid.UUID, code bool) error {
 	return nil
 }
 
-func (s *PeripheralService) SetGeoLocation(wId uuid.UUID, longitude float64, latitude float64) error {
+func (s *PeripheralService) SetGeoLocation(wId uuid.UUID, longitude float64, latitude float64, elevation *float64) error {
 	pInstance, err := s.repo.GetByWorkoutId(wId)
 	if err != nil {
 		return ports.ErrorPeripheralNotFound
 	}
-	pInstance.SetLocation(longitude, latitude)
+	pInstance.SetLocation(longitude, latitude, elevation)
 	s.repo.Update(pInstance)
 	return nil
 }
@@ -198,9 +214,28 @@ func (s *PeripheralService) SetLiveStatus(wId uuid.UUID, code bool) error {
 	return nil
 }
 
-func (s *PeripheralService) SendLastLocation(wId uuid.UUID, latitude float64, longitude float64, time time.Time) error {
+func (s *PeripheralService) GetPausedStatus(wId uuid.UUID) (bool, error) {
+	pInstance, err := s.repo.GetByWorkoutId(wId)
+	if err != nil {
+		return false, ports.ErrorPeripheralNotFound
+	}
+	return pInstance.Paused, nil
+}
+
+// SetPausedStatus pauses or resumes the live publishing and the heart rate average with the bound workout
+func (s *PeripheralService) SetPausedStatus(wId uuid.UUID, paused bool) error {
+	pInstance, err := s.repo.GetByWorkoutId(wId)
+	if err != nil {
+		return ports.ErrorPeripheralNotFound
+	}
+	pInstance.Paused = paused
+	s.repo.Update(pInstance)
+	return nil
+}
+
+func (s *PeripheralService) SendLastLocation(wId uuid.UUID, latitude float64, longitude float64, elevation *float64, time time.Time) error {
 	pInstance, _ := s.repo.GetByWorkoutId(wId)
-	err := s.publisher.SendLastLocation(wId, latitude, longitude, time, pInstance.ToShelter)
+	err := s.publisher.SendLastLocation(wId, pInstance.TrailId, latitude, longitude, elevation, time, pInstance.ToShelter)
 	if err != nil {
 		return ports.ErrorPeripheralPublishFailed
 	}
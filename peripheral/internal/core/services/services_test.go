@@ -45,7 +45,7 @@ func TestWorkoutService_StartAndStop(t *testing.T) {
 	// rabbitMQHandlerMock.On("SendLastLocation", mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("float64"), mock.AnythingOfType("float64"), mock.AnythingOfType("time.Time")).Return(nil)
 	// zoneClientMock.On("GetTrailLocation", mock.AnythingOfType("uuid.UUID")).Return(0.0, 0.0, 0.0, 0.0, nil)
 
-	err := peripheralService.BindPeripheral(playerID, workoutID, HRMID, hrmConnect, sendLiveLocation)
+	err := peripheralService.BindPeripheral(playerID, workoutID, HRMID, uuid.Nil, hrmConnect, sendLiveLocation)
 	assert.NoError(t, err)
 
 	// Optionally: You can check if the peripheral is now present in the memory repository
@@ -98,7 +98,7 @@ func TestDisconnectPeripheral_Exists(t *testing.T) {
 	pId := uuid.New()
 	hId := uuid.New()
 	wId := uuid.New()
-	_ = service.BindPeripheral(pId, wId, hId, true, true)
+	_ = service.BindPeripheral(pId, wId, hId, uuid.Nil, true, true)
 
 	// Now unbind the peripheral
 	err := service.DisconnectPeripheral(wId)
@@ -159,9 +159,10 @@ func TestBindPeripheral_NewPeripheral(t *testing.T) {
 	pId := uuid.New()
 	hId := uuid.New()
 	wId := uuid.New()
+	tId := uuid.New()
 
 	// Call the method under test
-	err := service.BindPeripheral(pId, wId, hId, true, true)
+	err := service.BindPeripheral(pId, wId, hId, tId, true, true)
 	assert.NoError(t, err)
 
 	// Verify peripheral is now in the repository and bound
@@ -169,6 +170,7 @@ func TestBindPeripheral_NewPeripheral(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, pId, pInstance.PlayerId)
 	assert.Equal(t, wId, pInstance.WorkoutId)
+	assert.Equal(t, tId, pInstance.TrailId)
 	assert.True(t, pInstance.HRMDev.HRMStatus)
 	assert.True(t, pInstance.LiveStatus)
 }
@@ -201,7 +203,7 @@ func TestSetHeartRateReading(t *testing.T) {
 	pId := uuid.New()
 	hId := uuid.New()
 	wId := uuid.New()
-	err := service.BindPeripheral(pId, wId, hId, true, true)
+	err := service.BindPeripheral(pId, wId, hId, uuid.Nil, true, true)
 	assert.NoError(t, err)
 
 	// Set a heart rate reading
@@ -226,7 +228,7 @@ func TestGetHRMAvgReading(t *testing.T) {
 	pId := uuid.New()
 	hId := uuid.New()
 	wId := uuid.New()
-	_ = service.BindPeripheral(pId, wId, hId, true, true)
+	_ = service.BindPeripheral(pId, wId, hId, uuid.Nil, true, true)
 	_ = service.SetHeartRateReading(hId, 80) // Example heart rate reading
 
 	// Now get the average HRM reading
@@ -247,7 +249,7 @@ func TestGetHRMReading(t *testing.T) {
 	hId := uuid.New()
 	wId := uuid.New()
 	reading := 85
-	_ = service.BindPeripheral(pId, wId, hId, true, true)
+	_ = service.BindPeripheral(pId, wId, hId, uuid.Nil, true, true)
 	_ = service.SetHeartRateReading(hId, reading)
 
 	hrmId, timeRead, currentReading, err := service.GetHRMReading(wId)
@@ -316,15 +318,23 @@ func TestSetGeoLocation(t *testing.T) {
 	pId := uuid.New()
 	longitude := 40.712776
 	latitude := -74.005974
+	elevation := 10.5
 	_ = service.CreatePeripheral(pId, hId)
-	service.BindPeripheral(pId, wId, hId, true, false)
+	service.BindPeripheral(pId, wId, hId, uuid.Nil, true, false)
 
-	err := service.SetGeoLocation(wId, longitude, latitude)
+	err := service.SetGeoLocation(wId, longitude, latitude, &elevation)
 	assert.NoError(t, err)
 
 	pInstance, _ := repo.GetByWorkoutId(wId)
 	assert.Equal(t, latitude, pInstance.GeoDev.Latitude)
 	assert.Equal(t, longitude, pInstance.GeoDev.Longitude)
+	assert.Equal(t, elevation, *pInstance.GeoDev.Elevation)
+
+	// A device without elevation clears the previous one
+	err = service.SetGeoLocation(wId, longitude, latitude, nil)
+	assert.NoError(t, err)
+	pInstance, _ = repo.GetByWorkoutId(wId)
+	assert.Nil(t, pInstance.GeoDev.Elevation)
 }
 
 // TestGetGeoDevStatus checks retrieving the geolocation device status for a peripheral.
@@ -339,7 +349,7 @@ func TestGetGeoDevStatus(t *testing.T) {
 	pId := uuid.New()
 
 	_ = service.CreatePeripheral(pId, hId)
-	service.BindPeripheral(pId, wId, hId, true, false)
+	service.BindPeripheral(pId, wId, hId, uuid.Nil, true, false)
 	status := true
 	_ = service.SetGeoDevStatus(wId, status)
 
@@ -359,7 +369,7 @@ func TestSetGeoDevStatus(t *testing.T) {
 	hId := uuid.New()
 	pId := uuid.New()
 	_ = service.CreatePeripheral(pId, hId)
-	service.BindPeripheral(pId, wId, hId, true, false)
+	service.BindPeripheral(pId, wId, hId, uuid.Nil, true, false)
 	status := true
 
 	err := service.SetGeoDevStatus(wId, status)
@@ -382,8 +392,8 @@ func TestGetGeoLocation(t *testing.T) {
 	longitude := 40.712776
 	latitude := -74.005974
 	_ = service.CreatePeripheral(pId, hId)
-	service.BindPeripheral(pId, wId, hId, true, false)
-	_ = service.SetGeoLocation(wId, longitude, latitude)
+	service.BindPeripheral(pId, wId, hId, uuid.Nil, true, false)
+	_ = service.SetGeoLocation(wId, longitude, latitude, nil)
 
 	_, retrievedLongitude, retrievedLatitude, _, err := service.GetGeoLocation(wId)
 	assert.NoError(t, err)
@@ -403,7 +413,7 @@ func TestGetLiveStatus(t *testing.T) {
 	pId := uuid.New()
 	_ = service.CreatePeripheral(wId, hId)
 	liveStatus := true
-	err := service.BindPeripheral(pId, wId, hId, true, false)
+	err := service.BindPeripheral(pId, wId, hId, uuid.Nil, true, false)
 	assert.NoError(t, err)
 
 	_ = service.SetLiveStatus(wId, liveStatus)
@@ -426,7 +436,7 @@ func TestSetLiveStatus(t *testing.T) {
 	err := service.CreatePeripheral(wId, hId)
 	assert.NoError(t, err)
 
-	err = service.BindPeripheral(pId, wId, hId, true, false)
+	err = service.BindPeripheral(pId, wId, hId, uuid.Nil, true, false)
 
 	assert.NoError(t, err)
 	liveStatus := true
@@ -437,3 +447,80 @@ func TestSetLiveStatus(t *testing.T) {
 	pInstance, _ := repo.GetByWorkoutId(wId)
 	assert.Equal(t, liveStatus, pInstance.LiveStatus)
 }
+
+// TestGetHRMSamples checks that every heart rate reading of a workout is kept in order.
+func TestGetHRMSamples(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	rabbitMQHandlerMock := rabbitmqhandler.NewRabbitMQHandlerMock()
+	zoneClientMock := clients.NewZoneServiceClientMock()
+	service := services.NewPeripheralService(repo, rabbitMQHandlerMock, zoneClientMock)
+
+	pId := uuid.New()
+	hId := uuid.New()
+	wId := uuid.New()
+	_ = service.BindPeripheral(pId, wId, hId, uuid.Nil, true, true)
+	readings := []int{80, 95, 110}
+	for _, r := range readings {
+		_ = service.SetHeartRateReading(hId, r)
+	}
+
+	hrmId, samples, err := service.GetHRMSamples(wId)
+	assert.NoError(t, err)
+	assert.Equal(t, hId, hrmId)
+	assert.Len(t, samples, len(readings))
+	for i, r := range readings {
+		assert.Equal(t, r, samples[i].HRate)
+	}
+
+	// Binding the device to a new workout starts a fresh series
+	newWId := uuid.New()
+	_ = service.BindPeripheral(pId, newWId, hId, uuid.Nil, true, true)
+	_, samples, err = service.GetHRMSamples(newWId)
+	assert.NoError(t, err)
+	assert.Empty(t, samples)
+}
+
+// TestSetPausedStatus checks that the readings of a paused workout are left out of its average and samples.
+func TestSetPausedStatus(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	rabbitMQHandlerMock := rabbitmqhandler.NewRabbitMQHandlerMock()
+	zoneClientMock := clients.NewZoneServiceClientMock()
+	service := services.NewPeripheralService(repo, rabbitMQHandlerMock, zoneClientMock)
+
+	pId := uuid.New()
+	hId := uuid.New()
+	wId := uuid.New()
+	_ = service.BindPeripheral(pId, wId, hId, uuid.Nil, true, true)
+	_ = service.SetHeartRateReading(hId, 100)
+
+	err := service.SetPausedStatus(wId, true)
+	assert.NoError(t, err)
+	paused, err := service.GetPausedStatus(wId)
+	assert.NoError(t, err)
+	assert.True(t, paused)
+
+	// the reading is shown but not kept
+	_ = service.SetHeartRateReading(hId, 60)
+	_, _, reading, _ := service.GetHRMReading(wId)
+	assert.Equal(t, 60, reading)
+
+	err = service.SetPausedStatus(wId, false)
+	assert.NoError(t, err)
+	_ = service.SetHeartRateReading(hId, 120)
+
+	_, _, average, err := service.GetHRMAvgReading(wId)
+	assert.NoError(t, err)
+	assert.Equal(t, 110, average)
+	_, samples, _ := service.GetHRMSamples(wId)
+	assert.Len(t, samples, 2)
+
+	// Binding the device to a new workout starts it running
+	_ = service.SetPausedStatus(wId, true)
+	newWId := uuid.New()
+	_ = service.BindPeripheral(pId, newWId, hId, uuid.Nil, true, true)
+	paused, _ = service.GetPausedStatus(newWId)
+	assert.False(t, paused)
+
+	err = service.SetPausedStatus(uuid.New(), true)
+	assert.ErrorIs(t, err, ports.ErrorPeripheralNotFound)
+}
@@ -163,6 +163,10 @@ const docTemplate = `{
                     "description": "CreatedAt is the time when the player registered",
                     "type": "string"
                 },
+                "distance_unit": {
+                    "description": "DistanceUnit the player reads distances in, km or mi",
+                    "type": "string"
+                },
                 "height": {
                     "description": "Height of the player",
                     "type": "number"
@@ -175,6 +179,10 @@ const docTemplate = `{
                     "description": "Preference of the player",
                     "type": "string"
                 },
+                "resting_heart_rate": {
+                    "description": "RestingHeartRate of the player in bpm, 0 or missing when unknown",
+                    "type": "integer"
+                },
                 "updated_at": {
                     "description": "UpdatedAt is the time when the player last updated the profile",
                     "type": "string"
@@ -228,7 +236,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "",
 	Schemes:          []string{},
 	Title:            "User Manager API",
-	Description:      "This provides a description of API endpoints for the Challenge Manager",
+	Description:      "This provides a description of API endpoints for the Player Manager",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
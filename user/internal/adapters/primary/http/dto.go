@@ -29,6 +29,10 @@ type playerDTO struct {
 	Height float64 `json:"height"`
 	// Preference of the player
 	Preference string `json:"preference"`
+	// DistanceUnit the player reads distances in, km or mi
+	DistanceUnit string `json:"distance_unit"`
+	// RestingHeartRate of the player in bpm, 0 or missing when unknown
+	RestingHeartRate uint8 `json:"resting_heart_rate"`
 	// GeographicalZone is a group of trails in a region
 	ZoneID string `json:"zone_id"`
 	// CreatedAt is the time when the player registered
@@ -45,14 +49,16 @@ func (playerDTO *playerDTO) toAggregate() *domain.Player {
 		DateOfBirth: playerDTO.User.DateOfBirth,
 	}
 	return &domain.Player{
-		ID:         playerDTO.ID,
-		User:       &userDTO,
-		Weight:     playerDTO.Weight,
-		Height:     playerDTO.Height,
-		Preference: domain.Preference(playerDTO.Preference),
-		ZoneID:     uuid.MustParse(playerDTO.ZoneID),
-		CreatedAt:  playerDTO.CreatedAt,
-		UpdatedAt:  playerDTO.UpdatedAt,
+		ID:               playerDTO.ID,
+		User:             &userDTO,
+		Weight:           playerDTO.Weight,
+		Height:           playerDTO.Height,
+		Preference:       domain.Preference(playerDTO.Preference),
+		DistanceUnit:     domain.DistanceUnit(playerDTO.DistanceUnit),
+		RestingHeartRate: playerDTO.RestingHeartRate,
+		ZoneID:           uuid.MustParse(playerDTO.ZoneID),
+		CreatedAt:        playerDTO.CreatedAt,
+		UpdatedAt:        playerDTO.UpdatedAt,
 	}
 }
 
@@ -65,11 +71,13 @@ func fromAggregate(player *domain.Player) *playerDTO {
 			Name:        player.User.Name,
 			DateOfBirth: player.User.DateOfBirth,
 		},
-		Weight:     player.Weight,
-		Height:     player.Height,
-		Preference: string(player.Preference),
-		ZoneID:     player.ZoneID.String(),
-		CreatedAt:  player.CreatedAt,
-		UpdatedAt:  player.UpdatedAt,
+		Weight:           player.Weight,
+		Height:           player.Height,
+		Preference:       string(player.Preference),
+		DistanceUnit:     string(player.DistanceUnit),
+		RestingHeartRate: player.RestingHeartRate,
+		ZoneID:           player.ZoneID.String(),
+		CreatedAt:        player.CreatedAt,
+		UpdatedAt:        player.UpdatedAt,
 	}
 }
@@ -39,14 +39,16 @@ func (r *Repository) Create(player *domain.Player) (*domain.Player, error) {
 	}
 
 	pp := &postgresPlayer{
-		ID:         player.ID,
-		UserID:     player.User.ID,
-		Weight:     player.Weight,
-		Height:     player.Height,
-		Preference: string(player.Preference),
-		ZoneID:     player.ZoneID,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+		ID:               player.ID,
+		UserID:           player.User.ID,
+		Weight:           player.Weight,
+		Height:           player.Height,
+		Preference:       string(player.Preference),
+		DistanceUnit:     string(player.DistanceUnit),
+		RestingHeartRate: player.RestingHeartRate,
+		ZoneID:           player.ZoneID,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
 	}
 	// pu, pp := fromAggregate(player)
 	err := r.db.Transaction(func(tx *gorm.DB) error {
@@ -120,6 +122,8 @@ func (r *Repository) Update(player *domain.Player) (*domain.Player, error) {
 	pp.Weight = player.Weight
 	pp.Height = player.Height
 	pp.Preference = string(player.Preference)
+	pp.DistanceUnit = string(player.DistanceUnit)
+	pp.RestingHeartRate = player.RestingHeartRate
 	pp.ZoneID = player.ZoneID
 	pp.UpdatedAt = time.Now()
 
@@ -67,6 +67,10 @@ type postgresPlayer struct {
 	Height float64 `gorm:"<-"`
 	// Preference of the player
 	Preference string `gorm:"<-"`
+	// DistanceUnit the player reads distances in
+	DistanceUnit string `gorm:"not null;default:km"`
+	// RestingHeartRate of the player in bpm, 0 when unknown
+	RestingHeartRate uint8 `gorm:"not null;default:0"`
 	// GeographicalZone is a group of trails in a region
 	ZoneID uuid.UUID
 	// CreatedAt is the time when the player registered
@@ -86,12 +90,14 @@ func toAggregate(pu *postgresUser, pp *postgresPlayer) *domain.Player {
 			Name:        pu.Name,
 			DateOfBirth: pu.DateOfBirth,
 		},
-		Weight:     pp.Weight,
-		Height:     pp.Height,
-		Preference: domain.Preference(pp.Preference),
-		ZoneID:     pp.ZoneID,
-		CreatedAt:  pp.CreatedAt,
-		UpdatedAt:  pp.UpdatedAt,
+		Weight:           pp.Weight,
+		Height:           pp.Height,
+		Preference:       domain.Preference(pp.Preference),
+		DistanceUnit:     domain.DistanceUnit(pp.DistanceUnit),
+		RestingHeartRate: pp.RestingHeartRate,
+		ZoneID:           pp.ZoneID,
+		CreatedAt:        pp.CreatedAt,
+		UpdatedAt:        pp.UpdatedAt,
 	}
 }
 
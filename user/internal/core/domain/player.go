@@ -12,6 +12,14 @@ var (
 	ErrInvalidPlayerWeight     = errors.New("a player has to have a valid weight")
 	ErrInvalidPlayerPreference = errors.New("a player has to have a valid preference")
 	ErrInvalidZoneID           = errors.New("a player must belong to a valid zone")
+	ErrInvalidPlayerDistance   = errors.New("a player has to have a valid distance unit")
+	ErrInvalidRestingHeartRate = errors.New("a player has to have a resting heart rate between 30 and 120 bpm")
+)
+
+// bounds of the resting heart rates a player can give, in beats per minute
+const (
+	minRestingHeartRate = 30
+	maxRestingHeartRate = 120
 )
 
 type Preference string
@@ -21,6 +29,14 @@ const (
 	Cardio   Preference = "cardio"
 )
 
+// DistanceUnit the player reads the distances of the workouts in
+type DistanceUnit string
+
+const (
+	Kilometres DistanceUnit = "km"
+	Miles      DistanceUnit = "mi"
+)
+
 // Player is a entity that represents a Player in all Domains
 type Player struct {
 	// User is the root entity of player
@@ -33,6 +49,10 @@ type Player struct {
 	Height float64
 	// Preference of player
 	Preference Preference
+	// DistanceUnit the player reads distances in
+	DistanceUnit DistanceUnit
+	// RestingHeartRate of the player in beats per minute, 0 when unknown
+	RestingHeartRate uint8
 	// GeographicalZone is a group of trails in a region
 	ZoneID uuid.UUID
 	// CreatedAt is the time when the player registered
@@ -78,11 +98,35 @@ func NewPlayer(name string, email string, dob string, weight float64, height flo
 		Height:     height,
 		Preference: pref,
 		ZoneID:     zoneID,
+		// distances are shown in kilometres unless the player asks otherwise
+		DistanceUnit: Kilometres,
 	}
 
 	return player, nil
 }
 
+// SetDistanceUnit changes the unit the player reads distances in, kilometres when none is given
+func (p *Player) SetDistanceUnit(unit DistanceUnit) error {
+	switch unit {
+	case "":
+		p.DistanceUnit = Kilometres
+	case Kilometres, Miles:
+		p.DistanceUnit = unit
+	default:
+		return ErrInvalidPlayerDistance
+	}
+	return nil
+}
+
+// SetRestingHeartRate changes the resting heart rate of the player, 0 when they do not know it
+func (p *Player) SetRestingHeartRate(heartRate uint8) error {
+	if heartRate != 0 && (heartRate < minRestingHeartRate || heartRate > maxRestingHeartRate) {
+		return ErrInvalidRestingHeartRate
+	}
+	p.RestingHeartRate = heartRate
+	return nil
+}
+
 func validateHeight(h float64) error {
 	if h == 0.0 {
 		return ErrInvalidPlayerHeight
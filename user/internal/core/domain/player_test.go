@@ -77,3 +77,91 @@ func TestPlayer_NewPlayer(t *testing.T) {
 		})
 	}
 }
+
+func TestPlayer_SetDistanceUnit(t *testing.T) {
+	type testCase struct {
+		test         string
+		unit         string
+		expectedUnit domain.DistanceUnit
+		expectedErr  error
+	}
+
+	testCases := []testCase{
+		{
+			test:         "Kilometres by default",
+			unit:         "",
+			expectedUnit: domain.Kilometres,
+		},
+		{
+			test:         "Miles",
+			unit:         "mi",
+			expectedUnit: domain.Miles,
+		},
+		{
+			test:         "Incorrect distance unit validation",
+			unit:         "furlong",
+			expectedUnit: domain.Kilometres,
+			expectedErr:  domain.ErrInvalidPlayerDistance,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.test, func(t *testing.T) {
+			player, err := domain.NewPlayer("Percy Bolmer", "percy@bolmer.com", "1998-19-08", 80.3, 180.4, domain.Cardio, uuid.New())
+			if err != nil {
+				t.Fatalf("expected a player, got %v", err)
+			}
+			err = player.SetDistanceUnit(domain.DistanceUnit(tc.unit))
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected err %v, got %v", tc.expectedErr, err)
+			}
+			if player.DistanceUnit != tc.expectedUnit {
+				t.Errorf("expected distance unit %s, got %s", tc.expectedUnit, player.DistanceUnit)
+			}
+		})
+	}
+}
+
+func TestPlayer_SetRestingHeartRate(t *testing.T) {
+	type testCase struct {
+		test              string
+		heartRate         uint8
+		expectedHeartRate uint8
+		expectedErr       error
+	}
+
+	testCases := []testCase{
+		{
+			test:              "Unknown resting heart rate",
+			heartRate:         0,
+			expectedHeartRate: 0,
+		},
+		{
+			test:              "Resting heart rate",
+			heartRate:         55,
+			expectedHeartRate: 55,
+		},
+		{
+			test:              "Incorrect resting heart rate validation",
+			heartRate:         180,
+			expectedHeartRate: 0,
+			expectedErr:       domain.ErrInvalidRestingHeartRate,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.test, func(t *testing.T) {
+			player, err := domain.NewPlayer("Percy Bolmer", "percy@bolmer.com", "1998-19-08", 80.3, 180.4, domain.Cardio, uuid.New())
+			if err != nil {
+				t.Fatalf("expected a player, got %v", err)
+			}
+			err = player.SetRestingHeartRate(tc.heartRate)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected err %v, got %v", tc.expectedErr, err)
+			}
+			if player.RestingHeartRate != tc.expectedHeartRate {
+				t.Errorf("expected resting heart rate %d, got %d", tc.expectedHeartRate, player.RestingHeartRate)
+			}
+		})
+	}
+}
@@ -34,6 +34,12 @@ func (s *PlayerService) Register(req *domain.Player) (*domain.Player, error) {
 	if err != nil {
 		return &domain.Player{}, ports.ErrorCreatePlayerFailed
 	}
+	if err := p.SetDistanceUnit(req.DistanceUnit); err != nil {
+		return &domain.Player{}, ports.ErrorCreatePlayerFailed
+	}
+	if err := p.SetRestingHeartRate(req.RestingHeartRate); err != nil {
+		return &domain.Player{}, ports.ErrorCreatePlayerFailed
+	}
 
 	player, err := s.repo.Create(p)
 	if err != nil {
@@ -63,6 +69,13 @@ func (s *PlayerService) Get(id uuid.UUID) (*domain.Player, error) {
 // }
 
 func (s *PlayerService) Update(req *domain.Player) (*domain.Player, error) {
+	if err := req.SetDistanceUnit(req.DistanceUnit); err != nil {
+		return &domain.Player{}, err
+	}
+	if err := req.SetRestingHeartRate(req.RestingHeartRate); err != nil {
+		return &domain.Player{}, err
+	}
+
 	player, err := s.repo.Update(req)
 	if err != nil {
 		return &domain.Player{}, err
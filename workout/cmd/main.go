@@ -7,9 +7,11 @@ import (
 	amqpSecondary "github.com/CAS735-F23/macrun-teamvsl/workout/internal/adapters/secondary/amqp"
 	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/adapters/secondary/clients"
 	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/adapters/secondary/repository/postgres"
+	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
 	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/services"
 	logger "github.com/CAS735-F23/macrun-teamvsl/workout/log"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 
 	"github.com/CAS735-F23/macrun-teamvsl/workout/docs"
 	swaggerFiles "github.com/swaggo/files"
@@ -20,6 +22,9 @@ var cfg *config.AppConfiguration = config.Config
 
 func main() {
 	logger.Info("workout manager is starting...")
+	if err := cfg.Validate(); err != nil {
+		logger.Fatal("invalid configuration", zap.Error(err))
+	}
 
 	// Initialize router
 	router := gin.New()
@@ -35,8 +40,26 @@ func main() {
 	// Initialize workout stats publisher
 	workoutStatsWorkoutStatsPublisher := amqpSecondary.NewWorkoutStatsPublisher(cfg.RabbitMQ)
 
+	// Initialize shelter reservation publisher
+	shelterReservationPublisher := amqpSecondary.NewShelterReservationPublisher(cfg.RabbitMQ)
+
+	// Initialize workout end publisher
+	workoutEndPublisher := amqpSecondary.NewWorkoutEndPublisher(cfg.RabbitMQ)
+
 	// Initialize workout service
-	workoutSvc := services.NewWorkoutService(store, peripheralClient, userClient, workoutStatsWorkoutStatsPublisher)
+	heartRateFormula, err := domain.ParseHeartRateFormula(cfg.HeartRateZoneFormula)
+	if err != nil {
+		logger.Fatal("invalid heart rate zone formula", zap.String("formula", cfg.HeartRateZoneFormula), zap.Error(err))
+	}
+	workoutSvc := services.NewWorkoutService(store, peripheralClient, userClient, workoutStatsWorkoutStatsPublisher, shelterReservationPublisher, workoutEndPublisher, cfg.DistanceScale, domain.AutoPause{Speed: cfg.AutoPauseSpeed, After: cfg.AutoPauseAfter}, heartRateFormula)
+	if err := workoutSvc.RestoreActiveWorkouts(); err != nil {
+		logger.Fatal("failed to restore active workouts", zap.Error(err))
+	}
+	if cfg.StaleWorkoutTimeout > 0 {
+		if err := workoutSvc.MonitorStaleWorkouts(cfg.StaleWorkoutTimeout, cfg.StalePausedWorkoutTimeout, cfg.StaleWorkoutSweep); err != nil {
+			logger.Fatal("invalid stale workout sweep", zap.Duration("sweep", cfg.StaleWorkoutSweep), zap.Error(err))
+		}
+	}
 	workoutHandler := http.NewWorkoutHanlder(router, workoutSvc)
 	workoutHandler.InitRouter()
 
@@ -44,6 +67,18 @@ func main() {
 	shelterDistanceConsumer := amqpPrimary.NewShelterDistanceConsumer(cfg.RabbitMQ, workoutSvc)
 	shelterDistanceConsumer.InitAMQP()
 
+	// Initialize off trail consumer
+	offTrailConsumer := amqpPrimary.NewOffTrailConsumer(cfg.RabbitMQ, workoutSvc)
+	offTrailConsumer.InitAMQP()
+
+	// Initialize shelter reservation consumer
+	shelterReservationConsumer := amqpPrimary.NewShelterReservationConsumer(cfg.RabbitMQ, workoutSvc)
+	shelterReservationConsumer.InitAMQP()
+
+	// Initialize geofence consumer
+	geofenceConsumer := amqpPrimary.NewGeofenceConsumer(cfg.RabbitMQ, workoutSvc)
+	geofenceConsumer.InitAMQP()
+
 	// Initialize location consumer
 	locationConsumer := amqpPrimary.NewLocationConsumer(cfg.RabbitMQ, workoutSvc)
 	locationConsumer.InitAMQP()
@@ -1,9 +1,18 @@
 package config
 
-import "os"
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
 
 var Config *AppConfiguration
 
+// invalidSettings are the settings whose value could not be read, the workout manager does not start with any
+var invalidSettings []error
+
 type AppConfiguration struct {
 	Mode             string
 	Port             string
@@ -11,6 +20,21 @@ type AppConfiguration struct {
 	RabbitMQ         *RabbitMQ
 	PeripheralClient string
 	UserClient       string
+	// factor applied to the distance between two locations, 1 outside of demos
+	DistanceScale float64
+	// speed in m/s under which the player is stopped, for the auto-pause
+	AutoPauseSpeed float64
+	// time the player stays stopped before the workout is paused by itself, workouts are not when it is 0
+	AutoPauseAfter time.Duration
+	// time without a location nor a heart rate reading after which a workout is abandoned, never when it is 0
+	StaleWorkoutTimeout time.Duration
+	// time after which a workout the player paused is abandoned, never when it is 0
+	StalePausedWorkoutTimeout time.Duration
+	// how often the stale workouts are looked for, the service does not start when it is not positive
+	StaleWorkoutSweep time.Duration
+	// formula of the heart rate zones, 'max' for percentages of 220 - age or 'karvonen' for percentages of
+	// the heart rate reserve above the resting heart rate of the player
+	HeartRateZoneFormula string
 }
 
 type Postgres struct {
@@ -29,8 +53,17 @@ type RabbitMQ struct {
 	User                    string
 	Password                string
 	ShelterDistanceConsumer string
+	OffTrailConsumer        string
 	LiveLocationConsumer    string
 	WorkoutStatsPublisher   string
+	// shelter places asked to the zone manager, and its answers
+	ShelterReservationPublisher string
+	ShelterReservationConsumer  string
+	// ends of workouts told to the zone manager
+	WorkoutEndPublisher string
+	// topic exchange of the geofence events of the zone, and the queue of the shelter arrivals
+	GeofenceExchange string
+	GeofenceConsumer string
 }
 
 func init() {
@@ -45,13 +78,19 @@ func init() {
 	}
 
 	rabbitmq := &RabbitMQ{
-		Host:                    getEnv("RABBITMQ_HOSTNAME", "localhost"),
-		Port:                    getEnv("RABBITMQ_PORT", "5672"),
-		User:                    getEnv("RABBITMQ_USER", "guest"),
-		Password:                getEnv("RABBITMQ_PASSWORD", "guest"),
-		ShelterDistanceConsumer: getEnv("RABBITMQ_SHELTER_DISTANCE_CONSUMER", "shelter_zone_workout_queue"),
-		LiveLocationConsumer:    getEnv("RABBITMQ_LOCATION_CONSUMER", "location_peripheral_workout_queue"),
-		WorkoutStatsPublisher:   getEnv("RABBITMQ_WORKOUT_STATS_PUBLISHER", "stats_workout_challenge_queue"),
+		Host:                        getEnv("RABBITMQ_HOSTNAME", "localhost"),
+		Port:                        getEnv("RABBITMQ_PORT", "5672"),
+		User:                        getEnv("RABBITMQ_USER", "guest"),
+		Password:                    getEnv("RABBITMQ_PASSWORD", "guest"),
+		ShelterDistanceConsumer:     getEnv("RABBITMQ_SHELTER_DISTANCE_CONSUMER", "shelter_zone_workout_queue"),
+		OffTrailConsumer:            getEnv("RABBITMQ_OFF_TRAIL_CONSUMER", "off_trail_zone_workout_queue"),
+		LiveLocationConsumer:        getEnv("RABBITMQ_LOCATION_CONSUMER", "location_peripheral_workout_queue"),
+		WorkoutStatsPublisher:       getEnv("RABBITMQ_WORKOUT_STATS_PUBLISHER", "stats_workout_challenge_queue"),
+		ShelterReservationPublisher: getEnv("RABBITMQ_SHELTER_RESERVATION_PUBLISHER", "shelter_reservation_workout_zone_queue"),
+		ShelterReservationConsumer:  getEnv("RABBITMQ_SHELTER_RESERVATION_CONSUMER", "shelter_reservation_zone_workout_queue"),
+		WorkoutEndPublisher:         getEnv("RABBITMQ_WORKOUT_END_PUBLISHER", "workout_end_workout_zone_queue"),
+		GeofenceExchange:            getEnv("RABBITMQ_GEOFENCE_EXCHANGE", "geofence_events"),
+		GeofenceConsumer:            getEnv("RABBITMQ_GEOFENCE_CONSUMER", "geofence_zone_workout_queue"),
 	}
 
 	Config = &AppConfiguration{
@@ -61,6 +100,15 @@ func init() {
 		RabbitMQ:         rabbitmq,
 		UserClient:       getEnv("USER_CLIENT_URL", "http://localhost:8010"),
 		PeripheralClient: getEnv("PERIPHERAL_CLIENT_URL", "http://localhost:8012"),
+		DistanceScale:    getEnvFloat("DISTANCE_SCALE", 1),
+		AutoPauseSpeed:   getEnvFloat("AUTO_PAUSE_SPEED", 0.5),
+		AutoPauseAfter:   getEnvDuration("AUTO_PAUSE_AFTER", 0),
+
+		StaleWorkoutTimeout:       getEnvDuration("STALE_WORKOUT_TIMEOUT", 30*time.Minute),
+		StalePausedWorkoutTimeout: getEnvDuration("STALE_PAUSED_WORKOUT_TIMEOUT", 12*time.Hour),
+		StaleWorkoutSweep:         getEnvDuration("STALE_WORKOUT_SWEEP", time.Minute),
+
+		HeartRateZoneFormula: getEnv("HEART_RATE_ZONE_FORMULA", "max"),
 	}
 }
 
@@ -70,3 +118,30 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// Validate returns the settings whose value could not be read, nil when they all could
+func (c *AppConfiguration) Validate() error {
+	return errors.Join(invalidSettings...)
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, exists := os.LookupEnv(key); exists {
+		f, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			return f
+		}
+		invalidSettings = append(invalidSettings, fmt.Errorf("%s=%q is not a number", key, value))
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		d, err := time.ParseDuration(value)
+		if err == nil {
+			return d
+		}
+		invalidSettings = append(invalidSettings, fmt.Errorf("%s=%q is not a duration", key, value))
+	}
+	return defaultValue
+}
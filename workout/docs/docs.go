@@ -16,6 +16,87 @@ const docTemplate = `{
     "basePath": "{{.BasePath}}",
     "paths": {
         "/api/v1/workout": {
+            "get": {
+                "description": "This endpoint lists past and ongoing workout sessions, newest first by default. Pages are walked with the next_page cursor of the previous response.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workout"
+                ],
+                "summary": "List workouts",
+                "operationId": "list-workouts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the player",
+                        "name": "player_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the trail",
+                        "name": "trail_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Workouts started at or after this time (RFC3339 format)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Workouts started at or before this time (RFC3339 format)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only completed (true) or ongoing (false) workouts",
+                        "name": "completed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Order of the workouts (start_time/distance)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Direction of the order (desc/asc)",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the page, next_page of the previous response",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of workouts per page, 20 by default and at most 100",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully listed workouts",
+                        "schema": {
+                            "$ref": "#/definitions/httphandler.WorkoutPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request with error details"
+                    }
+                }
+            },
             "post": {
                 "description": "This endpoint starts a new workout session for a player with the given details.",
                 "consumes": [
@@ -52,7 +133,7 @@ const docTemplate = `{
         },
         "/api/v1/workout/distance": {
             "get": {
-                "description": "This endpoint retrieves the distance covered in a workout session either by workout ID or by player ID within a date range.",
+                "description": "This endpoint retrieves the distance covered in a workout session either by workout ID or by player ID within a date range, with the number of workouts completed in the range. The distance is in the requested units, otherwise in the units preferred by the player.",
                 "consumes": [
                     "application/json"
                 ],
@@ -88,6 +169,12 @@ const docTemplate = `{
                         "description": "End date for the range (RFC3339 format)",
                         "name": "endDate",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Units of the distance (km/mi)",
+                        "name": "units",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -252,7 +339,7 @@ const docTemplate = `{
         },
         "/api/v1/workout/{workoutId}": {
             "put": {
-                "description": "This endpoint stops the workout session for a player based on the provided workout ID.",
+                "description": "This endpoint stops the workout session for a player based on the provided workout ID, and returns the workout with its summary.",
                 "consumes": [
                     "application/json"
                 ],
@@ -275,7 +362,10 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "202": {
-                        "description": "Successfully stopped workout session"
+                        "description": "Successfully stopped workout session",
+                        "schema": {
+                            "$ref": "#/definitions/httphandler.StoppedWorkout"
+                        }
                     },
                     "400": {
                         "description": "Bad Request with error details"
@@ -317,6 +407,85 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/v1/workout/{workoutId}/export": {
+            "get": {
+                "description": "This endpoint renders the track, heart rate and workout options of a completed workout session as a GPX 1.1 or TCX document.",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "workout"
+                ],
+                "summary": "Export a workout",
+                "operationId": "export-workout",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the workout session",
+                        "name": "workoutId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Export format (gpx/tcx)",
+                        "name": "format",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully exported workout"
+                    },
+                    "400": {
+                        "description": "Bad Request with error details"
+                    }
+                }
+            }
+        },
+        "/api/v1/workout/{workoutId}/heartrate": {
+            "get": {
+                "description": "This endpoint breaks the heart rate readings of a workout session, without its pauses, down into the five standard zones of the player: recovery (50-60%), endurance (60-70%), aerobic (70-80%), threshold (80-90%) and maximum (90-100%). The zones are percentages of the maximum heart rate, 220 - age, with the 'max' formula, or of the heart rate reserve above the resting heart rate of the player profile with the 'karvonen' formula, which falls back to 'max' when the player has no resting heart rate. Times are in seconds.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workout"
+                ],
+                "summary": "Get workout heart rate zones",
+                "operationId": "get-workout-heartrate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the workout session",
+                        "name": "workoutId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Formula of the zones, 'max' or 'karvonen', the configured one when not given",
+                        "name": "formula",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved workout heart rate zones",
+                        "schema": {
+                            "$ref": "#/definitions/domain.HeartRateAnalysis"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request with error details"
+                    }
+                }
+            }
+        },
         "/api/v1/workout/{workoutId}/options": {
             "get": {
                 "description": "This endpoint retrieves the available options for a workout session based on the workout ID.",
@@ -420,9 +589,464 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "/api/v1/workout/{workoutId}/pause": {
+            "post": {
+                "description": "This endpoint pauses the workout session until it is resumed. No distance, pace or heart rate is credited to the workout while it is paused, and the peripheral stops publishing the live location.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workout"
+                ],
+                "summary": "Pause a workout session",
+                "operationId": "pause-workout",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the workout session to pause",
+                        "name": "workoutId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully paused workout session",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Workout"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request with error details"
+                    }
+                }
+            }
+        },
+        "/api/v1/workout/{workoutId}/resume": {
+            "post": {
+                "description": "This endpoint resumes a workout session paused by the player or paused automatically because they stopped moving. The way covered during the pause is not credited to the workout.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workout"
+                ],
+                "summary": "Resume a workout session",
+                "operationId": "resume-workout",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the workout session to resume",
+                        "name": "workoutId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully resumed workout session",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Workout"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request with error details"
+                    }
+                }
+            }
+        },
+        "/api/v1/workout/{workoutId}/summary": {
+            "get": {
+                "description": "This endpoint computes the per kilometre splits, the average and best pace and the moving, paused and elapsed time of a workout session from its track. Distances are in metres, times in seconds and paces in seconds per km.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workout"
+                ],
+                "summary": "Get workout summary",
+                "operationId": "get-workout-summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the workout session",
+                        "name": "workoutId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved workout summary",
+                        "schema": {
+                            "$ref": "#/definitions/domain.WorkoutSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request with error details"
+                    }
+                }
+            }
+        },
+        "/api/v1/workout/{workoutId}/track": {
+            "get": {
+                "description": "This endpoint retrieves the ordered list of locations recorded during a workout session, with their elevation when the device reports it, and the metres climbed and descended.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workout"
+                ],
+                "summary": "Get workout track",
+                "operationId": "get-workout-track",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the workout session",
+                        "name": "workoutId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved workout track"
+                    },
+                    "400": {
+                        "description": "Bad Request with error details"
+                    }
+                }
+            }
+        },
+        "/api/v1/workout/{workoutId}/transitions": {
+            "get": {
+                "description": "This endpoint retrieves the transitions of the workout session through its states (created, running, in_option, paused, completed and abandoned), oldest first, with the event that caused each of them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workout"
+                ],
+                "summary": "Get workout transitions",
+                "operationId": "get-workout-transitions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the workout session",
+                        "name": "workoutId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved workout transitions"
+                    },
+                    "400": {
+                        "description": "Bad Request with error details"
+                    }
+                }
+            }
         }
     },
     "definitions": {
+        "domain.HeartRateAnalysis": {
+            "type": "object",
+            "properties": {
+                "average_heart_rate": {
+                    "description": "AverageHeartRate over the workout without its pauses, 0 without readings",
+                    "type": "integer"
+                },
+                "below_zones_time": {
+                    "description": "BelowZonesTime in seconds, the time with a reading under the first zone",
+                    "type": "number"
+                },
+                "formula": {
+                    "description": "Formula the zones are computed with",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.HeartRateFormula"
+                        }
+                    ]
+                },
+                "max_heart_rate": {
+                    "description": "MaxHeartRate of the player in beats per minute",
+                    "type": "integer"
+                },
+                "peak_heart_rate": {
+                    "description": "PeakHeartRate over the workout without its pauses",
+                    "type": "integer"
+                },
+                "resting_heart_rate": {
+                    "description": "RestingHeartRate of the player in beats per minute, 0 unless the zones are computed with it",
+                    "type": "integer"
+                },
+                "samples": {
+                    "description": "Samples of the workout without its pauses, in the order they were taken",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.HeartRateSample"
+                    }
+                },
+                "workout_id": {
+                    "description": "WorkoutID of the workout analysed",
+                    "type": "string"
+                },
+                "zones": {
+                    "description": "Zones from the lowest to the highest",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.HeartRateZone"
+                    }
+                }
+            }
+        },
+        "domain.HeartRateFormula": {
+            "type": "string",
+            "enum": [
+                "max",
+                "karvonen"
+            ],
+            "x-enum-varnames": [
+                "MaxHeartRateFormula",
+                "KarvonenFormula"
+            ]
+        },
+        "domain.HeartRateSample": {
+            "type": "object",
+            "properties": {
+                "heart_rate": {
+                    "description": "HeartRate in beats per minute",
+                    "type": "integer"
+                },
+                "time_of_reading": {
+                    "description": "TimeOfReading is the time at which the reading was taken",
+                    "type": "string"
+                }
+            }
+        },
+        "domain.HeartRateZone": {
+            "type": "object",
+            "properties": {
+                "max_heart_rate": {
+                    "description": "MaxHeartRate of the zone in beats per minute, excluded but for the last zone",
+                    "type": "integer"
+                },
+                "min_heart_rate": {
+                    "description": "MinHeartRate of the zone in beats per minute",
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Name of the zone",
+                    "type": "string"
+                },
+                "percentage": {
+                    "description": "Percentage of the time with a reading spent in the zone",
+                    "type": "number"
+                },
+                "time": {
+                    "description": "Time spent in the zone in seconds",
+                    "type": "number"
+                },
+                "zone": {
+                    "description": "Zone number, from 1 to 5",
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.Split": {
+            "type": "object",
+            "properties": {
+                "distance": {
+                    "description": "Distance of the split in metres",
+                    "type": "number"
+                },
+                "moving_time": {
+                    "description": "MovingTime spent on the split in seconds",
+                    "type": "number"
+                },
+                "number": {
+                    "description": "Number of the split, starting at 1",
+                    "type": "integer"
+                },
+                "pace": {
+                    "description": "Pace on the split in seconds per km",
+                    "type": "number"
+                }
+            }
+        },
+        "domain.Workout": {
+            "type": "object",
+            "properties": {
+                "auto_paused": {
+                    "description": "AutoPaused tells whether the pause going on was started because the player stopped moving",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "description": "CreatedAt is the time when the workout was started",
+                    "type": "string"
+                },
+                "distance_covered": {
+                    "description": "DistanceCovered in the workout in metres",
+                    "type": "number"
+                },
+                "elevation_gain": {
+                    "description": "Metres climbed in a given workout, from locations with an elevation",
+                    "type": "number"
+                },
+                "elevation_loss": {
+                    "description": "Metres descended in a given workout",
+                    "type": "number"
+                },
+                "ended_at": {
+                    "description": "EndedAt is the time when the workout was ended",
+                    "type": "string"
+                },
+                "escapes_made": {
+                    "description": "Escapes made in a given workout",
+                    "type": "integer"
+                },
+                "fights_fought": {
+                    "description": "Fights fought in a given workout",
+                    "type": "integer"
+                },
+                "hardcore_mode": {
+                    "description": "HardcoreMode is the difficulty level chosen by the player",
+                    "type": "boolean"
+                },
+                "hrm_connected": {
+                    "description": "HRMConnected tells whether a heart rate monitor is bound to the workout",
+                    "type": "boolean"
+                },
+                "is_completed": {
+                    "description": "IsCompleted tells whether the workout ended, completed or abandoned",
+                    "type": "boolean"
+                },
+                "off_trail": {
+                    "description": "OffTrail tells whether the player is currently away from the trail",
+                    "type": "boolean"
+                },
+                "off_trail_count": {
+                    "description": "Times the player strayed from the trail in a given workout",
+                    "type": "integer"
+                },
+                "paused_at": {
+                    "description": "PausedAt is the time when the workout was paused, zero while it is running",
+                    "type": "string"
+                },
+                "player_id": {
+                    "description": "PlayerID of the player starting the workout session",
+                    "type": "string"
+                },
+                "profile": {
+                    "description": "Player Profile can be either 'cardio' or 'strength'",
+                    "type": "string"
+                },
+                "resumed_at": {
+                    "description": "ResumedAt is the time when the workout was last resumed, zero if it never was",
+                    "type": "string"
+                },
+                "shelters_taken": {
+                    "description": "Shelters taken for a given workout",
+                    "type": "integer"
+                },
+                "state": {
+                    "description": "State of the workout in its lifecycle",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.WorkoutState"
+                        }
+                    ]
+                },
+                "trail_id": {
+                    "description": "trailId is the id of the trail player is on",
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version of the stored workout it was read at, bumped by every update",
+                    "type": "integer"
+                },
+                "workout_id": {
+                    "description": "ID is the identifier of the Entity, the ID is shared for all sub domains",
+                    "type": "string"
+                }
+            }
+        },
+        "domain.WorkoutState": {
+            "type": "string",
+            "enum": [
+                "created",
+                "running",
+                "in_option",
+                "paused",
+                "completed",
+                "abandoned"
+            ],
+            "x-enum-varnames": [
+                "WorkoutCreated",
+                "WorkoutRunning",
+                "WorkoutInOption",
+                "WorkoutPaused",
+                "WorkoutCompleted",
+                "WorkoutAbandoned"
+            ]
+        },
+        "domain.WorkoutSummary": {
+            "type": "object",
+            "properties": {
+                "average_pace": {
+                    "description": "AveragePace over the moving time in seconds per km, 0 before the player moved",
+                    "type": "number"
+                },
+                "best_pace": {
+                    "description": "BestPace of the full splits in seconds per km, 0 before the first full split",
+                    "type": "number"
+                },
+                "distance": {
+                    "description": "Distance covered in metres",
+                    "type": "number"
+                },
+                "elapsed_time": {
+                    "description": "ElapsedTime from the start to the end of the workout in seconds, until now while it is in progress",
+                    "type": "number"
+                },
+                "moving_time": {
+                    "description": "MovingTime in seconds, the elapsed time without the stops, the pauses and before the first location",
+                    "type": "number"
+                },
+                "paused_time": {
+                    "description": "PausedTime in seconds, the time the workout spent paused",
+                    "type": "number"
+                },
+                "splits": {
+                    "description": "Splits of the workout in order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Split"
+                    }
+                },
+                "workout_id": {
+                    "description": "WorkoutID of the workout summarized",
+                    "type": "string"
+                }
+            }
+        },
         "httphandler.StartWorkout": {
             "type": "object",
             "properties": {
@@ -456,6 +1080,131 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "httphandler.StoppedWorkout": {
+            "type": "object",
+            "properties": {
+                "auto_paused": {
+                    "description": "AutoPaused tells whether the pause going on was started because the player stopped moving",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "description": "CreatedAt is the time when the workout was started",
+                    "type": "string"
+                },
+                "distance_covered": {
+                    "description": "DistanceCovered in the workout in metres",
+                    "type": "number"
+                },
+                "elevation_gain": {
+                    "description": "Metres climbed in a given workout, from locations with an elevation",
+                    "type": "number"
+                },
+                "elevation_loss": {
+                    "description": "Metres descended in a given workout",
+                    "type": "number"
+                },
+                "ended_at": {
+                    "description": "EndedAt is the time when the workout was ended",
+                    "type": "string"
+                },
+                "escapes_made": {
+                    "description": "Escapes made in a given workout",
+                    "type": "integer"
+                },
+                "fights_fought": {
+                    "description": "Fights fought in a given workout",
+                    "type": "integer"
+                },
+                "hardcore_mode": {
+                    "description": "HardcoreMode is the difficulty level chosen by the player",
+                    "type": "boolean"
+                },
+                "hrm_connected": {
+                    "description": "HRMConnected tells whether a heart rate monitor is bound to the workout",
+                    "type": "boolean"
+                },
+                "is_completed": {
+                    "description": "IsCompleted tells whether the workout ended, completed or abandoned",
+                    "type": "boolean"
+                },
+                "off_trail": {
+                    "description": "OffTrail tells whether the player is currently away from the trail",
+                    "type": "boolean"
+                },
+                "off_trail_count": {
+                    "description": "Times the player strayed from the trail in a given workout",
+                    "type": "integer"
+                },
+                "paused_at": {
+                    "description": "PausedAt is the time when the workout was paused, zero while it is running",
+                    "type": "string"
+                },
+                "player_id": {
+                    "description": "PlayerID of the player starting the workout session",
+                    "type": "string"
+                },
+                "profile": {
+                    "description": "Player Profile can be either 'cardio' or 'strength'",
+                    "type": "string"
+                },
+                "resumed_at": {
+                    "description": "ResumedAt is the time when the workout was last resumed, zero if it never was",
+                    "type": "string"
+                },
+                "shelters_taken": {
+                    "description": "Shelters taken for a given workout",
+                    "type": "integer"
+                },
+                "state": {
+                    "description": "State of the workout in its lifecycle",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.WorkoutState"
+                        }
+                    ]
+                },
+                "summary": {
+                    "description": "Summary of the workout, missing when it could not be computed",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.WorkoutSummary"
+                        }
+                    ]
+                },
+                "trail_id": {
+                    "description": "trailId is the id of the trail player is on",
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version of the stored workout it was read at, bumped by every update",
+                    "type": "integer"
+                },
+                "workout_id": {
+                    "description": "ID is the identifier of the Entity, the ID is shared for all sub domains",
+                    "type": "string"
+                }
+            }
+        },
+        "httphandler.WorkoutPage": {
+            "type": "object",
+            "properties": {
+                "next_page": {
+                    "description": "Cursor of the next page, empty on the last page",
+                    "type": "string"
+                },
+                "page_size": {
+                    "description": "Number of workouts asked for",
+                    "type": "integer"
+                },
+                "workouts": {
+                    "description": "Workouts of the page in the requested order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Workout"
+                    }
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,124 @@
+package amqp
+
+import (
+	"fmt"
+
+	"github.com/CAS735-F23/macrun-teamvsl/workout/config"
+	logger "github.com/CAS735-F23/macrun-teamvsl/workout/log"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// dial connects to RabbitMQ, the workout manager does not start without it
+func dial(cfg *config.RabbitMQ) *amqp.Connection {
+	conn := fmt.Sprintf(
+		"amqp://%s:%s@%s:%s/",
+		cfg.User,
+		cfg.Password,
+		cfg.Host,
+		cfg.Port,
+	)
+
+	amqpConn, err := amqp.Dial(conn)
+	if err != nil {
+		logger.Fatal("unable to dial connection to RabbitMQ")
+	}
+	return amqpConn
+}
+
+// consumeQueue declares the queue, binds it to the exchange with the binding key when an exchange is given,
+// and hands the body of each message to handle in workerPoolSize workers until the channel is closed
+func consumeQueue(amqpConn *amqp.Connection, workerPoolSize int, exchangeName, queueName, bindingKey string, handle func(body []byte)) error {
+	ch, err := amqpConn.Channel()
+	if err != nil {
+		return fmt.Errorf("error amqpConn.Channel %w", err)
+	}
+
+	if exchangeName != "" {
+		logger.Debug("declaring exchange", zap.String("exchange_name", exchangeName))
+		err = ch.ExchangeDeclare(
+			exchangeName,
+			exchangeKind,
+			exchangeDurable,
+			exchangeAutoDelete,
+			exchangeInternal,
+			exchangeNoWait,
+			nil,
+		)
+		if err != nil {
+			ch.Close()
+			return fmt.Errorf("error ch.ExchangeDeclare %w", err)
+		}
+	}
+
+	queue, err := ch.QueueDeclare(
+		queueName,
+		queueDurable,
+		queueAutoDelete,
+		queueExclusive,
+		queueNoWait,
+		nil,
+	)
+	if err != nil {
+		ch.Close()
+		return fmt.Errorf("error ch.QueueDeclare %w", err)
+	}
+	logger.Debug("queue declared",
+		zap.String("queue_name", queue.Name),
+		zap.Int("message_count", queue.Messages),
+		zap.Int("consumer_count", queue.Consumers),
+	)
+
+	if exchangeName != "" {
+		err = ch.QueueBind(
+			queue.Name,
+			bindingKey,
+			exchangeName,
+			queueNoWait,
+			nil,
+		)
+		if err != nil {
+			ch.Close()
+			return fmt.Errorf("error ch.QueueBind %w", err)
+		}
+		logger.Debug("queue bound to exchange",
+			zap.String("queue_name", queue.Name),
+			zap.String("exchange_name", exchangeName),
+			zap.String("binding_key", bindingKey),
+		)
+	}
+
+	err = ch.Qos(
+		prefetchCount,  // prefetch count
+		prefetchSize,   // prefetch size
+		prefetchGlobal, // global
+	)
+	if err != nil {
+		ch.Close()
+		return fmt.Errorf("error ch.Qos %w", err)
+	}
+
+	deliveries, err := ch.Consume(
+		queue.Name,
+		"",
+		consumeAutoAck,
+		consumeExclusive,
+		consumeNoLocal,
+		consumeNoWait,
+		nil,
+	)
+	if err != nil {
+		ch.Close()
+		return fmt.Errorf("consume error %w", err)
+	}
+
+	for i := 0; i < workerPoolSize; i++ {
+		go func() {
+			for d := range deliveries {
+				logger.Debug("received a message", zap.String("queue_name", queue.Name), zap.String("delivery", string(d.Body)))
+				handle(d.Body)
+			}
+		}()
+	}
+	return nil
+}
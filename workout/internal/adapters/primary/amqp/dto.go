@@ -9,10 +9,40 @@ import (
 type ShelterAvailable struct {
 	// WorkoutID for which the Shelter Availability is there or not
 	WorkoutID uuid.UUID `json:"workout_id"`
+	// Closest shelter
+	ShelterID uuid.UUID `json:"shelter_id"`
+	// Whether the closest shelter has a place left
+	ShelterAvailability bool `json:"shelter_availability"`
 	// Distance to Shelter
 	DistanceToShelter float64 `json:"distance_to_shelter"`
 }
 
+type ShelterReservation struct {
+	// WorkoutID that asked for a place
+	WorkoutID uuid.UUID `json:"workout_id"`
+	// Shelter of the place
+	ShelterID uuid.UUID `json:"shelter_id"`
+	// true when the place is held, false when it was refused or expired
+	Reserved bool `json:"reserved"`
+	// why the place is not held: 'unavailable', 'not_found' or 'expired'
+	Reason string `json:"reason"`
+	// Time after which the zone gives the place back
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type OffTrail struct {
+	// WorkoutID that left or came back to its trail
+	WorkoutID uuid.UUID `json:"workout_id"`
+	// Trail of the workout
+	TrailID uuid.UUID `json:"trail_id"`
+	// true when the player left the trail, false when they are back on it
+	OffTrail bool `json:"off_trail"`
+	// Distance to the closest point of the trail in km
+	DistanceFromTrail float64 `json:"distance_from_trail"`
+	// Time of location
+	TimeOfLocation time.Time `json:"time_of_location"`
+}
+
 type LastLocation struct {
 	// WorkoutID for which the Shelter Availability is there or not
 	WorkoutID uuid.UUID `json:"workout_id"`
@@ -20,6 +50,23 @@ type LastLocation struct {
 	Latitude float64 `json:"latitude"`
 	// Longitude of the Player
 	Longitude float64 `json:"longitude"`
+	// Elevation of the Player in metres, missing when the device does not report it
+	Elevation *float64 `json:"elevation"`
+	// Time of location
+	TimeOfLocation time.Time `json:"time_of_location"`
+}
+
+type GeofenceEvent struct {
+	// 'trail_start_entered', 'trail_end_reached', 'shelter_arrived' or 'shelter_left'
+	Event string `json:"event"`
+	// WorkoutID that crossed the fence
+	WorkoutID uuid.UUID `json:"workout_id"`
+	// Trail of the workout, or of the shelter for shelter events
+	TrailID uuid.UUID `json:"trail_id"`
+	// Shelter of the event, nil for trail events
+	ShelterID uuid.UUID `json:"shelter_id"`
+	// Distance to the centre of the fence in km
+	Distance float64 `json:"distance"`
 	// Time of location
 	TimeOfLocation time.Time `json:"time_of_location"`
 }
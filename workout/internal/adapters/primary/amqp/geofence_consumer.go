@@ -0,0 +1,47 @@
+package amqp
+
+import (
+	"encoding/json"
+
+	"github.com/CAS735-F23/macrun-teamvsl/workout/config"
+	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/services"
+	logger "github.com/CAS735-F23/macrun-teamvsl/workout/log"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// Geofence AMQP Consumer
+type GeofenceConsumer struct {
+	amqpConn *amqp.Connection
+	svc      *services.WorkoutService
+	config   *config.RabbitMQ
+}
+
+func NewGeofenceConsumer(cfg *config.RabbitMQ, workoutSvc *services.WorkoutService) *GeofenceConsumer {
+	return &GeofenceConsumer{
+		config:   cfg,
+		amqpConn: dial(cfg),
+		svc:      workoutSvc,
+	}
+}
+
+// InitAMQP consumes the shelter arrivals published by the zone on its geofence exchange
+func (c *GeofenceConsumer) InitAMQP() {
+	if err := consumeQueue(c.amqpConn, 1, c.config.GeofenceExchange, c.config.GeofenceConsumer, shelterArrivedRoutingKey, c.handle); err != nil {
+		logger.Error("failed to consume geofence queue", zap.Error(err))
+	}
+}
+
+func (c *GeofenceConsumer) handle(body []byte) {
+	event := &GeofenceEvent{}
+	if err := json.Unmarshal(body, event); err != nil {
+		logger.Debug("failed to unmarshal geofence event", zap.Error(err))
+		return
+	}
+	if event.Event != shelterArrived {
+		return
+	}
+	if err := c.svc.ArriveAtShelter(event.WorkoutID, event.ShelterID); err != nil {
+		logger.Error("failed to handle shelter arrival", zap.Error(err))
+	}
+}
@@ -158,6 +158,6 @@ func (c *LocationConsumer) worker(ctx context.Context, deliveries <-chan amqp.De
 		if err != nil {
 			logger.Debug("failed to unmarshal %s", zap.Error(err))
 		}
-		c.svc.UpdateDistanceTravelled(lastLocation.WorkoutID, lastLocation.Latitude, lastLocation.Longitude, lastLocation.TimeOfLocation)
+		c.svc.UpdateDistanceTravelled(lastLocation.WorkoutID, lastLocation.Latitude, lastLocation.Longitude, lastLocation.Elevation, lastLocation.TimeOfLocation)
 	}
 }
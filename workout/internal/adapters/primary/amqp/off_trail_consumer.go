@@ -0,0 +1,43 @@
+package amqp
+
+import (
+	"encoding/json"
+
+	"github.com/CAS735-F23/macrun-teamvsl/workout/config"
+	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/services"
+	logger "github.com/CAS735-F23/macrun-teamvsl/workout/log"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// Off Trail AMQP Consumer
+type OffTrailConsumer struct {
+	amqpConn *amqp.Connection
+	svc      *services.WorkoutService
+	config   *config.RabbitMQ
+}
+
+func NewOffTrailConsumer(cfg *config.RabbitMQ, workoutSvc *services.WorkoutService) *OffTrailConsumer {
+	return &OffTrailConsumer{
+		config:   cfg,
+		amqpConn: dial(cfg),
+		svc:      workoutSvc,
+	}
+}
+
+func (c *OffTrailConsumer) InitAMQP() {
+	if err := consumeQueue(c.amqpConn, 1, "", c.config.OffTrailConsumer, "", c.handle); err != nil {
+		logger.Error("failed to consume off trail queue", zap.Error(err))
+	}
+}
+
+func (c *OffTrailConsumer) handle(body []byte) {
+	offTrail := &OffTrail{}
+	if err := json.Unmarshal(body, offTrail); err != nil {
+		logger.Debug("failed to unmarshal off trail status", zap.Error(err))
+		return
+	}
+	if err := c.svc.UpdateOffTrail(offTrail.WorkoutID, offTrail.OffTrail, offTrail.DistanceFromTrail); err != nil {
+		logger.Error("failed to update off trail status", zap.Error(err))
+	}
+}
@@ -1,11 +1,12 @@
 package amqp
 
 const (
-	// exchangeKind       = "direct"
-	// exchangeDurable    = true
-	// exchangeAutoDelete = false
-	// exchangeInternal   = false
-	// exchangeNoWait     = false
+	// the geofence events of the zone are published on a topic exchange
+	exchangeKind       = "topic"
+	exchangeDurable    = true
+	exchangeAutoDelete = false
+	exchangeInternal   = false
+	exchangeNoWait     = false
 
 	queueDurable    = true
 	queueAutoDelete = false
@@ -24,3 +25,9 @@ const (
 	consumeNoLocal   = false
 	consumeNoWait    = false
 )
+
+const (
+	// geofence event the workout completes its shelter option on, and its routing key
+	shelterArrived           = "shelter_arrived"
+	shelterArrivedRoutingKey = "geofence." + shelterArrived
+)
@@ -160,6 +160,6 @@ func (c *ShelterDistanceConsumer) worker(ctx context.Context, deliveries <-chan
 		if err != nil {
 			logger.Debug("failed to unmarshal %s", zap.Error(err))
 		}
-		c.svc.UpdateShelter(shelterAvailable.WorkoutID, shelterAvailable.DistanceToShelter)
+		c.svc.UpdateShelter(shelterAvailable.WorkoutID, shelterAvailable.ShelterID, shelterAvailable.ShelterAvailability, shelterAvailable.DistanceToShelter)
 	}
 }
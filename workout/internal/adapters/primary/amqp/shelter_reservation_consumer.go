@@ -0,0 +1,43 @@
+package amqp
+
+import (
+	"encoding/json"
+
+	"github.com/CAS735-F23/macrun-teamvsl/workout/config"
+	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/services"
+	logger "github.com/CAS735-F23/macrun-teamvsl/workout/log"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// Shelter Reservation AMQP Consumer
+type ShelterReservationConsumer struct {
+	amqpConn *amqp.Connection
+	svc      *services.WorkoutService
+	config   *config.RabbitMQ
+}
+
+func NewShelterReservationConsumer(cfg *config.RabbitMQ, workoutSvc *services.WorkoutService) *ShelterReservationConsumer {
+	return &ShelterReservationConsumer{
+		config:   cfg,
+		amqpConn: dial(cfg),
+		svc:      workoutSvc,
+	}
+}
+
+func (c *ShelterReservationConsumer) InitAMQP() {
+	if err := consumeQueue(c.amqpConn, 1, "", c.config.ShelterReservationConsumer, "", c.handle); err != nil {
+		logger.Error("failed to consume shelter reservation queue", zap.Error(err))
+	}
+}
+
+func (c *ShelterReservationConsumer) handle(body []byte) {
+	reservation := &ShelterReservation{}
+	if err := json.Unmarshal(body, reservation); err != nil {
+		logger.Debug("failed to unmarshal shelter reservation", zap.Error(err))
+		return
+	}
+	if err := c.svc.UpdateShelterReservation(reservation.WorkoutID, reservation.ShelterID, reservation.Reserved, reservation.Reason); err != nil {
+		logger.Error("failed to update shelter reservation", zap.Error(err))
+	}
+}
@@ -1,6 +1,9 @@
 package httphandler
 
-import "github.com/google/uuid"
+import (
+	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
+	"github.com/google/uuid"
+)
 
 type StartWorkout struct {
 	// TrailID chosen by the Player
@@ -24,3 +27,18 @@ type StopWorkoutOption struct {
 	// WorkoutID for which the workout option is to be stopped
 	WorkoutID uuid.UUID `json:"workout_id"`
 }
+
+type StoppedWorkout struct {
+	*domain.Workout
+	// Summary of the workout, missing when it could not be computed
+	Summary *domain.WorkoutSummary `json:"summary,omitempty"`
+}
+
+type WorkoutPage struct {
+	// Workouts of the page in the requested order
+	Workouts []*domain.Workout `json:"workouts"`
+	// Number of workouts asked for
+	PageSize int `json:"page_size"`
+	// Cursor of the next page, empty on the last page
+	NextPage string `json:"next_page,omitempty"`
+}
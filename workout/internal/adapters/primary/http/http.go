@@ -1,14 +1,19 @@
 package httphandler
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
 	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/services"
+	logger "github.com/CAS735-F23/macrun-teamvsl/workout/log"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 type WorkoutHanlder struct {
@@ -27,19 +32,144 @@ func (handler *WorkoutHanlder) InitRouter() {
 
 	router := handler.gin.Group("/api/v1")
 
+	router.GET("/workout", handler.ListWorkouts)
 	router.POST("/workout", handler.StartWorkout)
 	router.PUT("/workout/:workoutId", handler.StopWorkout)
+	router.POST("/workout/:workoutId/pause", handler.PauseWorkout)
+	router.POST("/workout/:workoutId/resume", handler.ResumeWorkout)
+	router.GET("/workout/:workoutId/transitions", handler.GetTransitions)
 
 	router.GET("/workout/:workoutId/options", handler.GetWorkoutOptions)
 	router.POST("/workout/:workoutId/options", handler.StartWorkoutOption)
 	router.PATCH("/workout/:workoutId/options", handler.StopWorkoutOption)
 
+	router.GET("/workout/:workoutId/track", handler.GetTrack)
+	router.GET("/workout/:workoutId/summary", handler.GetSummary)
+	router.GET("/workout/:workoutId/heartrate", handler.GetHeartRateZones)
+	router.GET("/workout/:workoutId/export", handler.ExportWorkout)
+
 	router.GET("workout/distance", handler.GetDistance)
 	router.GET("workout/shelters", handler.GetShelters)
 	router.GET("workout/escapes", handler.GetEscapes)
 	router.GET("workout/fights", handler.GetFights)
 }
 
+// ListWorkouts lists the workout history.
+//
+//	@Summary		List workouts
+//	@Description	This endpoint lists past and ongoing workout sessions, newest first by default. Pages are walked with the next_page cursor of the previous response.
+//	@Tags			workout
+//	@ID				list-workouts
+//	@Accept			json
+//	@Produce		json
+//	@Param			player_id	query		string		false	"ID of the player"
+//	@Param			trail_id	query		string		false	"ID of the trail"
+//	@Param			from		query		string		false	"Workouts started at or after this time (RFC3339 format)"
+//	@Param			to			query		string		false	"Workouts started at or before this time (RFC3339 format)"
+//	@Param			completed	query		bool		false	"Only completed (true) or ongoing (false) workouts"
+//	@Param			sort		query		string		false	"Order of the workouts (start_time/distance)"
+//	@Param			order		query		string		false	"Direction of the order (desc/asc)"
+//	@Param			page		query		string		false	"Cursor of the page, next_page of the previous response"
+//	@Param			page_size	query		int			false	"Number of workouts per page, 20 by default and at most 100"
+//	@Success		200			{object}	WorkoutPage	"Successfully listed workouts"
+//	@Failure		400			"Bad Request with error details"
+//	@Router			/api/v1/workout [get]
+func (h *WorkoutHanlder) ListWorkouts(ctx *gin.Context) {
+	var filter domain.WorkoutFilter
+	var err error
+
+	if ctx.Query("player_id") != "" {
+		if filter.PlayerID, err = parseUUID(ctx, "player_id"); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid player id",
+			})
+			return
+		}
+	}
+	if ctx.Query("trail_id") != "" {
+		if filter.TrailID, err = parseUUID(ctx, "trail_id"); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid trail id",
+			})
+			return
+		}
+	}
+	if ctx.Query("from") != "" {
+		if filter.From, err = parseTime(ctx, "from", time.RFC3339); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid from date format",
+			})
+			return
+		}
+	}
+	if ctx.Query("to") != "" {
+		if filter.To, err = parseTime(ctx, "to", time.RFC3339); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid to date format",
+			})
+			return
+		}
+	}
+	if completedStr := ctx.Query("completed"); completedStr != "" {
+		completed, err := strconv.ParseBool(completedStr)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid completed flag",
+			})
+			return
+		}
+		filter.Completed = &completed
+	}
+	filter.SortBy = ctx.Query("sort")
+	switch strings.ToLower(ctx.Query("order")) {
+	case "", "desc":
+	case "asc":
+		filter.Ascending = true
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid order",
+		})
+		return
+	}
+	if pageSizeStr := ctx.Query("page_size"); pageSizeStr != "" {
+		if filter.PageSize, err = strconv.Atoi(pageSizeStr); err != nil || filter.PageSize <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid page size",
+			})
+			return
+		}
+	}
+	if cursor := ctx.Query("page"); cursor != "" {
+		if filter.After, err = domain.DecodeWorkoutCursor(cursor); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
+
+	if err := filter.Normalize(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	page, err := h.svc.List(filter)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, WorkoutPage{
+		Workouts: page.Workouts,
+		PageSize: filter.PageSize,
+		NextPage: page.NextCursor,
+	})
+}
+
 // StartWorkout starts a new workout session for a player.
 //
 //	@Summary		Start a new workout session
@@ -91,13 +221,13 @@ func (h *WorkoutHanlder) StartWorkout(ctx *gin.Context) {
 // StopWorkout stops an ongoing workout session for a player.
 //
 //	@Summary		Stop an ongoing workout session
-//	@Description	This endpoint stops the workout session for a player based on the provided workout ID.
+//	@Description	This endpoint stops the workout session for a player based on the provided workout ID, and returns the workout with its summary.
 //	@Tags			workout
 //	@ID				stop-workout
 //	@Accept			json
 //	@Produce		json
-//	@Param			workoutId	path	string	true	"ID of the workout session to stop"
-//	@Success		202			"Successfully stopped workout session"
+//	@Param			workoutId	path		string			true	"ID of the workout session to stop"
+//	@Success		202			{object}	StoppedWorkout	"Successfully stopped workout session"
 //	@Failure		400			"Bad Request with error details"
 //	@Router			/api/v1/workout/{workoutId} [put]
 func (h *WorkoutHanlder) StopWorkout(ctx *gin.Context) {
@@ -120,7 +250,121 @@ func (h *WorkoutHanlder) StopWorkout(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusAccepted, w)
+	// the workout is stopped either way, the summary can still be asked for later
+	summary, err := h.svc.Summary(workoutID)
+	if err != nil {
+		logger.Error("failed to summarize stopped workout", zap.String("workout_id", workoutID.String()), zap.Error(err))
+	}
+
+	ctx.JSON(http.StatusAccepted, StoppedWorkout{Workout: w, Summary: summary})
+}
+
+// PauseWorkout pauses an ongoing workout session.
+//
+//	@Summary		Pause a workout session
+//	@Description	This endpoint pauses the workout session until it is resumed. No distance, pace or heart rate is credited to the workout while it is paused, and the peripheral stops publishing the live location.
+//	@Tags			workout
+//	@ID				pause-workout
+//	@Accept			json
+//	@Produce		json
+//	@Param			workoutId	path		string			true	"ID of the workout session to pause"
+//	@Success		200			{object}	domain.Workout	"Successfully paused workout session"
+//	@Failure		400			"Bad Request with error details"
+//	@Router			/api/v1/workout/{workoutId}/pause [post]
+func (h *WorkoutHanlder) PauseWorkout(ctx *gin.Context) {
+	workoutID, err := uuid.Parse(ctx.Param("workoutId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid workout id",
+		})
+		return
+	}
+
+	workout, err := h.svc.Pause(workoutID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, workout)
+}
+
+// ResumeWorkout resumes a paused workout session.
+//
+//	@Summary		Resume a workout session
+//	@Description	This endpoint resumes a workout session paused by the player or paused automatically because they stopped moving. The way covered during the pause is not credited to the workout.
+//	@Tags			workout
+//	@ID				resume-workout
+//	@Accept			json
+//	@Produce		json
+//	@Param			workoutId	path		string			true	"ID of the workout session to resume"
+//	@Success		200			{object}	domain.Workout	"Successfully resumed workout session"
+//	@Failure		400			"Bad Request with error details"
+//	@Router			/api/v1/workout/{workoutId}/resume [post]
+func (h *WorkoutHanlder) ResumeWorkout(ctx *gin.Context) {
+	workoutID, err := uuid.Parse(ctx.Param("workoutId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid workout id",
+		})
+		return
+	}
+
+	workout, err := h.svc.Resume(workoutID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, workout)
+}
+
+// GetTransitions retrieves the state history of a workout session.
+//
+//	@Summary		Get workout transitions
+//	@Description	This endpoint retrieves the transitions of the workout session through its states (created, running, in_option, paused, completed and abandoned), oldest first, with the event that caused each of them.
+//	@Tags			workout
+//	@ID				get-workout-transitions
+//	@Accept			json
+//	@Produce		json
+//	@Param			workoutId	path	string	true	"ID of the workout session"
+//	@Success		200			"Successfully retrieved workout transitions"
+//	@Failure		400			"Bad Request with error details"
+//	@Router			/api/v1/workout/{workoutId}/transitions [get]
+func (h *WorkoutHanlder) GetTransitions(ctx *gin.Context) {
+	workoutID, err := uuid.Parse(ctx.Param("workoutId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid workout id",
+		})
+		return
+	}
+
+	workout, err := h.svc.GetWorkout(workoutID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	transitions, err := h.svc.Transitions(workoutID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"workout_id":  workoutID,
+		"state":       workout.State,
+		"transitions": transitions,
+	})
 }
 
 // GetWorkoutOptions retrieves available options for a workout session.
@@ -239,6 +483,169 @@ func (h *WorkoutHanlder) StopWorkoutOption(ctx *gin.Context) {
 	})
 }
 
+// GetTrack retrieves the recorded GPS track of a workout session.
+//
+//	@Summary		Get workout track
+//	@Description	This endpoint retrieves the ordered list of locations recorded during a workout session, with their elevation when the device reports it, and the metres climbed and descended.
+//	@Tags			workout
+//	@ID				get-workout-track
+//	@Accept			json
+//	@Produce		json
+//	@Param			workoutId	path	string	true	"ID of the workout session"
+//	@Success		200			"Successfully retrieved workout track"
+//	@Failure		400			"Bad Request with error details"
+//	@Router			/api/v1/workout/{workoutId}/track [get]
+func (h *WorkoutHanlder) GetTrack(ctx *gin.Context) {
+	// Retrieve workoutId from the path parameter
+	workoutIdStr := ctx.Param("workoutId")
+
+	workoutID, err := uuid.Parse(workoutIdStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid workout id",
+		})
+		return
+	}
+
+	track, err := h.svc.GetTrack(workoutID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	workout, err := h.svc.GetWorkout(workoutID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"workout_id":     workoutID,
+		"elevation_gain": workout.ElevationGain,
+		"elevation_loss": workout.ElevationLoss,
+		"track":          track,
+	})
+}
+
+// GetSummary retrieves the pace, splits and moving time of a workout session.
+//
+//	@Summary		Get workout summary
+//	@Description	This endpoint computes the per kilometre splits, the average and best pace and the moving, paused and elapsed time of a workout session from its track. Distances are in metres, times in seconds and paces in seconds per km.
+//	@Tags			workout
+//	@ID				get-workout-summary
+//	@Accept			json
+//	@Produce		json
+//	@Param			workoutId	path		string					true	"ID of the workout session"
+//	@Success		200			{object}	domain.WorkoutSummary	"Successfully retrieved workout summary"
+//	@Failure		400			"Bad Request with error details"
+//	@Router			/api/v1/workout/{workoutId}/summary [get]
+func (h *WorkoutHanlder) GetSummary(ctx *gin.Context) {
+	workoutID, err := uuid.Parse(ctx.Param("workoutId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid workout id",
+		})
+		return
+	}
+
+	summary, err := h.svc.Summary(workoutID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, summary)
+}
+
+// GetHeartRateZones retrieves the time a workout session spent in each heart rate zone.
+//
+//	@Summary		Get workout heart rate zones
+//	@Description	This endpoint breaks the heart rate readings of a workout session, without its pauses, down into the five standard zones of the player: recovery (50-60%), endurance (60-70%), aerobic (70-80%), threshold (80-90%) and maximum (90-100%). The zones are percentages of the maximum heart rate, 220 - age, with the 'max' formula, or of the heart rate reserve above the resting heart rate of the player profile with the 'karvonen' formula, which falls back to 'max' when the player has no resting heart rate. Times are in seconds.
+//	@Tags			workout
+//	@ID				get-workout-heartrate
+//	@Accept			json
+//	@Produce		json
+//	@Param			workoutId	path		string						true	"ID of the workout session"
+//	@Param			formula		query		string						false	"Formula of the zones, 'max' or 'karvonen', the configured one when not given"
+//	@Success		200			{object}	domain.HeartRateAnalysis	"Successfully retrieved workout heart rate zones"
+//	@Failure		400			"Bad Request with error details"
+//	@Router			/api/v1/workout/{workoutId}/heartrate [get]
+func (h *WorkoutHanlder) GetHeartRateZones(ctx *gin.Context) {
+	workoutID, err := uuid.Parse(ctx.Param("workoutId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid workout id",
+		})
+		return
+	}
+
+	analysis, err := h.svc.HeartRateZones(workoutID, ctx.Query("formula"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, analysis)
+}
+
+// ExportWorkout exports a completed workout session for other fitness apps.
+//
+//	@Summary		Export a workout
+//	@Description	This endpoint renders the track, heart rate and workout options of a completed workout session as a GPX 1.1 or TCX document.
+//	@Tags			workout
+//	@ID				export-workout
+//	@Produce		xml
+//	@Param			workoutId	path	string	true	"ID of the workout session"
+//	@Param			format		query	string	true	"Export format (gpx/tcx)"
+//	@Success		200			"Successfully exported workout"
+//	@Failure		400			"Bad Request with error details"
+//	@Router			/api/v1/workout/{workoutId}/export [get]
+func (h *WorkoutHanlder) ExportWorkout(ctx *gin.Context) {
+	// Retrieve workoutId from the path parameter
+	workoutIdStr := ctx.Param("workoutId")
+
+	workoutID, err := uuid.Parse(workoutIdStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid workout id",
+		})
+		return
+	}
+
+	format := strings.ToLower(ctx.Query("format"))
+	var contentType string
+	switch format {
+	case domain.ExportFormatGPX:
+		contentType = "application/gpx+xml"
+	case domain.ExportFormatTCX:
+		contentType = "application/vnd.garmin.tcx+xml"
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": domain.ErrUnsupportedExportFormat.Error(),
+		})
+		return
+	}
+
+	body, err := h.svc.ExportWorkout(workoutID, format)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=workout-%s.%s", workoutID, format))
+	ctx.Data(http.StatusOK, contentType, body)
+}
+
 func parseUUID(ctx *gin.Context, paramName string) (uuid.UUID, error) {
 	uuidStr := ctx.Query(paramName)
 	uuidValue, err := uuid.Parse(uuidStr)
@@ -249,7 +656,7 @@ func parseUUID(ctx *gin.Context, paramName string) (uuid.UUID, error) {
 }
 
 func parseTime(ctx *gin.Context, paramName string, layout string) (time.Time, error) {
-	timeStr := ctx.Params.ByName(paramName)
+	timeStr := ctx.Query(paramName)
 	parsedTime, err := time.Parse(layout, timeStr)
 	if err != nil {
 		return time.Time{}, err
@@ -260,7 +667,7 @@ func parseTime(ctx *gin.Context, paramName string, layout string) (time.Time, er
 // GetDistance retrieves the distance covered in a workout session.
 //
 //	@Summary		Get distance covered in a workout
-//	@Description	This endpoint retrieves the distance covered in a workout session either by workout ID or by player ID within a date range.
+//	@Description	This endpoint retrieves the distance covered in a workout session either by workout ID or by player ID within a date range, with the number of workouts completed in the range. The distance is in the requested units, otherwise in the units preferred by the player.
 //	@Tags			workout
 //	@ID				get-distance
 //	@Accept			json
@@ -269,6 +676,7 @@ func parseTime(ctx *gin.Context, paramName string, layout string) (time.Time, er
 //	@Param			playerID	query	string	false	"ID of the player"
 //	@Param			startDate	query	string	false	"Start date for the range (RFC3339 format)"
 //	@Param			endDate		query	string	false	"End date for the range (RFC3339 format)"
+//	@Param			units		query	string	false	"Units of the distance (km/mi)"
 //	@Success		201			"Successfully retrieved distance"
 //	@Failure		400			"Bad Request with error details"
 //	@Router			/api/v1/workout/distance [get]
@@ -284,46 +692,98 @@ func (h *WorkoutHanlder) GetDistance(ctx *gin.Context) {
 			})
 			return
 		}
-	} else {
-		playerID, err := parseUUID(ctx, "playerID")
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "invalid player id",
-			})
-			return
-		}
 
-		startDate, err := parseTime(ctx, "startDate", time.RFC3339)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "invalid start date",
-			})
-			return
+		// the player of the workout is only needed for their preferred units
+		playerID := uuid.Nil
+		if ctx.Query("units") == "" {
+			workout, err := h.svc.GetWorkout(workoutID)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			playerID = workout.PlayerID
 		}
 
-		endDate, err := parseTime(ctx, "endDate", time.RFC3339)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "invalid end date",
-			})
+		unit, ok := h.distanceUnit(ctx, playerID)
+		if !ok {
 			return
 		}
 
-		distance, err = h.svc.GetDistanceCoveredBetweenDates(playerID, startDate, endDate)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
+		ctx.JSON(http.StatusCreated, gin.H{
+			"workout_id":       workoutID,
+			"distance_covered": unit.FromMetres(distance),
+			"units":            unit,
+		})
+		return
+	}
+
+	playerID, err := parseUUID(ctx, "playerID")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid player id",
+		})
+		return
+	}
+
+	startDate, err := parseTime(ctx, "startDate", time.RFC3339)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid start date",
+		})
+		return
+	}
+
+	endDate, err := parseTime(ctx, "endDate", time.RFC3339)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid end date",
+		})
+		return
+	}
+
+	unit, ok := h.distanceUnit(ctx, playerID)
+	if !ok {
+		return
+	}
+
+	distance, err = h.svc.GetDistanceCoveredBetweenDates(playerID, startDate, endDate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	workouts, err := h.svc.GetWorkoutsCompletedBetweenDates(playerID, startDate, endDate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
 	}
 
 	ctx.JSON(http.StatusCreated, gin.H{
-		"workout_id":       workoutID,
-		"distance_covered": distance,
+		"player_id":          playerID,
+		"distance_covered":   unit.FromMetres(distance),
+		"units":              unit,
+		"workouts_completed": workouts,
 	})
 }
 
+// distanceUnit reads the units query parameter, the units preferred by the player are used without it
+func (h *WorkoutHanlder) distanceUnit(ctx *gin.Context, playerID uuid.UUID) (domain.DistanceUnit, bool) {
+	unit, err := h.svc.DistanceUnit(playerID, ctx.Query("units"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return "", false
+	}
+	return unit, true
+}
+
 // GetShelters retrieves the number of shelters taken in a workout.
 //
 //	@Summary		Get shelters taken in a workout
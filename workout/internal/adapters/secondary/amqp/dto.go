@@ -3,13 +3,45 @@ package amqp
 import (
 	"time"
 
+	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
 	"github.com/google/uuid"
 )
 
 type challengeStatsDTO struct {
-	PlayerID        uuid.UUID `json:"player_id"`
+	PlayerID uuid.UUID `json:"player_id"`
+	// distance in km, the unit of the distance goals of the challenges
 	DistanceCovered float64   `json:"distance_covered"`
 	EnemiesFought   uint8     `json:"enemies_fought"`
 	EnemiesEscaped  uint8     `json:"enemies_escaped"`
 	WorkoutEnd      time.Time `json:"workout_end"`
+	// Abandoned tells the workout was ended without the player stopping it
+	Abandoned bool `json:"abandoned"`
+}
+
+func newChallengeStatsDTO(workout *domain.Workout) challengeStatsDTO {
+	return challengeStatsDTO{
+		PlayerID:        workout.PlayerID,
+		WorkoutEnd:      workout.EndedAt,
+		EnemiesFought:   workout.Fights,
+		EnemiesEscaped:  workout.Escapes,
+		DistanceCovered: domain.Kilometres.FromMetres(workout.DistanceCovered),
+		Abandoned:       workout.State == domain.WorkoutAbandoned,
+	}
+}
+
+// Actions the workout asks the zone for on its shelter place
+const (
+	shelterReservationReserve = "reserve"
+	shelterReservationRelease = "release"
+)
+
+type shelterReservationDTO struct {
+	WorkoutID uuid.UUID `json:"workout_id"`
+	ShelterID uuid.UUID `json:"shelter_id"`
+	Action    string    `json:"action"`
+}
+
+type workoutEndDTO struct {
+	WorkoutID uuid.UUID `json:"workout_id"`
+	EndedAt   time.Time `json:"ended_at"`
 }
@@ -0,0 +1,92 @@
+package amqp
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/CAS735-F23/macrun-teamvsl/workout/config"
+	logger "github.com/CAS735-F23/macrun-teamvsl/workout/log"
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+type ShelterReservationPublisher struct {
+	amqpConn *amqp.Connection
+	config   *config.RabbitMQ
+}
+
+// NewShelterReservationPublisher initializes a new ShelterReservationPublisher with a RabbitMQ connection
+func NewShelterReservationPublisher(cfg *config.RabbitMQ) *ShelterReservationPublisher {
+	conn := fmt.Sprintf(
+		"amqp://%s:%s@%s:%s/",
+		cfg.User,
+		cfg.Password,
+		cfg.Host,
+		cfg.Port,
+	)
+
+	amqpConn, err := amqp.Dial(conn)
+	if err != nil {
+		logger.Fatal("unable to dial connection to RabbitMQ", zap.Error(err))
+		return nil
+	}
+
+	return &ShelterReservationPublisher{
+		config:   cfg,
+		amqpConn: amqpConn,
+	}
+}
+
+// PublishShelterReservation asks the zone manager to hold a place in the shelter for the workout, or to give it back
+func (pub *ShelterReservationPublisher) PublishShelterReservation(workoutID uuid.UUID, shelterID uuid.UUID, reserve bool) error {
+	ch, err := pub.amqpConn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open a channel: %w", err)
+	}
+	defer ch.Close()
+
+	// Declare the queue to ensure it exists
+	_, err = ch.QueueDeclare(
+		pub.config.ShelterReservationPublisher, // queue name
+		queueDurable,                           // durable
+		queueAutoDelete,                        // delete when unused
+		queueExclusive,                         // exclusive
+		queueNoWait,                            // no-wait
+		nil,                                    // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare a queue: %w", err)
+	}
+
+	request := newShelterReservationDTO(workoutID, shelterID, reserve)
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to serialize shelter reservation: %w", err)
+	}
+
+	err = ch.Publish(
+		"",                                     // exchange
+		pub.config.ShelterReservationPublisher, // queue name
+		false,                                  // mandatory
+		false,                                  // immediate
+		amqp.Publishing{
+			ContentType: "application/json",
+			Body:        body,
+		},
+	)
+	logger.Info("shelter reservation published", zap.Any("reservation", request))
+	if err != nil {
+		return fmt.Errorf("failed to publish a message: %w", err)
+	}
+
+	return nil
+}
+
+func newShelterReservationDTO(workoutID uuid.UUID, shelterID uuid.UUID, reserve bool) shelterReservationDTO {
+	action := shelterReservationRelease
+	if reserve {
+		action = shelterReservationReserve
+	}
+	return shelterReservationDTO{WorkoutID: workoutID, ShelterID: shelterID, Action: action}
+}
@@ -0,0 +1,38 @@
+package amqp
+
+import (
+	"sync"
+
+	logger "github.com/CAS735-F23/macrun-teamvsl/workout/log"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ShelterReservationRequest is a reservation asked through the mock publisher
+type ShelterReservationRequest struct {
+	WorkoutID uuid.UUID
+	ShelterID uuid.UUID
+	Reserve   bool
+}
+
+// MockShelterReservationPublisher is a mock implementation of the ShelterReservationPublisher interface
+type MockShelterReservationPublisher struct {
+	Requests []ShelterReservationRequest
+	mu       sync.Mutex
+}
+
+// NewMockShelterReservationPublisher creates a new instance of MockShelterReservationPublisher
+func NewMockShelterReservationPublisher() *MockShelterReservationPublisher {
+	return &MockShelterReservationPublisher{
+		Requests: make([]ShelterReservationRequest, 0),
+	}
+}
+
+// PublishShelterReservation stores the request for verification in tests
+func (m *MockShelterReservationPublisher) PublishShelterReservation(workoutID uuid.UUID, shelterID uuid.UUID, reserve bool) error {
+	m.mu.Lock()
+	m.Requests = append(m.Requests, ShelterReservationRequest{WorkoutID: workoutID, ShelterID: shelterID, Reserve: reserve})
+	m.mu.Unlock()
+	logger.Debug("shelter reservation published to zone manager", zap.Any("reservation", newShelterReservationDTO(workoutID, shelterID, reserve)))
+	return nil
+}
@@ -0,0 +1,85 @@
+package amqp
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/CAS735-F23/macrun-teamvsl/workout/config"
+	logger "github.com/CAS735-F23/macrun-teamvsl/workout/log"
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+type WorkoutEndPublisher struct {
+	amqpConn *amqp.Connection
+	config   *config.RabbitMQ
+}
+
+// NewWorkoutEndPublisher initializes a new WorkoutEndPublisher with a RabbitMQ connection
+func NewWorkoutEndPublisher(cfg *config.RabbitMQ) *WorkoutEndPublisher {
+	conn := fmt.Sprintf(
+		"amqp://%s:%s@%s:%s/",
+		cfg.User,
+		cfg.Password,
+		cfg.Host,
+		cfg.Port,
+	)
+
+	amqpConn, err := amqp.Dial(conn)
+	if err != nil {
+		logger.Fatal("unable to dial connection to RabbitMQ", zap.Error(err))
+		return nil
+	}
+
+	return &WorkoutEndPublisher{
+		config:   cfg,
+		amqpConn: amqpConn,
+	}
+}
+
+// PublishWorkoutEnd tells the zone manager the workout ended, so that it closes the session of the workout
+func (pub *WorkoutEndPublisher) PublishWorkoutEnd(workoutID uuid.UUID, endedAt time.Time) error {
+	ch, err := pub.amqpConn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open a channel: %w", err)
+	}
+	defer ch.Close()
+
+	// Declare the queue to ensure it exists
+	_, err = ch.QueueDeclare(
+		pub.config.WorkoutEndPublisher, // queue name
+		queueDurable,                   // durable
+		queueAutoDelete,                // delete when unused
+		queueExclusive,                 // exclusive
+		queueNoWait,                    // no-wait
+		nil,                            // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare a queue: %w", err)
+	}
+
+	workoutEnd := workoutEndDTO{WorkoutID: workoutID, EndedAt: endedAt}
+	body, err := json.Marshal(workoutEnd)
+	if err != nil {
+		return fmt.Errorf("failed to serialize workout end: %w", err)
+	}
+
+	err = ch.Publish(
+		"",                             // exchange
+		pub.config.WorkoutEndPublisher, // queue name
+		false,                          // mandatory
+		false,                          // immediate
+		amqp.Publishing{
+			ContentType: "application/json",
+			Body:        body,
+		},
+	)
+	logger.Info("workout end published", zap.Any("workout_end", workoutEnd))
+	if err != nil {
+		return fmt.Errorf("failed to publish a message: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package amqp
+
+import (
+	"sync"
+	"time"
+
+	logger "github.com/CAS735-F23/macrun-teamvsl/workout/log"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// MockWorkoutEndPublisher is a mock implementation of the WorkoutEndPublisher interface
+type MockWorkoutEndPublisher struct {
+	// workouts whose end was published, in order
+	EndedWorkouts []uuid.UUID
+	mu            sync.Mutex
+}
+
+// NewMockWorkoutEndPublisher creates a new instance of MockWorkoutEndPublisher
+func NewMockWorkoutEndPublisher() *MockWorkoutEndPublisher {
+	return &MockWorkoutEndPublisher{
+		EndedWorkouts: make([]uuid.UUID, 0),
+	}
+}
+
+// PublishWorkoutEnd stores the workout for verification in tests
+func (m *MockWorkoutEndPublisher) PublishWorkoutEnd(workoutID uuid.UUID, endedAt time.Time) error {
+	m.mu.Lock()
+	m.EndedWorkouts = append(m.EndedWorkouts, workoutID)
+	m.mu.Unlock()
+	logger.Debug("workout end published to zone manager", zap.Any("workout_end", workoutEndDTO{WorkoutID: workoutID, EndedAt: endedAt}))
+	return nil
+}
@@ -84,13 +84,7 @@ func (pub *WorkoutStatsPublisher) PublishWorkoutStats(workoutStats *domain.Worko
 		return fmt.Errorf("failed to declare a queue: %w", err)
 	}
 
-	var challengeStatsDTO = challengeStatsDTO{
-		PlayerID:        workoutStats.PlayerID,
-		WorkoutEnd:      workoutStats.EndedAt,
-		EnemiesFought:   workoutStats.Fights,
-		EnemiesEscaped:  workoutStats.Escapes,
-		DistanceCovered: workoutStats.DistanceCovered,
-	}
+	challengeStatsDTO := newChallengeStatsDTO(workoutStats)
 
 	body, err := json.Marshal(challengeStatsDTO)
 	if err != nil {
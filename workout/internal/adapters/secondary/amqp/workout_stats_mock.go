@@ -1,6 +1,8 @@
 package amqp
 
 import (
+	"sync"
+
 	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
 	logger "github.com/CAS735-F23/macrun-teamvsl/workout/log"
 	"go.uber.org/zap"
@@ -10,6 +12,7 @@ import (
 type MockWorkoutStatsPublisher struct {
 	// Add fields to store information about calls to the methods, if necessary
 	PublishedWorkouts []*domain.Workout
+	mu                sync.Mutex
 }
 
 // NewMockWorkoutStatsPublisher creates a new instance of MockWorkoutStatsPublisher
@@ -23,14 +26,10 @@ func NewMockWorkoutStatsPublisher() *MockWorkoutStatsPublisher {
 func (m *MockWorkoutStatsPublisher) PublishWorkoutStats(workoutStats *domain.Workout) error {
 	// In the mock, we just store the workoutStats for verification in tests
 
-	var challengeStatsDTO = challengeStatsDTO{
-		PlayerID:        workoutStats.PlayerID,
-		WorkoutEnd:      workoutStats.EndedAt,
-		EnemiesFought:   workoutStats.Fights,
-		EnemiesEscaped:  workoutStats.Escapes,
-		DistanceCovered: workoutStats.DistanceCovered,
-	}
+	challengeStatsDTO := newChallengeStatsDTO(workoutStats)
+	m.mu.Lock()
 	m.PublishedWorkouts = append(m.PublishedWorkouts, workoutStats)
+	m.mu.Unlock()
 	logger.Debug("workout statistics published to challenge manager", zap.Any("stats", challengeStatsDTO))
 	return nil // Return nil to simulate successful execution
 }
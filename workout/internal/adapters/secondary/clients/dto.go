@@ -26,6 +26,13 @@ type UnbindPeripheralData struct {
 	WorkoutID uuid.UUID `json:"workout_id"`
 }
 
+type PausePeripheralData struct {
+	// WorkoutID of the workout paused or resumed
+	WorkoutID uuid.UUID `json:"workout_id"`
+	// Whether the live publishing is paused
+	Paused bool `json:"paused"`
+}
+
 type AverageHeartRate struct {
 	// WorkoutID for the workout to be stopped
 	WorkoutID uuid.UUID `json:"workout_id"`
@@ -33,6 +40,20 @@ type AverageHeartRate struct {
 	AverageHeartRate uint8 `json:"heart_rate"`
 }
 
+type HeartRateSample struct {
+	// Heart Rate reading
+	HeartRate int `json:"heart_rate"`
+	// Time of the reading
+	TimeOfReading time.Time `json:"time_of_reading"`
+}
+
+type HeartRateSamples struct {
+	// WorkoutID the readings were taken for
+	WorkoutID uuid.UUID `json:"workout_id"`
+	// Heart Rate readings in the order they were taken
+	Samples []HeartRateSample `json:"samples"`
+}
+
 type userDTO struct {
 	// ID is the identifier of the Entity, the ID is shared for all sub domains
 	ID uuid.UUID `json:"id"`
@@ -55,6 +76,10 @@ type playerDTO struct {
 	Height float64 `json:"height"`
 	// Preference of the player
 	Preference string `json:"preference"`
+	// DistanceUnit the player reads distances in, km or mi
+	DistanceUnit string `json:"distance_unit"`
+	// RestingHeartRate of the player in bpm, 0 when unknown
+	RestingHeartRate uint8 `json:"resting_heart_rate"`
 	// GeographicalZone is a group of trails in a region
 	ZoneID uuid.UUID `json:"zone_id"`
 	// CreatedAt is the time when the player registered
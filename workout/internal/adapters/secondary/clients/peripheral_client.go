@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"net/http"
 
+	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
 	"github.com/google/uuid"
 )
 
@@ -84,6 +85,38 @@ func (p *PeripheralClientImpl) UnbindPeripheralData(workoutID uuid.UUID) error {
 	return err
 }
 
+func (p *PeripheralClientImpl) PausePeripheralData(workoutID uuid.UUID, paused bool) error {
+	// Prepare the data for the PUT request
+	pauseData := PausePeripheralData{
+		WorkoutID: workoutID,
+		Paused:    paused,
+	}
+
+	pausePayload, err := json.Marshal(pauseData)
+	if err != nil {
+		return err
+	}
+
+	url := p.clientURL + "/api/v1/peripheral/pause"
+	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(pausePayload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("failed to pause peripheral: " + resp.Status)
+	}
+	return nil
+}
+
 func (p *PeripheralClientImpl) GetAverageHeartRateOfUser(workoutID uuid.UUID) (uint8, error) {
 	// Ensure workoutID is valid
 	if workoutID == uuid.Nil {
@@ -119,3 +152,51 @@ func (p *PeripheralClientImpl) GetAverageHeartRateOfUser(workoutID uuid.UUID) (u
 
 	return averageHeartRate.AverageHeartRate, nil
 }
+
+func (p *PeripheralClientImpl) GetHeartRateSamples(workoutID uuid.UUID) ([]domain.HeartRateSample, error) {
+	// Ensure workoutID is valid
+	if workoutID == uuid.Nil {
+		return nil, errors.New("invalid workout ID")
+	}
+
+	url := p.clientURL + "/api/v1/peripheral/hrm?workout_id=" + workoutID.String() + "&type=samples"
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Body == nil {
+		return nil, errors.New("received nil response or nil body")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("failed to get heart rate samples: " + resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var heartRateSamples HeartRateSamples
+	err = json.Unmarshal(body, &heartRateSamples)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]domain.HeartRateSample, len(heartRateSamples.Samples))
+	for i, sample := range heartRateSamples.Samples {
+		samples[i] = domain.HeartRateSample{
+			HeartRate:     uint8(sample.HeartRate),
+			TimeOfReading: sample.TimeOfReading,
+		}
+	}
+
+	return samples, nil
+}
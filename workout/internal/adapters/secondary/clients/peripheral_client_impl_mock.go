@@ -1,6 +1,7 @@
 package clients
 
 import (
+	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 )
@@ -40,8 +41,20 @@ func (m *PeripheralClientMock) UnbindPeripheralData(workoutID uuid.UUID) error {
 	return args.Error(0)
 }
 
+// PausePeripheralData provides a mock function with given fields
+func (m *PeripheralClientMock) PausePeripheralData(workoutID uuid.UUID, paused bool) error {
+	args := m.Called(workoutID, paused)
+	return args.Error(0)
+}
+
 // GetAverageHeartRateOfUser provides a mock function with given fields
 func (m *PeripheralClientMock) GetAverageHeartRateOfUser(workoutID uuid.UUID) (uint8, error) {
 	args := m.Called(workoutID)
 	return args.Get(0).(uint8), args.Error(1)
 }
+
+// GetHeartRateSamples provides a mock function with given fields
+func (m *PeripheralClientMock) GetHeartRateSamples(workoutID uuid.UUID) ([]domain.HeartRateSample, error) {
+	args := m.Called(workoutID)
+	return args.Get(0).([]domain.HeartRateSample), args.Error(1)
+}
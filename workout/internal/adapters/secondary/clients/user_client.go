@@ -95,3 +95,53 @@ func (u *UserServiceClientImpl) GetUserAge(playerID uuid.UUID) (uint8, error) {
 
 	return uint8(age), nil
 }
+
+// GetDistanceUnitOfUser returns the unit the player reads distances in
+func (u *UserServiceClientImpl) GetDistanceUnitOfUser(playerID uuid.UUID) (string, error) {
+
+	url := u.clientURL + "/api/v1/players/" + playerID.String()
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var playerDTO playerDTO
+	err = json.NewDecoder(resp.Body).Decode(&playerDTO)
+	if err != nil {
+		return "", err
+	}
+	return playerDTO.DistanceUnit, nil
+}
+
+// GetRestingHeartRateOfUser returns the resting heart rate of the player, 0 when they did not give it
+func (u *UserServiceClientImpl) GetRestingHeartRateOfUser(playerID uuid.UUID) (uint8, error) {
+
+	url := u.clientURL + "/api/v1/players/" + playerID.String()
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var playerDTO playerDTO
+	err = json.NewDecoder(resp.Body).Decode(&playerDTO)
+	if err != nil {
+		return 0, err
+	}
+	return playerDTO.RestingHeartRate, nil
+}
@@ -32,3 +32,15 @@ func (m *UserServiceClientMock) GetUserAge(playerID uuid.UUID) (uint8, error) {
 	args := m.Called(playerID)
 	return uint8(args.Int(0)), args.Error(1)
 }
+
+// GetDistanceUnitOfUser provides a mock function to get the distance unit of a user
+func (m *UserServiceClientMock) GetDistanceUnitOfUser(playerID uuid.UUID) (string, error) {
+	args := m.Called(playerID)
+	return args.String(0), args.Error(1)
+}
+
+// GetRestingHeartRateOfUser provides a mock function to get the resting heart rate of a user
+func (m *UserServiceClientMock) GetRestingHeartRateOfUser(playerID uuid.UUID) (uint8, error) {
+	args := m.Called(playerID)
+	return uint8(args.Int(0)), args.Error(1)
+}
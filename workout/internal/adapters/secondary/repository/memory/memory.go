@@ -1,8 +1,11 @@
 package repository
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
 	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/ports"
@@ -10,59 +13,373 @@ import (
 	"github.com/google/uuid"
 )
 
+// MemoryRepository keeps the workouts in memory, it behaves like the postgres repository including the
+// versioning of the updates and the single workout in progress per player
 type MemoryRepository struct {
-	workouts map[uuid.UUID]domain.Workout
+	workouts       map[uuid.UUID]domain.Workout
+	workoutOptions map[uuid.UUID]domain.WorkoutOptions
+	tracks         map[uuid.UUID][]domain.TrackPoint
+	optionEvents   map[uuid.UUID][]domain.WorkoutOptionEvent
+	pauses         map[uuid.UUID][]domain.WorkoutPause
+	transitions    map[uuid.UUID][]domain.WorkoutTransition
+	heartRates     map[uuid.UUID][]domain.HeartRateSample
 	sync.Mutex
 }
 
+var _ ports.WorkoutRepository = (*MemoryRepository)(nil)
+
 func NewMemoryRepository() *MemoryRepository {
 	return &MemoryRepository{
-		workouts: make(map[uuid.UUID]domain.Workout),
+		workouts:       make(map[uuid.UUID]domain.Workout),
+		workoutOptions: make(map[uuid.UUID]domain.WorkoutOptions),
+		tracks:         make(map[uuid.UUID][]domain.TrackPoint),
+		optionEvents:   make(map[uuid.UUID][]domain.WorkoutOptionEvent),
+		pauses:         make(map[uuid.UUID][]domain.WorkoutPause),
+		transitions:    make(map[uuid.UUID][]domain.WorkoutTransition),
+		heartRates:     make(map[uuid.UUID][]domain.HeartRateSample),
 	}
 }
 
-func (r *MemoryRepository) List() ([]*domain.Workout, error) {
-	if r.workouts == nil {
-		// If r.workouts is nil, return an error or handle the case accordingly
-		return nil, fmt.Errorf("workouts map doesn't exit %w", ports.ErrorListWorkoutsFailed)
+func (r *MemoryRepository) ListWorkouts(filter domain.WorkoutFilter, limit int) ([]*domain.Workout, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	less := func(a, b *domain.Workout) bool {
+		if filter.SortBy == domain.WorkoutSortDistance && a.DistanceCovered != b.DistanceCovered {
+			return a.DistanceCovered < b.DistanceCovered
+		}
+		if filter.SortBy != domain.WorkoutSortDistance && !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return bytes.Compare(a.WorkoutID[:], b.WorkoutID[:]) < 0
+	}
+	before := func(a, b *domain.Workout) bool {
+		if filter.Ascending {
+			return less(a, b)
+		}
+		return less(b, a)
+	}
+	var after *domain.Workout
+	if filter.After != nil {
+		after = &domain.Workout{WorkoutID: filter.After.WorkoutID, CreatedAt: filter.After.CreatedAt, DistanceCovered: filter.After.Distance}
 	}
-	workouts := make([]*domain.Workout, 0, len(r.workouts))
 
+	workouts := make([]*domain.Workout, 0)
 	for _, workout := range r.workouts {
+		workout := workout
+		switch {
+		case filter.PlayerID != uuid.Nil && workout.PlayerID != filter.PlayerID,
+			filter.TrailID != uuid.Nil && workout.TrailID != filter.TrailID,
+			!filter.From.IsZero() && workout.CreatedAt.Before(filter.From),
+			!filter.To.IsZero() && workout.CreatedAt.After(filter.To),
+			filter.Completed != nil && workout.IsCompleted != *filter.Completed,
+			after != nil && !before(after, &workout):
+			continue
+		}
 		workouts = append(workouts, &workout)
 	}
+
+	sort.Slice(workouts, func(i, j int) bool {
+		return before(workouts[i], workouts[j])
+	})
+	if len(workouts) > limit {
+		workouts = workouts[:limit]
+	}
 	return workouts, nil
 }
 
-func (r *MemoryRepository) Create(workout domain.Workout) error {
-	if r.workouts == nil {
-		r.Lock()
-		r.workouts = make(map[uuid.UUID]domain.Workout)
-		r.Unlock()
-	}
+func (r *MemoryRepository) Create(workout *domain.Workout, workoutOptions *domain.WorkoutOptions) error {
+	r.Lock()
+	defer r.Unlock()
 
-	if _, ok := r.workouts[workout.GetID()]; ok {
+	if _, ok := r.workouts[workout.WorkoutID]; ok {
 		return fmt.Errorf("workout already exist: %w", ports.ErrorCreateWorkoutFailed)
 	}
+	if !workout.IsCompleted {
+		for _, other := range r.workouts {
+			if other.PlayerID == workout.PlayerID && !other.IsCompleted {
+				return ports.ErrorActiveWorkoutAlreadyExists
+			}
+		}
+	}
+
+	r.logTransitions(workout)
+	r.workouts[workout.WorkoutID] = *workout
+	r.workoutOptions[workout.WorkoutID] = *workoutOptions
+	return nil
+}
+
+func (r *MemoryRepository) GetWorkout(workoutID uuid.UUID) (*domain.Workout, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	workout, ok := r.workouts[workoutID]
+	if !ok {
+		return &domain.Workout{}, ports.ErrorWorkoutNotFound
+	}
+	return &workout, nil
+}
+
+func (r *MemoryRepository) UpdateWorkout(workout *domain.Workout) (*domain.Workout, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	stored, ok := r.workouts[workout.WorkoutID]
+	if !ok {
+		return &domain.Workout{}, ports.ErrorWorkoutNotFound
+	}
+	if stored.Version != workout.Version {
+		return &domain.Workout{}, ports.ErrorWorkoutVersionConflict
+	}
+
+	workout.Version++
+	r.logTransitions(workout)
+	r.workouts[workout.WorkoutID] = *workout
+	updated := *workout
+	return &updated, nil
+}
+
+// logTransitions moves the new transitions of the workout to its event log
+func (r *MemoryRepository) logTransitions(workout *domain.Workout) {
+	for _, transition := range workout.NewTransitions {
+		r.transitions[workout.WorkoutID] = append(r.transitions[workout.WorkoutID], *transition)
+	}
+	workout.NewTransitions = nil
+}
+
+func (r *MemoryRepository) GetWorkoutTransitions(workoutID uuid.UUID) ([]*domain.WorkoutTransition, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	transitions := make([]*domain.WorkoutTransition, len(r.transitions[workoutID]))
+	for i := range r.transitions[workoutID] {
+		transition := r.transitions[workoutID][i]
+		transitions[i] = &transition
+	}
+	return transitions, nil
+}
+
+func (r *MemoryRepository) ListActiveWorkouts() ([]*domain.Workout, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	workouts := make([]*domain.Workout, 0)
+	for _, workout := range r.workouts {
+		if !workout.IsCompleted {
+			workout := workout
+			workouts = append(workouts, &workout)
+		}
+	}
+	sort.Slice(workouts, func(i, j int) bool {
+		return workouts[i].CreatedAt.Before(workouts[j].CreatedAt)
+	})
+	return workouts, nil
+}
+
+func (r *MemoryRepository) GetWorkoutOptions(workoutID uuid.UUID) (*domain.WorkoutOptions, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	workoutOptions, ok := r.workoutOptions[workoutID]
+	if !ok {
+		return &domain.WorkoutOptions{}, ports.ErrorWorkoutNotFound
+	}
+	return &workoutOptions, nil
+}
+
+func (r *MemoryRepository) UpdateWorkoutOptions(workoutOptions *domain.WorkoutOptions) (*domain.WorkoutOptions, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	stored, ok := r.workoutOptions[workoutOptions.WorkoutID]
+	if !ok {
+		return &domain.WorkoutOptions{}, ports.ErrorWorkoutNotFound
+	}
+	if stored.Version != workoutOptions.Version {
+		return &domain.WorkoutOptions{}, ports.ErrorWorkoutVersionConflict
+	}
+
+	workoutOptions.Version++
+	r.workoutOptions[workoutOptions.WorkoutID] = *workoutOptions
+	updated := *workoutOptions
+	return &updated, nil
+}
+
+func (r *MemoryRepository) DeleteWorkoutOptions(workoutID uuid.UUID) error {
+	r.Lock()
+	defer r.Unlock()
+
+	delete(r.workoutOptions, workoutID)
+	return nil
+}
+
+func (r *MemoryRepository) AddTrackPoint(point *domain.TrackPoint) error {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, stored := range r.tracks[point.WorkoutID] {
+		if stored.Sequence == point.Sequence {
+			return fmt.Errorf("track point %d of workout %s already exists", point.Sequence, point.WorkoutID)
+		}
+	}
+	r.tracks[point.WorkoutID] = append(r.tracks[point.WorkoutID], *point)
+	return nil
+}
+
+func (r *MemoryRepository) GetTrack(workoutID uuid.UUID) ([]*domain.TrackPoint, error) {
 	r.Lock()
-	r.workouts[workout.GetID()] = workout
-	r.Unlock()
+	defer r.Unlock()
+
+	track := make([]*domain.TrackPoint, len(r.tracks[workoutID]))
+	for i := range r.tracks[workoutID] {
+		point := r.tracks[workoutID][i]
+		track[i] = &point
+	}
+	sort.Slice(track, func(i, j int) bool {
+		return track[i].Sequence < track[j].Sequence
+	})
+	return track, nil
+}
+
+func (r *MemoryRepository) GetLastTrackPoint(workoutID uuid.UUID) (*domain.TrackPoint, error) {
+	track, err := r.GetTrack(workoutID)
+	if err != nil || len(track) == 0 {
+		return nil, err
+	}
+	return track[len(track)-1], nil
+}
+
+func (r *MemoryRepository) AddWorkoutOptionEvent(event *domain.WorkoutOptionEvent) error {
+	r.Lock()
+	defer r.Unlock()
+
+	r.optionEvents[event.WorkoutID] = append(r.optionEvents[event.WorkoutID], *event)
 	return nil
 }
 
-func (mr *MemoryRepository) Get(pid uuid.UUID) (*domain.Workout, error) {
-	if workout, ok := mr.workouts[pid]; ok {
-		return &workout, nil
+func (r *MemoryRepository) GetWorkoutOptionEvents(workoutID uuid.UUID) ([]*domain.WorkoutOptionEvent, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	events := make([]*domain.WorkoutOptionEvent, len(r.optionEvents[workoutID]))
+	for i := range r.optionEvents[workoutID] {
+		event := r.optionEvents[workoutID][i]
+		events[i] = &event
 	}
-	return &domain.Workout{}, ports.ErrorWorkoutNotFound
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].StartedAt.Before(events[j].StartedAt)
+	})
+	return events, nil
+}
+
+func (r *MemoryRepository) AddWorkoutPause(pause *domain.WorkoutPause) error {
+	r.Lock()
+	defer r.Unlock()
+
+	r.pauses[pause.WorkoutID] = append(r.pauses[pause.WorkoutID], *pause)
+	return nil
 }
 
-func (r *MemoryRepository) Update(workout domain.Workout) error {
-	if _, ok := r.workouts[workout.GetID()]; !ok {
-		return fmt.Errorf("workout does not exist: %w", ports.ErrorUpdateWorkoutFailed)
+func (r *MemoryRepository) GetWorkoutPauses(workoutID uuid.UUID) ([]*domain.WorkoutPause, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	pauses := make([]*domain.WorkoutPause, len(r.pauses[workoutID]))
+	for i := range r.pauses[workoutID] {
+		pause := r.pauses[workoutID][i]
+		pauses[i] = &pause
 	}
+	sort.SliceStable(pauses, func(i, j int) bool {
+		return pauses[i].StartedAt.Before(pauses[j].StartedAt)
+	})
+	return pauses, nil
+}
+
+func (r *MemoryRepository) AddHeartRateSamples(workoutID uuid.UUID, samples []domain.HeartRateSample) error {
 	r.Lock()
-	r.workouts[workout.GetID()] = workout
-	r.Unlock()
+	defer r.Unlock()
+
+	r.heartRates[workoutID] = append(r.heartRates[workoutID], samples...)
 	return nil
 }
+
+func (r *MemoryRepository) GetHeartRateSamples(workoutID uuid.UUID) ([]domain.HeartRateSample, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	samples := append([]domain.HeartRateSample{}, r.heartRates[workoutID]...)
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].TimeOfReading.Before(samples[j].TimeOfReading)
+	})
+	return samples, nil
+}
+
+// workout returns a copy of the workout, the zero workout when it does not exist
+func (r *MemoryRepository) workout(workoutID uuid.UUID) domain.Workout {
+	r.Lock()
+	defer r.Unlock()
+	return r.workouts[workoutID]
+}
+
+// sumBetweenDates adds up the value of the workouts of the player started and ended in the range
+func (r *MemoryRepository) sumBetweenDates(playerID uuid.UUID, startDate time.Time, endDate time.Time, value func(workout domain.Workout) float64) float64 {
+	r.Lock()
+	defer r.Unlock()
+
+	sum := 0.0
+	for _, workout := range r.workouts {
+		if workout.PlayerID == playerID && !workout.CreatedAt.Before(startDate) && !workout.EndedAt.After(endDate) {
+			sum += value(workout)
+		}
+	}
+	return sum
+}
+
+func (r *MemoryRepository) GetDistanceByID(workoutID uuid.UUID) (float64, error) {
+	return r.workout(workoutID).DistanceCovered, nil
+}
+
+func (r *MemoryRepository) GetDistanceCoveredBetweenDates(playerID uuid.UUID, startDate time.Time, endDate time.Time) (float64, error) {
+	return r.sumBetweenDates(playerID, startDate, endDate, func(workout domain.Workout) float64 {
+		return workout.DistanceCovered
+	}), nil
+}
+
+func (r *MemoryRepository) GetWorkoutsCompletedBetweenDates(playerID uuid.UUID, startDate time.Time, endDate time.Time) (uint16, error) {
+	return uint16(r.sumBetweenDates(playerID, startDate, endDate, func(workout domain.Workout) float64 {
+		if workout.IsCompleted {
+			return 1
+		}
+		return 0
+	})), nil
+}
+
+func (r *MemoryRepository) GetEscapesMadeByID(workoutID uuid.UUID) (uint16, error) {
+	return uint16(r.workout(workoutID).Escapes), nil
+}
+
+func (r *MemoryRepository) GetEscapesMadeBetweenDates(playerID uuid.UUID, startDate time.Time, endDate time.Time) (uint16, error) {
+	return uint16(r.sumBetweenDates(playerID, startDate, endDate, func(workout domain.Workout) float64 {
+		return float64(workout.Escapes)
+	})), nil
+}
+
+func (r *MemoryRepository) GetFightsFoughtByID(workoutID uuid.UUID) (uint16, error) {
+	return uint16(r.workout(workoutID).Fights), nil
+}
+
+func (r *MemoryRepository) GetFightsFoughtBetweenDates(playerID uuid.UUID, startDate time.Time, endDate time.Time) (uint16, error) {
+	return uint16(r.sumBetweenDates(playerID, startDate, endDate, func(workout domain.Workout) float64 {
+		return float64(workout.Fights)
+	})), nil
+}
+
+func (r *MemoryRepository) GetSheltersTakenByID(workoutID uuid.UUID) (uint16, error) {
+	return uint16(r.workout(workoutID).Shelters), nil
+}
+
+func (r *MemoryRepository) GetSheltersTakenBetweenDates(playerID uuid.UUID, startDate time.Time, endDate time.Time) (uint16, error) {
+	return uint16(r.sumBetweenDates(playerID, startDate, endDate, func(workout domain.Workout) float64 {
+		return float64(workout.Shelters)
+	})), nil
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"fmt"
+
+	logger "github.com/CAS735-F23/macrun-teamvsl/workout/log"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Unique constraints of earlier versions of the workouts table, they allowed a single workout per player and
+// per trail ever. AutoMigrate does not drop them.
+var legacyWorkoutConstraints = []string{
+	"postgres_workouts_trail_id_key",
+	"postgres_workouts_player_id_key",
+}
+
+// migrate brings the schema up to date, existing databases are moved over before the tables are migrated
+func migrate(db *gorm.DB) error {
+	if db.Migrator().HasTable(&postgresWorkout{}) {
+		if err := migrateWorkouts(db); err != nil {
+			return err
+		}
+	}
+	if err := db.AutoMigrate(&postgresWorkout{}, &postgresWorkoutOptions{}, &postgresTrackPoint{}, &postgresWorkoutOptionEvent{}, &postgresWorkoutPause{}, &postgresWorkoutTransition{}, &postgresHeartRateSample{}); err != nil {
+		return err
+	}
+	return migrateWorkoutStates(db)
+}
+
+// migrateWorkoutStates gives the workouts stored before their lifecycle was tracked the state their flags
+// tell, they have no event log before it. A workout that was never paused has the zero time in UTC, which
+// is compared as such whatever the time zone of the session.
+func migrateWorkoutStates(db *gorm.DB) error {
+	res := db.Exec(`
+		UPDATE postgres_workouts AS w
+		SET state = CASE
+			WHEN w.is_completed THEN 'completed'
+			WHEN w.paused_at IS NOT NULL AND w.paused_at > '0001-01-01 00:00:00+00'::timestamptz THEN 'paused'
+			WHEN EXISTS (
+				SELECT 1 FROM postgres_workout_options AS o
+				WHERE o.workout_id = w.workout_id AND o.is_workout_option_active
+			) THEN 'in_option'
+			ELSE 'running'
+		END
+		WHERE w.state IS NULL OR w.state = ''`)
+	if res.Error != nil {
+		return fmt.Errorf("failed to set the state of the workouts: %w", res.Error)
+	}
+	if res.RowsAffected > 0 {
+		logger.Info("set the state of the workouts", zap.Int64("workouts", res.RowsAffected))
+	}
+	return nil
+}
+
+// migrateWorkouts drops the legacy unique constraints and leaves a single workout in progress per player, so
+// that the index keeping it that way can be created
+func migrateWorkouts(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, constraint := range legacyWorkoutConstraints {
+			if err := tx.Exec(fmt.Sprintf("ALTER TABLE postgres_workouts DROP CONSTRAINT IF EXISTS %s", constraint)).Error; err != nil {
+				return fmt.Errorf("failed to drop constraint %s: %w", constraint, err)
+			}
+		}
+
+		// the latest workout of a player stays in progress, the ones before it are ended when it started
+		res := tx.Exec(`
+			UPDATE postgres_workouts AS w
+			SET is_completed = true, ended_at = latest.created_at
+			FROM (
+				SELECT DISTINCT ON (player_id) player_id, workout_id, created_at
+				FROM postgres_workouts
+				WHERE NOT is_completed
+				ORDER BY player_id, created_at DESC, workout_id DESC
+			) AS latest
+			WHERE NOT w.is_completed AND w.player_id = latest.player_id AND w.workout_id <> latest.workout_id`)
+		if res.Error != nil {
+			return fmt.Errorf("failed to end duplicate workouts in progress: %w", res.Error)
+		}
+		if res.RowsAffected > 0 {
+			logger.Info("ended duplicate workouts in progress", zap.Int64("workouts", res.RowsAffected))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CAS735-F23/macrun-teamvsl/workout/config"
+	"github.com/google/uuid"
+)
+
+func TestMigrateWorkoutStates(t *testing.T) {
+	repo := NewRepository(config.Config.Postgres)
+
+	// Everything is rolled back. In a session ahead of UTC a date written without a time zone is earlier
+	// than the zero time the workouts that were never paused have.
+	tx := repo.db.Begin()
+	defer tx.Rollback()
+	if err := tx.Exec("SET LOCAL TIME ZONE 'Asia/Tokyo'").Error; err != nil {
+		t.Fatalf("failed to set the time zone: %v", err)
+	}
+
+	now := time.Now()
+	workouts := []struct {
+		workout postgresWorkout
+		state   string
+	}{
+		{postgresWorkout{CreatedAt: now}, "running"},
+		{postgresWorkout{CreatedAt: now, PausedAt: now}, "paused"},
+		{postgresWorkout{CreatedAt: now, IsCompleted: true, EndedAt: now}, "completed"},
+	}
+	for i := range workouts {
+		workout := &workouts[i].workout
+		workout.WorkoutID, workout.TrailID, workout.PlayerID = uuid.New(), uuid.New(), uuid.New()
+		if err := tx.Create(workout).Error; err != nil {
+			t.Fatalf("failed to create the workout: %v", err)
+		}
+	}
+
+	if err := migrateWorkoutStates(tx); err != nil {
+		t.Fatalf("expected the states to be set, got %v", err)
+	}
+	for _, test := range workouts {
+		var stored postgresWorkout
+		if err := tx.First(&stored, "workout_id = ?", test.workout.WorkoutID).Error; err != nil {
+			t.Fatalf("failed to read the workout: %v", err)
+		}
+		if stored.State != test.state {
+			t.Errorf("expected the workout to be %s, got %q", test.state, stored.State)
+		}
+	}
+}
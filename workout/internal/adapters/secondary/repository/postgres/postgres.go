@@ -7,6 +7,7 @@ import (
 
 	"github.com/CAS735-F23/macrun-teamvsl/workout/config"
 	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
+	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/ports"
 	logger "github.com/CAS735-F23/macrun-teamvsl/workout/log"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -34,12 +35,16 @@ func NewRepository(cfg *config.Postgres) *Repository {
 
 	db, err := gorm.Open(postgres.Open(conn), &gorm.Config{
 		Logger: gormLogger.Default.LogMode(logLevel),
+		// unique violations come back as gorm.ErrDuplicatedKey
+		TranslateError: true,
 	})
 	if err != nil {
 		logger.Fatal("failed to connect to database", zap.Error(err))
 	}
 
-	db.AutoMigrate(&postgresWorkout{}, &postgresWorkoutOptions{})
+	if err := migrate(db); err != nil {
+		logger.Fatal("failed to migrate database", zap.Error(err))
+	}
 
 	return &Repository{
 		db: db,
@@ -52,13 +57,15 @@ type postgresWorkout struct {
 	// ID is the identifier of the Entity, the ID is shared for all sub domains
 	WorkoutID uuid.UUID `gorm:"type:uuid;primaryKey"`
 	// trailId is the id of the trail player is on
-	TrailID uuid.UUID `gorm:"type:uuid;unique not null"`
-	// PlayerID of the player starting the workout session
-	PlayerID uuid.UUID `gorm:"type:uuid;unique not null"`
-	// InProgress tells whether the workout is in progress
+	TrailID uuid.UUID `gorm:"type:uuid;not null;index"`
+	// PlayerID of the player starting the workout session, a player has at most one workout in progress
+	PlayerID uuid.UUID `gorm:"type:uuid;not null;index:idx_postgres_workouts_player_created,priority:1;uniqueIndex:idx_postgres_workouts_active_player,where:NOT is_completed"`
+	// State of the workout in its lifecycle
+	State string
+	// IsCompleted tells whether the workout ended, completed or abandoned
 	IsCompleted bool
 	// CreatedAt is the time when the workout was started
-	CreatedAt time.Time
+	CreatedAt time.Time `gorm:"index:idx_postgres_workouts_player_created,priority:2"`
 	// Duration of the workout
 	EndedAt time.Time
 	// EndedAt is the time when the workout was ended
@@ -67,12 +74,30 @@ type postgresWorkout struct {
 	Profile string
 	// HardcoreMode is the difficulty level chosen by the player
 	HardcoreMode bool
+	// HRMConnected tells whether a heart rate monitor is bound to the workout
+	HRMConnected bool
 	// Shelters taken for a given workout
 	Shelters uint8
 	// Fights fought in a given workout
 	Fights uint8
 	// Escapes made in a given workout
 	Escapes uint8
+	// OffTrail tells whether the player is currently away from the trail
+	OffTrail bool
+	// Times the player strayed from the trail in a given workout
+	OffTrailCount uint8
+	// Metres climbed in a given workout
+	ElevationGain float64
+	// Metres descended in a given workout
+	ElevationLoss float64
+	// PausedAt is the time when the workout was paused, zero while it is running
+	PausedAt time.Time
+	// AutoPaused tells whether the pause going on was started because the player stopped moving
+	AutoPaused bool
+	// ResumedAt is the time when the workout was last resumed
+	ResumedAt time.Time
+	// Version is bumped on every update, a workout is only written over the version it was read at
+	Version uint64 `gorm:"not null;default:0"`
 }
 
 type postgresWorkoutOptions struct {
@@ -88,6 +113,85 @@ type postgresWorkoutOptions struct {
 	IsWorkoutOptionActive bool
 	// Distance to Shelter
 	DistanceToShelter float64
+	// Time when the current WorkoutOption was started
+	OptionStartedAt time.Time
+	// Closest shelter reported by the zone
+	ShelterID uuid.UUID `gorm:"type:uuid"`
+	// Whether the closest shelter has a place left
+	ShelterAvailable bool
+	// Shelter holding a place for the current shelter option
+	ReservedShelterID uuid.UUID `gorm:"type:uuid"`
+	// Version is bumped on every update
+	Version uint64 `gorm:"not null;default:0"`
+}
+
+type postgresTrackPoint struct {
+	// WorkoutID of the workout the point belongs to
+	WorkoutID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	// Sequence is the position of the point in the track
+	Sequence uint32 `gorm:"primaryKey;autoIncrement:false"`
+	// TimeOfLocation is the time at which the location was recorded
+	TimeOfLocation time.Time
+	// Latitude of the Player
+	Latitude float64
+	// Longitude of the Player
+	Longitude float64
+	// Elevation of the Player in metres, null when it is not known
+	Elevation *float64
+	// SegmentDistance is the distance credited since the previous point
+	SegmentDistance float64
+}
+
+type postgresWorkoutOptionEvent struct {
+	// ID of the event
+	ID uint `gorm:"primaryKey"`
+	// WorkoutID of the workout the event belongs to
+	WorkoutID uuid.UUID `gorm:"type:uuid;index"`
+	// Option can be either 'Shelter', 'Fight' or 'Escape'
+	Option string
+	// StartedAt is the time when the option was started
+	StartedAt time.Time
+	// EndedAt is the time when the option was stopped
+	EndedAt time.Time
+}
+
+type postgresWorkoutTransition struct {
+	// ID of the transition, in the order they were made
+	ID uint `gorm:"primaryKey"`
+	// WorkoutID of the workout
+	WorkoutID uuid.UUID `gorm:"type:uuid;index"`
+	// Event that moved the workout
+	Event string
+	// From is the state the workout left
+	From string
+	// To is the state the workout entered
+	To string
+	// At is the time of the transition
+	At time.Time
+}
+
+type postgresWorkoutPause struct {
+	// ID of the pause
+	ID uint `gorm:"primaryKey"`
+	// WorkoutID of the workout paused
+	WorkoutID uuid.UUID `gorm:"type:uuid;index"`
+	// StartedAt is the time when the workout was paused
+	StartedAt time.Time
+	// EndedAt is the time when the workout was resumed or stopped
+	EndedAt time.Time
+	// Auto tells whether the workout was paused because the player stopped moving
+	Auto bool
+}
+
+type postgresHeartRateSample struct {
+	// ID of the reading
+	ID uint `gorm:"primaryKey"`
+	// WorkoutID of the workout the reading was taken for
+	WorkoutID uuid.UUID `gorm:"type:uuid;index"`
+	// HeartRate in beats per minute
+	HeartRate uint8
+	// TimeOfReading is the time at which the reading was taken
+	TimeOfReading time.Time
 }
 
 func toWorkoutAggregate(pworkout *postgresWorkout) *domain.Workout {
@@ -96,15 +200,25 @@ func toWorkoutAggregate(pworkout *postgresWorkout) *domain.Workout {
 		WorkoutID:       pworkout.WorkoutID,
 		TrailID:         pworkout.TrailID,
 		PlayerID:        pworkout.PlayerID,
+		State:           domain.WorkoutState(pworkout.State),
 		IsCompleted:     pworkout.IsCompleted,
 		CreatedAt:       pworkout.CreatedAt,
 		EndedAt:         pworkout.EndedAt,
 		DistanceCovered: pworkout.DistanceCovered,
 		Profile:         pworkout.Profile,
 		HardcoreMode:    pworkout.HardcoreMode,
+		HRMConnected:    pworkout.HRMConnected,
 		Shelters:        pworkout.Shelters,
 		Fights:          pworkout.Fights,
 		Escapes:         pworkout.Escapes,
+		OffTrail:        pworkout.OffTrail,
+		OffTrailCount:   pworkout.OffTrailCount,
+		ElevationGain:   pworkout.ElevationGain,
+		ElevationLoss:   pworkout.ElevationLoss,
+		PausedAt:        pworkout.PausedAt,
+		AutoPaused:      pworkout.AutoPaused,
+		ResumedAt:       pworkout.ResumedAt,
+		Version:         pworkout.Version,
 	}
 }
 
@@ -114,15 +228,25 @@ func toWorkoutPostgres(workout *domain.Workout) *postgresWorkout {
 		WorkoutID:       workout.WorkoutID,
 		TrailID:         workout.TrailID,
 		PlayerID:        workout.PlayerID,
+		State:           string(workout.State),
 		IsCompleted:     workout.IsCompleted,
 		CreatedAt:       workout.CreatedAt,
 		EndedAt:         workout.EndedAt,
 		DistanceCovered: workout.DistanceCovered,
 		Profile:         workout.Profile,
 		HardcoreMode:    workout.HardcoreMode,
+		HRMConnected:    workout.HRMConnected,
 		Shelters:        workout.Shelters,
 		Fights:          workout.Fights,
 		Escapes:         workout.Escapes,
+		OffTrail:        workout.OffTrail,
+		OffTrailCount:   workout.OffTrailCount,
+		ElevationGain:   workout.ElevationGain,
+		ElevationLoss:   workout.ElevationLoss,
+		PausedAt:        workout.PausedAt,
+		AutoPaused:      workout.AutoPaused,
+		ResumedAt:       workout.ResumedAt,
+		Version:         workout.Version,
 	}
 }
 
@@ -135,6 +259,11 @@ func toWorkoutOptionsAggregate(pworkoutOptions *postgresWorkoutOptions) *domain.
 		FightsPushDown:          pworkoutOptions.FightsPushDown,
 		IsWorkoutOptionActive:   pworkoutOptions.IsWorkoutOptionActive,
 		DistanceToShelter:       pworkoutOptions.DistanceToShelter,
+		OptionStartedAt:         pworkoutOptions.OptionStartedAt,
+		ShelterID:               pworkoutOptions.ShelterID,
+		ShelterAvailable:        pworkoutOptions.ShelterAvailable,
+		ReservedShelterID:       pworkoutOptions.ReservedShelterID,
+		Version:                 pworkoutOptions.Version,
 	}
 }
 
@@ -147,66 +276,136 @@ func toWorkoutOptionsPostgres(workoutOptions *domain.WorkoutOptions) *postgresWo
 		FightsPushDown:          workoutOptions.FightsPushDown,
 		IsWorkoutOptionActive:   workoutOptions.IsWorkoutOptionActive,
 		DistanceToShelter:       workoutOptions.DistanceToShelter,
+		OptionStartedAt:         workoutOptions.OptionStartedAt,
+		ShelterID:               workoutOptions.ShelterID,
+		ShelterAvailable:        workoutOptions.ShelterAvailable,
+		ReservedShelterID:       workoutOptions.ReservedShelterID,
+		Version:                 workoutOptions.Version,
 	}
 }
 
-// Repository Functions
+func toTrackPointAggregate(ppoint *postgresTrackPoint) *domain.TrackPoint {
 
-func (r *Repository) Create(workout *domain.Workout, workoutOptions *domain.WorkoutOptions) error {
+	return &domain.TrackPoint{
+		WorkoutID:       ppoint.WorkoutID,
+		Sequence:        ppoint.Sequence,
+		TimeOfLocation:  ppoint.TimeOfLocation,
+		Latitude:        ppoint.Latitude,
+		Longitude:       ppoint.Longitude,
+		Elevation:       ppoint.Elevation,
+		SegmentDistance: ppoint.SegmentDistance,
+	}
+}
 
-	pworkout := &postgresWorkout{
-		WorkoutID:       workout.WorkoutID,
-		TrailID:         workout.TrailID,
-		PlayerID:        workout.PlayerID,
-		IsCompleted:     workout.IsCompleted,
-		CreatedAt:       workout.CreatedAt,
-		EndedAt:         workout.EndedAt,
-		DistanceCovered: workout.DistanceCovered,
-		Profile:         workout.Profile,
-		HardcoreMode:    workout.HardcoreMode,
-		Shelters:        workout.Shelters,
-		Fights:          workout.Fights,
-		Escapes:         workout.Escapes,
+func toTrackPointPostgres(point *domain.TrackPoint) *postgresTrackPoint {
+
+	return &postgresTrackPoint{
+		WorkoutID:       point.WorkoutID,
+		Sequence:        point.Sequence,
+		TimeOfLocation:  point.TimeOfLocation,
+		Latitude:        point.Latitude,
+		Longitude:       point.Longitude,
+		Elevation:       point.Elevation,
+		SegmentDistance: point.SegmentDistance,
 	}
+}
 
-	pworkoutOptions := &postgresWorkoutOptions{
-		WorkoutID:               workoutOptions.WorkoutID,
-		WorkoutOptionsAvailable: workoutOptions.WorkoutOptionsAvailable,
-		IsWorkoutOptionActive:   workoutOptions.IsWorkoutOptionActive,
-		CurrentWorkoutOption:    workoutOptions.CurrentWorkoutOption,
-		FightsPushDown:          workoutOptions.FightsPushDown,
-		DistanceToShelter:       workoutOptions.DistanceToShelter,
+func toWorkoutOptionEventAggregate(pevent *postgresWorkoutOptionEvent) *domain.WorkoutOptionEvent {
+
+	return &domain.WorkoutOptionEvent{
+		WorkoutID: pevent.WorkoutID,
+		Option:    pevent.Option,
+		StartedAt: pevent.StartedAt,
+		EndedAt:   pevent.EndedAt,
 	}
+}
 
-	err := r.db.Transaction(func(tx *gorm.DB) error {
-		res := tx.First(&pworkout, "workout_id = ?", workout.WorkoutID)
-		if res.Error != nil {
-			// If error occurs in finding the workout, return the error.
-			return res.Error
+func toWorkoutOptionEventPostgres(event *domain.WorkoutOptionEvent) *postgresWorkoutOptionEvent {
+
+	return &postgresWorkoutOptionEvent{
+		WorkoutID: event.WorkoutID,
+		Option:    event.Option,
+		StartedAt: event.StartedAt,
+		EndedAt:   event.EndedAt,
+	}
+}
+
+func toWorkoutTransitionAggregate(ptransition *postgresWorkoutTransition) *domain.WorkoutTransition {
+
+	return &domain.WorkoutTransition{
+		WorkoutID: ptransition.WorkoutID,
+		Event:     domain.WorkoutEvent(ptransition.Event),
+		From:      domain.WorkoutState(ptransition.From),
+		To:        domain.WorkoutState(ptransition.To),
+		At:        ptransition.At,
+	}
+}
+
+func toWorkoutTransitionsPostgres(transitions []*domain.WorkoutTransition) []*postgresWorkoutTransition {
+
+	ptransitions := make([]*postgresWorkoutTransition, len(transitions))
+	for i, transition := range transitions {
+		ptransitions[i] = &postgresWorkoutTransition{
+			WorkoutID: transition.WorkoutID,
+			Event:     string(transition.Event),
+			From:      string(transition.From),
+			To:        string(transition.To),
+			At:        transition.At,
 		}
+	}
+	return ptransitions
+}
 
-		if res.RowsAffected == 0 {
-			if err := tx.Create(&pworkout).Error; err != nil {
-				// Log and return error if the creation fails.
-				logger.Debug("FAILED TO CREATE WORKOUT", zap.String("error", err.Error()))
-				return err
-			}
+func toWorkoutPauseAggregate(ppause *postgresWorkoutPause) *domain.WorkoutPause {
+
+	return &domain.WorkoutPause{
+		WorkoutID: ppause.WorkoutID,
+		StartedAt: ppause.StartedAt,
+		EndedAt:   ppause.EndedAt,
+		Auto:      ppause.Auto,
+	}
+}
+
+func toWorkoutPausePostgres(pause *domain.WorkoutPause) *postgresWorkoutPause {
+
+	return &postgresWorkoutPause{
+		WorkoutID: pause.WorkoutID,
+		StartedAt: pause.StartedAt,
+		EndedAt:   pause.EndedAt,
+		Auto:      pause.Auto,
+	}
+}
+
+// Repository Functions
+
+func (r *Repository) Create(workout *domain.Workout, workoutOptions *domain.WorkoutOptions) error {
+
+	pworkout := toWorkoutPostgres(workout)
+	pworkoutOptions := toWorkoutOptionsPostgres(workoutOptions)
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(pworkout).Error; err != nil {
+			// Log and return error if the creation fails.
+			logger.Debug("FAILED TO CREATE WORKOUT", zap.String("error", err.Error()))
+			return err
 		}
 
-		// Create 'pworkoutOptions' if 'pworkout' creation was successful or it already existed.
-		if err := tx.Create(&pworkoutOptions).Error; err != nil {
+		// Create 'pworkoutOptions' if 'pworkout' creation was successful.
+		if err := tx.Create(pworkoutOptions).Error; err != nil {
 			// Log and return error if the creation fails.
 			logger.Debug("FAILED TO CREATE WORKOUT OPTIONS", zap.String("error", err.Error()))
 			return err
 		}
-		return nil
+		return logTransitions(tx, workout)
 	})
 
-	// TODO Define Errors in Repo Interface file and return them instead of this
-	if err != nil {
-		return err
+	// the workout has a new id, so only another workout in progress of the player can clash
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ports.ErrorActiveWorkoutAlreadyExists
+	}
+	if err == nil {
+		workout.NewTransitions = nil
 	}
-
 	return err
 }
 
@@ -216,20 +415,90 @@ func (r *Repository) GetWorkout(workoutID uuid.UUID) (*domain.Workout, error) {
 	res := r.db.First(&pworkout, "workout_id = ?", workoutID)
 
 	if res.Error != nil {
-		return &domain.Workout{}, res.Error
+		return &domain.Workout{}, notFound(res.Error)
 	}
 
 	workout := toWorkoutAggregate(&pworkout)
 	return workout, nil
 }
 
+func (r *Repository) ListWorkouts(filter domain.WorkoutFilter, limit int) ([]*domain.Workout, error) {
+	query := r.db.Model(&postgresWorkout{})
+	if filter.PlayerID != uuid.Nil {
+		query = query.Where("player_id = ?", filter.PlayerID)
+	}
+	if filter.TrailID != uuid.Nil {
+		query = query.Where("trail_id = ?", filter.TrailID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at <= ?", filter.To)
+	}
+	if filter.Completed != nil {
+		query = query.Where("is_completed = ?", *filter.Completed)
+	}
+
+	// keyset pagination, the workout id breaks ties so that no workout is skipped or repeated
+	column := "created_at"
+	if filter.SortBy == domain.WorkoutSortDistance {
+		column = "distance_covered"
+	}
+	direction, comparison := "DESC", "<"
+	if filter.Ascending {
+		direction, comparison = "ASC", ">"
+	}
+	if filter.After != nil {
+		var after interface{} = filter.After.CreatedAt
+		if filter.SortBy == domain.WorkoutSortDistance {
+			after = filter.After.Distance
+		}
+		query = query.Where(fmt.Sprintf("(%s, workout_id) %s (?, ?)", column, comparison), after, filter.After.WorkoutID)
+	}
+
+	var pworkouts []postgresWorkout
+	err := query.
+		Order(fmt.Sprintf("%s %s, workout_id %s", column, direction, direction)).
+		Limit(limit).
+		Find(&pworkouts).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	workouts := make([]*domain.Workout, len(pworkouts))
+	for i := range pworkouts {
+		workouts[i] = toWorkoutAggregate(&pworkouts[i])
+	}
+	return workouts, nil
+}
+
+func (r *Repository) ListActiveWorkouts() ([]*domain.Workout, error) {
+	var pworkouts []postgresWorkout
+
+	err := r.db.Where("NOT is_completed").
+		Order("created_at asc").
+		Find(&pworkouts).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	workouts := make([]*domain.Workout, len(pworkouts))
+	for i := range pworkouts {
+		workouts[i] = toWorkoutAggregate(&pworkouts[i])
+	}
+	return workouts, nil
+}
+
 func (r *Repository) GetWorkoutOptions(workoutID uuid.UUID) (*domain.WorkoutOptions, error) {
 	var pworkoutOptions postgresWorkoutOptions
 
 	res := r.db.First(&pworkoutOptions, "workout_id = ?", workoutID)
 
 	if res.Error != nil {
-		return &domain.WorkoutOptions{}, res.Error
+		return &domain.WorkoutOptions{}, notFound(res.Error)
 	}
 
 	workoutOptions := toWorkoutOptionsAggregate(&pworkoutOptions)
@@ -239,22 +508,97 @@ func (r *Repository) GetWorkoutOptions(workoutID uuid.UUID) (*domain.WorkoutOpti
 func (r *Repository) UpdateWorkout(workout *domain.Workout) (*domain.Workout, error) {
 
 	pworkout := toWorkoutPostgres(workout)
+	pworkout.Version++
 
-	if err := r.db.Save(&pworkout).Error; err != nil {
+	// the workout is only written over the version it was read at, its transitions are logged with it
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(pworkout).
+			Where("version = ?", workout.Version).
+			Select("*").
+			Updates(pworkout)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return r.updateConflict(&postgresWorkout{}, workout.WorkoutID)
+		}
+		return logTransitions(tx, workout)
+	})
+	if err != nil {
 		return &domain.Workout{}, err
 	}
 
+	workout.Version = pworkout.Version
+	workout.NewTransitions = nil
 	return toWorkoutAggregate(pworkout), nil
 }
 
+// logTransitions appends the new transitions of the workout to its event log
+func logTransitions(tx *gorm.DB, workout *domain.Workout) error {
+	if len(workout.NewTransitions) == 0 {
+		return nil
+	}
+	return tx.Create(toWorkoutTransitionsPostgres(workout.NewTransitions)).Error
+}
+
+func (r *Repository) GetWorkoutTransitions(workoutID uuid.UUID) ([]*domain.WorkoutTransition, error) {
+	var ptransitions []postgresWorkoutTransition
+
+	err := r.db.Where("workout_id = ?", workoutID).
+		Order("id asc").
+		Find(&ptransitions).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	transitions := make([]*domain.WorkoutTransition, len(ptransitions))
+	for i := range ptransitions {
+		transitions[i] = toWorkoutTransitionAggregate(&ptransitions[i])
+	}
+
+	return transitions, nil
+}
+
+// notFound returns ErrorWorkoutNotFound for a missing workout row, other errors are returned as they are
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ports.ErrorWorkoutNotFound
+	}
+	return err
+}
+
+// updateConflict tells why a versioned update changed no row, either the row is gone or it was updated since
+// it was read
+func (r *Repository) updateConflict(model interface{}, workoutID uuid.UUID) error {
+	var count int64
+	if err := r.db.Model(model).Where("workout_id = ?", workoutID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ports.ErrorWorkoutNotFound
+	}
+	return ports.ErrorWorkoutVersionConflict
+}
+
 func (r *Repository) UpdateWorkoutOptions(workoutOptions *domain.WorkoutOptions) (*domain.WorkoutOptions, error) {
 
 	pworkoutOptions := toWorkoutOptionsPostgres(workoutOptions)
+	pworkoutOptions.Version++
 
-	if err := r.db.Save(&pworkoutOptions).Error; err != nil {
-		return &domain.WorkoutOptions{}, err
+	res := r.db.Model(pworkoutOptions).
+		Where("version = ?", workoutOptions.Version).
+		Select("*").
+		Updates(pworkoutOptions)
+	if res.Error != nil {
+		return &domain.WorkoutOptions{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return &domain.WorkoutOptions{}, r.updateConflict(&postgresWorkoutOptions{}, workoutOptions.WorkoutID)
 	}
 
+	workoutOptions.Version = pworkoutOptions.Version
 	return toWorkoutOptionsAggregate(pworkoutOptions), nil
 }
 
@@ -282,6 +626,160 @@ func (r *Repository) DeleteWorkoutOptions(workoutID uuid.UUID) error {
 	return nil
 }
 
+func (r *Repository) AddTrackPoint(point *domain.TrackPoint) error {
+
+	ppoint := toTrackPointPostgres(point)
+
+	if err := r.db.Create(&ppoint).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Repository) GetLastTrackPoint(workoutID uuid.UUID) (*domain.TrackPoint, error) {
+	var ppoints []postgresTrackPoint
+
+	err := r.db.Where("workout_id = ?", workoutID).
+		Order("sequence desc").
+		Limit(1).
+		Find(&ppoints).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	if len(ppoints) == 0 {
+		return nil, nil
+	}
+
+	return toTrackPointAggregate(&ppoints[0]), nil
+}
+
+func (r *Repository) GetTrack(workoutID uuid.UUID) ([]*domain.TrackPoint, error) {
+	var ppoints []postgresTrackPoint
+
+	err := r.db.Where("workout_id = ?", workoutID).
+		Order("sequence asc").
+		Find(&ppoints).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	track := make([]*domain.TrackPoint, len(ppoints))
+	for i := range ppoints {
+		track[i] = toTrackPointAggregate(&ppoints[i])
+	}
+
+	return track, nil
+}
+
+func (r *Repository) AddWorkoutOptionEvent(event *domain.WorkoutOptionEvent) error {
+
+	pevent := toWorkoutOptionEventPostgres(event)
+
+	if err := r.db.Create(&pevent).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Repository) GetWorkoutOptionEvents(workoutID uuid.UUID) ([]*domain.WorkoutOptionEvent, error) {
+	var pevents []postgresWorkoutOptionEvent
+
+	err := r.db.Where("workout_id = ?", workoutID).
+		Order("started_at asc").
+		Find(&pevents).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*domain.WorkoutOptionEvent, len(pevents))
+	for i := range pevents {
+		events[i] = toWorkoutOptionEventAggregate(&pevents[i])
+	}
+
+	return events, nil
+}
+
+func (r *Repository) AddWorkoutPause(pause *domain.WorkoutPause) error {
+
+	ppause := toWorkoutPausePostgres(pause)
+
+	if err := r.db.Create(ppause).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Repository) GetWorkoutPauses(workoutID uuid.UUID) ([]*domain.WorkoutPause, error) {
+	var ppauses []postgresWorkoutPause
+
+	err := r.db.Where("workout_id = ?", workoutID).
+		Order("started_at asc").
+		Find(&ppauses).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	pauses := make([]*domain.WorkoutPause, len(ppauses))
+	for i := range ppauses {
+		pauses[i] = toWorkoutPauseAggregate(&ppauses[i])
+	}
+
+	return pauses, nil
+}
+
+// heart rate readings written at once, a workout of an hour has a few thousands of them
+const heartRateSamplesBatch = 500
+
+func (r *Repository) AddHeartRateSamples(workoutID uuid.UUID, samples []domain.HeartRateSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	psamples := make([]*postgresHeartRateSample, len(samples))
+	for i, sample := range samples {
+		psamples[i] = &postgresHeartRateSample{
+			WorkoutID:     workoutID,
+			HeartRate:     sample.HeartRate,
+			TimeOfReading: sample.TimeOfReading,
+		}
+	}
+
+	return r.db.CreateInBatches(psamples, heartRateSamplesBatch).Error
+}
+
+func (r *Repository) GetHeartRateSamples(workoutID uuid.UUID) ([]domain.HeartRateSample, error) {
+	var psamples []postgresHeartRateSample
+
+	err := r.db.Where("workout_id = ?", workoutID).
+		Order("time_of_reading asc, id asc").
+		Find(&psamples).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]domain.HeartRateSample, len(psamples))
+	for i, psample := range psamples {
+		samples[i] = domain.HeartRateSample{
+			HeartRate:     psample.HeartRate,
+			TimeOfReading: psample.TimeOfReading,
+		}
+	}
+
+	return samples, nil
+}
+
 func (r *Repository) GetDistanceByID(workoutID uuid.UUID) (float64, error) {
 	var distanceCovered = 0.0
 
@@ -303,7 +801,7 @@ func (r *Repository) GetDistanceCoveredBetweenDates(playerID uuid.UUID, startDat
 
 	err := r.db.Table("postgres_workouts").
 		Where("player_id = ? AND created_at >= ? AND ended_at <= ?", playerID, startDate, endDate).
-		Select("COALESCE(SUM(DistanceCovered), 0)").Row().
+		Select("COALESCE(SUM(distance_covered), 0)").Row().
 		Scan(&totalDistanceCovered)
 
 	// TODO Define Errors in Repo Interface file and return them instead of this
@@ -314,6 +812,17 @@ func (r *Repository) GetDistanceCoveredBetweenDates(playerID uuid.UUID, startDat
 	return totalDistanceCovered, err
 }
 
+func (r *Repository) GetWorkoutsCompletedBetweenDates(playerID uuid.UUID, startDate time.Time, endDate time.Time) (uint16, error) {
+	var workoutsCompleted int64
+
+	err := r.db.Table("postgres_workouts").
+		Where("player_id = ? AND is_completed AND created_at >= ? AND ended_at <= ?", playerID, startDate, endDate).
+		Count(&workoutsCompleted).
+		Error
+
+	return uint16(workoutsCompleted), err
+}
+
 func (r *Repository) GetEscapesMadeByID(workoutID uuid.UUID) (uint16, error) {
 	escapesMade := 0
 
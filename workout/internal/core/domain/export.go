@@ -0,0 +1,295 @@
+package domain
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Supported export formats
+const (
+	ExportFormatGPX = "gpx"
+	ExportFormatTCX = "tcx"
+)
+
+const exportCreator = "ACME RUN"
+
+var (
+	ErrUnsupportedExportFormat = errors.New("unsupported export format")
+)
+
+// GPX is a GPX 1.1 document, see https://www.topografix.com/GPX/1/1/
+type GPX struct {
+	XMLName   xml.Name      `xml:"http://www.topografix.com/GPX/1/1 gpx"`
+	Version   string        `xml:"version,attr"`
+	Creator   string        `xml:"creator,attr"`
+	Metadata  GPXMetadata   `xml:"metadata"`
+	Waypoints []GPXWaypoint `xml:"wpt"`
+	Tracks    []GPXTrack    `xml:"trk"`
+}
+
+type GPXMetadata struct {
+	Name string    `xml:"name"`
+	Desc string    `xml:"desc,omitempty"`
+	Time time.Time `xml:"time"`
+}
+
+// GPXWaypoint marks a shelter, fight or escape taken during the workout
+type GPXWaypoint struct {
+	Latitude  float64   `xml:"lat,attr"`
+	Longitude float64   `xml:"lon,attr"`
+	Time      time.Time `xml:"time"`
+	Name      string    `xml:"name"`
+	Desc      string    `xml:"desc,omitempty"`
+	Type      string    `xml:"type"`
+}
+
+type GPXTrack struct {
+	Name     string            `xml:"name"`
+	Type     string            `xml:"type"`
+	Segments []GPXTrackSegment `xml:"trkseg"`
+}
+
+type GPXTrackSegment struct {
+	Points []GPXTrackPoint `xml:"trkpt"`
+}
+
+type GPXTrackPoint struct {
+	Latitude   float64        `xml:"lat,attr"`
+	Longitude  float64        `xml:"lon,attr"`
+	Elevation  *float64       `xml:"ele,omitempty"`
+	Time       time.Time      `xml:"time"`
+	Extensions *GPXExtensions `xml:"extensions,omitempty"`
+}
+
+// GPXExtensions carries the heart rate using the Garmin TrackPointExtension
+type GPXExtensions struct {
+	TrackPointExtension *GPXTrackPointExtension `xml:"http://www.garmin.com/xmlschemas/TrackPointExtension/v1 TrackPointExtension"`
+}
+
+type GPXTrackPointExtension struct {
+	HeartRate uint8 `xml:"hr"`
+}
+
+// TCX is a Training Center Database v2 document
+type TCX struct {
+	XMLName    xml.Name      `xml:"http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2 TrainingCenterDatabase"`
+	Activities TCXActivities `xml:"Activities"`
+}
+
+type TCXActivities struct {
+	Activities []TCXActivity `xml:"Activity"`
+}
+
+type TCXActivity struct {
+	Sport string    `xml:"Sport,attr"`
+	ID    time.Time `xml:"Id"`
+	Laps  []TCXLap  `xml:"Lap"`
+	Notes string    `xml:"Notes,omitempty"`
+}
+
+type TCXLap struct {
+	StartTime           time.Time     `xml:"StartTime,attr"`
+	TotalTimeSeconds    float64       `xml:"TotalTimeSeconds"`
+	DistanceMeters      float64       `xml:"DistanceMeters"`
+	Calories            uint16        `xml:"Calories"`
+	AverageHeartRateBpm *TCXHeartRate `xml:"AverageHeartRateBpm,omitempty"`
+	MaximumHeartRateBpm *TCXHeartRate `xml:"MaximumHeartRateBpm,omitempty"`
+	Intensity           string        `xml:"Intensity"`
+	TriggerMethod       string        `xml:"TriggerMethod"`
+	Track               TCXTrack      `xml:"Track"`
+}
+
+type TCXTrack struct {
+	Trackpoints []TCXTrackpoint `xml:"Trackpoint"`
+}
+
+type TCXTrackpoint struct {
+	Time           time.Time     `xml:"Time"`
+	Position       *TCXPosition  `xml:"Position,omitempty"`
+	AltitudeMeters *float64      `xml:"AltitudeMeters,omitempty"`
+	DistanceMeters float64       `xml:"DistanceMeters"`
+	HeartRateBpm   *TCXHeartRate `xml:"HeartRateBpm,omitempty"`
+}
+
+type TCXPosition struct {
+	LatitudeDegrees  float64 `xml:"LatitudeDegrees"`
+	LongitudeDegrees float64 `xml:"LongitudeDegrees"`
+}
+
+type TCXHeartRate struct {
+	Value uint8 `xml:"Value"`
+}
+
+// ExportWorkout renders a completed workout in the given format ('gpx' or 'tcx')
+func ExportWorkout(format string, workout *Workout, track []*TrackPoint, heartRates []HeartRateSample, events []*WorkoutOptionEvent) ([]byte, error) {
+	var doc interface{}
+	switch strings.ToLower(format) {
+	case ExportFormatGPX:
+		doc = NewGPX(workout, track, heartRates, events)
+	case ExportFormatTCX:
+		doc = NewTCX(workout, track, heartRates, events)
+	default:
+		return nil, ErrUnsupportedExportFormat
+	}
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// NewGPX builds the GPX document of a workout
+func NewGPX(workout *Workout, track []*TrackPoint, heartRates []HeartRateSample, events []*WorkoutOptionEvent) *GPX {
+	samples := sortedHeartRates(heartRates)
+
+	segment := GPXTrackSegment{Points: make([]GPXTrackPoint, 0, len(track))}
+	for _, point := range track {
+		trkpt := GPXTrackPoint{
+			Latitude:  point.Latitude,
+			Longitude: point.Longitude,
+			Elevation: point.Elevation,
+			Time:      point.TimeOfLocation.UTC(),
+		}
+		if hr, ok := heartRateAt(samples, point.TimeOfLocation); ok {
+			trkpt.Extensions = &GPXExtensions{TrackPointExtension: &GPXTrackPointExtension{HeartRate: hr}}
+		}
+		segment.Points = append(segment.Points, trkpt)
+	}
+
+	// Waypoints need a position, so events are placed on the closest recorded location
+	var waypoints []GPXWaypoint
+	for _, event := range events {
+		point := closestTrackPoint(track, event.StartedAt)
+		if point == nil {
+			continue
+		}
+		waypoints = append(waypoints, GPXWaypoint{
+			Latitude:  point.Latitude,
+			Longitude: point.Longitude,
+			Time:      event.StartedAt.UTC(),
+			Name:      event.Option,
+			Desc:      describeEvent(event),
+			Type:      strings.ToLower(event.Option),
+		})
+	}
+
+	return &GPX{
+		Version: "1.1",
+		Creator: exportCreator,
+		Metadata: GPXMetadata{
+			Name: workoutTitle(workout),
+			Desc: fmt.Sprintf("shelters taken: %d, fights fought: %d, escapes made: %d", workout.Shelters, workout.Fights, workout.Escapes),
+			Time: workout.CreatedAt.UTC(),
+		},
+		Waypoints: waypoints,
+		Tracks: []GPXTrack{{
+			Name:     workoutTitle(workout),
+			Type:     "running",
+			Segments: []GPXTrackSegment{segment},
+		}},
+	}
+}
+
+// NewTCX builds the TCX document of a workout, distances are in meters
+func NewTCX(workout *Workout, track []*TrackPoint, heartRates []HeartRateSample, events []*WorkoutOptionEvent) *TCX {
+	samples := sortedHeartRates(heartRates)
+
+	trackpoints := make([]TCXTrackpoint, 0, len(track))
+	cumulative := 0.0
+	for _, point := range track {
+		cumulative += point.SegmentDistance
+		trackpoint := TCXTrackpoint{
+			Time:           point.TimeOfLocation.UTC(),
+			Position:       &TCXPosition{LatitudeDegrees: point.Latitude, LongitudeDegrees: point.Longitude},
+			AltitudeMeters: point.Elevation,
+			DistanceMeters: cumulative,
+		}
+		if hr, ok := heartRateAt(samples, point.TimeOfLocation); ok {
+			trackpoint.HeartRateBpm = &TCXHeartRate{Value: hr}
+		}
+		trackpoints = append(trackpoints, trackpoint)
+	}
+
+	lap := TCXLap{
+		StartTime:        workout.CreatedAt.UTC(),
+		TotalTimeSeconds: workout.EndedAt.Sub(workout.CreatedAt).Seconds(),
+		DistanceMeters:   workout.DistanceCovered,
+		Intensity:        "Active",
+		TriggerMethod:    "Manual",
+		Track:            TCXTrack{Trackpoints: trackpoints},
+	}
+	if len(samples) > 0 {
+		sum, max := 0, uint8(0)
+		for _, sample := range samples {
+			sum += int(sample.HeartRate)
+			if sample.HeartRate > max {
+				max = sample.HeartRate
+			}
+		}
+		lap.AverageHeartRateBpm = &TCXHeartRate{Value: uint8(sum / len(samples))}
+		lap.MaximumHeartRateBpm = &TCXHeartRate{Value: max}
+	}
+
+	notes := make([]string, 0, len(events))
+	for _, event := range events {
+		notes = append(notes, describeEvent(event))
+	}
+
+	return &TCX{
+		Activities: TCXActivities{Activities: []TCXActivity{{
+			Sport: "Running",
+			ID:    workout.CreatedAt.UTC(),
+			Laps:  []TCXLap{lap},
+			Notes: strings.Join(notes, "\n"),
+		}}},
+	}
+}
+
+func workoutTitle(workout *Workout) string {
+	return fmt.Sprintf("%s workout %s", exportCreator, workout.WorkoutID)
+}
+
+func describeEvent(event *WorkoutOptionEvent) string {
+	return fmt.Sprintf("%s from %s to %s", event.Option, event.StartedAt.UTC().Format(time.RFC3339), event.EndedAt.UTC().Format(time.RFC3339))
+}
+
+func sortedHeartRates(heartRates []HeartRateSample) []HeartRateSample {
+	samples := make([]HeartRateSample, len(heartRates))
+	copy(samples, heartRates)
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].TimeOfReading.Before(samples[j].TimeOfReading)
+	})
+	return samples
+}
+
+// heartRateAt returns the latest reading taken at or before t
+func heartRateAt(samples []HeartRateSample, t time.Time) (uint8, bool) {
+	i := sort.Search(len(samples), func(i int) bool {
+		return samples[i].TimeOfReading.After(t)
+	})
+	if i == 0 {
+		return 0, false
+	}
+	return samples[i-1].HeartRate, true
+}
+
+// closestTrackPoint returns the recorded location closest in time to t
+func closestTrackPoint(track []*TrackPoint, t time.Time) *TrackPoint {
+	var closest *TrackPoint
+	var best time.Duration
+	for _, point := range track {
+		diff := point.TimeOfLocation.Sub(t)
+		if diff < 0 {
+			diff = -diff
+		}
+		if closest == nil || diff < best {
+			closest, best = point, diff
+		}
+	}
+	return closest
+}
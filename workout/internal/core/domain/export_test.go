@@ -0,0 +1,180 @@
+package domain_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
+	"github.com/google/uuid"
+	"github.com/umahmood/haversine"
+)
+
+// exportFixture returns a completed workout whose track spans the whole session, every point but the
+// last one has an elevation
+func exportFixture() (*domain.Workout, []*domain.TrackPoint, []domain.HeartRateSample, []*domain.WorkoutOptionEvent) {
+	start := time.Date(2023, 11, 20, 14, 0, 0, 0, time.UTC)
+	workout := &domain.Workout{
+		WorkoutID: uuid.New(),
+		TrailID:   uuid.New(),
+		PlayerID:  uuid.New(),
+		CreatedAt: start,
+		EndedAt:   start.Add(10 * time.Minute),
+		Shelters:  1,
+		Fights:    1,
+	}
+
+	var track []*domain.TrackPoint
+	var heartRates []domain.HeartRateSample
+	lat, lon := 43.260888, -79.919225
+	for i := 0; i <= 10; i++ {
+		point := &domain.TrackPoint{
+			WorkoutID:      workout.WorkoutID,
+			Sequence:       uint32(i),
+			TimeOfLocation: start.Add(time.Duration(i) * time.Minute),
+			Latitude:       lat + float64(i)*0.001,
+			Longitude:      lon + float64(i)*0.0005,
+		}
+		if i < 10 {
+			elevation := 100 + float64(i)*2
+			point.Elevation = &elevation
+		}
+		if i > 0 {
+			prev := track[i-1]
+			_, km := haversine.Distance(
+				haversine.Coord{Lat: prev.Latitude, Lon: prev.Longitude},
+				haversine.Coord{Lat: point.Latitude, Lon: point.Longitude},
+			)
+			point.SegmentDistance = km * 1000
+		}
+		workout.DistanceCovered += point.SegmentDistance
+		track = append(track, point)
+		heartRates = append(heartRates, domain.HeartRateSample{HeartRate: uint8(90 + i*5), TimeOfReading: point.TimeOfLocation})
+	}
+
+	events := []*domain.WorkoutOptionEvent{
+		{WorkoutID: workout.WorkoutID, Option: "Shelter", StartedAt: start.Add(2 * time.Minute), EndedAt: start.Add(4 * time.Minute)},
+		{WorkoutID: workout.WorkoutID, Option: "Fight", StartedAt: start.Add(7 * time.Minute), EndedAt: start.Add(8 * time.Minute)},
+	}
+
+	return workout, track, heartRates, events
+}
+
+func TestExportWorkout_GPXRoundTrip(t *testing.T) {
+	workout, track, heartRates, events := exportFixture()
+
+	body, err := domain.ExportWorkout(domain.ExportFormatGPX, workout, track, heartRates, events)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var gpx domain.GPX
+	if err := xml.Unmarshal(body, &gpx); err != nil {
+		t.Fatalf("failed to parse exported gpx: %v", err)
+	}
+
+	if len(gpx.Tracks) != 1 || len(gpx.Tracks[0].Segments) != 1 {
+		t.Fatalf("expected a single track segment, got %+v", gpx.Tracks)
+	}
+	points := gpx.Tracks[0].Segments[0].Points
+	if len(points) != len(track) {
+		t.Fatalf("expected %d track points, got %d", len(track), len(points))
+	}
+
+	distance := 0.0
+	for i := 1; i < len(points); i++ {
+		_, km := haversine.Distance(
+			haversine.Coord{Lat: points[i-1].Latitude, Lon: points[i-1].Longitude},
+			haversine.Coord{Lat: points[i].Latitude, Lon: points[i].Longitude},
+		)
+		distance += km * 1000
+	}
+	if diff := distance - workout.DistanceCovered; diff > 1e-6 || diff < -1e-6 {
+		t.Errorf("expected distance %f, got %f", workout.DistanceCovered, distance)
+	}
+
+	duration := points[len(points)-1].Time.Sub(points[0].Time)
+	if duration != workout.EndedAt.Sub(workout.CreatedAt) {
+		t.Errorf("expected duration %v, got %v", workout.EndedAt.Sub(workout.CreatedAt), duration)
+	}
+
+	for i, point := range points {
+		if point.Extensions == nil || point.Extensions.TrackPointExtension == nil {
+			t.Fatalf("expected heart rate on track point %d", i)
+		}
+		if point.Extensions.TrackPointExtension.HeartRate != heartRates[i].HeartRate {
+			t.Errorf("expected heart rate %d on track point %d, got %d", heartRates[i].HeartRate, i, point.Extensions.TrackPointExtension.HeartRate)
+		}
+	}
+
+	if points[3].Elevation == nil || *points[3].Elevation != 106 || points[10].Elevation != nil {
+		t.Errorf("expected the elevation of the track points, got %v and %v", points[3].Elevation, points[10].Elevation)
+	}
+
+	if len(gpx.Waypoints) != len(events) {
+		t.Fatalf("expected %d waypoints, got %d", len(events), len(gpx.Waypoints))
+	}
+	if gpx.Waypoints[0].Type != "shelter" || gpx.Waypoints[0].Latitude != track[2].Latitude {
+		t.Errorf("expected shelter waypoint at the third track point, got %+v", gpx.Waypoints[0])
+	}
+}
+
+func TestExportWorkout_TCXRoundTrip(t *testing.T) {
+	workout, track, heartRates, events := exportFixture()
+
+	body, err := domain.ExportWorkout(domain.ExportFormatTCX, workout, track, heartRates, events)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var tcx domain.TCX
+	if err := xml.Unmarshal(body, &tcx); err != nil {
+		t.Fatalf("failed to parse exported tcx: %v", err)
+	}
+
+	if len(tcx.Activities.Activities) != 1 || len(tcx.Activities.Activities[0].Laps) != 1 {
+		t.Fatalf("expected a single activity with a single lap, got %+v", tcx.Activities)
+	}
+	activity := tcx.Activities.Activities[0]
+	lap := activity.Laps[0]
+
+	if diff := lap.DistanceMeters - workout.DistanceCovered; diff > 1e-6 || diff < -1e-6 {
+		t.Errorf("expected lap distance %f m, got %f m", workout.DistanceCovered, lap.DistanceMeters)
+	}
+	trackpoints := lap.Track.Trackpoints
+	if len(trackpoints) != len(track) {
+		t.Fatalf("expected %d trackpoints, got %d", len(track), len(trackpoints))
+	}
+	if diff := trackpoints[len(trackpoints)-1].DistanceMeters - lap.DistanceMeters; diff > 1e-6 || diff < -1e-6 {
+		t.Errorf("expected last trackpoint distance %f, got %f", lap.DistanceMeters, trackpoints[len(trackpoints)-1].DistanceMeters)
+	}
+
+	if trackpoints[3].AltitudeMeters == nil || *trackpoints[3].AltitudeMeters != 106 || trackpoints[10].AltitudeMeters != nil {
+		t.Errorf("expected the altitude of the trackpoints, got %v and %v", trackpoints[3].AltitudeMeters, trackpoints[10].AltitudeMeters)
+	}
+
+	duration := time.Duration(lap.TotalTimeSeconds * float64(time.Second))
+	if duration != workout.EndedAt.Sub(workout.CreatedAt) {
+		t.Errorf("expected duration %v, got %v", workout.EndedAt.Sub(workout.CreatedAt), duration)
+	}
+	if !lap.StartTime.Equal(workout.CreatedAt) {
+		t.Errorf("expected lap start %v, got %v", workout.CreatedAt, lap.StartTime)
+	}
+
+	if lap.MaximumHeartRateBpm == nil || lap.MaximumHeartRateBpm.Value != 140 {
+		t.Errorf("expected maximum heart rate 140, got %+v", lap.MaximumHeartRateBpm)
+	}
+	if activity.Notes == "" {
+		t.Errorf("expected workout option events in the activity notes")
+	}
+}
+
+func TestExportWorkout_UnsupportedFormat(t *testing.T) {
+	workout, track, heartRates, events := exportFixture()
+
+	_, err := domain.ExportWorkout("fit", workout, track, heartRates, events)
+	if !errors.Is(err, domain.ErrUnsupportedExportFormat) {
+		t.Errorf("expected %v, got %v", domain.ErrUnsupportedExportFormat, err)
+	}
+}
@@ -0,0 +1,189 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HeartRateFormula tells how the bounds of the heart rate zones are computed from the player
+type HeartRateFormula string
+
+const (
+	// MaxHeartRateFormula takes the zones as percentages of the maximum heart rate, 220 - age
+	MaxHeartRateFormula HeartRateFormula = "max"
+	// KarvonenFormula takes the zones as percentages of the heart rate reserve, the maximum heart rate less
+	// the resting heart rate, above the resting heart rate
+	KarvonenFormula HeartRateFormula = "karvonen"
+)
+
+var ErrInvalidHeartRateFormula = errors.New("heart rate formula must be max or karvonen")
+
+// ParseHeartRateFormula reads a formula given by a client or the configuration
+func ParseHeartRateFormula(formula string) (HeartRateFormula, error) {
+	switch HeartRateFormula(formula) {
+	case MaxHeartRateFormula, KarvonenFormula:
+		return HeartRateFormula(formula), nil
+	default:
+		return "", ErrInvalidHeartRateFormula
+	}
+}
+
+// MaxHeartRateSampleGap is the longest a reading holds for, the time between readings further apart is
+// not spent in any zone as the monitor was likely disconnected
+const MaxHeartRateSampleGap = 30 * time.Second
+
+// heartRateZones are the names and the lower bounds of the five standard zones, in percent of the maximum
+// heart rate or of the heart rate reserve
+var heartRateZones = []struct {
+	name    string
+	percent float64
+}{
+	{"recovery", 50},
+	{"endurance", 60},
+	{"aerobic", 70},
+	{"threshold", 80},
+	{"maximum", 90},
+}
+
+// HeartRateZone is the time a workout spent in a heart rate zone
+type HeartRateZone struct {
+	// Zone number, from 1 to 5
+	Zone int `json:"zone"`
+	// Name of the zone
+	Name string `json:"name"`
+	// MinHeartRate of the zone in beats per minute
+	MinHeartRate uint8 `json:"min_heart_rate"`
+	// MaxHeartRate of the zone in beats per minute, excluded but for the last zone
+	MaxHeartRate uint8 `json:"max_heart_rate"`
+	// Time spent in the zone in seconds
+	Time float64 `json:"time"`
+	// Percentage of the time with a reading spent in the zone
+	Percentage float64 `json:"percentage"`
+}
+
+// HeartRateAnalysis is the time a workout spent in each heart rate zone of the player, computed from the
+// readings of its heart rate monitor
+type HeartRateAnalysis struct {
+	// WorkoutID of the workout analysed
+	WorkoutID uuid.UUID `json:"workout_id"`
+	// Formula the zones are computed with
+	Formula HeartRateFormula `json:"formula"`
+	// MaxHeartRate of the player in beats per minute
+	MaxHeartRate uint8 `json:"max_heart_rate"`
+	// RestingHeartRate of the player in beats per minute, 0 unless the zones are computed with it
+	RestingHeartRate uint8 `json:"resting_heart_rate"`
+	// AverageHeartRate over the workout without its pauses, 0 without readings
+	AverageHeartRate uint8 `json:"average_heart_rate"`
+	// PeakHeartRate over the workout without its pauses
+	PeakHeartRate uint8 `json:"peak_heart_rate"`
+	// BelowZonesTime in seconds, the time with a reading under the first zone
+	BelowZonesTime float64 `json:"below_zones_time"`
+	// Zones from the lowest to the highest
+	Zones []HeartRateZone `json:"zones"`
+	// Samples of the workout without its pauses, in the order they were taken
+	Samples []HeartRateSample `json:"samples"`
+}
+
+// NewHeartRateAnalysis breaks the readings of the workout down into the heart rate zones of the player. A
+// reading holds until the next one, a pause or for MaxHeartRateSampleGap, whichever comes first. The
+// resting heart rate is only used by the Karvonen formula, the zones are those of the maximum heart rate
+// when it is 0.
+func NewHeartRateAnalysis(workout *Workout, heartRates []HeartRateSample, pauses []*WorkoutPause, formula HeartRateFormula, age uint8, restingHeartRate uint8) *HeartRateAnalysis {
+	maxHeartRate := 220 - int(age)
+	// the Karvonen zones need the resting heart rate
+	if formula != KarvonenFormula || restingHeartRate == 0 || int(restingHeartRate) >= maxHeartRate {
+		formula = MaxHeartRateFormula
+		restingHeartRate = 0
+	}
+
+	analysis := &HeartRateAnalysis{
+		WorkoutID:        workout.WorkoutID,
+		Formula:          formula,
+		MaxHeartRate:     uint8(maxHeartRate),
+		RestingHeartRate: restingHeartRate,
+		Zones:            make([]HeartRateZone, len(heartRateZones)),
+		Samples:          ExcludePauses(workout, pauses, heartRates),
+	}
+
+	reserve := float64(maxHeartRate - int(restingHeartRate))
+	bound := func(percent float64) uint8 {
+		return uint8(math.Round(float64(restingHeartRate) + reserve*percent/100))
+	}
+	for i, zone := range heartRateZones {
+		analysis.Zones[i] = HeartRateZone{
+			Zone:         i + 1,
+			Name:         zone.name,
+			MinHeartRate: bound(zone.percent),
+			MaxHeartRate: uint8(maxHeartRate),
+		}
+		if i > 0 {
+			analysis.Zones[i-1].MaxHeartRate = analysis.Zones[i].MinHeartRate
+		}
+	}
+
+	analysis.AverageHeartRate = AverageHeartRate(analysis.Samples)
+	total := 0.0
+	for i, sample := range analysis.Samples {
+		if sample.HeartRate > analysis.PeakHeartRate {
+			analysis.PeakHeartRate = sample.HeartRate
+		}
+
+		end := sample.TimeOfReading.Add(MaxHeartRateSampleGap)
+		if i+1 < len(analysis.Samples) && analysis.Samples[i+1].TimeOfReading.Before(end) {
+			end = analysis.Samples[i+1].TimeOfReading
+		} else if i+1 == len(analysis.Samples) {
+			// the last reading has nothing to hold until
+			end = sample.TimeOfReading
+		}
+		if pausedAt, ok := nextPause(workout, pauses, sample.TimeOfReading); ok && pausedAt.Before(end) {
+			end = pausedAt
+		}
+		seconds := end.Sub(sample.TimeOfReading).Seconds()
+		if seconds <= 0 {
+			continue
+		}
+
+		total += seconds
+		zone := analysis.zoneOf(sample.HeartRate)
+		if zone < 0 {
+			analysis.BelowZonesTime += seconds
+		} else {
+			analysis.Zones[zone].Time += seconds
+		}
+	}
+
+	if total > 0 {
+		for i := range analysis.Zones {
+			analysis.Zones[i].Percentage = analysis.Zones[i].Time / total * 100
+		}
+	}
+	return analysis
+}
+
+// zoneOf is the index of the zone of the heart rate, -1 below the zones, the readings above the maximum
+// heart rate are in the last zone
+func (a *HeartRateAnalysis) zoneOf(heartRate uint8) int {
+	for i := len(a.Zones) - 1; i >= 0; i-- {
+		if heartRate >= a.Zones[i].MinHeartRate {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextPause is the time the first pause of the workout starting after the time started, if any
+func nextPause(workout *Workout, pauses []*WorkoutPause, at time.Time) (time.Time, bool) {
+	var next time.Time
+	for _, pause := range pauses {
+		if pause.StartedAt.After(at) && (next.IsZero() || pause.StartedAt.Before(next)) {
+			next = pause.StartedAt
+		}
+	}
+	if workout.IsPaused() && workout.PausedAt.After(at) && (next.IsZero() || workout.PausedAt.Before(next)) {
+		next = workout.PausedAt
+	}
+	return next, !next.IsZero()
+}
@@ -0,0 +1,92 @@
+package domain_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+func TestParseHeartRateFormula(t *testing.T) {
+	for _, formula := range []string{"max", "karvonen"} {
+		if parsed, err := domain.ParseHeartRateFormula(formula); err != nil || string(parsed) != formula {
+			t.Errorf("expected %s to be read, got %q and %v", formula, parsed, err)
+		}
+	}
+	if _, err := domain.ParseHeartRateFormula("tanaka"); !errors.Is(err, domain.ErrInvalidHeartRateFormula) {
+		t.Errorf("expected an unknown formula to be refused, got %v", err)
+	}
+}
+
+func TestNewHeartRateAnalysis(t *testing.T) {
+	start := time.Date(2023, 11, 5, 9, 0, 0, 0, time.UTC)
+	workout := &domain.Workout{WorkoutID: uuid.New(), State: domain.WorkoutCompleted, CreatedAt: start, EndedAt: start.Add(3 * time.Minute)}
+	pauses := []*domain.WorkoutPause{
+		{WorkoutID: workout.WorkoutID, StartedAt: start.Add(85 * time.Second), EndedAt: start.Add(120 * time.Second)},
+	}
+	heartRates := []domain.HeartRateSample{
+		{HeartRate: 90, TimeOfReading: start},
+		{HeartRate: 120, TimeOfReading: start.Add(10 * time.Second)},
+		// the monitor drops out for a minute after this reading
+		{HeartRate: 140, TimeOfReading: start.Add(20 * time.Second)},
+		// the workout is paused 5 s after this reading
+		{HeartRate: 160, TimeOfReading: start.Add(80 * time.Second)},
+		{HeartRate: 100, TimeOfReading: start.Add(100 * time.Second)},
+		{HeartRate: 200, TimeOfReading: start.Add(120 * time.Second)},
+		{HeartRate: 175, TimeOfReading: start.Add(130 * time.Second)},
+	}
+
+	tests := []struct {
+		name       string
+		formula    domain.HeartRateFormula
+		resting    uint8
+		expected   domain.HeartRateFormula
+		bounds     []uint8
+		belowZones float64
+		zones      []float64
+	}{
+		{"max", domain.MaxHeartRateFormula, 60, domain.MaxHeartRateFormula, []uint8{95, 114, 133, 152, 171}, 10, []float64{0, 10, 30, 5, 10}},
+		{"karvonen", domain.KarvonenFormula, 60, domain.KarvonenFormula, []uint8{125, 138, 151, 164, 177}, 20, []float64{0, 30, 5, 0, 10}},
+		// without a resting heart rate, the zones are those of the maximum heart rate
+		{"karvonen without resting heart rate", domain.KarvonenFormula, 0, domain.MaxHeartRateFormula, []uint8{95, 114, 133, 152, 171}, 10, []float64{0, 10, 30, 5, 10}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			analysis := domain.NewHeartRateAnalysis(workout, heartRates, pauses, test.formula, 30, test.resting)
+
+			if analysis.WorkoutID != workout.WorkoutID || analysis.Formula != test.expected || analysis.MaxHeartRate != 190 {
+				t.Fatalf("expected the %s zones of a maximum heart rate of 190, got %+v", test.expected, analysis)
+			}
+			if analysis.AverageHeartRate != 147 || analysis.PeakHeartRate != 200 || len(analysis.Samples) != 6 {
+				t.Errorf("expected the readings of the pause to be left out, got an average of %d, a peak of %d and %d samples", analysis.AverageHeartRate, analysis.PeakHeartRate, len(analysis.Samples))
+			}
+			if analysis.BelowZonesTime != test.belowZones {
+				t.Errorf("expected %f s below the zones, got %f", test.belowZones, analysis.BelowZonesTime)
+			}
+			if len(analysis.Zones) != 5 {
+				t.Fatalf("expected five zones, got %d", len(analysis.Zones))
+			}
+			for i, zone := range analysis.Zones {
+				if zone.Zone != i+1 || zone.MinHeartRate != test.bounds[i] || zone.Time != test.zones[i] {
+					t.Errorf("expected zone %d from %d bpm with %f s, got %+v", i+1, test.bounds[i], test.zones[i], zone)
+				}
+				if math.Abs(zone.Percentage-test.zones[i]/65*100) > 0.001 {
+					t.Errorf("expected zone %d to have %f%% of the time, got %f", i+1, test.zones[i]/65*100, zone.Percentage)
+				}
+			}
+			if analysis.Zones[4].MaxHeartRate != 190 || analysis.Zones[0].MaxHeartRate != analysis.Zones[1].MinHeartRate {
+				t.Errorf("expected each zone to end where the next one starts and the last one at the maximum, got %+v", analysis.Zones)
+			}
+		})
+	}
+
+	// a workout without readings spends no time in the zones
+	analysis := domain.NewHeartRateAnalysis(workout, nil, nil, domain.MaxHeartRateFormula, 30, 0)
+	if analysis.AverageHeartRate != 0 || analysis.BelowZonesTime != 0 || analysis.Zones[0].Time != 0 || analysis.Zones[0].Percentage != 0 {
+		t.Errorf("expected no time in the zones without readings, got %+v", analysis)
+	}
+}
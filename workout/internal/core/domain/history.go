@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Orders of the workout history
+const (
+	WorkoutSortStartTime = "start_time"
+	WorkoutSortDistance  = "distance"
+)
+
+const (
+	DefaultWorkoutPageSize = 20
+	MaxWorkoutPageSize     = 100
+)
+
+var (
+	ErrInvalidWorkoutFilter = errors.New("invalid workout filter")
+	ErrInvalidWorkoutCursor = errors.New("invalid workout page cursor")
+)
+
+// WorkoutFilter selects a page of the workout history, every zero field matches any workout
+type WorkoutFilter struct {
+	PlayerID uuid.UUID
+	TrailID  uuid.UUID
+	// workouts started at or after From and at or before To
+	From time.Time
+	To   time.Time
+	// nil for both completed and ongoing workouts
+	Completed *bool
+	// start_time or distance, newest or longest first unless Ascending
+	SortBy    string
+	Ascending bool
+	PageSize  int
+	// last workout of the previous page, nil for the first page
+	After *WorkoutCursor
+}
+
+// Normalize fills in the default order and page size and checks the filter
+func (f *WorkoutFilter) Normalize() error {
+	if f.SortBy == "" {
+		f.SortBy = WorkoutSortStartTime
+	}
+	if f.SortBy != WorkoutSortStartTime && f.SortBy != WorkoutSortDistance {
+		return ErrInvalidWorkoutFilter
+	}
+	if f.PageSize == 0 {
+		f.PageSize = DefaultWorkoutPageSize
+	}
+	if f.PageSize < 0 || f.PageSize > MaxWorkoutPageSize {
+		return ErrInvalidWorkoutFilter
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+		return ErrInvalidWorkoutFilter
+	}
+	// a cursor only carries on the order it was made for
+	if f.After != nil && (f.After.SortBy != f.SortBy || f.After.Ascending != f.Ascending) {
+		return ErrInvalidWorkoutCursor
+	}
+	return nil
+}
+
+// WorkoutCursor marks where a page of the history ended, the next page starts after it
+type WorkoutCursor struct {
+	SortBy    string    `json:"s"`
+	Ascending bool      `json:"a,omitempty"`
+	CreatedAt time.Time `json:"t"`
+	Distance  float64   `json:"d"`
+	WorkoutID uuid.UUID `json:"w"`
+}
+
+func NewWorkoutCursor(filter WorkoutFilter, last *Workout) WorkoutCursor {
+	return WorkoutCursor{
+		SortBy:    filter.SortBy,
+		Ascending: filter.Ascending,
+		CreatedAt: last.CreatedAt,
+		Distance:  last.DistanceCovered,
+		WorkoutID: last.WorkoutID,
+	}
+}
+
+// Encode returns the cursor as an opaque token for the API
+func (c WorkoutCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeWorkoutCursor reads a token returned by Encode
+func DecodeWorkoutCursor(token string) (*WorkoutCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidWorkoutCursor
+	}
+	var cursor WorkoutCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.WorkoutID == uuid.Nil {
+		return nil, ErrInvalidWorkoutCursor
+	}
+	return &cursor, nil
+}
+
+// WorkoutPage is a page of the workout history
+type WorkoutPage struct {
+	Workouts []*Workout
+	// cursor of the next page, empty on the last page
+	NextCursor string
+}
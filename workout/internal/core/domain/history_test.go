@@ -0,0 +1,62 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+func TestWorkoutFilter_Normalize(t *testing.T) {
+	var filter domain.WorkoutFilter
+	if err := filter.Normalize(); err != nil {
+		t.Fatalf("expected empty filter to be valid, got %v", err)
+	}
+	if filter.SortBy != domain.WorkoutSortStartTime || filter.PageSize != domain.DefaultWorkoutPageSize {
+		t.Errorf("expected newest first by pages of %d, got %+v", domain.DefaultWorkoutPageSize, filter)
+	}
+
+	now := time.Now()
+	invalid := []domain.WorkoutFilter{
+		{SortBy: "fights"},
+		{PageSize: -1},
+		{PageSize: domain.MaxWorkoutPageSize + 1},
+		{From: now, To: now.Add(-time.Hour)},
+	}
+	for _, filter := range invalid {
+		if err := filter.Normalize(); !errors.Is(err, domain.ErrInvalidWorkoutFilter) {
+			t.Errorf("expected ErrInvalidWorkoutFilter for %+v, got %v", filter, err)
+		}
+	}
+}
+
+func TestWorkoutCursor_RoundTrip(t *testing.T) {
+	filter := domain.WorkoutFilter{SortBy: domain.WorkoutSortDistance, Ascending: true}
+	last := &domain.Workout{WorkoutID: uuid.New(), CreatedAt: time.Date(2023, 11, 5, 9, 30, 0, 123000, time.UTC), DistanceCovered: 4.25}
+
+	cursor, err := domain.DecodeWorkoutCursor(domain.NewWorkoutCursor(filter, last).Encode())
+	if err != nil {
+		t.Fatalf("expected cursor to decode, got %v", err)
+	}
+	if cursor.WorkoutID != last.WorkoutID || !cursor.CreatedAt.Equal(last.CreatedAt) || cursor.Distance != last.DistanceCovered {
+		t.Errorf("expected cursor after the last workout, got %+v", cursor)
+	}
+
+	// The cursor carries on the same order only
+	filter.After = cursor
+	if err := filter.Normalize(); err != nil {
+		t.Errorf("expected cursor to match its filter, got %v", err)
+	}
+	filter.Ascending = false
+	if err := filter.Normalize(); !errors.Is(err, domain.ErrInvalidWorkoutCursor) {
+		t.Errorf("expected ErrInvalidWorkoutCursor for another order, got %v", err)
+	}
+
+	for _, token := range []string{"not a cursor", "e30"} {
+		if _, err := domain.DecodeWorkoutCursor(token); !errors.Is(err, domain.ErrInvalidWorkoutCursor) {
+			t.Errorf("expected ErrInvalidWorkoutCursor for %q, got %v", token, err)
+		}
+	}
+}
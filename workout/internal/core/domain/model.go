@@ -20,24 +20,46 @@ type Workout struct {
 	TrailID uuid.UUID `json:"trail_id"`
 	// PlayerID of the player starting the workout session
 	PlayerID uuid.UUID `json:"player_id"`
-	// InProgress tells whether the workout is in progress
+	// State of the workout in its lifecycle
+	State WorkoutState `json:"state"`
+	// IsCompleted tells whether the workout ended, completed or abandoned
 	IsCompleted bool `json:"is_completed"`
 	// CreatedAt is the time when the workout was started
 	CreatedAt time.Time `json:"created_at"`
-	// Duration of the workout
-	EndedAt time.Time `json:"ended_at"`
 	// EndedAt is the time when the workout was ended
+	EndedAt time.Time `json:"ended_at"`
+	// DistanceCovered in the workout in metres
 	DistanceCovered float64 `json:"distance_covered"`
 	// Player Profile can be either 'cardio' or 'strength'
 	Profile string `json:"profile"`
 	// HardcoreMode is the difficulty level chosen by the player
 	HardcoreMode bool `json:"hardcore_mode"`
+	// HRMConnected tells whether a heart rate monitor is bound to the workout
+	HRMConnected bool `json:"hrm_connected"`
 	// Shelters taken for a given workout
 	Shelters uint8 `json:"shelters_taken"`
 	// Fights fought in a given workout
 	Fights uint8 `json:"fights_fought"`
 	// Escapes made in a given workout
 	Escapes uint8 `json:"escapes_made"`
+	// OffTrail tells whether the player is currently away from the trail
+	OffTrail bool `json:"off_trail"`
+	// Times the player strayed from the trail in a given workout
+	OffTrailCount uint8 `json:"off_trail_count"`
+	// Metres climbed in a given workout, from locations with an elevation
+	ElevationGain float64 `json:"elevation_gain"`
+	// Metres descended in a given workout
+	ElevationLoss float64 `json:"elevation_loss"`
+	// PausedAt is the time when the workout was paused, zero while it is running
+	PausedAt time.Time `json:"paused_at"`
+	// AutoPaused tells whether the pause going on was started because the player stopped moving
+	AutoPaused bool `json:"auto_paused"`
+	// ResumedAt is the time when the workout was last resumed, zero if it never was
+	ResumedAt time.Time `json:"resumed_at"`
+	// Version of the stored workout it was read at, bumped by every update
+	Version uint64 `json:"version"`
+	// NewTransitions made since the workout was read, the repository logs them with the workout
+	NewTransitions []*WorkoutTransition `json:"-"`
 }
 
 type WorkoutOptions struct {
@@ -53,6 +75,16 @@ type WorkoutOptions struct {
 	IsWorkoutOptionActive bool `json:"is_workout_option_active"`
 	// Distance to Shelter
 	DistanceToShelter float64 `json:"distance_to_shelter"`
+	// Time when the current Workout Option was started
+	OptionStartedAt time.Time `json:"option_started_at"`
+	// Closest shelter reported by the zone, nil until one is reported
+	ShelterID uuid.UUID `json:"shelter_id"`
+	// Whether the closest shelter has a place left
+	ShelterAvailable bool `json:"shelter_available"`
+	// Shelter holding a place for the current shelter option, nil when none is held
+	ReservedShelterID uuid.UUID `json:"reserved_shelter_id"`
+	// Version of the stored options they were read at, bumped by every update
+	Version uint64 `json:"version"`
 }
 
 func NewWorkout(PlayerID uuid.UUID, TrailID uuid.UUID, HRMID uuid.UUID, HRMConnected bool, hardCoreMode bool) (Workout, error) {
@@ -65,8 +97,10 @@ func NewWorkout(PlayerID uuid.UUID, TrailID uuid.UUID, HRMID uuid.UUID, HRMConne
 		PlayerID:        PlayerID,
 		TrailID:         TrailID,
 		Profile:         "cardio",
+		State:           WorkoutCreated,
 		IsCompleted:     false,
 		HardcoreMode:    hardCoreMode,
+		HRMConnected:    HRMConnected,
 		CreatedAt:       time.Now(),
 		EndedAt:         time.Time{},
 		DistanceCovered: 0,
@@ -84,9 +118,61 @@ func (w *Workout) SetID(id uuid.UUID) {
 	w.WorkoutID = id
 }
 
+// TrackPoint is a single location of the player recorded during a workout
+type TrackPoint struct {
+	// WorkoutID of the workout the point belongs to
+	WorkoutID uuid.UUID `json:"workout_id"`
+	// Sequence is the position of the point in the track, starting at 0
+	Sequence uint32 `json:"sequence"`
+	// TimeOfLocation is the time at which the location was recorded
+	TimeOfLocation time.Time `json:"time_of_location"`
+	// Latitude of the Player
+	Latitude float64 `json:"latitude"`
+	// Longitude of the Player
+	Longitude float64 `json:"longitude"`
+	// Elevation of the Player in metres, nil when the device does not report it
+	Elevation *float64 `json:"elevation,omitempty"`
+	// SegmentDistance is the distance in metres credited to the workout since the previous point
+	SegmentDistance float64 `json:"segment_distance"`
+}
+
+// AddClimb credits the change of elevation between two locations to the workout, nothing is credited
+// unless both elevations are known. It returns whether the workout changed.
+func (w *Workout) AddClimb(from *float64, to *float64) bool {
+	if from == nil || to == nil || *from == *to {
+		return false
+	}
+	if *to > *from {
+		w.ElevationGain += *to - *from
+	} else {
+		w.ElevationLoss += *from - *to
+	}
+	return true
+}
+
 type WorkoutOptionLink struct {
 	Option      string `json:"option"`
 	Rank        uint   `json:"rank"`
 	Description string `json:"description"`
 	URL         string `json:"url"`
 }
+
+// HeartRateSample is a single heart rate reading taken during a workout
+type HeartRateSample struct {
+	// HeartRate in beats per minute
+	HeartRate uint8 `json:"heart_rate"`
+	// TimeOfReading is the time at which the reading was taken
+	TimeOfReading time.Time `json:"time_of_reading"`
+}
+
+// WorkoutOptionEvent is a shelter, fight or escape taken during a workout
+type WorkoutOptionEvent struct {
+	// WorkoutID of the workout the event belongs to
+	WorkoutID uuid.UUID `json:"workout_id"`
+	// Option can be either 'Shelter', 'Fight' or 'Escape'
+	Option string `json:"option"`
+	// StartedAt is the time when the option was started
+	StartedAt time.Time `json:"started_at"`
+	// EndedAt is the time when the option was stopped
+	EndedAt time.Time `json:"ended_at"`
+}
@@ -0,0 +1,34 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
+)
+
+func TestWorkout_AddClimb(t *testing.T) {
+	elevation := func(metres float64) *float64 {
+		return &metres
+	}
+
+	var workout domain.Workout
+	steps := []struct {
+		from, to *float64
+		changed  bool
+	}{
+		{from: elevation(100), to: elevation(112.5), changed: true},
+		{from: elevation(112.5), to: elevation(104), changed: true},
+		{from: elevation(104), to: elevation(104), changed: false},
+		{from: nil, to: elevation(150), changed: false},
+		{from: elevation(104), to: nil, changed: false},
+	}
+	for i, step := range steps {
+		if changed := workout.AddClimb(step.from, step.to); changed != step.changed {
+			t.Errorf("expected step %d to change the workout: %t, got %t", i, step.changed, changed)
+		}
+	}
+
+	if workout.ElevationGain != 12.5 || workout.ElevationLoss != 8.5 {
+		t.Errorf("expected 12.5 m climbed and 8.5 m descended, got %f and %f", workout.ElevationGain, workout.ElevationLoss)
+	}
+}
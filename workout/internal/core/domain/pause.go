@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWorkoutAlreadyPaused = errors.New("workout already paused")
+	ErrWorkoutNotPaused     = errors.New("workout not paused")
+)
+
+// AutoPause pauses the workouts by themselves while the player is slower than Speed, in m/s, for longer
+// than After. Workouts are only paused by the players when After is 0.
+type AutoPause struct {
+	Speed float64
+	After time.Duration
+}
+
+// Enabled tells whether workouts are paused automatically
+func (a AutoPause) Enabled() bool {
+	return a.After > 0
+}
+
+// WorkoutPause is a pause of a workout, no distance, pace or heart rate is credited to the workout during it
+type WorkoutPause struct {
+	// WorkoutID of the workout paused
+	WorkoutID uuid.UUID `json:"workout_id"`
+	// StartedAt is the time when the workout was paused
+	StartedAt time.Time `json:"started_at"`
+	// EndedAt is the time when the workout was resumed or stopped
+	EndedAt time.Time `json:"ended_at"`
+	// Auto tells whether the workout was paused because the player stopped moving
+	Auto bool `json:"auto"`
+}
+
+// IsPaused tells whether the workout is paused
+func (w *Workout) IsPaused() bool {
+	return w.State == WorkoutPaused
+}
+
+// Pause pauses the workout at the time. Pausing an automatic pause keeps the workout paused until the
+// player resumes it.
+func (w *Workout) Pause(at time.Time, auto bool) error {
+	if w.IsPaused() && w.AutoPaused && !auto {
+		w.AutoPaused = false
+		return nil
+	}
+	if err := w.transition(EventPause, at); err != nil {
+		return err
+	}
+	w.PausedAt = at
+	w.AutoPaused = auto
+	return nil
+}
+
+// Resume resumes the workout at the time and returns the pause it ended
+func (w *Workout) Resume(at time.Time) (*WorkoutPause, error) {
+	if err := w.Can(EventResume); err != nil {
+		return nil, err
+	}
+
+	pause := w.endPause(at)
+	w.transition(EventResume, pause.EndedAt)
+	w.ResumedAt = pause.EndedAt
+	return pause, nil
+}
+
+// endPause closes the pause going on at the time, or when it started if the time is before
+func (w *Workout) endPause(at time.Time) *WorkoutPause {
+	if at.Before(w.PausedAt) {
+		at = w.PausedAt
+	}
+
+	pause := &WorkoutPause{
+		WorkoutID: w.WorkoutID,
+		StartedAt: w.PausedAt,
+		EndedAt:   at,
+		Auto:      w.AutoPaused,
+	}
+	w.PausedAt = time.Time{}
+	w.AutoPaused = false
+	return pause
+}
+
+// PausedAtTime tells whether the workout was paused at the time, in one of its ended pauses or in the
+// one going on
+func PausedAtTime(workout *Workout, pauses []*WorkoutPause, at time.Time) bool {
+	if workout.IsPaused() && !at.Before(workout.PausedAt) {
+		return true
+	}
+	for _, pause := range pauses {
+		if !at.Before(pause.StartedAt) && at.Before(pause.EndedAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// PausedTime is the time in seconds the workout spent paused until the end
+func PausedTime(workout *Workout, pauses []*WorkoutPause, end time.Time) float64 {
+	paused := 0.0
+	for _, pause := range pauses {
+		paused += pause.EndedAt.Sub(pause.StartedAt).Seconds()
+	}
+	if workout.IsPaused() && end.After(workout.PausedAt) {
+		paused += end.Sub(workout.PausedAt).Seconds()
+	}
+	return paused
+}
+
+// ExcludePauses leaves out the heart rate readings taken while the workout was paused
+func ExcludePauses(workout *Workout, pauses []*WorkoutPause, heartRates []HeartRateSample) []HeartRateSample {
+	active := make([]HeartRateSample, 0, len(heartRates))
+	for _, sample := range heartRates {
+		if !PausedAtTime(workout, pauses, sample.TimeOfReading) {
+			active = append(active, sample)
+		}
+	}
+	return active
+}
+
+// AverageHeartRate of the readings, 0 without readings
+func AverageHeartRate(heartRates []HeartRateSample) uint8 {
+	if len(heartRates) == 0 {
+		return 0
+	}
+	sum := 0
+	for _, sample := range heartRates {
+		sum += int(sample.HeartRate)
+	}
+	return uint8(sum / len(heartRates))
+}
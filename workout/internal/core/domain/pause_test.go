@@ -0,0 +1,79 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+func TestWorkout_PauseResume(t *testing.T) {
+	start := time.Date(2023, 11, 5, 9, 0, 0, 0, time.UTC)
+	workout := &domain.Workout{WorkoutID: uuid.New(), State: domain.WorkoutRunning, CreatedAt: start}
+
+	if _, err := workout.Resume(start); !errors.Is(err, domain.ErrWorkoutNotPaused) {
+		t.Errorf("expected a running workout not to be resumed, got %v", err)
+	}
+
+	if err := workout.Pause(start.Add(time.Minute), true); err != nil || !workout.IsPaused() || !workout.AutoPaused {
+		t.Fatalf("expected the workout to be paused automatically, got %v and %+v", err, workout)
+	}
+	if err := workout.Pause(start.Add(2*time.Minute), true); !errors.Is(err, domain.ErrWorkoutAlreadyPaused) {
+		t.Errorf("expected a paused workout not to be paused again, got %v", err)
+	}
+	// the player pausing keeps the automatic pause, which now waits for them
+	if err := workout.Pause(start.Add(2*time.Minute), false); err != nil || workout.AutoPaused || !workout.PausedAt.Equal(start.Add(time.Minute)) {
+		t.Errorf("expected the automatic pause to be taken over by the player, got %v and %+v", err, workout)
+	}
+	if err := workout.Pause(start.Add(3*time.Minute), false); !errors.Is(err, domain.ErrWorkoutAlreadyPaused) {
+		t.Errorf("expected a paused workout not to be paused again, got %v", err)
+	}
+
+	pause, err := workout.Resume(start.Add(4 * time.Minute))
+	if err != nil {
+		t.Fatalf("expected the workout to be resumed, got %v", err)
+	}
+	if pause.WorkoutID != workout.WorkoutID || !pause.StartedAt.Equal(start.Add(time.Minute)) || !pause.EndedAt.Equal(start.Add(4*time.Minute)) || pause.Auto {
+		t.Errorf("expected a pause of the player from the first to the fourth minute, got %+v", pause)
+	}
+	if workout.IsPaused() || !workout.ResumedAt.Equal(start.Add(4*time.Minute)) {
+		t.Errorf("expected the workout to run again since the fourth minute, got %+v", workout)
+	}
+}
+
+func TestExcludePauses(t *testing.T) {
+	start := time.Date(2023, 11, 5, 9, 0, 0, 0, time.UTC)
+	workout := &domain.Workout{WorkoutID: uuid.New(), State: domain.WorkoutPaused, CreatedAt: start, PausedAt: start.Add(5 * time.Minute)}
+	pauses := []*domain.WorkoutPause{
+		{WorkoutID: workout.WorkoutID, StartedAt: start.Add(time.Minute), EndedAt: start.Add(2 * time.Minute)},
+	}
+	heartRates := []domain.HeartRateSample{
+		{HeartRate: 120, TimeOfReading: start.Add(30 * time.Second)},
+		{HeartRate: 90, TimeOfReading: start.Add(time.Minute)},
+		{HeartRate: 90, TimeOfReading: start.Add(90 * time.Second)},
+		{HeartRate: 140, TimeOfReading: start.Add(2 * time.Minute)},
+		{HeartRate: 80, TimeOfReading: start.Add(6 * time.Minute)},
+	}
+
+	active := domain.ExcludePauses(workout, pauses, heartRates)
+	if len(active) != 2 || active[0].HeartRate != 120 || active[1].HeartRate != 140 {
+		t.Errorf("expected the readings of the pauses to be left out, got %+v", active)
+	}
+	if average := domain.AverageHeartRate(active); average != 130 {
+		t.Errorf("expected an average heart rate of 130, got %d", average)
+	}
+	if average := domain.AverageHeartRate(nil); average != 0 {
+		t.Errorf("expected no average heart rate without readings, got %d", average)
+	}
+
+	// the pause going on lasts until the end
+	if paused := domain.PausedTime(workout, pauses, start.Add(7*time.Minute)); paused != 180 {
+		t.Errorf("expected 180 s paused, got %f", paused)
+	}
+	summary := domain.NewWorkoutSummary(workout, nil, pauses, start.Add(7*time.Minute))
+	if summary.PausedTime != 180 || summary.ElapsedTime != 420 {
+		t.Errorf("expected 180 s paused out of 420 s, got %f and %f", summary.PausedTime, summary.ElapsedTime)
+	}
+}
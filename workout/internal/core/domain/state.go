@@ -0,0 +1,191 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WorkoutState is the step of its lifecycle a workout is at
+type WorkoutState string
+
+const (
+	// WorkoutCreated is stored but its peripheral is not bound yet
+	WorkoutCreated WorkoutState = "created"
+	WorkoutRunning WorkoutState = "running"
+	// WorkoutInOption is running a shelter, a fight or an escape
+	WorkoutInOption  WorkoutState = "in_option"
+	WorkoutPaused    WorkoutState = "paused"
+	WorkoutCompleted WorkoutState = "completed"
+	// WorkoutAbandoned was ended without the player stopping it
+	WorkoutAbandoned WorkoutState = "abandoned"
+)
+
+// Ended tells whether the workout is over, no transition leaves the ended states
+func (s WorkoutState) Ended() bool {
+	return s == WorkoutCompleted || s == WorkoutAbandoned
+}
+
+// WorkoutEvent moves a workout from a state to another
+type WorkoutEvent string
+
+const (
+	EventStart       WorkoutEvent = "start"
+	EventStartOption WorkoutEvent = "start_option"
+	EventStopOption  WorkoutEvent = "stop_option"
+	EventPause       WorkoutEvent = "pause"
+	EventResume      WorkoutEvent = "resume"
+	EventStop        WorkoutEvent = "stop"
+	EventAbandon     WorkoutEvent = "abandon"
+)
+
+// workoutTransitions is the state each event leads to from each state, the events missing are illegal
+var workoutTransitions = map[WorkoutState]map[WorkoutEvent]WorkoutState{
+	WorkoutCreated: {
+		EventStart:   WorkoutRunning,
+		EventStop:    WorkoutCompleted,
+		EventAbandon: WorkoutAbandoned,
+	},
+	WorkoutRunning: {
+		EventStartOption: WorkoutInOption,
+		EventPause:       WorkoutPaused,
+		EventStop:        WorkoutCompleted,
+		EventAbandon:     WorkoutAbandoned,
+	},
+	WorkoutInOption: {
+		EventStopOption: WorkoutRunning,
+		EventStop:       WorkoutCompleted,
+		EventAbandon:    WorkoutAbandoned,
+	},
+	WorkoutPaused: {
+		EventResume:  WorkoutRunning,
+		EventStop:    WorkoutCompleted,
+		EventAbandon: WorkoutAbandoned,
+	},
+}
+
+var (
+	ErrIllegalTransition          = errors.New("illegal workout transition")
+	ErrWorkoutAlreadyCompleted    = errors.New("workout already completed")
+	ErrWorkoutOptionAlreadyActive = errors.New("workout option is already active")
+	ErrWorkoutOptionNotActive     = errors.New("no workout option is active")
+)
+
+// TransitionError is returned for an event the state of the workout does not allow. It is an
+// ErrIllegalTransition, and the error of the usual cases such as ErrWorkoutAlreadyCompleted.
+type TransitionError struct {
+	From  WorkoutState
+	Event WorkoutEvent
+}
+
+func (e *TransitionError) Error() string {
+	if reason := e.reason(); reason != nil {
+		return reason.Error()
+	}
+	return fmt.Sprintf("cannot %s a workout %s", strings.ReplaceAll(string(e.Event), "_", " "), strings.ReplaceAll(string(e.From), "_", " "))
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrIllegalTransition || (target != nil && target == e.reason())
+}
+
+// reason is the error of the usual illegal transitions, nil for the others
+func (e *TransitionError) reason() error {
+	switch {
+	case e.From.Ended():
+		return ErrWorkoutAlreadyCompleted
+	case e.Event == EventStartOption && e.From == WorkoutInOption:
+		return ErrWorkoutOptionAlreadyActive
+	case e.Event == EventStopOption:
+		return ErrWorkoutOptionNotActive
+	case e.Event == EventPause && e.From == WorkoutPaused:
+		return ErrWorkoutAlreadyPaused
+	case e.Event == EventResume:
+		return ErrWorkoutNotPaused
+	}
+	return nil
+}
+
+// WorkoutTransition is an entry of the event log of a workout
+type WorkoutTransition struct {
+	// WorkoutID of the workout
+	WorkoutID uuid.UUID `json:"workout_id"`
+	// Event that moved the workout
+	Event WorkoutEvent `json:"event"`
+	// From is the state the workout left
+	From WorkoutState `json:"from"`
+	// To is the state the workout entered
+	To WorkoutState `json:"to"`
+	// At is the time of the transition
+	At time.Time `json:"at"`
+}
+
+// Can tells whether the event is allowed in the state of the workout, with the TransitionError it is not
+func (w *Workout) Can(event WorkoutEvent) error {
+	if _, ok := workoutTransitions[w.State][event]; !ok {
+		return &TransitionError{From: w.State, Event: event}
+	}
+	return nil
+}
+
+// transition moves the workout to the state the event leads to and logs the transition, to be stored with
+// the workout
+func (w *Workout) transition(event WorkoutEvent, at time.Time) error {
+	if err := w.Can(event); err != nil {
+		return err
+	}
+
+	to := workoutTransitions[w.State][event]
+	w.NewTransitions = append(w.NewTransitions, &WorkoutTransition{
+		WorkoutID: w.WorkoutID,
+		Event:     event,
+		From:      w.State,
+		To:        to,
+		At:        at,
+	})
+	w.State = to
+	w.IsCompleted = to.Ended()
+	return nil
+}
+
+// Start runs the workout once its peripheral is bound
+func (w *Workout) Start(at time.Time) error {
+	return w.transition(EventStart, at)
+}
+
+// StartOption starts a shelter, a fight or an escape
+func (w *Workout) StartOption(at time.Time) error {
+	return w.transition(EventStartOption, at)
+}
+
+// StopOption ends the shelter, fight or escape going on
+func (w *Workout) StopOption(at time.Time) error {
+	return w.transition(EventStopOption, at)
+}
+
+// Stop completes the workout and returns the pause it ended, if it was paused
+func (w *Workout) Stop(at time.Time) (*WorkoutPause, error) {
+	return w.end(EventStop, at)
+}
+
+// Abandon ends the workout the player left and returns the pause it ended, if it was paused
+func (w *Workout) Abandon(at time.Time) (*WorkoutPause, error) {
+	return w.end(EventAbandon, at)
+}
+
+func (w *Workout) end(event WorkoutEvent, at time.Time) (*WorkoutPause, error) {
+	if err := w.Can(event); err != nil {
+		return nil, err
+	}
+
+	var pause *WorkoutPause
+	if w.IsPaused() {
+		pause = w.endPause(at)
+	}
+	w.transition(event, at)
+	w.EndedAt = at
+	return pause, nil
+}
@@ -0,0 +1,97 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+func TestWorkout_Transitions(t *testing.T) {
+	start := time.Date(2023, 11, 5, 9, 0, 0, 0, time.UTC)
+	workout, err := domain.NewWorkout(uuid.New(), uuid.New(), uuid.New(), false, false)
+	if err != nil || workout.State != domain.WorkoutCreated {
+		t.Fatalf("expected a new workout to be created, got %v and %q", err, workout.State)
+	}
+
+	steps := []struct {
+		apply func(at time.Time) error
+		state domain.WorkoutState
+	}{
+		{workout.Start, domain.WorkoutRunning},
+		{workout.StartOption, domain.WorkoutInOption},
+		{workout.StopOption, domain.WorkoutRunning},
+		{func(at time.Time) error { return workout.Pause(at, false) }, domain.WorkoutPaused},
+		{func(at time.Time) error { _, err := workout.Resume(at); return err }, domain.WorkoutRunning},
+		{func(at time.Time) error { return workout.Pause(at, true) }, domain.WorkoutPaused},
+	}
+	for i, step := range steps {
+		if err := step.apply(start.Add(time.Duration(i) * time.Minute)); err != nil || workout.State != step.state {
+			t.Fatalf("step %d: expected the workout to be %q, got %v and %q", i, step.state, err, workout.State)
+		}
+	}
+
+	// stopping a paused workout ends its pause
+	pause, err := workout.Stop(start.Add(10 * time.Minute))
+	if err != nil || workout.State != domain.WorkoutCompleted || !workout.IsCompleted || !workout.EndedAt.Equal(start.Add(10*time.Minute)) {
+		t.Fatalf("expected the workout to be completed, got %v and %+v", err, workout)
+	}
+	if pause == nil || !pause.StartedAt.Equal(start.Add(5*time.Minute)) || !pause.EndedAt.Equal(start.Add(10*time.Minute)) || !pause.Auto {
+		t.Errorf("expected the automatic pause to end with the workout, got %+v", pause)
+	}
+
+	if len(workout.NewTransitions) != len(steps)+1 {
+		t.Fatalf("expected %d transitions, got %d", len(steps)+1, len(workout.NewTransitions))
+	}
+	last := workout.NewTransitions[len(steps)]
+	if last.WorkoutID != workout.WorkoutID || last.Event != domain.EventStop || last.From != domain.WorkoutPaused || last.To != domain.WorkoutCompleted {
+		t.Errorf("expected the last transition to stop the paused workout, got %+v", last)
+	}
+}
+
+func TestWorkout_IllegalTransitions(t *testing.T) {
+	start := time.Date(2023, 11, 5, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		state domain.WorkoutState
+		event domain.WorkoutEvent
+		err   error
+	}{
+		{domain.WorkoutCreated, domain.EventPause, domain.ErrIllegalTransition},
+		{domain.WorkoutRunning, domain.EventStart, domain.ErrIllegalTransition},
+		{domain.WorkoutRunning, domain.EventStopOption, domain.ErrWorkoutOptionNotActive},
+		{domain.WorkoutInOption, domain.EventStartOption, domain.ErrWorkoutOptionAlreadyActive},
+		{domain.WorkoutInOption, domain.EventPause, domain.ErrIllegalTransition},
+		{domain.WorkoutPaused, domain.EventPause, domain.ErrWorkoutAlreadyPaused},
+		{domain.WorkoutPaused, domain.EventStartOption, domain.ErrIllegalTransition},
+		{domain.WorkoutRunning, domain.EventResume, domain.ErrWorkoutNotPaused},
+		{domain.WorkoutCompleted, domain.EventStop, domain.ErrWorkoutAlreadyCompleted},
+		{domain.WorkoutAbandoned, domain.EventResume, domain.ErrWorkoutAlreadyCompleted},
+	}
+	for _, test := range tests {
+		workout := &domain.Workout{WorkoutID: uuid.New(), State: test.state}
+		err := workout.Can(test.event)
+
+		var transitionErr *domain.TransitionError
+		if !errors.As(err, &transitionErr) || !errors.Is(err, domain.ErrIllegalTransition) || !errors.Is(err, test.err) {
+			t.Errorf("expected %s of a workout %s to fail with %v, got %v", test.event, test.state, test.err, err)
+		}
+		if transitionErr != nil && (transitionErr.From != test.state || transitionErr.Event != test.event) {
+			t.Errorf("expected the error to tell the state and the event, got %+v", transitionErr)
+		}
+	}
+
+	// an illegal event leaves the workout as it was
+	workout := &domain.Workout{WorkoutID: uuid.New(), State: domain.WorkoutCompleted, EndedAt: start}
+	if _, err := workout.Abandon(start.Add(time.Minute)); !errors.Is(err, domain.ErrWorkoutAlreadyCompleted) {
+		t.Errorf("expected a completed workout not to be abandoned, got %v", err)
+	}
+	if workout.State != domain.WorkoutCompleted || !workout.EndedAt.Equal(start) || len(workout.NewTransitions) != 0 {
+		t.Errorf("expected the completed workout to be left as it was, got %+v", workout)
+	}
+	if err := (&domain.TransitionError{From: domain.WorkoutInOption, Event: domain.EventPause}).Error(); err != "cannot pause a workout in option" {
+		t.Errorf("expected the error to tell what was refused, got %q", err)
+	}
+}
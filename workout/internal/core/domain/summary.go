@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StopSpeed is the speed in m/s under which the player is stopped between two locations, the time spent
+// there is not moving time
+const StopSpeed = 0.5
+
+// SplitDistance is the distance in metres of a split
+const SplitDistance = 1000
+
+// Split is a kilometre of the workout, the last one may be shorter
+type Split struct {
+	// Number of the split, starting at 1
+	Number int `json:"number"`
+	// Distance of the split in metres
+	Distance float64 `json:"distance"`
+	// MovingTime spent on the split in seconds
+	MovingTime float64 `json:"moving_time"`
+	// Pace on the split in seconds per km
+	Pace float64 `json:"pace"`
+}
+
+// WorkoutSummary is the pace, the splits and the moving time of a workout, computed from its track
+type WorkoutSummary struct {
+	// WorkoutID of the workout summarized
+	WorkoutID uuid.UUID `json:"workout_id"`
+	// Distance covered in metres
+	Distance float64 `json:"distance"`
+	// ElapsedTime from the start to the end of the workout in seconds, until now while it is in progress
+	ElapsedTime float64 `json:"elapsed_time"`
+	// MovingTime in seconds, the elapsed time without the stops, the pauses and before the first location
+	MovingTime float64 `json:"moving_time"`
+	// PausedTime in seconds, the time the workout spent paused
+	PausedTime float64 `json:"paused_time"`
+	// AveragePace over the moving time in seconds per km, 0 before the player moved
+	AveragePace float64 `json:"average_pace"`
+	// BestPace of the full splits in seconds per km, 0 before the first full split
+	BestPace float64 `json:"best_pace"`
+	// Splits of the workout in order
+	Splits []Split `json:"splits"`
+}
+
+// NewWorkoutSummary summarizes the workout from its track ordered by sequence and its pauses, now ends the
+// workouts in progress. Nothing is credited to the segments of the track during a pause, they are left out
+// of the moving time as stops.
+func NewWorkoutSummary(workout *Workout, track []*TrackPoint, pauses []*WorkoutPause, now time.Time) *WorkoutSummary {
+	summary := &WorkoutSummary{
+		WorkoutID: workout.WorkoutID,
+		Distance:  workout.DistanceCovered,
+		Splits:    make([]Split, 0),
+	}
+
+	end := now
+	if workout.IsCompleted {
+		end = workout.EndedAt
+	}
+	if end.After(workout.CreatedAt) {
+		summary.ElapsedTime = end.Sub(workout.CreatedAt).Seconds()
+	}
+	summary.PausedTime = PausedTime(workout, pauses, end)
+
+	split := Split{Number: 1}
+	for i := 1; i < len(track); i++ {
+		distance := track[i].SegmentDistance
+		seconds := track[i].TimeOfLocation.Sub(track[i-1].TimeOfLocation).Seconds()
+		if seconds <= 0 {
+			seconds = 0
+		} else if distance/seconds < StopSpeed {
+			// the player stopped, the distance still counts but not the time
+			seconds = 0
+		}
+		summary.MovingTime += seconds
+
+		// the segment is shared between the splits it crosses, in proportion of the distance
+		for distance > 0 {
+			part := distance
+			if left := SplitDistance - split.Distance; part > left {
+				part = left
+			}
+			share := seconds * part / distance
+			split.Distance += part
+			split.MovingTime += share
+			distance -= part
+			seconds -= share
+
+			if split.Distance >= SplitDistance-1e-9 {
+				split.Distance = SplitDistance
+				summary.Splits = append(summary.Splits, split.withPace())
+				split = Split{Number: split.Number + 1}
+			}
+		}
+	}
+	if split.Distance > 0 {
+		summary.Splits = append(summary.Splits, split.withPace())
+	}
+
+	if summary.MovingTime > 0 && summary.Distance > 0 {
+		summary.AveragePace = summary.MovingTime / (summary.Distance / SplitDistance)
+	}
+	for _, split := range summary.Splits {
+		if split.Distance == SplitDistance && split.Pace > 0 && (summary.BestPace == 0 || split.Pace < summary.BestPace) {
+			summary.BestPace = split.Pace
+		}
+	}
+	return summary
+}
+
+func (s Split) withPace() Split {
+	if s.Distance > 0 {
+		s.Pace = s.MovingTime / (s.Distance / SplitDistance)
+	}
+	return s
+}
@@ -0,0 +1,100 @@
+package domain_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+// summaryTrack builds a track from the seconds since start and the metres covered since the previous point
+func summaryTrack(workoutID uuid.UUID, start time.Time, steps [][2]float64) []*domain.TrackPoint {
+	track := make([]*domain.TrackPoint, 0, len(steps))
+	for i, step := range steps {
+		track = append(track, &domain.TrackPoint{
+			WorkoutID:       workoutID,
+			Sequence:        uint32(i),
+			TimeOfLocation:  start.Add(time.Duration(step[0]) * time.Second),
+			SegmentDistance: step[1],
+		})
+	}
+	return track
+}
+
+func TestNewWorkoutSummary(t *testing.T) {
+	start := time.Date(2023, 11, 5, 9, 0, 0, 0, time.UTC)
+	workout := &domain.Workout{
+		WorkoutID:       uuid.New(),
+		CreatedAt:       start.Add(-30 * time.Second),
+		EndedAt:         start.Add(600 * time.Second),
+		IsCompleted:     true,
+		DistanceCovered: 2500,
+	}
+	track := summaryTrack(workout.WorkoutID, start, [][2]float64{
+		{0, 0},
+		{60, 250},
+		{120, 250},
+		// stopped for two minutes
+		{240, 0},
+		{300, 250},
+		{360, 250},
+		// faster, the second split ends in the middle of the last segment
+		{420, 500},
+		{540, 1000},
+	})
+
+	summary := domain.NewWorkoutSummary(workout, track, nil, start.Add(time.Hour))
+
+	if summary.WorkoutID != workout.WorkoutID || summary.Distance != 2500 {
+		t.Errorf("expected the summary of the workout over 2500 m, got %+v", summary)
+	}
+	if summary.ElapsedTime != 630 {
+		t.Errorf("expected an elapsed time of 630 s, got %f", summary.ElapsedTime)
+	}
+	if summary.MovingTime != 420 {
+		t.Errorf("expected a moving time of 420 s without the stop, got %f", summary.MovingTime)
+	}
+	if math.Abs(summary.AveragePace-168) > 1e-9 || summary.BestPace != 120 {
+		t.Errorf("expected an average pace of 168 s/km and a best pace of 120 s/km, got %f and %f", summary.AveragePace, summary.BestPace)
+	}
+
+	expected := []domain.Split{
+		{Number: 1, Distance: 1000, MovingTime: 240, Pace: 240},
+		{Number: 2, Distance: 1000, MovingTime: 120, Pace: 120},
+		{Number: 3, Distance: 500, MovingTime: 60, Pace: 120},
+	}
+	if len(summary.Splits) != len(expected) {
+		t.Fatalf("expected %d splits, got %+v", len(expected), summary.Splits)
+	}
+	for i, split := range summary.Splits {
+		if split.Number != expected[i].Number || math.Abs(split.Distance-expected[i].Distance) > 1e-9 ||
+			math.Abs(split.MovingTime-expected[i].MovingTime) > 1e-9 || math.Abs(split.Pace-expected[i].Pace) > 1e-9 {
+			t.Errorf("expected split %+v, got %+v", expected[i], split)
+		}
+	}
+}
+
+func TestNewWorkoutSummary_InProgress(t *testing.T) {
+	start := time.Date(2023, 11, 5, 9, 0, 0, 0, time.UTC)
+	workout := &domain.Workout{WorkoutID: uuid.New(), CreatedAt: start, DistanceCovered: 400}
+	track := summaryTrack(workout.WorkoutID, start, [][2]float64{{10, 0}, {70, 200}, {130, 200}})
+
+	summary := domain.NewWorkoutSummary(workout, track, nil, start.Add(150*time.Second))
+	if summary.ElapsedTime != 150 || summary.MovingTime != 120 {
+		t.Errorf("expected 150 s elapsed until now and 120 s moving, got %f and %f", summary.ElapsedTime, summary.MovingTime)
+	}
+	if len(summary.Splits) != 1 || summary.Splits[0].Distance != 400 || summary.Splits[0].Pace != 300 {
+		t.Errorf("expected a single split of 400 m at 300 s/km, got %+v", summary.Splits)
+	}
+	if summary.AveragePace != 300 || summary.BestPace != 0 {
+		t.Errorf("expected an average pace of 300 s/km and no best pace before a full split, got %f and %f", summary.AveragePace, summary.BestPace)
+	}
+
+	// a workout without locations has no splits nor pace
+	summary = domain.NewWorkoutSummary(&domain.Workout{WorkoutID: uuid.New(), CreatedAt: start}, nil, nil, start.Add(time.Minute))
+	if len(summary.Splits) != 0 || summary.MovingTime != 0 || summary.AveragePace != 0 || summary.ElapsedTime != 60 {
+		t.Errorf("expected an empty summary over a minute, got %+v", summary)
+	}
+}
@@ -0,0 +1,36 @@
+package domain
+
+import "errors"
+
+// DistanceUnit is the unit distances are shown to the players in, they are stored in metres
+type DistanceUnit string
+
+const (
+	Kilometres DistanceUnit = "km"
+	Miles      DistanceUnit = "mi"
+)
+
+const (
+	metresPerKilometre = 1000
+	metresPerMile      = 1609.344
+)
+
+var ErrInvalidDistanceUnit = errors.New("distance unit must be km or mi")
+
+// ParseDistanceUnit reads a unit given by a player or a client
+func ParseDistanceUnit(unit string) (DistanceUnit, error) {
+	switch DistanceUnit(unit) {
+	case Kilometres, Miles:
+		return DistanceUnit(unit), nil
+	default:
+		return "", ErrInvalidDistanceUnit
+	}
+}
+
+// FromMetres converts a distance in metres to the unit
+func (u DistanceUnit) FromMetres(metres float64) float64 {
+	if u == Miles {
+		return metres / metresPerMile
+	}
+	return metres / metresPerKilometre
+}
@@ -0,0 +1,41 @@
+package domain_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
+)
+
+func TestParseDistanceUnit(t *testing.T) {
+	for _, unit := range []string{"km", "mi"} {
+		parsed, err := domain.ParseDistanceUnit(unit)
+		if err != nil || string(parsed) != unit {
+			t.Errorf("expected unit %s, got %s and %v", unit, parsed, err)
+		}
+	}
+	for _, unit := range []string{"", "m", "miles", "KM"} {
+		if _, err := domain.ParseDistanceUnit(unit); !errors.Is(err, domain.ErrInvalidDistanceUnit) {
+			t.Errorf("expected unit %q to be refused, got %v", unit, err)
+		}
+	}
+}
+
+func TestDistanceUnit_FromMetres(t *testing.T) {
+	tests := []struct {
+		unit     domain.DistanceUnit
+		metres   float64
+		expected float64
+	}{
+		{unit: domain.Kilometres, metres: 5000, expected: 5},
+		{unit: domain.Miles, metres: 1609.344, expected: 1},
+		{unit: domain.Miles, metres: 42195, expected: 26.2188},
+		{unit: domain.Kilometres, metres: 0, expected: 0},
+	}
+	for _, test := range tests {
+		if distance := test.unit.FromMetres(test.metres); math.Abs(distance-test.expected) > 1e-4 {
+			t.Errorf("expected %f m to be %f %s, got %f", test.metres, test.expected, test.unit, distance)
+		}
+	}
+}
@@ -15,31 +15,46 @@ var (
 	ErrorCreateWorkoutFailed        = errors.New("failed to create the workout")
 	ErrorActiveWorkoutAlreadyExists = errors.New("active workout already exists")
 	ErrorUpdateWorkoutFailed        = errors.New("failed to update workout")
+	ErrorWorkoutVersionConflict     = errors.New("workout was updated since it was read")
 	ErrorWorkoutOptionUnavailable   = errors.New("workout option unavailable")
 	ErrorWorkoutOptionInvalid       = errors.New("workout option invalid")
 	ErrInvalidWorkout               = errors.New("no workout_id matched")
-	ErrWorkoutOptionAlreadyActive   = errors.New("workout option is already active")
-	ErrWorkoutOptionAlreadyInActive = errors.New("no workout option is active")
-	ErrWorkoutAlreadyCompleted      = errors.New("workout already completed")
+	ErrWorkoutOptionAlreadyActive   = domain.ErrWorkoutOptionAlreadyActive
+	ErrWorkoutOptionAlreadyInActive = domain.ErrWorkoutOptionNotActive
+	ErrWorkoutAlreadyCompleted      = domain.ErrWorkoutAlreadyCompleted
+	ErrWorkoutNotCompleted          = errors.New("workout not completed yet")
+	ErrorInvalidStaleWorkoutSweep   = errors.New("stale workout sweep must be positive")
 )
 
 type WorkoutService interface {
-	List() ([]*domain.Workout, error)
+	List(filter domain.WorkoutFilter) (*domain.WorkoutPage, error)
 	GetWorkout(workoutID uuid.UUID) (*domain.Workout, error)
 
 	StartWorkout(workout domain.Workout) error
 	StopWorkout(workout domain.Workout) (*domain.Workout, error)
+	Pause(workoutID uuid.UUID) (*domain.Workout, error)
+	Resume(workoutID uuid.UUID) (*domain.Workout, error)
+	Transitions(workoutID uuid.UUID) ([]*domain.WorkoutTransition, error)
 
 	GetWorkoutOptions(workoutID uuid.UUID) (uint8, error)
 	StartWorkoutOption(workoutID uuid.UUID, option string) (string, error)
 	StopWorkoutOption(workoutID uuid.UUID) (string, error)
 
-	UpdateDistanceTravelled(workoutID uuid.UUID, latitude float64, longitude float64, timeOfLocation time.Time) error
-	UpdateShelter(workoutID uuid.UUID, DistanceToShelter float64) error
+	UpdateDistanceTravelled(workoutID uuid.UUID, latitude float64, longitude float64, elevation *float64, timeOfLocation time.Time) error
+	GetTrack(workoutID uuid.UUID) ([]*domain.TrackPoint, error)
+	Summary(workoutID uuid.UUID) (*domain.WorkoutSummary, error)
+	ExportWorkout(workoutID uuid.UUID, format string) ([]byte, error)
+	HeartRateZones(workoutID uuid.UUID, formula string) (*domain.HeartRateAnalysis, error)
+	UpdateShelter(workoutID uuid.UUID, shelterID uuid.UUID, shelterAvailable bool, DistanceToShelter float64) error
+	UpdateShelterReservation(workoutID uuid.UUID, shelterID uuid.UUID, reserved bool, reason string) error
+	UpdateOffTrail(workoutID uuid.UUID, offTrail bool, distanceFromTrail float64) error
+	ArriveAtShelter(workoutID uuid.UUID, shelterID uuid.UUID) error
 	ComputeWorkoutOptionsOrder() error
 
 	GetDistanceById(workoutID uuid.UUID) (float64, error)
 	GetDistanceCoveredBetweenDates(playerID uuid.UUID, startDate time.Time, endDate time.Time) (float64, error)
+	GetWorkoutsCompletedBetweenDates(playerID uuid.UUID, startDate time.Time, endDate time.Time) (uint16, error)
+	DistanceUnit(playerID uuid.UUID, requested string) (domain.DistanceUnit, error)
 	GetEscapesMadeById(workoutID uuid.UUID) (uint16, error)
 	GetEscapesMadeBetweenDates(playerID uuid.UUID, startDate time.Time, endDate time.Time) (uint16, error)
 	GetFightsFoughtById(workoutID uuid.UUID) (uint16, error)
@@ -49,18 +64,42 @@ type WorkoutService interface {
 }
 
 type WorkoutRepository interface {
-	//List() ([]*domain.Workout, error)
+	// ListWorkouts returns up to limit workouts matching the filter, after its cursor
+	ListWorkouts(filter domain.WorkoutFilter, limit int) ([]*domain.Workout, error)
 	Create(workout *domain.Workout, workoutOptions *domain.WorkoutOptions) error
 
 	GetWorkout(workoutID uuid.UUID) (*domain.Workout, error)
+	// UpdateWorkout stores the workout and logs its new transitions with it
 	UpdateWorkout(workout *domain.Workout) (*domain.Workout, error)
+	// GetWorkoutTransitions returns the event log of the workout, oldest first
+	GetWorkoutTransitions(workoutID uuid.UUID) ([]*domain.WorkoutTransition, error)
+	// ListActiveWorkouts returns the workouts in progress, oldest first
+	ListActiveWorkouts() ([]*domain.Workout, error)
 	GetWorkoutOptions(workoutID uuid.UUID) (*domain.WorkoutOptions, error)
 	UpdateWorkoutOptions(workoutOptions *domain.WorkoutOptions) (*domain.WorkoutOptions, error)
 
 	DeleteWorkoutOptions(workoutID uuid.UUID) error
 
+	AddTrackPoint(point *domain.TrackPoint) error
+	GetTrack(workoutID uuid.UUID) ([]*domain.TrackPoint, error)
+	// GetLastTrackPoint returns nil when no location was recorded yet
+	GetLastTrackPoint(workoutID uuid.UUID) (*domain.TrackPoint, error)
+
+	AddWorkoutOptionEvent(event *domain.WorkoutOptionEvent) error
+	GetWorkoutOptionEvents(workoutID uuid.UUID) ([]*domain.WorkoutOptionEvent, error)
+
+	AddWorkoutPause(pause *domain.WorkoutPause) error
+	// GetWorkoutPauses returns the ended pauses of the workout, oldest first
+	GetWorkoutPauses(workoutID uuid.UUID) ([]*domain.WorkoutPause, error)
+
+	// AddHeartRateSamples keeps the readings of the heart rate monitor of the workout
+	AddHeartRateSamples(workoutID uuid.UUID, samples []domain.HeartRateSample) error
+	// GetHeartRateSamples returns the readings kept for the workout, oldest first
+	GetHeartRateSamples(workoutID uuid.UUID) ([]domain.HeartRateSample, error)
+
 	GetDistanceByID(workoutID uuid.UUID) (float64, error)
 	GetDistanceCoveredBetweenDates(playerID uuid.UUID, startDate time.Time, endDate time.Time) (float64, error)
+	GetWorkoutsCompletedBetweenDates(playerID uuid.UUID, startDate time.Time, endDate time.Time) (uint16, error)
 	GetEscapesMadeByID(workoutID uuid.UUID) (uint16, error)
 	GetEscapesMadeBetweenDates(playerID uuid.UUID, startDate time.Time, endDate time.Time) (uint16, error)
 	GetFightsFoughtByID(workoutID uuid.UUID) (uint16, error)
@@ -73,13 +112,27 @@ type WorkoutStatsPublisher interface {
 	PublishWorkoutStats(workoutStats *domain.Workout) error
 }
 
+type ShelterReservationPublisher interface {
+	PublishShelterReservation(workoutID uuid.UUID, shelterID uuid.UUID, reserve bool) error
+}
+
+type WorkoutEndPublisher interface {
+	PublishWorkoutEnd(workoutID uuid.UUID, endedAt time.Time) error
+}
+
 type UserServiceClient interface {
 	GetWorkoutPreferenceOfUser(playerID uuid.UUID) (string, error)
 	GetUserAge(playerID uuid.UUID) (uint8, error)
+	GetDistanceUnitOfUser(playerID uuid.UUID) (string, error)
+	// GetRestingHeartRateOfUser returns the resting heart rate of the player in bpm, 0 when unknown
+	GetRestingHeartRateOfUser(playerID uuid.UUID) (uint8, error)
 }
 
 type PeripheralClient interface {
 	GetAverageHeartRateOfUser(workoutID uuid.UUID) (uint8, error)
+	GetHeartRateSamples(workoutID uuid.UUID) ([]domain.HeartRateSample, error)
 	BindPeripheralData(trailID uuid.UUID, playerID uuid.UUID, workoutID uuid.UUID, hrmID uuid.UUID, HRMConnected bool, SendLiveLocationToTrailManager bool) error
 	UnbindPeripheralData(workoutID uuid.UUID) error
+	// PausePeripheralData pauses or resumes the live publishing of the peripheral bound to the workout
+	PausePeripheralData(workoutID uuid.UUID, paused bool) error
 }
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const workoutLockShards = 64
+
+// workoutLocks serializes the changes made to a workout by the HTTP handlers and the consumers. Workouts are
+// spread over a fixed set of mutexes, so two workouts may share one but no lock ever has to be cleaned up.
+// A goroutine never holds more than one of them.
+type workoutLocks struct {
+	shards [workoutLockShards]sync.Mutex
+}
+
+// lock locks the workout, or the player, and returns the function unlocking it
+func (l *workoutLocks) lock(id uuid.UUID) func() {
+	shard := &l.shards[binary.BigEndian.Uint32(id[12:])%workoutLockShards]
+	shard.Lock()
+	return shard.Unlock
+}
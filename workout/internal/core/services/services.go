@@ -1,9 +1,11 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
@@ -20,8 +22,14 @@ type ActiveWorkoutsLastLocation struct {
 	Latitude float64 `json:"latitude"`
 	// Longitude of the Player
 	Longitude float64 `json:"longitude"`
+	// Elevation of the Player in metres, nil when it is not known
+	Elevation *float64 `json:"elevation"`
 	// Time of location
 	TimeOfLocation time.Time `json:"time_of_location"`
+	// Sequence of the location in the workout track
+	Sequence uint32 `json:"sequence"`
+	// Time since which the player is slower than the auto-pause speed, zero while they move
+	SlowSince time.Time `json:"slow_since"`
 }
 
 type ActiveWorkoutsHeartRate struct {
@@ -34,26 +42,70 @@ type WorkoutService struct {
 	peripheral                 ports.PeripheralClient
 	user                       ports.UserServiceClient
 	workoutStatsPublisher      ports.WorkoutStatsPublisher
+	shelterReservation         ports.ShelterReservationPublisher
+	workoutEndPublisher        ports.WorkoutEndPublisher
 	activeWorkoutsLastLocation map[uuid.UUID]ActiveWorkoutsLastLocation
 	activeWorkoutsHeartRate    map[uuid.UUID]ActiveWorkoutsHeartRate
 	activePlayers              map[uuid.UUID]bool
+	// time of the last update received for each workout in progress, to abandon the stale ones
+	lastActivity map[uuid.UUID]time.Time
+	// factor applied to the distance between two locations
+	distanceScale float64
+	autoPause     domain.AutoPause
+	// formula of the heart rate zones unless the client asks for another one
+	heartRateFormula domain.HeartRateFormula
+	// stateMu guards the maps above, locks serializes the changes to each workout
+	stateMu sync.RWMutex
+	locks   workoutLocks
 }
 
+// times a workout is read again when another writer updated it first
+const maxUpdateAttempts = 3
+
 // Factory for creating a new WorkoutService
-func NewWorkoutService(repo ports.WorkoutRepository, peripheral ports.PeripheralClient, user ports.UserServiceClient, workoutStatsPublisher ports.WorkoutStatsPublisher) *WorkoutService {
+func NewWorkoutService(repo ports.WorkoutRepository, peripheral ports.PeripheralClient, user ports.UserServiceClient, workoutStatsPublisher ports.WorkoutStatsPublisher, shelterReservation ports.ShelterReservationPublisher, workoutEndPublisher ports.WorkoutEndPublisher, distanceScale float64, autoPause domain.AutoPause, heartRateFormula domain.HeartRateFormula) *WorkoutService {
+	if distanceScale <= 0 {
+		distanceScale = 1
+	}
+	if heartRateFormula == "" {
+		heartRateFormula = domain.MaxHeartRateFormula
+	}
 	return &WorkoutService{
 		repo:                       repo,
 		peripheral:                 peripheral,
 		user:                       user,
 		workoutStatsPublisher:      workoutStatsPublisher,
+		shelterReservation:         shelterReservation,
+		workoutEndPublisher:        workoutEndPublisher,
 		activeWorkoutsLastLocation: make(map[uuid.UUID]ActiveWorkoutsLastLocation),
 		activeWorkoutsHeartRate:    make(map[uuid.UUID]ActiveWorkoutsHeartRate),
 		activePlayers:              make(map[uuid.UUID]bool),
+		lastActivity:               make(map[uuid.UUID]time.Time),
+		distanceScale:              distanceScale,
+		autoPause:                  autoPause,
+		heartRateFormula:           heartRateFormula,
 	}
 }
 
-func (s *WorkoutService) List() ([]*domain.Workout, error) {
-	return []*domain.Workout{}, nil
+// List returns a page of the workout history matching the filter
+func (s *WorkoutService) List(filter domain.WorkoutFilter) (*domain.WorkoutPage, error) {
+	if err := filter.Normalize(); err != nil {
+		return nil, err
+	}
+
+	// one more workout than the page tells whether there is a next page
+	workouts, err := s.repo.ListWorkouts(filter, filter.PageSize+1)
+	if err != nil {
+		logger.Debug("failed to list workouts", zap.Error(err))
+		return nil, fmt.Errorf("%w: %v", ports.ErrorListWorkoutsFailed, err)
+	}
+
+	page := &domain.WorkoutPage{Workouts: workouts}
+	if len(workouts) > filter.PageSize {
+		page.Workouts = workouts[:filter.PageSize]
+		page.NextCursor = domain.NewWorkoutCursor(filter, page.Workouts[filter.PageSize-1]).Encode()
+	}
+	return page, nil
 }
 
 func (s *WorkoutService) GetWorkout(id uuid.UUID) (*domain.Workout, error) {
@@ -65,12 +117,57 @@ func (s *WorkoutService) GetWorkout(id uuid.UUID) (*domain.Workout, error) {
 	return workout, nil
 }
 
+// RestoreActiveWorkouts rebuilds the workouts in progress from the repository, so that a restart of the
+// service goes unnoticed by the players running
+func (s *WorkoutService) RestoreActiveWorkouts() error {
+	workouts, err := s.repo.ListActiveWorkouts()
+	if err != nil {
+		return fmt.Errorf("failed to list active workouts: %w", err)
+	}
+
+	for _, workout := range workouts {
+		point, err := s.repo.GetLastTrackPoint(workout.WorkoutID)
+		if err != nil {
+			return fmt.Errorf("failed to get last location of workout %s: %w", workout.WorkoutID, err)
+		}
+
+		s.stateMu.Lock()
+		s.activePlayers[workout.PlayerID] = true
+		s.activeWorkoutsHeartRate[workout.WorkoutID] = ActiveWorkoutsHeartRate{
+			HRMConnected: workout.HRMConnected,
+		}
+		// the updates missed while the service was down are not held against the workout
+		s.lastActivity[workout.WorkoutID] = time.Now()
+		if point != nil {
+			s.activeWorkoutsLastLocation[workout.WorkoutID] = lastLocationOf(point)
+		}
+		s.stateMu.Unlock()
+	}
+
+	logger.Info("active workouts restored", zap.Int("workouts", len(workouts)))
+	return nil
+}
+
+func lastLocationOf(point *domain.TrackPoint) ActiveWorkoutsLastLocation {
+	return ActiveWorkoutsLastLocation{
+		Latitude:       point.Latitude,
+		Longitude:      point.Longitude,
+		Elevation:      point.Elevation,
+		TimeOfLocation: point.TimeOfLocation,
+		Sequence:       point.Sequence,
+	}
+}
+
 func (s *WorkoutService) Start(workout *domain.Workout, HRMID uuid.UUID, HRMConnected bool) (string, error) {
+	// Two starts of the same player are handled one after the other
+	defer s.locks.lock(workout.PlayerID)()
 
+	s.stateMu.RLock()
 	_, validActiveWorkout := s.activePlayers[workout.PlayerID]
+	s.stateMu.RUnlock()
 	if validActiveWorkout {
 		logger.Debug(ports.ErrorActiveWorkoutAlreadyExists.Error(), zap.String("workoutID", workout.WorkoutID.String()))
-		return "", fmt.Errorf(ports.ErrorActiveWorkoutAlreadyExists.Error())
+		return "", ports.ErrorActiveWorkoutAlreadyExists
 	}
 
 	// Retrieve user profile details
@@ -80,35 +177,11 @@ func (s *WorkoutService) Start(workout *domain.Workout, HRMID uuid.UUID, HRMConn
 		return "", fmt.Errorf("failed to get profile for user %s: %w", workout.PlayerID, err)
 	}
 
-	if err != nil {
-		logger.Debug("failed to get hardcore mode for user", zap.String("playerID", workout.PlayerID.String()), zap.Error(err))
-		return "", fmt.Errorf("failed to get hardcore mode for user %s: %w", workout.PlayerID, err)
-	}
-
 	// Set workout profile
 	workout.Profile = profile
-
-	// Update workout details in the repository
-	_, err = s.repo.UpdateWorkout(workout)
-	if err != nil {
-		logger.Debug("failed to update workout", zap.String("workoutID", workout.WorkoutID.String()), zap.Error(err))
-		return "", fmt.Errorf("failed to update workout %s: %w", workout.WorkoutID, err)
-	}
+	workout.HRMConnected = HRMConnected
 
 	shelterNeeded := !workout.HardcoreMode
-
-	err = s.peripheral.BindPeripheralData(workout.TrailID, workout.PlayerID, workout.WorkoutID, HRMID, HRMConnected, shelterNeeded)
-	logger.Info("peripheral bounded", zap.String("workout_id", workout.WorkoutID.String()))
-	if err != nil {
-		logger.Debug("failed to bind peripheral data", zap.String("HRMID", HRMID.String()), zap.Error(err))
-		return "", fmt.Errorf("failed to bind HRM device %s for workout %s: %w", HRMID, workout.WorkoutID, err)
-	}
-
-	// Record the heart rate monitor connection status
-	s.activeWorkoutsHeartRate[workout.WorkoutID] = ActiveWorkoutsHeartRate{
-		HRMConnected: HRMConnected,
-	}
-	s.activePlayers[workout.PlayerID] = true
 	var workoutOptionsAvailable int8
 	if shelterNeeded {
 		workoutOptionsAvailable = 7
@@ -125,13 +198,50 @@ func (s *WorkoutService) Start(workout *domain.Workout, HRMID uuid.UUID, HRMConn
 		IsWorkoutOptionActive:   false,
 	}
 
-	// Create the workout and its options in the repository
+	// Create the workout and its options in the repository, which refuses a second workout in progress
 	err = s.repo.Create(workout, workoutOptions)
+	if errors.Is(err, ports.ErrorActiveWorkoutAlreadyExists) {
+		logger.Debug(ports.ErrorActiveWorkoutAlreadyExists.Error(), zap.String("workoutID", workout.WorkoutID.String()))
+		return "", err
+	}
 	if err != nil {
 		logger.Debug(ports.ErrorCreateWorkoutFailed.Error(), zap.String("workoutID", workout.WorkoutID.String()), zap.Error(err))
 		return "", fmt.Errorf(ports.ErrorCreateWorkoutFailed.Error())
 	}
 
+	err = s.peripheral.BindPeripheralData(workout.TrailID, workout.PlayerID, workout.WorkoutID, HRMID, HRMConnected, shelterNeeded)
+	logger.Info("peripheral bounded", zap.String("workout_id", workout.WorkoutID.String()))
+	if err != nil {
+		logger.Debug("failed to bind peripheral data", zap.String("HRMID", HRMID.String()), zap.Error(err))
+		// the workout never ran, it is abandoned so that the player can start another one
+		if _, abandonErr := s.updateWorkout(workout.WorkoutID, func(workout *domain.Workout) bool {
+			_, err := workout.Abandon(time.Now())
+			return err == nil
+		}); abandonErr != nil {
+			logger.Debug("failed to abandon workout", zap.String("workoutID", workout.WorkoutID.String()), zap.Error(abandonErr))
+		}
+		return "", fmt.Errorf("failed to bind HRM device %s for workout %s: %w", HRMID, workout.WorkoutID, err)
+	}
+
+	started, err := s.updateWorkout(workout.WorkoutID, func(workout *domain.Workout) bool {
+		return workout.Start(time.Now()) == nil
+	})
+	if err != nil {
+		logger.Debug("failed to start workout", zap.String("workoutID", workout.WorkoutID.String()), zap.Error(err))
+		return "", fmt.Errorf("failed to start workout %s: %w", workout.WorkoutID, err)
+	}
+	workout.State = started.State
+	workout.Version = started.Version
+
+	// Record the heart rate monitor connection status
+	s.stateMu.Lock()
+	s.activeWorkoutsHeartRate[workout.WorkoutID] = ActiveWorkoutsHeartRate{
+		HRMConnected: HRMConnected,
+	}
+	s.activePlayers[workout.PlayerID] = true
+	s.lastActivity[workout.WorkoutID] = time.Now()
+	s.stateMu.Unlock()
+
 	// Log the successful creation of the workout
 	logger.Info("workout started", zap.String("workout_id", workout.WorkoutID.String()))
 
@@ -140,10 +250,36 @@ func (s *WorkoutService) Start(workout *domain.Workout, HRMID uuid.UUID, HRMConn
 	return linkURL, nil // Return nil explicitly to indicate no error occurred
 }
 
+// updateWorkout applies the change to the stored workout, reading it again when another writer updated it
+// first. The change returns false when there is nothing to update.
+func (s *WorkoutService) updateWorkout(workoutID uuid.UUID, change func(workout *domain.Workout) bool) (*domain.Workout, error) {
+	for attempt := 1; ; attempt++ {
+		workout, err := s.repo.GetWorkout(workoutID)
+		if err != nil {
+			return nil, err
+		}
+		if !change(workout) {
+			return workout, nil
+		}
+
+		_, err = s.repo.UpdateWorkout(workout)
+		if errors.Is(err, ports.ErrorWorkoutVersionConflict) && attempt < maxUpdateAttempts {
+			logger.Debug("workout updated concurrently, retrying", zap.String("workoutID", workoutID.String()))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return workout, nil
+	}
+}
+
 func (s *WorkoutService) GetWorkoutOptions(workoutID uuid.UUID) ([]domain.WorkoutOptionLink, error) {
 
+	defer s.locks.lock(workoutID)()
+
 	// Compute Workout Options
-	s.ComputeWorkoutOptionsOrder(workoutID)
+	s.computeWorkoutOptionsOrder(workoutID)
 
 	// Retrieve workout options from the repository
 	pworkoutOptions, err := s.repo.GetWorkoutOptions(workoutID)
@@ -192,8 +328,9 @@ func getWorkoutType(bit int8) string {
 func computeOptionsOrder(pworkoutOptions *domain.WorkoutOptions) []uint8 {
 	order := []uint8{}
 
-	// Add Shelter to the order only if the bit is set for the current workout option
-	if pworkoutOptions.WorkoutOptionsAvailable&1 != 0 {
+	// Add Shelter to the order only if the bit is set for the current workout option, and the
+	// closest shelter, when the zone told us which one it is, has a place left
+	if pworkoutOptions.WorkoutOptionsAvailable&1 != 0 && shelterAvailable(pworkoutOptions) {
 		order = append(order, ShelterBit)
 	}
 
@@ -207,6 +344,11 @@ func computeOptionsOrder(pworkoutOptions *domain.WorkoutOptions) []uint8 {
 	return order
 }
 
+// shelterAvailable is true unless the zone reported the closest shelter full or closed
+func shelterAvailable(pworkoutOptions *domain.WorkoutOptions) bool {
+	return pworkoutOptions.ShelterID == uuid.Nil || pworkoutOptions.ShelterAvailable
+}
+
 func getRandomOptionString() string {
 	options := []string{"Grumpy Prof", "Enraged Beavers"}
 	return options[rand.Intn(len(options))]
@@ -239,74 +381,340 @@ func generateStartWorkoutOptionLinks(workoutID uuid.UUID, optionsOrder []uint8,
 	return links
 }
 
-func (s *WorkoutService) UpdateDistanceTravelled(workoutID uuid.UUID, latitude float64, longitude float64, timeOfLocation time.Time) error {
+func (s *WorkoutService) UpdateDistanceTravelled(workoutID uuid.UUID, latitude float64, longitude float64, elevation *float64, timeOfLocation time.Time) error {
+	// Locations of a workout are credited one after the other
+	defer s.locks.lock(workoutID)()
+
 	// Check if the workout ID exists in the location map
+	s.stateMu.RLock()
 	lastLocation, locationExists := s.activeWorkoutsLastLocation[workoutID]
+	s.stateMu.RUnlock()
 
-	if locationExists {
-		// Calculate the distance between existing and new location
-		distanceCovered := 0.0
-		if lastLocation.Latitude != latitude || lastLocation.Longitude != longitude {
-			point1 := haversine.Coord{Lat: lastLocation.Latitude, Lon: lastLocation.Longitude}
-			point2 := haversine.Coord{Lat: latitude, Lon: longitude}
-
-			// Calculate the distance using the Haversine formula
-			_, distanceCovered = haversine.Distance(point1, point2)
+	if !locationExists {
+		workout, err := s.repo.GetWorkout(workoutID)
+		if err != nil || workout.IsCompleted {
+			return nil // Locations of unknown or completed workouts are ignored
 		}
 
-		// Update the workout distance if the distance covered is greater than 0
-		if distanceCovered > 0 {
-
-			s.activeWorkoutsLastLocation[workoutID] = ActiveWorkoutsLastLocation{
+		// The track goes on from its last point if the workout was running before the service started
+		point, err := s.repo.GetLastTrackPoint(workoutID)
+		if err != nil {
+			return err
+		}
+		if point == nil {
+			// If the location doesn't exist, add it to the map
+			s.setLastLocation(workoutID, ActiveWorkoutsLastLocation{
 				Latitude:       latitude,
 				Longitude:      longitude,
+				Elevation:      elevation,
 				TimeOfLocation: timeOfLocation,
-			}
+				Sequence:       0,
+			})
+			return s.recordTrackPoint(workoutID, 0, latitude, longitude, elevation, timeOfLocation, 0)
+		}
+		lastLocation = lastLocationOf(point)
+	}
 
-			// Get the workout from the repository
-			workout, err := s.repo.GetWorkout(workoutID)
-			if err != nil {
-				return err // Propagate the error from the repository
-			}
+	// Calculate the distance between existing and new location
+	distanceCovered := 0.0
+	if lastLocation.Latitude != latitude || lastLocation.Longitude != longitude {
+		point1 := haversine.Coord{Lat: lastLocation.Latitude, Lon: lastLocation.Longitude}
+		point2 := haversine.Coord{Lat: latitude, Lon: longitude}
 
-			// Update the workout distance
-			// ******************NOTE*******************
-			// Scaling the distance covered for the demo
-			// *****************************************
-			workout.DistanceCovered += distanceCovered * 50000
+		// Calculate the distance using the Haversine formula, in metres
+		_, km := haversine.Distance(point1, point2)
+		distanceCovered = km * 1000 * s.distanceScale
+	}
 
-			// Update the workout in the repository
-			_, err = s.repo.UpdateWorkout(workout)
-			if err != nil {
-				return err // Propagate the error from the repository
-			}
+	// Update the workout distance and elevation in the repository, nothing is credited while it is paused nor
+	// for the way covered during a pause
+	var credited float64
+	var slowSince time.Time
+	var resumed *domain.WorkoutPause
+	_, err := s.updateWorkout(workoutID, func(workout *domain.Workout) bool {
+		wasPaused := workout.IsPaused()
+		slowSince, resumed = s.autoPauseOrResume(workout, lastLocation, distanceCovered, timeOfLocation)
+		credited = 0
+		if workout.IsPaused() || lastLocation.TimeOfLocation.Before(workout.ResumedAt) {
+			return workout.IsPaused() != wasPaused
 		}
-	} else {
-		// If the location doesn't exist, add it to the map
-		workout, err := s.repo.GetWorkout(workoutID)
 
-		if err == nil && !workout.IsCompleted {
-			s.activeWorkoutsLastLocation[workoutID] = ActiveWorkoutsLastLocation{
-				Latitude:       latitude,
-				Longitude:      longitude,
-				TimeOfLocation: timeOfLocation,
-			}
+		credited = distanceCovered
+		workout.DistanceCovered += credited
+		climbed := workout.AddClimb(lastLocation.Elevation, elevation)
+		return workout.IsPaused() != wasPaused || credited > 0 || climbed
+	})
+	if err != nil {
+		return err // Propagate the error from the repository
+	}
+	if resumed != nil {
+		s.recordPause(resumed)
+	}
+
+	s.setLastLocation(workoutID, ActiveWorkoutsLastLocation{
+		Latitude:       latitude,
+		Longitude:      longitude,
+		Elevation:      elevation,
+		TimeOfLocation: timeOfLocation,
+		Sequence:       lastLocation.Sequence + 1,
+		SlowSince:      slowSince,
+	})
+
+	return s.recordTrackPoint(workoutID, lastLocation.Sequence+1, latitude, longitude, elevation, timeOfLocation, credited)
+}
+
+// autoPauseOrResume pauses the workout once the player stayed slower than the auto-pause speed for long enough,
+// and resumes the automatic pause as soon as they are faster again, from the last location so that the
+// segment is credited. It returns since when the player is slow and the pause it ended.
+func (s *WorkoutService) autoPauseOrResume(workout *domain.Workout, lastLocation ActiveWorkoutsLastLocation, distance float64, timeOfLocation time.Time) (time.Time, *domain.WorkoutPause) {
+	// the speed over a pause of the player, or across their resume, says nothing about them stopping
+	if !s.autoPause.Enabled() || (workout.IsPaused() && !workout.AutoPaused) || lastLocation.TimeOfLocation.Before(workout.ResumedAt) {
+		return time.Time{}, nil
+	}
+
+	slowSince := lastLocation.SlowSince
+	if seconds := timeOfLocation.Sub(lastLocation.TimeOfLocation).Seconds(); seconds > 0 {
+		if distance/seconds >= s.autoPause.Speed {
+			slowSince = time.Time{}
+		} else if slowSince.IsZero() {
+			slowSince = lastLocation.TimeOfLocation
 		}
 	}
 
-	return nil // Return nil to indicate success
+	switch {
+	case workout.AutoPaused && slowSince.IsZero():
+		pause, _ := workout.Resume(lastLocation.TimeOfLocation)
+		logger.Info("workout resumed automatically", zap.String("workout_id", workout.WorkoutID.String()))
+		return slowSince, pause
+	case workout.Can(domain.EventPause) == nil && !slowSince.IsZero() && timeOfLocation.Sub(slowSince) >= s.autoPause.After:
+		workout.Pause(timeOfLocation, true)
+		logger.Info("workout paused automatically", zap.String("workout_id", workout.WorkoutID.String()))
+	}
+	return slowSince, nil
+}
+
+// Pause pauses the workout in progress, nothing is credited to it and the live publishing of its peripheral
+// stops until it is resumed
+func (s *WorkoutService) Pause(workoutID uuid.UUID) (*domain.Workout, error) {
+	defer s.locks.lock(workoutID)()
+
+	var pauseErr error
+	now := time.Now()
+	workout, err := s.updateWorkout(workoutID, func(workout *domain.Workout) bool {
+		pauseErr = workout.Pause(now, false)
+		return pauseErr == nil
+	})
+	if err == nil {
+		err = pauseErr
+	}
+	if err != nil {
+		logger.Debug("failed to pause workout", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to pause workout %s: %w", workoutID, err)
+	}
+
+	s.clearSlowSince(workoutID)
+	s.touch(workoutID, now)
+	if err := s.peripheral.PausePeripheralData(workoutID, true); err != nil {
+		logger.Debug("failed to pause peripheral data", zap.String("workoutID", workoutID.String()), zap.Error(err))
+	}
+
+	logger.Info("workout paused", zap.String("workout_id", workoutID.String()))
+	return workout, nil
+}
+
+// Resume resumes the paused workout, the way covered during the pause is not credited to it
+func (s *WorkoutService) Resume(workoutID uuid.UUID) (*domain.Workout, error) {
+	defer s.locks.lock(workoutID)()
+
+	var resumeErr error
+	var pause *domain.WorkoutPause
+	now := time.Now()
+	workout, err := s.updateWorkout(workoutID, func(workout *domain.Workout) bool {
+		pause, resumeErr = workout.Resume(now)
+		return resumeErr == nil
+	})
+	if err == nil {
+		err = resumeErr
+	}
+	if err != nil {
+		logger.Debug("failed to resume workout", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to resume workout %s: %w", workoutID, err)
+	}
+
+	s.recordPause(pause)
+	s.clearSlowSince(workoutID)
+	s.touch(workoutID, now)
+	// the peripheral of an automatic pause kept publishing
+	if !pause.Auto {
+		if err := s.peripheral.PausePeripheralData(workoutID, false); err != nil {
+			logger.Debug("failed to resume peripheral data", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		}
+	}
+
+	logger.Info("workout resumed", zap.String("workout_id", workoutID.String()))
+	return workout, nil
+}
+
+// Transitions returns the event log of the workout, oldest first
+func (s *WorkoutService) Transitions(workoutID uuid.UUID) ([]*domain.WorkoutTransition, error) {
+	if _, err := s.repo.GetWorkout(workoutID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetWorkoutTransitions(workoutID)
+}
+
+// recordPause stores an ended pause, the workout is resumed either way
+func (s *WorkoutService) recordPause(pause *domain.WorkoutPause) {
+	if err := s.repo.AddWorkoutPause(pause); err != nil {
+		logger.Debug("failed to record workout pause", zap.String("workoutID", pause.WorkoutID.String()), zap.Error(err))
+	}
+}
+
+// clearSlowSince starts the auto-pause over once the player paused or resumed the workout
+func (s *WorkoutService) clearSlowSince(workoutID uuid.UUID) {
+	s.stateMu.Lock()
+	if location, ok := s.activeWorkoutsLastLocation[workoutID]; ok {
+		location.SlowSince = time.Time{}
+		s.activeWorkoutsLastLocation[workoutID] = location
+	}
+	s.stateMu.Unlock()
+}
+
+func (s *WorkoutService) setLastLocation(workoutID uuid.UUID, location ActiveWorkoutsLastLocation) {
+	s.stateMu.Lock()
+	s.activeWorkoutsLastLocation[workoutID] = location
+	s.lastActivity[workoutID] = time.Now()
+	s.stateMu.Unlock()
+}
+
+// touch records an update of the workout at the time, unless a later one is known
+func (s *WorkoutService) touch(workoutID uuid.UUID, at time.Time) {
+	s.stateMu.Lock()
+	if last, ok := s.lastActivity[workoutID]; ok && at.After(last) {
+		s.lastActivity[workoutID] = at
+	}
+	s.stateMu.Unlock()
+}
+
+// recordTrackPoint appends a location to the stored track of the workout
+func (s *WorkoutService) recordTrackPoint(workoutID uuid.UUID, sequence uint32, latitude float64, longitude float64, elevation *float64, timeOfLocation time.Time, segmentDistance float64) error {
+	point := &domain.TrackPoint{
+		WorkoutID:       workoutID,
+		Sequence:        sequence,
+		TimeOfLocation:  timeOfLocation,
+		Latitude:        latitude,
+		Longitude:       longitude,
+		Elevation:       elevation,
+		SegmentDistance: segmentDistance,
+	}
+
+	err := s.repo.AddTrackPoint(point)
+	if err != nil {
+		logger.Debug("failed to record track point", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		return fmt.Errorf("failed to record track point for workout %s: %w", workoutID, err)
+	}
+	return nil
 }
 
-func (s *WorkoutService) UpdateShelter(workoutID uuid.UUID, DistanceToShelter float64) error {
+func (s *WorkoutService) GetTrack(workoutID uuid.UUID) ([]*domain.TrackPoint, error) {
+	_, err := s.repo.GetWorkout(workoutID)
+	if err != nil {
+		logger.Debug("failed to get workout for track", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to get workout with ID %s: %w", workoutID, err)
+	}
+
+	track, err := s.repo.GetTrack(workoutID)
+	if err != nil {
+		logger.Debug("failed to get track", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to get track for workout %s: %w", workoutID, err)
+	}
+	return track, nil
+}
+
+// Summary computes the pace, the splits and the moving time of a workout from its track
+func (s *WorkoutService) Summary(workoutID uuid.UUID) (*domain.WorkoutSummary, error) {
+	workout, err := s.repo.GetWorkout(workoutID)
+	if err != nil {
+		logger.Debug("failed to get workout for summary", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to get workout with ID %s: %w", workoutID, err)
+	}
+
+	track, err := s.repo.GetTrack(workoutID)
+	if err != nil {
+		logger.Debug("failed to get track for summary", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to get track for workout %s: %w", workoutID, err)
+	}
+
+	pauses, err := s.repo.GetWorkoutPauses(workoutID)
+	if err != nil {
+		logger.Debug("failed to get pauses for summary", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to get pauses for workout %s: %w", workoutID, err)
+	}
+	return domain.NewWorkoutSummary(workout, track, pauses, time.Now()), nil
+}
+
+// ExportWorkout renders a completed workout as a GPX or TCX document
+func (s *WorkoutService) ExportWorkout(workoutID uuid.UUID, format string) ([]byte, error) {
+	workout, err := s.repo.GetWorkout(workoutID)
+	if err != nil {
+		logger.Debug("failed to get workout for export", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to get workout with ID %s: %w", workoutID, err)
+	}
+
+	if !workout.IsCompleted {
+		logger.Debug(ports.ErrWorkoutNotCompleted.Error(), zap.String("workoutID", workoutID.String()))
+		return nil, ports.ErrWorkoutNotCompleted
+	}
+
+	track, err := s.repo.GetTrack(workoutID)
+	if err != nil {
+		logger.Debug("failed to get track for export", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to get track for workout %s: %w", workoutID, err)
+	}
+
+	events, err := s.repo.GetWorkoutOptionEvents(workoutID)
+	if err != nil {
+		logger.Debug("failed to get workout option events for export", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to get workout option events for workout %s: %w", workoutID, err)
+	}
+
+	pauses, err := s.repo.GetWorkoutPauses(workoutID)
+	if err != nil {
+		logger.Debug("failed to get pauses for export", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to get pauses for workout %s: %w", workoutID, err)
+	}
+
+	// Heart rate is optional, the workout may have been done without an HRM
+	heartRates, err := s.heartRateSamples(workout)
+	if err != nil {
+		logger.Debug("failed to get heart rate samples for export", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		heartRates = nil
+	}
+	heartRates = domain.ExcludePauses(workout, pauses, heartRates)
+
+	body, err := domain.ExportWorkout(format, workout, track, heartRates, events)
+	if err != nil {
+		logger.Debug("failed to export workout", zap.String("workoutID", workoutID.String()), zap.String("format", format), zap.Error(err))
+		return nil, err
+	}
+
+	logger.Info("workout exported", zap.String("workout_id", workoutID.String()), zap.String("format", format))
+	return body, nil
+}
+
+func (s *WorkoutService) UpdateShelter(workoutID uuid.UUID, shelterID uuid.UUID, shelterAvailable bool, DistanceToShelter float64) error {
+	defer s.locks.lock(workoutID)()
+
 	// Get the workout options from the repository
 	workoutOptions, err := s.repo.GetWorkoutOptions(workoutID)
 	if err != nil {
 		return err // Propagate the error from the repository
 	}
+	workoutOptions.ShelterID = shelterID
+	workoutOptions.ShelterAvailable = shelterAvailable
 	workoutOptions.DistanceToShelter = DistanceToShelter
 
-	s.repo.UpdateWorkoutOptions(workoutOptions)
-
+	_, err = s.repo.UpdateWorkoutOptions(workoutOptions)
 	if err != nil {
 		return err // Propagate the error from the repository
 	}
@@ -314,7 +722,111 @@ func (s *WorkoutService) UpdateShelter(workoutID uuid.UUID, DistanceToShelter fl
 	return nil // Return nil to indicate success
 }
 
+// UpdateShelterReservation handles the answer of the zone to a reservation. When the place is refused or has
+// expired while the shelter option is active, the option is given up without counting a shelter
+func (s *WorkoutService) UpdateShelterReservation(workoutID uuid.UUID, shelterID uuid.UUID, reserved bool, reason string) error {
+	defer s.locks.lock(workoutID)()
+
+	workoutOptions, err := s.repo.GetWorkoutOptions(workoutID)
+	if err != nil {
+		return err
+	}
+	if workoutOptions.ReservedShelterID != shelterID {
+		logger.Debug("ignoring reservation of another shelter", zap.String("workout_id", workoutID.String()), zap.String("shelter_id", shelterID.String()))
+		return nil
+	}
+	if reserved {
+		logger.Info("shelter place reserved", zap.String("workout_id", workoutID.String()), zap.String("shelter_id", shelterID.String()))
+		return nil
+	}
+
+	workoutOptions.ReservedShelterID = uuid.Nil
+	workoutOptions.ShelterAvailable = false
+	optionLost := workoutOptions.IsWorkoutOptionActive && workoutOptions.CurrentWorkoutOption == ShelterBit
+	if optionLost {
+		workoutOptions.IsWorkoutOptionActive = false
+		workoutOptions.CurrentWorkoutOption = -1
+		workoutOptions.OptionStartedAt = time.Time{}
+	}
+	if _, err := s.repo.UpdateWorkoutOptions(workoutOptions); err != nil {
+		return err
+	}
+	if optionLost {
+		// the shelter option is over without being taken
+		if _, err := s.updateWorkout(workoutID, func(workout *domain.Workout) bool {
+			return workout.StopOption(time.Now()) == nil
+		}); err != nil {
+			return err
+		}
+	}
+	logger.Info("shelter place lost", zap.String("workout_id", workoutID.String()), zap.String("shelter_id", shelterID.String()), zap.String("reason", reason))
+	return nil
+}
+
+// releaseShelter gives back the place held for the workout, if any
+func (s *WorkoutService) releaseShelter(workoutOptions *domain.WorkoutOptions) {
+	if workoutOptions.ReservedShelterID == uuid.Nil {
+		return
+	}
+	if err := s.shelterReservation.PublishShelterReservation(workoutOptions.WorkoutID, workoutOptions.ReservedShelterID, false); err != nil {
+		logger.Debug("failed to release shelter", zap.String("workoutID", workoutOptions.WorkoutID.String()), zap.Error(err))
+	}
+	workoutOptions.ReservedShelterID = uuid.Nil
+}
+
+// ArriveAtShelter completes the shelter option of the workout when the zone reports the player reached the
+// shelter holding their place, or any shelter when no place is held
+func (s *WorkoutService) ArriveAtShelter(workoutID uuid.UUID, shelterID uuid.UUID) error {
+	defer s.locks.lock(workoutID)()
+
+	workoutOptions, err := s.repo.GetWorkoutOptions(workoutID)
+	if err != nil {
+		return err
+	}
+	if !workoutOptions.IsWorkoutOptionActive || workoutOptions.CurrentWorkoutOption != ShelterBit {
+		logger.Debug("ignoring shelter arrival without shelter option", zap.String("workout_id", workoutID.String()), zap.String("shelter_id", shelterID.String()))
+		return nil
+	}
+	if workoutOptions.ReservedShelterID != uuid.Nil && workoutOptions.ReservedShelterID != shelterID {
+		logger.Debug("ignoring arrival at another shelter", zap.String("workout_id", workoutID.String()), zap.String("shelter_id", shelterID.String()))
+		return nil
+	}
+
+	if _, err := s.stopWorkoutOption(workoutID); err != nil {
+		return err
+	}
+	logger.Info("shelter reached", zap.String("workout_id", workoutID.String()), zap.String("shelter_id", shelterID.String()))
+	return nil
+}
+
+// UpdateOffTrail flags the workout when the zone reports the player left the trail, and clears it when they are back
+func (s *WorkoutService) UpdateOffTrail(workoutID uuid.UUID, offTrail bool, distanceFromTrail float64) error {
+	defer s.locks.lock(workoutID)()
+
+	changed := false
+	_, err := s.updateWorkout(workoutID, func(workout *domain.Workout) bool {
+		changed = !workout.IsCompleted && workout.OffTrail != offTrail
+		if changed {
+			workout.OffTrail = offTrail
+			if offTrail {
+				workout.OffTrailCount++
+			}
+		}
+		return changed
+	})
+	if errors.Is(err, ports.ErrorWorkoutVersionConflict) {
+		return ports.ErrorUpdateWorkoutFailed
+	}
+	if err != nil || !changed {
+		return err
+	}
+	logger.Info("workout off trail status changed", zap.String("workout_id", workoutID.String()), zap.Bool("off_trail", offTrail), zap.Float64("distance_from_trail", distanceFromTrail))
+	return nil
+}
+
 func (s *WorkoutService) StartWorkoutOption(workoutID uuid.UUID, option string) (string, error) {
+	defer s.locks.lock(workoutID)()
+
 	// Get the workout options from the repository
 	workoutOptions, err := s.repo.GetWorkoutOptions(workoutID)
 	if err != nil {
@@ -333,38 +845,71 @@ func (s *WorkoutService) StartWorkoutOption(workoutID uuid.UUID, option string)
 		return "", ports.ErrorWorkoutOptionInvalid
 	}
 
-	// Check if the workout option is already active
-	if workoutOptions.IsWorkoutOptionActive {
-		return "", ports.ErrWorkoutOptionAlreadyActive
+	// Check that the workout is running without an option going on
+	workout, err := s.repo.GetWorkout(workoutID)
+	if err != nil {
+		return "", err
+	}
+	if err := workout.Can(domain.EventStartOption); err != nil {
+		return "", err
 	}
 
 	// Check if shelter is available or not
-	if workoutType == 0 && workoutOptions.WorkoutOptionsAvailable&1 == 0 {
+	if workoutType == 0 && (workoutOptions.WorkoutOptionsAvailable&1 == 0 || !shelterAvailable(workoutOptions)) {
 		return "", ports.ErrorWorkoutOptionUnavailable
 	}
 
+	// Hold a place in the closest shelter, the zone answers if it is refused
+	if workoutType == 0 && workoutOptions.ShelterID != uuid.Nil {
+		if err := s.shelterReservation.PublishShelterReservation(workoutID, workoutOptions.ShelterID, true); err != nil {
+			logger.Debug("failed to reserve shelter", zap.String("workoutID", workoutID.String()), zap.Error(err))
+			return "", ports.ErrorWorkoutOptionUnavailable
+		}
+		workoutOptions.ReservedShelterID = workoutOptions.ShelterID
+	}
+
 	// Update the workout option to make it active (you need to set appropriate fields)
 	workoutOptions.IsWorkoutOptionActive = true
 	workoutOptions.CurrentWorkoutOption = int8(workoutType)
+	workoutOptions.OptionStartedAt = time.Now()
 
-	// Update the workout options in the repository
+	// Update the workout options in the repository, the place is given back when the option does not start
 	_, err = s.repo.UpdateWorkoutOptions(workoutOptions)
 
 	if err != nil {
+		s.releaseShelter(workoutOptions)
 		return "", err // Propagate the error from the repository
 	}
+
+	var transitionErr error
+	_, err = s.updateWorkout(workoutID, func(workout *domain.Workout) bool {
+		transitionErr = workout.StartOption(workoutOptions.OptionStartedAt)
+		return transitionErr == nil
+	})
+	if err == nil {
+		err = transitionErr
+	}
+	if err != nil {
+		logger.Debug("failed to update workout on option start", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		s.releaseShelter(workoutOptions)
+		workoutOptions.IsWorkoutOptionActive = false
+		workoutOptions.CurrentWorkoutOption = -1
+		workoutOptions.OptionStartedAt = time.Time{}
+		if _, updateErr := s.repo.UpdateWorkoutOptions(workoutOptions); updateErr != nil {
+			logger.Debug("failed to reset workout options on option start", zap.String("workoutID", workoutID.String()), zap.Error(updateErr))
+		}
+		return "", err
+	}
 	logger.Info("workout option started", zap.String("workout_id", workoutOptions.WorkoutID.String()), zap.String("option_type", getWorkoutType(workoutOptions.CurrentWorkoutOption)))
 	return getWorkoutType(workoutOptions.CurrentWorkoutOption), nil // Return nil to indicate success
 }
 
 func (s *WorkoutService) StopWorkoutOption(workoutID uuid.UUID) (string, error) {
-	// Get the workout from the repository
-	workout, err := s.repo.GetWorkout(workoutID)
-	if err != nil {
-		logger.Debug("failed to get workout for stopping options", zap.String("workoutID", workoutID.String()), zap.Error(err))
-		return "", fmt.Errorf("failed to get workout %s for stopping options: %w", workoutID, err)
-	}
+	defer s.locks.lock(workoutID)()
+	return s.stopWorkoutOption(workoutID)
+}
 
+func (s *WorkoutService) stopWorkoutOption(workoutID uuid.UUID) (string, error) {
 	// Get the workout options from the repository
 	workoutOptions, err := s.repo.GetWorkoutOptions(workoutID)
 	if err != nil {
@@ -372,26 +917,26 @@ func (s *WorkoutService) StopWorkoutOption(workoutID uuid.UUID) (string, error)
 		return "", fmt.Errorf("failed to get workout options for workout %s: %w", workoutID, err)
 	}
 
-	// Check if the workout option is already inactive
-	if !workoutOptions.IsWorkoutOptionActive {
+	// Check that an option is going on
+	workout, err := s.repo.GetWorkout(workoutID)
+	if err != nil {
+		return "", err
+	}
+	if err := workout.Can(domain.EventStopOption); err != nil {
 		logger.Debug("workout option already inactive", zap.String("workoutID", workoutID.String()))
-		return "", ports.ErrWorkoutOptionAlreadyInActive
+		return "", err
 	}
 
-	// Update the Shelters, Fights and Escapes
-	if workoutOptions.CurrentWorkoutOption == ShelterBit {
-		workout.Shelters++
-	} else if workoutOptions.CurrentWorkoutOption == FightBit {
-		workout.Fights++
-	} else if workoutOptions.CurrentWorkoutOption == EscapeBit {
-		workout.Escapes++
-	}
+	currentWorkoutOption := workoutOptions.CurrentWorkoutOption
 	workoutType := getWorkoutType(workoutOptions.CurrentWorkoutOption) // Assuming getWorkoutType is a valid function
 
 	returnOption := getWorkoutType(workoutOptions.CurrentWorkoutOption)
+	optionStartedAt := workoutOptions.OptionStartedAt
+	s.releaseShelter(workoutOptions)
 	// Update the workout option to make it inactive
 	workoutOptions.IsWorkoutOptionActive = false
 	workoutOptions.CurrentWorkoutOption = -1
+	workoutOptions.OptionStartedAt = time.Time{}
 
 	// Update the workout options in the repository
 	_, err = s.repo.UpdateWorkoutOptions(workoutOptions)
@@ -400,13 +945,39 @@ func (s *WorkoutService) StopWorkoutOption(workoutID uuid.UUID) (string, error)
 		return "", fmt.Errorf("failed to update workout options for workout %s on stop: %w", workoutID, err)
 	}
 
-	// Update the workout in the repository
-	_, err = s.repo.UpdateWorkout(workout)
+	// Update the state, Shelters, Fights and Escapes of the workout in the repository
+	endedAt := time.Now()
+	_, err = s.updateWorkout(workoutID, func(workout *domain.Workout) bool {
+		if err := workout.StopOption(endedAt); err != nil {
+			return false
+		}
+		if currentWorkoutOption == ShelterBit {
+			workout.Shelters++
+		} else if currentWorkoutOption == FightBit {
+			workout.Fights++
+		} else if currentWorkoutOption == EscapeBit {
+			workout.Escapes++
+		}
+		return true
+	})
 	if err != nil {
 		logger.Debug("failed to update workout on stop", zap.String("workoutID", workoutID.String()), zap.Error(err))
 		return "", fmt.Errorf("failed to update workout %s on stop: %w", workoutID, err)
 	}
 
+	// Keep the event for the workout exports, the counters above are already saved
+	event := &domain.WorkoutOptionEvent{
+		WorkoutID: workoutID,
+		Option:    workoutType,
+		StartedAt: optionStartedAt,
+		EndedAt:   endedAt,
+	}
+	err = s.repo.AddWorkoutOptionEvent(event)
+	if err != nil {
+		logger.Debug("failed to record workout option event", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		// need not return the error
+	}
+
 	// Log the successful stopping of the workout option
 	logger.Info("workout option stopped", zap.String("workout_id", workoutID.String()), zap.String("option_type", workoutType))
 
@@ -414,31 +985,45 @@ func (s *WorkoutService) StopWorkoutOption(workoutID uuid.UUID) (string, error)
 }
 
 func (s *WorkoutService) Stop(id uuid.UUID) (*domain.Workout, error) {
-	// Retrieve the workout to be stopped
-	tempWorkout, err := s.repo.GetWorkout(id)
+	defer s.locks.lock(id)()
+
+	// Complete the workout and mark the end time, a pause going on ends with it
+	endedAt := time.Now()
+	var pause *domain.WorkoutPause
+	var stopErr error
+	tempWorkout, err := s.updateWorkout(id, func(workout *domain.Workout) bool {
+		pause, stopErr = workout.Stop(endedAt)
+		return stopErr == nil
+	})
 	if err != nil {
-		logger.Debug("failed to get workout for stopping", zap.String("workoutID", id.String()), zap.Error(err))
-		return nil, fmt.Errorf("failed to get workout %s for stopping: %w", id, err)
+		logger.Debug("failed to update workout on stop", zap.String("workoutID", id.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to update workout %s on stop: %w", id, err)
+	}
+	if stopErr != nil {
+		logger.Debug("workout already completed", zap.String("workout_id", id.String()))
+		return nil, stopErr
 	}
 
-	if tempWorkout.IsCompleted {
-		logger.Debug("workout already completed", zap.String("workout_id", tempWorkout.WorkoutID.String()))
-		return nil, ports.ErrWorkoutAlreadyCompleted
+	if err := s.closeWorkout(tempWorkout, pause); err != nil {
+		return nil, err
 	}
+	return tempWorkout, nil
+}
 
-	// Set the workout as completed and mark the end time
-	tempWorkout.EndedAt = time.Now()
-	tempWorkout.IsCompleted = true
+// closeWorkout lets go of a workout that just ended: it records the pause it ended, gives back its shelter
+// place, publishes its stats and its end, forgets it and unbinds its peripheral
+func (s *WorkoutService) closeWorkout(tempWorkout *domain.Workout, pause *domain.WorkoutPause) error {
+	if pause != nil {
+		s.recordPause(pause)
+	}
 
-	// Update the workout's status in the repository
-	_, err = s.repo.UpdateWorkout(tempWorkout)
-	if err != nil {
-		logger.Debug("failed to update workout on stop", zap.String("workoutID", tempWorkout.WorkoutID.String()), zap.Error(err))
-		return nil, fmt.Errorf("failed to update workout %s on stop: %w", tempWorkout.WorkoutID, err)
+	// Give back the shelter place before the options holding it are deleted
+	if workoutOptions, err := s.repo.GetWorkoutOptions(tempWorkout.WorkoutID); err == nil {
+		s.releaseShelter(workoutOptions)
 	}
 
 	// Delete the workout options associated with the workout
-	err = s.repo.DeleteWorkoutOptions(tempWorkout.WorkoutID)
+	err := s.repo.DeleteWorkoutOptions(tempWorkout.WorkoutID)
 	if err != nil {
 		logger.Debug("failed to delete workout options on stop", zap.String("workoutID", tempWorkout.WorkoutID.String()), zap.Error(err))
 		// need not return the error
@@ -446,21 +1031,222 @@ func (s *WorkoutService) Stop(id uuid.UUID) (*domain.Workout, error) {
 
 	s.workoutStatsPublisher.PublishWorkoutStats(tempWorkout)
 
+	// The zone manager closes the session of the workout
+	if err := s.workoutEndPublisher.PublishWorkoutEnd(tempWorkout.WorkoutID, tempWorkout.EndedAt); err != nil {
+		logger.Debug("failed to publish workout end", zap.String("workoutID", tempWorkout.WorkoutID.String()), zap.Error(err))
+	}
+
 	// Remove the workout from active workouts tracking
+	s.stateMu.Lock()
 	delete(s.activeWorkoutsLastLocation, tempWorkout.WorkoutID)
 	delete(s.activeWorkoutsHeartRate, tempWorkout.WorkoutID)
 	delete(s.activePlayers, tempWorkout.PlayerID)
-	logger.Info("workout stopped", zap.String("workout_id", tempWorkout.WorkoutID.String()))
+	delete(s.lastActivity, tempWorkout.WorkoutID)
+	s.stateMu.Unlock()
+	logger.Info("workout stopped", zap.String("workout_id", tempWorkout.WorkoutID.String()), zap.String("state", string(tempWorkout.State)))
+
+	// The peripheral forgets the readings once unbound
+	if tempWorkout.HRMConnected {
+		s.keepHeartRateSamples(tempWorkout.WorkoutID)
+	}
 
 	// Unbind peripheral data associated with the workout
 	err = s.peripheral.UnbindPeripheralData(tempWorkout.WorkoutID)
 	if err != nil {
 		logger.Debug("failed to unbind peripheral data on stop", zap.String("workoutID", tempWorkout.WorkoutID.String()), zap.Error(err))
-		return nil, fmt.Errorf("failed to unbind peripheral data on stop")
+		return fmt.Errorf("failed to unbind peripheral data on stop")
 	}
 	logger.Info("peripheral unbounded", zap.String("workout_id", tempWorkout.WorkoutID.String()))
 
-	return tempWorkout, nil
+	return nil
+}
+
+// keepHeartRateSamples stores the readings of the heart rate monitor of the workout that ended
+func (s *WorkoutService) keepHeartRateSamples(workoutID uuid.UUID) {
+	samples, err := s.peripheral.GetHeartRateSamples(workoutID)
+	if err != nil {
+		logger.Debug("failed to get heart rate samples", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		return
+	}
+	if err := s.repo.AddHeartRateSamples(workoutID, samples); err != nil {
+		logger.Debug("failed to store heart rate samples", zap.String("workoutID", workoutID.String()), zap.Error(err))
+	}
+}
+
+// heartRateSamples of the workout, from the peripheral while it is in progress and stored once it ended. The
+// workouts ended before the readings were stored only have those the peripheral still holds.
+func (s *WorkoutService) heartRateSamples(workout *domain.Workout) ([]domain.HeartRateSample, error) {
+	if workout.IsCompleted {
+		samples, err := s.repo.GetHeartRateSamples(workout.WorkoutID)
+		if err != nil || len(samples) > 0 {
+			return samples, err
+		}
+	}
+	return s.peripheral.GetHeartRateSamples(workout.WorkoutID)
+}
+
+// HeartRateZones breaks the heart rate of the workout down into the five zones of the player, with the
+// formula asked for or the one configured. The Karvonen formula needs the resting heart rate of the
+// player, the zones are taken from the maximum heart rate without it.
+func (s *WorkoutService) HeartRateZones(workoutID uuid.UUID, formula string) (*domain.HeartRateAnalysis, error) {
+	heartRateFormula := s.heartRateFormula
+	if formula != "" {
+		parsed, err := domain.ParseHeartRateFormula(formula)
+		if err != nil {
+			return nil, err
+		}
+		heartRateFormula = parsed
+	}
+
+	workout, err := s.repo.GetWorkout(workoutID)
+	if err != nil {
+		logger.Debug("failed to get workout for heart rate zones", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to get workout %s: %w", workoutID, err)
+	}
+
+	age, err := s.user.GetUserAge(workout.PlayerID)
+	if err != nil {
+		logger.Debug("failed to get age of player", zap.String("playerID", workout.PlayerID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to get age of player %s: %w", workout.PlayerID, err)
+	}
+
+	var restingHeartRate uint8
+	if heartRateFormula == domain.KarvonenFormula {
+		restingHeartRate, err = s.user.GetRestingHeartRateOfUser(workout.PlayerID)
+		if err != nil {
+			logger.Debug("failed to get resting heart rate of player", zap.String("playerID", workout.PlayerID.String()), zap.Error(err))
+			restingHeartRate = 0
+		}
+	}
+
+	pauses, err := s.repo.GetWorkoutPauses(workoutID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pauses for workout %s: %w", workoutID, err)
+	}
+
+	// Heart rate is optional, the zones of a workout without an HRM are empty
+	var heartRates []domain.HeartRateSample
+	if workout.HRMConnected {
+		heartRates, err = s.heartRateSamples(workout)
+		if err != nil {
+			logger.Debug("failed to get heart rate samples", zap.String("workoutID", workoutID.String()), zap.Error(err))
+			heartRates = nil
+		}
+	}
+
+	return domain.NewHeartRateAnalysis(workout, heartRates, pauses, heartRateFormula, age, restingHeartRate), nil
+}
+
+// AbandonStaleWorkouts abandons the workouts in progress without a location nor a heart rate reading for
+// the timeout at the given time, as their player is gone. A workout the player paused gets no update by
+// design, it is only abandoned after the paused timeout, never when it is 0. They end at their last update.
+// It returns the number of workouts abandoned.
+func (s *WorkoutService) AbandonStaleWorkouts(now time.Time, timeout time.Duration, pausedTimeout time.Duration) (int, error) {
+	s.stateMu.RLock()
+	stale := make([]uuid.UUID, 0)
+	for workoutID, last := range s.lastActivity {
+		if now.Sub(last) >= timeout {
+			stale = append(stale, workoutID)
+		}
+	}
+	s.stateMu.RUnlock()
+
+	abandoned := 0
+	var errs []error
+	for _, workoutID := range stale {
+		ok, err := s.abandonStaleWorkout(workoutID, now, timeout, pausedTimeout)
+		if err != nil {
+			logger.Error("failed to abandon stale workout", zap.String("workout_id", workoutID.String()), zap.Error(err))
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			abandoned++
+		}
+	}
+	return abandoned, errors.Join(errs...)
+}
+
+// abandonStaleWorkout abandons the workout unless it got an update meanwhile or the player paused it for
+// less than the paused timeout, it returns whether it did
+func (s *WorkoutService) abandonStaleWorkout(workoutID uuid.UUID, now time.Time, timeout time.Duration, pausedTimeout time.Duration) (bool, error) {
+	defer s.locks.lock(workoutID)()
+
+	s.stateMu.RLock()
+	last, active := s.lastActivity[workoutID]
+	hrmConnected := s.activeWorkoutsHeartRate[workoutID].HRMConnected
+	s.stateMu.RUnlock()
+	if !active || now.Sub(last) < timeout {
+		return false, nil
+	}
+
+	// the heart rate monitor may still be read while the locations stopped
+	if hrmConnected {
+		samples, err := s.peripheral.GetHeartRateSamples(workoutID)
+		if err != nil {
+			logger.Debug("failed to get heart rate samples", zap.String("workoutID", workoutID.String()), zap.Error(err))
+		}
+		for _, sample := range samples {
+			if sample.TimeOfReading.After(last) {
+				last = sample.TimeOfReading
+			}
+		}
+		if now.Sub(last) < timeout {
+			s.touch(workoutID, last)
+			return false, nil
+		}
+	}
+
+	var pause *domain.WorkoutPause
+	var abandonErr error
+	keptPaused := false
+	workout, err := s.updateWorkout(workoutID, func(workout *domain.Workout) bool {
+		// the player paused the workout, they are given longer to come back
+		keptPaused = workout.State == domain.WorkoutPaused && !workout.AutoPaused && (pausedTimeout <= 0 || now.Sub(last) < pausedTimeout)
+		if keptPaused {
+			return false
+		}
+		pause, abandonErr = workout.Abandon(last)
+		return abandonErr == nil
+	})
+	if err == nil && keptPaused {
+		return false, nil
+	}
+	if err == nil {
+		err = abandonErr
+	}
+	if errors.Is(err, domain.ErrWorkoutAlreadyCompleted) {
+		// ended elsewhere, only the tracking is left over
+		s.stateMu.Lock()
+		delete(s.lastActivity, workoutID)
+		s.stateMu.Unlock()
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	logger.Info("stale workout abandoned", zap.String("workout_id", workoutID.String()), zap.Time("last_update", last))
+	return true, s.closeWorkout(workout, pause)
+}
+
+// MonitorStaleWorkouts starts abandoning the workouts without an update for the timeout, or paused by their
+// player for the paused timeout, every interval. It fails without starting when the interval is not positive.
+func (s *WorkoutService) MonitorStaleWorkouts(timeout time.Duration, pausedTimeout time.Duration, interval time.Duration) error {
+	if interval <= 0 {
+		return ports.ErrorInvalidStaleWorkoutSweep
+	}
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if _, err := s.AbandonStaleWorkouts(now, timeout, pausedTimeout); err != nil {
+				logger.Error("failed to abandon stale workouts", zap.Error(err))
+			}
+		}
+	}()
+	return nil
 }
 
 func (s *WorkoutService) GetDistanceById(workoutID uuid.UUID) (float64, error) {
@@ -471,6 +1257,29 @@ func (s *WorkoutService) GetDistanceCoveredBetweenDates(playerID uuid.UUID, star
 	return s.repo.GetDistanceCoveredBetweenDates(playerID, startDate, endDate)
 }
 
+// DistanceUnit returns the unit to show the distances of the player in, the requested one when given,
+// otherwise the preference of the player, and kilometres when the user service cannot tell
+func (s *WorkoutService) DistanceUnit(playerID uuid.UUID, requested string) (domain.DistanceUnit, error) {
+	if requested != "" {
+		return domain.ParseDistanceUnit(requested)
+	}
+
+	preference, err := s.user.GetDistanceUnitOfUser(playerID)
+	if err != nil {
+		logger.Debug("failed to get distance unit of player", zap.String("playerID", playerID.String()), zap.Error(err))
+		return domain.Kilometres, nil
+	}
+	unit, err := domain.ParseDistanceUnit(preference)
+	if err != nil {
+		return domain.Kilometres, nil
+	}
+	return unit, nil
+}
+
+func (s *WorkoutService) GetWorkoutsCompletedBetweenDates(playerID uuid.UUID, startDate time.Time, endDate time.Time) (uint16, error) {
+	return s.repo.GetWorkoutsCompletedBetweenDates(playerID, startDate, endDate)
+}
+
 func (s *WorkoutService) GetEscapesMadeById(workoutID uuid.UUID) (uint16, error) {
 	return s.repo.GetEscapesMadeByID(workoutID)
 }
@@ -497,6 +1306,11 @@ func (s *WorkoutService) GetSheltersTakenBetweenDates(playerID uuid.UUID, startD
 
 // ComputeWorkoutOptionsOrder is modified to take profile directly
 func (s *WorkoutService) ComputeWorkoutOptionsOrder(workoutID uuid.UUID) error {
+	defer s.locks.lock(workoutID)()
+	return s.computeWorkoutOptionsOrder(workoutID)
+}
+
+func (s *WorkoutService) computeWorkoutOptionsOrder(workoutID uuid.UUID) error {
 	// Get the workout options from the repository
 	workoutOptions, err := s.repo.GetWorkoutOptions(workoutID)
 	if err != nil {
@@ -525,7 +1339,7 @@ func (s *WorkoutService) ComputeWorkoutOptionsOrder(workoutID uuid.UUID) error {
 	// Calculate the weight based on fights and escapes
 	fightEscapeWeight := calculateFightEscapeWeight(fights, escapes, workout.Profile)
 
-	avgHeartRate, err := s.peripheral.GetAverageHeartRateOfUser(workout.WorkoutID)
+	avgHeartRate, err := s.averageHeartRate(workout)
 
 	if err != nil {
 		return err
@@ -564,6 +1378,24 @@ func (s *WorkoutService) ComputeWorkoutOptionsOrder(workoutID uuid.UUID) error {
 	return nil // Return nil to indicate success
 }
 
+// averageHeartRate of the player over the workout without its pauses, the peripheral keeps the average of
+// the workouts never paused
+func (s *WorkoutService) averageHeartRate(workout *domain.Workout) (uint8, error) {
+	pauses, err := s.repo.GetWorkoutPauses(workout.WorkoutID)
+	if err != nil {
+		return 0, err
+	}
+	if len(pauses) == 0 && !workout.IsPaused() {
+		return s.peripheral.GetAverageHeartRateOfUser(workout.WorkoutID)
+	}
+
+	heartRates, err := s.peripheral.GetHeartRateSamples(workout.WorkoutID)
+	if err != nil {
+		return 0, err
+	}
+	return domain.AverageHeartRate(domain.ExcludePauses(workout, pauses, heartRates)), nil
+}
+
 // Helper function to calculate the weight based on fights and escapes
 func calculateFightEscapeWeight(fights, escapes uint16, profile string) int {
 	if fights-escapes >= 2 && profile == "strength" {
@@ -1,16 +1,21 @@
 package services_test
 
 import (
+	"encoding/xml"
+	"errors"
 	"math/rand"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/CAS735-F23/macrun-teamvsl/workout/config"
 	amqpsecondaryadapter "github.com/CAS735-F23/macrun-teamvsl/workout/internal/adapters/secondary/amqp"
 	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/adapters/secondary/clients"
+	memory "github.com/CAS735-F23/macrun-teamvsl/workout/internal/adapters/secondary/repository/memory"
 	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/adapters/secondary/repository/postgres"
 	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/domain"
+	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/ports"
 	"github.com/CAS735-F23/macrun-teamvsl/workout/internal/core/services"
 
 	"github.com/google/uuid"
@@ -32,9 +37,11 @@ func TestWorkoutService_StartAndStop(t *testing.T) {
 	userClientMock := clients.NewUserServiceClientMock()
 	peripheralClientMock := clients.NewPeripheralClientMock()
 	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	WorkoutEndPublisherMock := amqpsecondaryadapter.NewMockWorkoutEndPublisher()
 	store := postgres.NewRepository(cfg.Postgres)
 
-	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock)
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, WorkoutEndPublisherMock, cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
 
 	// Setup test data
 	playerID := uuid.New()
@@ -46,6 +53,7 @@ func TestWorkoutService_StartAndStop(t *testing.T) {
 	userClientMock.On("GetHardcoreModeOfUser", playerID).Return(true, nil)
 	peripheralClientMock.On("BindPeripheralData", trailID, playerID, workout.WorkoutID, HRMID, true, true).Return(nil)
 	peripheralClientMock.On("UnbindPeripheralData", workout.WorkoutID).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
 
 	// Test the Start function
 	link, startErr := service.Start(&workout, HRMID, true)
@@ -67,6 +75,9 @@ func TestWorkoutService_StartAndStop(t *testing.T) {
 	assert.True(t, stoppedWorkout.IsCompleted)
 	assert.NotEmpty(t, stoppedWorkout.EndedAt)
 
+	// The zone is told the workout ended
+	assert.Equal(t, []uuid.UUID{workout.WorkoutID}, WorkoutEndPublisherMock.EndedWorkouts)
+
 	// Assert that the peripheral device was unbound correctly
 	peripheralClientMock.AssertCalled(t, "UnbindPeripheralData", workout.WorkoutID)
 }
@@ -83,9 +94,10 @@ func TestWorkoutService_StartWorkoutTwice(t *testing.T) {
 	userClientMock := clients.NewUserServiceClientMock()
 	peripheralClientMock := clients.NewPeripheralClientMock()
 	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
 	store := postgres.NewRepository(cfg.Postgres)
 
-	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock)
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
 
 	// Setup test data
 	playerID := uuid.New()
@@ -97,6 +109,7 @@ func TestWorkoutService_StartWorkoutTwice(t *testing.T) {
 	userClientMock.On("GetWorkoutPreferenceOfUser", playerID).Return("cardio", nil)
 	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
 
 	// Start the workout
 	_, startErr := service.Start(&workout, HRMID, true)
@@ -126,9 +139,10 @@ func TestWorkoutService_HardcoreModeNoShelter(t *testing.T) {
 	userClientMock := clients.NewUserServiceClientMock()
 	peripheralClientMock := clients.NewPeripheralClientMock()
 	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
 	store := postgres.NewRepository(cfg.Postgres)
 
-	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock)
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
 
 	// Setup test data
 	playerID := uuid.New()
@@ -141,6 +155,7 @@ func TestWorkoutService_HardcoreModeNoShelter(t *testing.T) {
 	userClientMock.On("GetHardcoreModeOfUser", playerID).Return(true, nil) // Hardcore mode is on
 	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
 
 	// Start the workout
 	_, startErr := service.Start(&workout, HRMID, true)
@@ -169,9 +184,10 @@ func TestWorkoutService_WorkoutOptionsStartMultipleTimesStop(t *testing.T) {
 	userClientMock := clients.NewUserServiceClientMock()
 	peripheralClientMock := clients.NewPeripheralClientMock()
 	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
 	store := postgres.NewRepository(cfg.Postgres)
 
-	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock)
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
 
 	// Setup test data
 	playerID := uuid.New()
@@ -183,6 +199,7 @@ func TestWorkoutService_WorkoutOptionsStartMultipleTimesStop(t *testing.T) {
 	userClientMock.On("GetWorkoutPreferenceOfUser", playerID).Return("cardio", nil)
 	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
 
 	// Start the workout
 	_, startErr := service.Start(&workout, HRMID, true)
@@ -230,9 +247,10 @@ func TestWorkoutService_UpdateDistanceTravelled(t *testing.T) {
 	userClientMock := clients.NewUserServiceClientMock()
 	peripheralClientMock := clients.NewPeripheralClientMock()
 	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
 	store := postgres.NewRepository(cfg.Postgres)
 
-	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock)
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
 
 	// Setup test data
 	playerID := uuid.New()
@@ -248,6 +266,7 @@ func TestWorkoutService_UpdateDistanceTravelled(t *testing.T) {
 	// Mocked response for peripheral device client calls
 	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
 
 	// Assume the Start function initializes the workout correctly
 	_, startErr := service.Start(&workout, HRMID, true)
@@ -263,11 +282,13 @@ func TestWorkoutService_UpdateDistanceTravelled(t *testing.T) {
 		long := startLong + float64(i)*(endLong-startLong)/100
 		timeOfLocation := time.Now().Add(time.Duration(rand.Intn(1000)) * time.Millisecond)
 
-		err := service.UpdateDistanceTravelled(workout.WorkoutID, lat, long, timeOfLocation)
+		err := service.UpdateDistanceTravelled(workout.WorkoutID, lat, long, nil, timeOfLocation)
 		assert.NoError(t, err)
 
-		expectedTotalDistance += 0.01 // Adding 10 meters for each update in km
-		// there are 100 points
+		// there are 100 points, each about 10 metres from the previous one
+		if i > 0 {
+			expectedTotalDistance += 10
+		}
 	}
 
 	_, stopErr := service.Stop(workout.WorkoutID)
@@ -281,7 +302,7 @@ func TestWorkoutService_UpdateDistanceTravelled(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Assert the distance is as expected
-	assert.InDelta(t, expectedTotalDistance*50000, actualTotalDistance, 0.01*50000, "The actual distance should be close to the expected distance")
+	assert.InDelta(t, expectedTotalDistance, actualTotalDistance, 1, "The actual distance should be close to the expected distance")
 }
 
 /*
@@ -296,9 +317,10 @@ func TestWorkoutProcess_Shelters(t *testing.T) {
 	userClientMock := clients.NewUserServiceClientMock()
 	peripheralClientMock := clients.NewPeripheralClientMock()
 	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
 	store := postgres.NewRepository(cfg.Postgres)
 
-	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock)
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
 
 	// Setup test data
 	playerID := uuid.New()
@@ -314,6 +336,7 @@ func TestWorkoutProcess_Shelters(t *testing.T) {
 	// Mocked response for peripheral device client calls
 	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
 
 	// Start the workout using the service
 	_, startErr := service.Start(&workout, HRMID, true)
@@ -349,9 +372,10 @@ func TestWorkoutService_HardcoreMode(t *testing.T) {
 	userClientMock := clients.NewUserServiceClientMock()
 	peripheralClientMock := clients.NewPeripheralClientMock()
 	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
 	store := postgres.NewRepository(cfg.Postgres)
 
-	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock)
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
 
 	// Setup test data
 	playerID := uuid.New()
@@ -369,6 +393,7 @@ func TestWorkoutService_HardcoreMode(t *testing.T) {
 	// Mock the peripheral client to assert that the shelter request is set to false
 	peripheralClientMock.On("BindPeripheralData", trailID, playerID, workout.WorkoutID, HRMID, true, false).Return(nil)
 	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
 	randomHeartRate := uint8(rand.Intn(87) + 134)
 	peripheralClientMock.On("GetAverageHeartRateOfUser", mock.Anything).Return(randomHeartRate, nil)
 
@@ -407,9 +432,10 @@ func TestWorkoutService_InitialWorkoutOptionsIfCardio(t *testing.T) {
 	userClientMock := clients.NewUserServiceClientMock()
 	peripheralClientMock := clients.NewPeripheralClientMock()
 	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
 	store := postgres.NewRepository(cfg.Postgres)
 
-	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock)
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
 
 	// Setup test data
 	playerID := uuid.New()
@@ -426,6 +452,7 @@ func TestWorkoutService_InitialWorkoutOptionsIfCardio(t *testing.T) {
 	// Mock the peripheral client to assert that the shelter request is set to false
 	peripheralClientMock.On("BindPeripheralData", trailID, playerID, workout.WorkoutID, HRMID, true, false).Return(nil)
 	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
 
 	// First call, return a value less than 133
 	firstHeartRate := uint8(rand.Intn(133)) // Random number between 0 and 132
@@ -473,9 +500,10 @@ func TestWorkoutService_WorkoutOptionsIfCardio(t *testing.T) {
 	userClientMock := clients.NewUserServiceClientMock()
 	peripheralClientMock := clients.NewPeripheralClientMock()
 	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
 	store := postgres.NewRepository(cfg.Postgres)
 
-	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock)
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
 
 	// Setup test data
 	playerID := uuid.New()
@@ -492,6 +520,7 @@ func TestWorkoutService_WorkoutOptionsIfCardio(t *testing.T) {
 	// Mock the peripheral client to assert that the shelter request is set to false
 	peripheralClientMock.On("BindPeripheralData", trailID, playerID, workout.WorkoutID, HRMID, true, false).Return(nil)
 	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
 
 	randomHeartRate := uint8(rand.Intn(133))
 	peripheralClientMock.On("GetAverageHeartRateOfUser", mock.Anything).Return(randomHeartRate, nil)
@@ -552,9 +581,10 @@ func TestWorkoutService_InitialWorkoutOptionsIfStrength(t *testing.T) {
 	userClientMock := clients.NewUserServiceClientMock()
 	peripheralClientMock := clients.NewPeripheralClientMock()
 	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
 	store := postgres.NewRepository(cfg.Postgres)
 
-	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock)
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
 
 	// Setup test data
 	playerID := uuid.New()
@@ -571,6 +601,7 @@ func TestWorkoutService_InitialWorkoutOptionsIfStrength(t *testing.T) {
 	// Mock the peripheral client to assert that the shelter request is set to false
 	peripheralClientMock.On("BindPeripheralData", trailID, playerID, workout.WorkoutID, HRMID, true, false).Return(nil)
 	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
 
 	randomHeartRate := uint8(rand.Intn(87) + 134)
 	peripheralClientMock.On("GetAverageHeartRateOfUser", mock.Anything).Return(randomHeartRate, nil)
@@ -607,9 +638,10 @@ func TestWorkoutService_WorkoutOptionsIfStrength(t *testing.T) {
 	userClientMock := clients.NewUserServiceClientMock()
 	peripheralClientMock := clients.NewPeripheralClientMock()
 	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
 	store := postgres.NewRepository(cfg.Postgres)
 
-	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock)
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
 
 	// Setup test data
 	playerID := uuid.New()
@@ -626,6 +658,7 @@ func TestWorkoutService_WorkoutOptionsIfStrength(t *testing.T) {
 	// Mock the peripheral client to assert that the shelter request is set to false
 	peripheralClientMock.On("BindPeripheralData", trailID, playerID, workout.WorkoutID, HRMID, true, false).Return(nil)
 	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
 
 	randomHeartRate := uint8(rand.Intn(87) + 134)
 	peripheralClientMock.On("GetAverageHeartRateOfUser", mock.Anything).Return(randomHeartRate, nil)
@@ -685,9 +718,10 @@ func TestWorkoutService_DistanceToShelterUpdatesTest(t *testing.T) {
 	userClientMock := clients.NewUserServiceClientMock()
 	peripheralClientMock := clients.NewPeripheralClientMock()
 	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
 	store := postgres.NewRepository(cfg.Postgres)
 
-	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock)
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
 
 	// Setup test data
 	playerID := uuid.New()
@@ -704,6 +738,7 @@ func TestWorkoutService_DistanceToShelterUpdatesTest(t *testing.T) {
 	// Mock the peripheral client to assert that the shelter request is set to true
 	peripheralClientMock.On("BindPeripheralData", trailID, playerID, workout.WorkoutID, HRMID, true, true).Return(nil)
 	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
 
 	randomHeartRate := uint8(rand.Intn(87) + 134)
 	peripheralClientMock.On("GetAverageHeartRateOfUser", mock.Anything).Return(randomHeartRate, nil)
@@ -716,7 +751,8 @@ func TestWorkoutService_DistanceToShelterUpdatesTest(t *testing.T) {
 
 	// Mocking the Trail Manager
 	distance := 10.0
-	service.UpdateShelter(workout.WorkoutID, distance)
+	shelterID := uuid.New()
+	service.UpdateShelter(workout.WorkoutID, shelterID, true, distance)
 
 	// Get workout options and assert shelter is not an option
 	links, err := service.GetWorkoutOptions(workout.WorkoutID)
@@ -727,7 +763,7 @@ func TestWorkoutService_DistanceToShelterUpdatesTest(t *testing.T) {
 
 	// Mocking the Trail Manager
 	distance = 15.0
-	service.UpdateShelter(workout.WorkoutID, distance)
+	service.UpdateShelter(workout.WorkoutID, shelterID, true, distance)
 
 	// Get workout options and assert shelter is not an option
 	links, err = service.GetWorkoutOptions(workout.WorkoutID)
@@ -742,3 +778,1614 @@ func TestWorkoutService_DistanceToShelterUpdatesTest(t *testing.T) {
 	assert.NotNil(t, stoppedWorkout, "stopped workout should not be nil")
 	assert.True(t, stoppedWorkout.IsCompleted, "stopped workout should be marked as completed")
 }
+
+/*
+TestWorkoutService_Track:
+
+	This test checks that every location received for a workout is stored as an
+	ordered track point, and that the segment distances add up to the distance
+	covered by the workout.
+*/
+func TestWorkoutService_Track(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	store := postgres.NewRepository(cfg.Postgres)
+
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
+
+	// Setup test data
+	playerID := uuid.New()
+	trailID := uuid.New()
+	HRMID := uuid.New()
+
+	workout, _ := domain.NewWorkout(playerID, trailID, HRMID, false, false)
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", playerID).Return("cardio", nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
+
+	_, startErr := service.Start(&workout, HRMID, true)
+	assert.NoError(t, startErr)
+
+	// Send 10 locations, the third one repeats the second one
+	startLat, startLong := 40.730610, -73.935242
+	startTime := time.Now()
+	for i := 0; i < 10; i++ {
+		step := i
+		if i > 2 {
+			step = i - 1
+		} else if i == 2 {
+			step = 1
+		}
+		lat := startLat + float64(step)*0.0001
+		err := service.UpdateDistanceTravelled(workout.WorkoutID, lat, startLong, nil, startTime.Add(time.Duration(i)*time.Second))
+		assert.NoError(t, err)
+	}
+
+	_, stopErr := service.Stop(workout.WorkoutID)
+	assert.NoError(t, stopErr)
+
+	track, err := service.GetTrack(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.Len(t, track, 10, "every location should be stored")
+
+	totalDistance := 0.0
+	for i, point := range track {
+		assert.Equal(t, uint32(i), point.Sequence, "track points should be ordered")
+		totalDistance += point.SegmentDistance
+	}
+	assert.Zero(t, track[0].SegmentDistance, "the first point has no segment")
+	assert.Zero(t, track[2].SegmentDistance, "a repeated location has no segment")
+
+	actualTotalDistance, err := service.GetDistanceById(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.InDelta(t, actualTotalDistance, totalDistance, 0.0001)
+}
+
+/*
+TestWorkoutService_ExportTCX:
+
+	This test checks that a completed workout is exported as a TCX document
+	carrying the track, the heart rate samples and the workout options taken,
+	and that the exported distance and duration match the workout.
+*/
+func TestWorkoutService_ExportTCX(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	store := postgres.NewRepository(cfg.Postgres)
+
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
+
+	// Setup test data
+	playerID := uuid.New()
+	trailID := uuid.New()
+	HRMID := uuid.New()
+
+	workout, _ := domain.NewWorkout(playerID, trailID, HRMID, false, false)
+
+	startTime := time.Now()
+	heartRates := []domain.HeartRateSample{
+		{HeartRate: 100, TimeOfReading: startTime},
+		{HeartRate: 120, TimeOfReading: startTime.Add(3 * time.Second)},
+	}
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", playerID).Return("cardio", nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", workout.WorkoutID).Return(heartRates, nil)
+
+	_, startErr := service.Start(&workout, HRMID, true)
+	assert.NoError(t, startErr)
+
+	// Exporting an active workout is not allowed
+	_, err := service.ExportWorkout(workout.WorkoutID, domain.ExportFormatTCX)
+	assert.ErrorIs(t, err, ports.ErrWorkoutNotCompleted)
+
+	startLat, startLong := 40.730610, -73.935242
+	for i := 0; i < 5; i++ {
+		lat := startLat + float64(i)*0.0001
+		err := service.UpdateDistanceTravelled(workout.WorkoutID, lat, startLong, nil, startTime.Add(time.Duration(i)*time.Second))
+		assert.NoError(t, err)
+	}
+
+	_, err = service.StartWorkoutOption(workout.WorkoutID, "fight")
+	assert.NoError(t, err)
+	_, err = service.StopWorkoutOption(workout.WorkoutID)
+	assert.NoError(t, err)
+
+	stoppedWorkout, stopErr := service.Stop(workout.WorkoutID)
+	assert.NoError(t, stopErr)
+
+	body, err := service.ExportWorkout(workout.WorkoutID, domain.ExportFormatTCX)
+	assert.NoError(t, err)
+
+	var tcx domain.TCX
+	assert.NoError(t, xml.Unmarshal(body, &tcx))
+	assert.Len(t, tcx.Activities.Activities, 1)
+	activity := tcx.Activities.Activities[0]
+	lap := activity.Laps[0]
+
+	assert.InDelta(t, stoppedWorkout.DistanceCovered, lap.DistanceMeters, 0.001)
+	assert.InDelta(t, stoppedWorkout.EndedAt.Sub(stoppedWorkout.CreatedAt).Seconds(), lap.TotalTimeSeconds, 0.001)
+	assert.Len(t, lap.Track.Trackpoints, 5)
+	assert.Equal(t, uint8(120), lap.MaximumHeartRateBpm.Value)
+	assert.Contains(t, activity.Notes, "Fight")
+}
+
+func TestWorkoutService_OffTrail(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	store := postgres.NewRepository(cfg.Postgres)
+
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
+
+	// Setup test data
+	playerID := uuid.New()
+	trailID := uuid.New()
+	HRMID := uuid.New()
+
+	workout, _ := domain.NewWorkout(playerID, trailID, HRMID, false, false)
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", playerID).Return("cardio", nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
+
+	_, startErr := service.Start(&workout, HRMID, true)
+	assert.NoError(t, startErr)
+
+	// Leaving the trail flags the workout and is counted once
+	assert.NoError(t, service.UpdateOffTrail(workout.WorkoutID, true, 0.2))
+	assert.NoError(t, service.UpdateOffTrail(workout.WorkoutID, true, 0.3))
+	retrievedWorkout, err := service.GetWorkout(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.True(t, retrievedWorkout.OffTrail)
+	assert.Equal(t, uint8(1), retrievedWorkout.OffTrailCount)
+
+	// Coming back clears the flag but keeps the count
+	assert.NoError(t, service.UpdateOffTrail(workout.WorkoutID, false, 0.01))
+	assert.NoError(t, service.UpdateOffTrail(workout.WorkoutID, true, 0.2))
+	retrievedWorkout, err = service.GetWorkout(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.True(t, retrievedWorkout.OffTrail)
+	assert.Equal(t, uint8(2), retrievedWorkout.OffTrailCount)
+
+	_, stopErr := service.Stop(workout.WorkoutID)
+	assert.NoError(t, stopErr)
+}
+
+/*
+TestWorkoutService_ShelterReservation:
+
+	This test checks that taking the shelter option reserves a place in the closest
+	shelter and that stopping it gives the place back, that a full shelter is not
+	offered, and that a refused reservation ends the option without counting a shelter.
+*/
+func TestWorkoutService_ShelterReservation(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	store := postgres.NewRepository(cfg.Postgres)
+
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
+
+	// Setup test data
+	playerID := uuid.New()
+	trailID := uuid.New()
+	HRMID := uuid.New()
+	shelterID := uuid.New()
+
+	workout, _ := domain.NewWorkout(playerID, trailID, HRMID, false, false)
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", playerID).Return("strength", nil)
+	userClientMock.On("GetHardcoreModeOfUser", playerID).Return(false, nil)
+	userClientMock.On("GetUserAge", playerID).Return(30, nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
+	peripheralClientMock.On("GetAverageHeartRateOfUser", mock.Anything).Return(uint8(120), nil)
+
+	_, startErr := service.Start(&workout, HRMID, true)
+	assert.NoError(t, startErr)
+
+	// A full shelter is neither offered nor taken
+	assert.NoError(t, service.UpdateShelter(workout.WorkoutID, shelterID, false, 0.5))
+	links, err := service.GetWorkoutOptions(workout.WorkoutID)
+	assert.NoError(t, err)
+	for _, link := range links {
+		assert.NotEqual(t, "shelter", link.Option)
+	}
+	_, err = service.StartWorkoutOption(workout.WorkoutID, "shelter")
+	assert.ErrorIs(t, err, ports.ErrorWorkoutOptionUnavailable)
+	assert.Empty(t, ShelterReservationPublisherMock.Requests)
+
+	// Taking the shelter reserves a place and stopping gives it back
+	assert.NoError(t, service.UpdateShelter(workout.WorkoutID, shelterID, true, 0.5))
+	_, err = service.StartWorkoutOption(workout.WorkoutID, "shelter")
+	assert.NoError(t, err)
+	assert.NoError(t, service.UpdateShelterReservation(workout.WorkoutID, shelterID, true, ""))
+	_, err = service.StopWorkoutOption(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.Equal(t, []amqpsecondaryadapter.ShelterReservationRequest{
+		{WorkoutID: workout.WorkoutID, ShelterID: shelterID, Reserve: true},
+		{WorkoutID: workout.WorkoutID, ShelterID: shelterID, Reserve: false},
+	}, ShelterReservationPublisherMock.Requests)
+
+	// A refused reservation ends the option without counting the shelter
+	_, err = service.StartWorkoutOption(workout.WorkoutID, "shelter")
+	assert.NoError(t, err)
+	assert.NoError(t, service.UpdateShelterReservation(workout.WorkoutID, shelterID, false, "unavailable"))
+	_, err = service.StopWorkoutOption(workout.WorkoutID)
+	assert.ErrorIs(t, err, ports.ErrWorkoutOptionAlreadyInActive)
+	assert.Len(t, ShelterReservationPublisherMock.Requests, 3)
+
+	stoppedWorkout, stopErr := service.Stop(workout.WorkoutID)
+	assert.NoError(t, stopErr)
+	assert.Equal(t, uint8(1), stoppedWorkout.Shelters)
+	assert.Len(t, ShelterReservationPublisherMock.Requests, 3)
+}
+
+// failingRepository is the memory repository failing the updates of workouts or of their options on demand
+type failingRepository struct {
+	*memory.MemoryRepository
+	failWorkout bool
+	failOptions bool
+}
+
+func (r *failingRepository) UpdateWorkout(workout *domain.Workout) (*domain.Workout, error) {
+	if r.failWorkout {
+		return nil, ports.ErrorUpdateWorkoutFailed
+	}
+	return r.MemoryRepository.UpdateWorkout(workout)
+}
+
+func (r *failingRepository) UpdateWorkoutOptions(workoutOptions *domain.WorkoutOptions) (*domain.WorkoutOptions, error) {
+	if r.failOptions {
+		return nil, ports.ErrorUpdateWorkoutFailed
+	}
+	return r.MemoryRepository.UpdateWorkoutOptions(workoutOptions)
+}
+
+/*
+TestWorkoutService_ShelterReservationReleasedOnFailure:
+
+	This test fails saving the workout options, then the workout, while the shelter option starts. It
+	checks that the place reserved in the shelter is given back each time, that the option is left
+	inactive, and that it can be started once the repository works again.
+*/
+
+func TestWorkoutService_ShelterReservationReleasedOnFailure(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	store := &failingRepository{MemoryRepository: memory.NewMemoryRepository()}
+
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
+
+	// Setup test data
+	playerID := uuid.New()
+	HRMID := uuid.New()
+	shelterID := uuid.New()
+
+	workout, _ := domain.NewWorkout(playerID, uuid.New(), HRMID, false, false)
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", playerID).Return("strength", nil)
+	userClientMock.On("GetHardcoreModeOfUser", playerID).Return(false, nil)
+	userClientMock.On("GetUserAge", playerID).Return(30, nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetAverageHeartRateOfUser", mock.Anything).Return(uint8(120), nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
+
+	_, err := service.Start(&workout, HRMID, true)
+	assert.NoError(t, err)
+	assert.NoError(t, service.UpdateShelter(workout.WorkoutID, shelterID, true, 0.5))
+
+	reserve := amqpsecondaryadapter.ShelterReservationRequest{WorkoutID: workout.WorkoutID, ShelterID: shelterID, Reserve: true}
+	release := amqpsecondaryadapter.ShelterReservationRequest{WorkoutID: workout.WorkoutID, ShelterID: shelterID, Reserve: false}
+
+	// The options cannot be saved
+	store.failOptions = true
+	_, err = service.StartWorkoutOption(workout.WorkoutID, "shelter")
+	assert.ErrorIs(t, err, ports.ErrorUpdateWorkoutFailed)
+	assert.Equal(t, []amqpsecondaryadapter.ShelterReservationRequest{reserve, release}, ShelterReservationPublisherMock.Requests)
+	store.failOptions = false
+
+	// The workout cannot be saved, the options are left inactive
+	store.failWorkout = true
+	_, err = service.StartWorkoutOption(workout.WorkoutID, "shelter")
+	assert.ErrorIs(t, err, ports.ErrorUpdateWorkoutFailed)
+	assert.Equal(t, []amqpsecondaryadapter.ShelterReservationRequest{reserve, release, reserve, release}, ShelterReservationPublisherMock.Requests)
+	store.failWorkout = false
+
+	workoutOptions, err := store.GetWorkoutOptions(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.False(t, workoutOptions.IsWorkoutOptionActive)
+	assert.Equal(t, uuid.Nil, workoutOptions.ReservedShelterID)
+
+	// The option starts once the repository works again
+	option, err := service.StartWorkoutOption(workout.WorkoutID, "shelter")
+	assert.NoError(t, err)
+	assert.Equal(t, "Shelter", option)
+	assert.Len(t, ShelterReservationPublisherMock.Requests, 5)
+
+	_, err = service.Stop(workout.WorkoutID)
+	assert.NoError(t, err)
+}
+
+/*
+TestWorkoutService_Elevation:
+
+	This test sends locations with an elevation, including a climb on the spot and a location without
+	elevation, and checks the metres climbed and descended and the elevation stored with the track.
+*/
+
+func TestWorkoutService_Elevation(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	store := postgres.NewRepository(cfg.Postgres)
+
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
+
+	// Setup test data
+	playerID := uuid.New()
+	trailID := uuid.New()
+	HRMID := uuid.New()
+
+	workout, _ := domain.NewWorkout(playerID, trailID, HRMID, false, false)
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", playerID).Return("strength", nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
+
+	_, startErr := service.Start(&workout, HRMID, true)
+	assert.NoError(t, startErr)
+
+	// Up 20 m, up 5 m on the spot, a location without elevation, then down 10 m
+	elevation := func(metres float64) *float64 {
+		return &metres
+	}
+	locations := []struct {
+		lat       float64
+		elevation *float64
+	}{
+		{lat: 40.730610, elevation: elevation(100)},
+		{lat: 40.730710, elevation: elevation(120)},
+		{lat: 40.730710, elevation: elevation(125)},
+		{lat: 40.730810, elevation: nil},
+		{lat: 40.730910, elevation: elevation(130)},
+		{lat: 40.731010, elevation: elevation(120)},
+	}
+	startTime := time.Now()
+	for i, location := range locations {
+		err := service.UpdateDistanceTravelled(workout.WorkoutID, location.lat, -73.935242, location.elevation, startTime.Add(time.Duration(i)*time.Second))
+		assert.NoError(t, err)
+	}
+
+	stoppedWorkout, stopErr := service.Stop(workout.WorkoutID)
+	assert.NoError(t, stopErr)
+	assert.Equal(t, 25.0, stoppedWorkout.ElevationGain)
+	assert.Equal(t, 10.0, stoppedWorkout.ElevationLoss)
+
+	track, err := service.GetTrack(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.Len(t, track, len(locations))
+	assert.Equal(t, 125.0, *track[2].Elevation)
+	assert.Nil(t, track[3].Elevation)
+}
+
+/*
+TestWorkoutService_DistanceBetweenDates:
+
+	This test completes a workout and checks the distance covered and the number of workouts completed
+	by the player in a date range, as used by the zone to recommend trails.
+*/
+
+func TestWorkoutService_DistanceBetweenDates(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	store := postgres.NewRepository(cfg.Postgres)
+
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
+
+	// Setup test data
+	playerID := uuid.New()
+	HRMID := uuid.New()
+	workout, _ := domain.NewWorkout(playerID, uuid.New(), HRMID, false, false)
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", playerID).Return("cardio", nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
+
+	startDate := time.Now().Add(-time.Hour)
+	_, startErr := service.Start(&workout, HRMID, true)
+	assert.NoError(t, startErr)
+	for i := 0; i < 3; i++ {
+		err := service.UpdateDistanceTravelled(workout.WorkoutID, 40.730610+float64(i)*0.0001, -73.935242, nil, time.Now())
+		assert.NoError(t, err)
+	}
+	stoppedWorkout, stopErr := service.Stop(workout.WorkoutID)
+	assert.NoError(t, stopErr)
+	endDate := time.Now().Add(time.Hour)
+
+	distance, err := service.GetDistanceCoveredBetweenDates(playerID, startDate, endDate)
+	assert.NoError(t, err)
+	assert.InDelta(t, stoppedWorkout.DistanceCovered, distance, 0.0001)
+	workouts, err := service.GetWorkoutsCompletedBetweenDates(playerID, startDate, endDate)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(1), workouts)
+
+	// Nothing was completed before the workout started
+	workouts, err = service.GetWorkoutsCompletedBetweenDates(playerID, startDate.Add(-time.Hour), startDate)
+	assert.NoError(t, err)
+	assert.Zero(t, workouts)
+}
+
+/*
+TestWorkoutService_ShelterArrival:
+
+	This test checks that the shelter option is completed when the zone reports the player
+	reached the shelter holding their place, and that arrivals at other shelters or without
+	the shelter option are ignored.
+*/
+
+func TestWorkoutService_ShelterArrival(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	store := postgres.NewRepository(cfg.Postgres)
+
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
+
+	// Setup test data
+	playerID := uuid.New()
+	HRMID := uuid.New()
+	shelterID := uuid.New()
+	workout, _ := domain.NewWorkout(playerID, uuid.New(), HRMID, false, false)
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", playerID).Return("strength", nil)
+	userClientMock.On("GetUserAge", playerID).Return(30, nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
+	peripheralClientMock.On("GetAverageHeartRateOfUser", mock.Anything).Return(uint8(120), nil)
+
+	_, startErr := service.Start(&workout, HRMID, true)
+	assert.NoError(t, startErr)
+
+	// Arriving at a shelter without the shelter option changes nothing
+	assert.NoError(t, service.ArriveAtShelter(workout.WorkoutID, shelterID))
+	_, err := service.StartWorkoutOption(workout.WorkoutID, "fight")
+	assert.NoError(t, err)
+	assert.NoError(t, service.ArriveAtShelter(workout.WorkoutID, shelterID))
+	_, err = service.StopWorkoutOption(workout.WorkoutID)
+	assert.NoError(t, err)
+
+	// Arriving at another shelter than the reserved one does not complete the option
+	assert.NoError(t, service.UpdateShelter(workout.WorkoutID, shelterID, true, 0.5))
+	_, err = service.StartWorkoutOption(workout.WorkoutID, "shelter")
+	assert.NoError(t, err)
+	assert.NoError(t, service.ArriveAtShelter(workout.WorkoutID, uuid.New()))
+	workoutOptions, err := store.GetWorkoutOptions(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.True(t, workoutOptions.IsWorkoutOptionActive)
+
+	// Arriving at the reserved shelter completes the option and gives the place back
+	assert.NoError(t, service.ArriveAtShelter(workout.WorkoutID, shelterID))
+	_, err = service.StopWorkoutOption(workout.WorkoutID)
+	assert.ErrorIs(t, err, ports.ErrWorkoutOptionAlreadyInActive)
+	assert.Equal(t, []amqpsecondaryadapter.ShelterReservationRequest{
+		{WorkoutID: workout.WorkoutID, ShelterID: shelterID, Reserve: true},
+		{WorkoutID: workout.WorkoutID, ShelterID: shelterID, Reserve: false},
+	}, ShelterReservationPublisherMock.Requests)
+
+	stoppedWorkout, stopErr := service.Stop(workout.WorkoutID)
+	assert.NoError(t, stopErr)
+	assert.Equal(t, uint8(1), stoppedWorkout.Shelters)
+	assert.Equal(t, uint8(1), stoppedWorkout.Fights)
+}
+
+/*
+TestWorkoutService_List:
+
+	This test starts workouts in a time window of their own and walks the history page by page,
+	sorted by start time and by distance, and filtered by completion and player.
+*/
+
+func TestWorkoutService_List(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	store := postgres.NewRepository(cfg.Postgres)
+
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", mock.Anything).Return("cardio", nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+
+	// Three workouts an hour apart in the past, the first one the longest and the last one still going
+	from := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(rand.Intn(100000)) * 4 * time.Hour)
+	steps := []int{4, 2, 0}
+	workouts := make([]domain.Workout, len(steps))
+	for i, count := range steps {
+		workouts[i], _ = domain.NewWorkout(uuid.New(), uuid.New(), uuid.New(), false, false)
+		workouts[i].CreatedAt = from.Add(time.Duration(i+1) * time.Hour)
+		_, err := service.Start(&workouts[i], uuid.New(), false)
+		assert.NoError(t, err)
+		for j := 0; j < count; j++ {
+			err := service.UpdateDistanceTravelled(workouts[i].WorkoutID, 40.730610+float64(j)*0.0001, -73.935242, nil, time.Now())
+			assert.NoError(t, err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		_, err := service.Stop(workouts[i].WorkoutID)
+		assert.NoError(t, err)
+	}
+	ids := func(page *domain.WorkoutPage) []uuid.UUID {
+		listed := make([]uuid.UUID, len(page.Workouts))
+		for i, workout := range page.Workouts {
+			listed[i] = workout.WorkoutID
+		}
+		return listed
+	}
+	filter := domain.WorkoutFilter{From: from, To: from.Add(4 * time.Hour), PageSize: 2}
+
+	// Newest first, two per page
+	page, err := service.List(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{workouts[2].WorkoutID, workouts[1].WorkoutID}, ids(page))
+	assert.NotEmpty(t, page.NextCursor)
+	filter.After, err = domain.DecodeWorkoutCursor(page.NextCursor)
+	assert.NoError(t, err)
+	page, err = service.List(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{workouts[0].WorkoutID}, ids(page))
+	assert.Empty(t, page.NextCursor)
+
+	// A cursor only carries on its own order
+	filter.SortBy = domain.WorkoutSortDistance
+	_, err = service.List(filter)
+	assert.ErrorIs(t, err, domain.ErrInvalidWorkoutCursor)
+
+	// Longest first
+	filter.After, filter.PageSize = nil, 10
+	page, err = service.List(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{workouts[0].WorkoutID, workouts[1].WorkoutID, workouts[2].WorkoutID}, ids(page))
+
+	// Only the completed workouts, oldest first
+	completed := true
+	filter.SortBy, filter.Ascending, filter.Completed = domain.WorkoutSortStartTime, true, &completed
+	page, err = service.List(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{workouts[0].WorkoutID, workouts[1].WorkoutID}, ids(page))
+
+	// The workouts of a player
+	page, err = service.List(domain.WorkoutFilter{PlayerID: workouts[1].PlayerID})
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{workouts[1].WorkoutID}, ids(page))
+
+	// Pages larger than the maximum are refused
+	_, err = service.List(domain.WorkoutFilter{PageSize: domain.MaxWorkoutPageSize + 1})
+	assert.ErrorIs(t, err, domain.ErrInvalidWorkoutFilter)
+
+	_, err = service.Stop(workouts[2].WorkoutID)
+	assert.NoError(t, err)
+}
+
+/*
+TestWorkoutService_RepeatedWorkouts:
+
+	This test runs the same trail twice with the same player while another player is on it, and
+	checks that the database refuses a second workout in progress for a player even when the
+	service does not know about the first one.
+*/
+
+func TestWorkoutService_RepeatedWorkouts(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	store := postgres.NewRepository(cfg.Postgres)
+
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", mock.Anything).Return("cardio", nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+
+	playerID := uuid.New()
+	otherPlayerID := uuid.New()
+	trailID := uuid.New()
+
+	// Another player is on the trail the whole time
+	other, _ := domain.NewWorkout(otherPlayerID, trailID, uuid.New(), false, false)
+	_, err := service.Start(&other, uuid.New(), false)
+	assert.NoError(t, err)
+
+	// The player runs the trail twice
+	for i := 0; i < 2; i++ {
+		workout, _ := domain.NewWorkout(playerID, trailID, uuid.New(), false, false)
+		_, err := service.Start(&workout, uuid.New(), false)
+		assert.NoError(t, err)
+		_, err = service.Stop(workout.WorkoutID)
+		assert.NoError(t, err)
+	}
+	page, err := service.List(domain.WorkoutFilter{PlayerID: playerID})
+	assert.NoError(t, err)
+	assert.Len(t, page.Workouts, 2)
+	page, err = service.List(domain.WorkoutFilter{TrailID: trailID})
+	assert.NoError(t, err)
+	assert.Len(t, page.Workouts, 3)
+
+	// A service that lost track of the workout in progress is still refused a second one
+	restarted := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
+	duplicate, _ := domain.NewWorkout(otherPlayerID, uuid.New(), uuid.New(), false, false)
+	_, err = restarted.Start(&duplicate, uuid.New(), false)
+	assert.ErrorIs(t, err, ports.ErrorActiveWorkoutAlreadyExists)
+
+	_, err = service.Stop(other.WorkoutID)
+	assert.NoError(t, err)
+}
+
+/*
+TestWorkoutService_Restart:
+
+	This test restarts the service in the middle of a workout and checks that the player cannot
+	start a second workout and that the track and distance carry on from the last location.
+*/
+
+func TestWorkoutService_Restart(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	store := postgres.NewRepository(cfg.Postgres)
+	newService := func() *services.WorkoutService {
+		return services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
+	}
+	service := newService()
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", mock.Anything).Return("cardio", nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
+
+	playerID := uuid.New()
+	workout, _ := domain.NewWorkout(playerID, uuid.New(), uuid.New(), true, false)
+	_, err := service.Start(&workout, uuid.New(), true)
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		err := service.UpdateDistanceTravelled(workout.WorkoutID, 40.730610+float64(i)*0.0001, -73.935242, nil, time.Now())
+		assert.NoError(t, err)
+	}
+
+	// After a restart the player still has a workout in progress
+	restarted := newService()
+	assert.NoError(t, restarted.RestoreActiveWorkouts())
+	second, _ := domain.NewWorkout(playerID, uuid.New(), uuid.New(), false, false)
+	_, err = restarted.Start(&second, uuid.New(), false)
+	assert.EqualError(t, err, ports.ErrorActiveWorkoutAlreadyExists.Error())
+
+	// The track carries on from the last location
+	err = restarted.UpdateDistanceTravelled(workout.WorkoutID, 40.730810, -73.935242, nil, time.Now())
+	assert.NoError(t, err)
+
+	// A service that was not restored picks up the track on the next location
+	unrestored := newService()
+	err = unrestored.UpdateDistanceTravelled(workout.WorkoutID, 40.730910, -73.935242, nil, time.Now())
+	assert.NoError(t, err)
+
+	track, err := unrestored.GetTrack(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.Len(t, track, 4)
+	totalDistance := 0.0
+	for i, point := range track {
+		assert.Equal(t, uint32(i), point.Sequence)
+		totalDistance += point.SegmentDistance
+	}
+	stored, err := unrestored.GetWorkout(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.True(t, stored.HRMConnected)
+	assert.InDelta(t, totalDistance, stored.DistanceCovered, 0.0001)
+	assert.Greater(t, track[3].SegmentDistance, 0.0)
+
+	_, err = restarted.Stop(workout.WorkoutID)
+	assert.NoError(t, err)
+}
+
+/*
+TestWorkoutService_ConcurrentUpdates:
+
+	This test sends locations, workout option changes, shelter and off trail updates for several
+	workouts at the same time, as the HTTP handlers and the AMQP consumers do, and checks that no
+	update is lost: the track has one point per location in order, the distance is the sum of the
+	segments and every option and off trail change is counted. A player starting several workouts at
+	once gets exactly one, and an update made from a stale copy of a workout is refused.
+	Run it with -race.
+*/
+
+func TestWorkoutService_ConcurrentUpdates(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	store := memory.NewMemoryRepository()
+
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", mock.Anything).Return("cardio", nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("GetHeartRateSamples", mock.Anything).Return([]domain.HeartRateSample{}, nil)
+
+	const (
+		workoutCount       = 4
+		locationSenders    = 4
+		locationsPerSender = 25
+		optionChanges      = 10
+	)
+
+	workouts := make([]domain.Workout, workoutCount)
+	for i := range workouts {
+		workouts[i], _ = domain.NewWorkout(uuid.New(), uuid.New(), uuid.New(), true, false)
+		_, err := service.Start(&workouts[i], uuid.New(), true)
+		assert.NoError(t, err)
+	}
+
+	var wg sync.WaitGroup
+	for _, workout := range workouts {
+		workoutID := workout.WorkoutID
+
+		// Locations arrive from several consumers at once
+		for sender := 0; sender < locationSenders; sender++ {
+			wg.Add(1)
+			go func(sender int) {
+				defer wg.Done()
+				for i := 0; i < locationsPerSender; i++ {
+					latitude := 40.730610 + float64(sender*locationsPerSender+i)*0.0001
+					err := service.UpdateDistanceTravelled(workoutID, latitude, -73.935242, nil, time.Now())
+					assert.NoError(t, err)
+				}
+			}(sender)
+		}
+
+		// The player fights while moving
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < optionChanges; i++ {
+				_, err := service.StartWorkoutOption(workoutID, "fight")
+				assert.NoError(t, err)
+				_, err = service.StopWorkoutOption(workoutID)
+				assert.NoError(t, err)
+			}
+		}()
+
+		// The zone reports shelters and the player leaving the trail
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < optionChanges; i++ {
+				assert.NoError(t, service.UpdateShelter(workoutID, uuid.New(), true, float64(i)))
+				assert.NoError(t, service.UpdateOffTrail(workoutID, true, 120))
+				assert.NoError(t, service.UpdateOffTrail(workoutID, false, 0))
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, workout := range workouts {
+		track, err := service.GetTrack(workout.WorkoutID)
+		assert.NoError(t, err)
+		assert.Len(t, track, locationSenders*locationsPerSender)
+		totalDistance := 0.0
+		for i, point := range track {
+			assert.Equal(t, uint32(i), point.Sequence)
+			totalDistance += point.SegmentDistance
+		}
+
+		stored, err := service.GetWorkout(workout.WorkoutID)
+		assert.NoError(t, err)
+		assert.InDelta(t, totalDistance, stored.DistanceCovered, 0.0001)
+		assert.Equal(t, optionChanges, int(stored.Fights))
+		assert.Equal(t, optionChanges, int(stored.OffTrailCount))
+		assert.False(t, stored.OffTrail)
+	}
+
+	// A player starting several workouts at once gets exactly one
+	playerID := uuid.New()
+	started := make(chan uuid.UUID, locationSenders)
+	for i := 0; i < locationSenders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			workout, _ := domain.NewWorkout(playerID, uuid.New(), uuid.New(), false, false)
+			if _, err := service.Start(&workout, uuid.New(), false); err == nil {
+				started <- workout.WorkoutID
+			} else {
+				assert.ErrorIs(t, err, ports.ErrorActiveWorkoutAlreadyExists)
+			}
+		}()
+	}
+	wg.Wait()
+	close(started)
+	assert.Len(t, started, 1)
+
+	// An update from a stale copy of the workout is refused
+	first, err := store.GetWorkout(workouts[0].WorkoutID)
+	assert.NoError(t, err)
+	stale, err := store.GetWorkout(workouts[0].WorkoutID)
+	assert.NoError(t, err)
+	first.Escapes++
+	_, err = store.UpdateWorkout(first)
+	assert.NoError(t, err)
+	stale.Fights++
+	_, err = store.UpdateWorkout(stale)
+	assert.ErrorIs(t, err, ports.ErrorWorkoutVersionConflict)
+
+	for _, workout := range workouts {
+		_, err := service.Stop(workout.WorkoutID)
+		assert.NoError(t, err)
+	}
+	for workoutID := range started {
+		_, err := service.Stop(workoutID)
+		assert.NoError(t, err)
+	}
+}
+
+// racingRepository is the Postgres repository with another writer updating a workout once, right after the
+// service read it, as another instance of the service would
+type racingRepository struct {
+	*postgres.Repository
+	race bool
+}
+
+func (r *racingRepository) GetWorkout(workoutID uuid.UUID) (*domain.Workout, error) {
+	workout, err := r.Repository.GetWorkout(workoutID)
+	if err != nil || !r.race {
+		return workout, err
+	}
+	r.race = false
+	other, err := r.Repository.GetWorkout(workoutID)
+	if err != nil {
+		return nil, err
+	}
+	other.Escapes++
+	if _, err := r.Repository.UpdateWorkout(other); err != nil {
+		return nil, err
+	}
+	return workout, nil
+}
+
+/*
+TestWorkoutService_PostgresVersionConflict:
+
+	This test checks the versioned updates of the Postgres repository: an update made from a stale copy of
+	a workout or of its options is refused with ErrorWorkoutVersionConflict, and the service reads the
+	workout again and applies its change when another writer updated it in between, keeping both changes,
+	including when the workout is stopped. Missing workouts are reported with ErrorWorkoutNotFound, as by
+	the memory repository.
+*/
+
+func TestWorkoutService_PostgresVersionConflict(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	store := &racingRepository{Repository: postgres.NewRepository(cfg.Postgres)}
+
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), cfg.DistanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", mock.Anything).Return("cardio", nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+
+	workout, _ := domain.NewWorkout(uuid.New(), uuid.New(), uuid.New(), false, false)
+	_, err := service.Start(&workout, uuid.New(), false)
+	assert.NoError(t, err)
+	started, err := store.GetWorkout(workout.WorkoutID)
+	assert.NoError(t, err)
+
+	// The service retries over the escape made by the other writer
+	store.race = true
+	assert.NoError(t, service.UpdateOffTrail(workout.WorkoutID, true, 120))
+	assert.False(t, store.race)
+	stored, err := store.GetWorkout(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.True(t, stored.OffTrail)
+	assert.Equal(t, uint8(1), stored.OffTrailCount)
+	assert.Equal(t, started.Escapes+1, stored.Escapes)
+	assert.Equal(t, started.Version+2, stored.Version)
+
+	// An update from a stale copy is refused and leaves the workout as it was
+	started.Fights++
+	_, err = store.UpdateWorkout(started)
+	assert.ErrorIs(t, err, ports.ErrorWorkoutVersionConflict)
+	unchanged, err := store.GetWorkout(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.Equal(t, stored.Fights, unchanged.Fights)
+	assert.Equal(t, stored.Version, unchanged.Version)
+
+	first, err := store.GetWorkoutOptions(workout.WorkoutID)
+	assert.NoError(t, err)
+	stale, err := store.GetWorkoutOptions(workout.WorkoutID)
+	assert.NoError(t, err)
+	_, err = store.UpdateWorkoutOptions(first)
+	assert.NoError(t, err)
+	_, err = store.UpdateWorkoutOptions(stale)
+	assert.ErrorIs(t, err, ports.ErrorWorkoutVersionConflict)
+
+	// Missing workouts are not found
+	unknownID := uuid.New()
+	_, err = store.GetWorkout(unknownID)
+	assert.ErrorIs(t, err, ports.ErrorWorkoutNotFound)
+	_, err = store.GetWorkoutOptions(unknownID)
+	assert.ErrorIs(t, err, ports.ErrorWorkoutNotFound)
+	_, err = store.UpdateWorkout(&domain.Workout{WorkoutID: unknownID})
+	assert.ErrorIs(t, err, ports.ErrorWorkoutNotFound)
+	assert.ErrorIs(t, service.UpdateOffTrail(unknownID, true, 120), ports.ErrorWorkoutNotFound)
+
+	// Stopping retries over the escape made by the other writer as well
+	store.race = true
+	stopped, err := service.Stop(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.False(t, store.race)
+	assert.Equal(t, domain.WorkoutCompleted, stopped.State)
+	assert.Equal(t, stored.Escapes+1, stopped.Escapes)
+}
+
+/*
+TestWorkoutService_DistanceUnits:
+
+	This test moves two players along the same path, one on a service scaling the distances as
+	a demo environment would, and checks that the distance is stored in metres and scaled only
+	when asked. It also checks that distances are shown in the requested units, otherwise in the
+	units the player prefers, and in kilometres when the user service cannot tell.
+*/
+
+func TestWorkoutService_DistanceUnits(t *testing.T) {
+	// Initialize the mocks and the services
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	newService := func(distanceScale float64) *services.WorkoutService {
+		return services.NewWorkoutService(memory.NewMemoryRepository(), peripheralClientMock, userClientMock, amqpsecondaryadapter.NewMockWorkoutStatsPublisher(), amqpsecondaryadapter.NewMockShelterReservationPublisher(), amqpsecondaryadapter.NewMockWorkoutEndPublisher(), distanceScale, domain.AutoPause{}, domain.MaxHeartRateFormula)
+	}
+	service := newService(1)
+	demoService := newService(25)
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", mock.Anything).Return("cardio", nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+
+	// Both players run about 1 km north
+	distances := make([]float64, 0, 2)
+	for _, service := range []*services.WorkoutService{service, demoService} {
+		workout, _ := domain.NewWorkout(uuid.New(), uuid.New(), uuid.New(), false, false)
+		_, err := service.Start(&workout, uuid.New(), false)
+		assert.NoError(t, err)
+		assert.NoError(t, service.UpdateDistanceTravelled(workout.WorkoutID, 40.730610, -73.935242, nil, time.Now()))
+		assert.NoError(t, service.UpdateDistanceTravelled(workout.WorkoutID, 40.739604, -73.935242, nil, time.Now()))
+
+		stopped, err := service.Stop(workout.WorkoutID)
+		assert.NoError(t, err)
+		distances = append(distances, stopped.DistanceCovered)
+	}
+	assert.InDelta(t, 1000, distances[0], 1, "the distance is stored in metres")
+	assert.InDelta(t, distances[0]*25, distances[1], 0.001, "the demo scales the distance")
+
+	// The requested units come first
+	unit, err := service.DistanceUnit(uuid.New(), "mi")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Miles, unit)
+	_, err = service.DistanceUnit(uuid.New(), "yd")
+	assert.ErrorIs(t, err, domain.ErrInvalidDistanceUnit)
+
+	// Otherwise the units the player prefers, and kilometres when they are not known
+	playerID := uuid.New()
+	unknownPlayerID := uuid.New()
+	userClientMock.On("GetDistanceUnitOfUser", playerID).Return("mi", nil)
+	userClientMock.On("GetDistanceUnitOfUser", unknownPlayerID).Return("", errors.New("player not found"))
+	unit, err = service.DistanceUnit(playerID, "")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Miles, unit)
+	assert.InDelta(t, 0.6214, unit.FromMetres(distances[0]), 0.001)
+	unit, err = service.DistanceUnit(unknownPlayerID, "")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Kilometres, unit)
+}
+
+/*
+TestWorkoutService_Summary:
+
+	This test runs a little over a kilometre with a stop on the way and checks that the summary
+	of the stopped workout has a full split and a partial one adding up to the distance covered,
+	and that the stop is left out of the moving time and the pace.
+*/
+
+func TestWorkoutService_Summary(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	store := memory.NewMemoryRepository()
+
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), 1, domain.AutoPause{}, domain.MaxHeartRateFormula)
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", mock.Anything).Return("cardio", nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+
+	workout, _ := domain.NewWorkout(uuid.New(), uuid.New(), uuid.New(), false, false)
+	_, err := service.Start(&workout, uuid.New(), false)
+	assert.NoError(t, err)
+
+	// About 333 metres a minute, with a two minute stop after the second minute
+	start := time.Now()
+	locations := []struct {
+		latitude float64
+		minutes  int
+	}{
+		{40.730610, 0},
+		{40.733610, 1},
+		{40.736610, 2},
+		{40.736610, 4},
+		{40.739610, 5},
+		{40.742610, 6},
+	}
+	for _, location := range locations {
+		err := service.UpdateDistanceTravelled(workout.WorkoutID, location.latitude, -73.935242, nil, start.Add(time.Duration(location.minutes)*time.Minute))
+		assert.NoError(t, err)
+	}
+
+	stopped, err := service.Stop(workout.WorkoutID)
+	assert.NoError(t, err)
+
+	summary, err := service.Summary(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.Equal(t, stopped.DistanceCovered, summary.Distance)
+	assert.Greater(t, summary.ElapsedTime, 0.0)
+	assert.InDelta(t, 240, summary.MovingTime, 0.001, "the stop is not moving time")
+	assert.InDelta(t, 240/(stopped.DistanceCovered/1000), summary.AveragePace, 0.001)
+
+	assert.Len(t, summary.Splits, 2)
+	assert.Equal(t, 1000.0, summary.Splits[0].Distance)
+	assert.InDelta(t, stopped.DistanceCovered, summary.Splits[0].Distance+summary.Splits[1].Distance, 0.001)
+	assert.Equal(t, summary.Splits[0].Pace, summary.BestPace)
+
+	// Unknown workouts have no summary
+	_, err = service.Summary(uuid.New())
+	assert.ErrorIs(t, err, ports.ErrorWorkoutNotFound)
+}
+
+/*
+TestWorkoutService_PauseResume:
+
+	This test pauses and resumes a workout and checks that the way covered while it was paused,
+	and across the pause, is not credited, that the peripheral is paused with it, that pausing
+	or resuming twice fails, and that the pause shows up in the summary.
+*/
+
+func TestWorkoutService_PauseResume(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	store := memory.NewMemoryRepository()
+
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), 1, domain.AutoPause{}, domain.MaxHeartRateFormula)
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", mock.Anything).Return("cardio", nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("PausePeripheralData", mock.Anything, mock.Anything).Return(nil)
+
+	workout, _ := domain.NewWorkout(uuid.New(), uuid.New(), uuid.New(), false, false)
+	_, err := service.Start(&workout, uuid.New(), false)
+	assert.NoError(t, err)
+
+	// Resuming a running workout fails
+	_, err = service.Resume(workout.WorkoutID)
+	assert.ErrorIs(t, err, domain.ErrWorkoutNotPaused)
+
+	start := time.Now().Add(-10 * time.Minute)
+	assert.NoError(t, service.UpdateDistanceTravelled(workout.WorkoutID, 40.730610, -73.935242, nil, start))
+	assert.NoError(t, service.UpdateDistanceTravelled(workout.WorkoutID, 40.733610, -73.935242, nil, start.Add(time.Minute)))
+	running, _ := service.GetWorkout(workout.WorkoutID)
+	assert.Greater(t, running.DistanceCovered, 300.0)
+
+	paused, err := service.Pause(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.True(t, paused.IsPaused())
+	assert.False(t, paused.AutoPaused)
+	peripheralClientMock.AssertCalled(t, "PausePeripheralData", workout.WorkoutID, true)
+	_, err = service.Pause(workout.WorkoutID)
+	assert.ErrorIs(t, err, domain.ErrWorkoutAlreadyPaused)
+
+	// A location sent while paused is recorded but not credited
+	assert.NoError(t, service.UpdateDistanceTravelled(workout.WorkoutID, 40.736610, -73.935242, nil, time.Now()))
+	time.Sleep(10 * time.Millisecond)
+
+	resumed, err := service.Resume(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.False(t, resumed.IsPaused())
+	peripheralClientMock.AssertCalled(t, "PausePeripheralData", workout.WorkoutID, false)
+
+	// The way to the first location after the pause is not credited, the next one is
+	assert.NoError(t, service.UpdateDistanceTravelled(workout.WorkoutID, 40.739610, -73.935242, nil, time.Now().Add(time.Minute)))
+	afterPause, _ := service.GetWorkout(workout.WorkoutID)
+	assert.Equal(t, running.DistanceCovered, afterPause.DistanceCovered)
+	assert.NoError(t, service.UpdateDistanceTravelled(workout.WorkoutID, 40.742610, -73.935242, nil, time.Now().Add(2*time.Minute)))
+
+	track, err := service.GetTrack(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.Len(t, track, 5)
+	assert.Zero(t, track[2].SegmentDistance)
+	assert.Zero(t, track[3].SegmentDistance)
+	assert.InDelta(t, track[1].SegmentDistance, track[4].SegmentDistance, 0.001)
+
+	// A pause going on ends with the workout
+	_, err = service.Pause(workout.WorkoutID)
+	assert.NoError(t, err)
+	stopped, err := service.Stop(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.False(t, stopped.IsPaused())
+	assert.InDelta(t, 2*running.DistanceCovered, stopped.DistanceCovered, 0.001)
+
+	pauses, err := store.GetWorkoutPauses(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.Len(t, pauses, 2)
+	summary, err := service.Summary(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.Greater(t, summary.PausedTime, 0.0)
+
+	// A completed workout is neither paused nor resumed
+	_, err = service.Pause(workout.WorkoutID)
+	assert.ErrorIs(t, err, ports.ErrWorkoutAlreadyCompleted)
+	_, err = service.Resume(workout.WorkoutID)
+	assert.ErrorIs(t, err, ports.ErrWorkoutAlreadyCompleted)
+}
+
+/*
+TestWorkoutService_AutoPause:
+
+	This test stands still for longer than the auto-pause allows and checks that the workout is
+	paused by itself while the peripheral keeps publishing, and resumed as soon as the player
+	moves again, with the first segment after the stop still credited.
+*/
+
+func TestWorkoutService_AutoPause(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	store := memory.NewMemoryRepository()
+
+	autoPause := domain.AutoPause{Speed: 0.5, After: 2 * time.Minute}
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), 1, autoPause, domain.MaxHeartRateFormula)
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", mock.Anything).Return("cardio", nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	workout, _ := domain.NewWorkout(uuid.New(), uuid.New(), uuid.New(), false, false)
+	_, err := service.Start(&workout, uuid.New(), false)
+	assert.NoError(t, err)
+
+	// Stopped from the first minute, for two minutes at the third
+	start := time.Now().Add(-time.Hour)
+	locations := []struct {
+		latitude float64
+		minutes  int
+		paused   bool
+	}{
+		{40.730610, 0, false},
+		{40.733610, 1, false},
+		{40.733610, 2, false},
+		{40.733610, 3, true},
+		{40.733610, 4, true},
+		{40.736610, 5, false},
+	}
+	for _, location := range locations {
+		err := service.UpdateDistanceTravelled(workout.WorkoutID, location.latitude, -73.935242, nil, start.Add(time.Duration(location.minutes)*time.Minute))
+		assert.NoError(t, err)
+		current, _ := service.GetWorkout(workout.WorkoutID)
+		assert.Equal(t, location.paused, current.IsPaused(), "paused at minute %d", location.minutes)
+		assert.Equal(t, location.paused, current.AutoPaused, "paused automatically at minute %d", location.minutes)
+	}
+	peripheralClientMock.AssertNotCalled(t, "PausePeripheralData", mock.Anything, mock.Anything)
+
+	pauses, err := store.GetWorkoutPauses(workout.WorkoutID)
+	assert.NoError(t, err)
+	if assert.Len(t, pauses, 1) {
+		assert.True(t, pauses[0].Auto)
+		assert.Equal(t, start.Add(3*time.Minute), pauses[0].StartedAt)
+		assert.Equal(t, start.Add(4*time.Minute), pauses[0].EndedAt)
+	}
+
+	track, _ := service.GetTrack(workout.WorkoutID)
+	current, _ := service.GetWorkout(workout.WorkoutID)
+	assert.InDelta(t, 2*track[1].SegmentDistance, current.DistanceCovered, 0.001)
+	assert.InDelta(t, track[1].SegmentDistance, track[5].SegmentDistance, 0.001)
+}
+
+/*
+TestWorkoutService_StateTransitions:
+
+	This test takes a workout through its states, checks that each transition is logged in
+	order with the event that caused it, that illegal transitions are refused with a typed
+	error and leave the log as it was, and that a workout whose peripheral cannot be bound
+	is abandoned so that the player can start another one.
+*/
+
+func TestWorkoutService_StateTransitions(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	store := memory.NewMemoryRepository()
+
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), 1, domain.AutoPause{}, domain.MaxHeartRateFormula)
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", mock.Anything).Return("strength", nil)
+	userClientMock.On("GetUserAge", mock.Anything).Return(25, nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("PausePeripheralData", mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("GetAverageHeartRateOfUser", mock.Anything).Return(uint8(80), nil)
+
+	// A workout whose peripheral cannot be bound is abandoned
+	playerID := uuid.New()
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, playerID, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("no device")).Once()
+	unbound, _ := domain.NewWorkout(playerID, uuid.New(), uuid.New(), false, false)
+	_, err := service.Start(&unbound, uuid.New(), false)
+	assert.Error(t, err)
+	abandoned, err := service.GetWorkout(unbound.WorkoutID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.WorkoutAbandoned, abandoned.State)
+	assert.True(t, abandoned.IsCompleted)
+
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	workout, _ := domain.NewWorkout(playerID, uuid.New(), uuid.New(), false, false)
+	_, err = service.Start(&workout, uuid.New(), false)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.WorkoutRunning, workout.State)
+
+	_, err = service.StartWorkoutOption(workout.WorkoutID, "fight")
+	assert.NoError(t, err)
+	current, _ := service.GetWorkout(workout.WorkoutID)
+	assert.Equal(t, domain.WorkoutInOption, current.State)
+
+	// Pausing during a fight is illegal, so is a second fight
+	_, err = service.Pause(workout.WorkoutID)
+	assert.ErrorIs(t, err, domain.ErrIllegalTransition)
+	_, err = service.StartWorkoutOption(workout.WorkoutID, "escape")
+	assert.ErrorIs(t, err, ports.ErrWorkoutOptionAlreadyActive)
+	var transitionErr *domain.TransitionError
+	if assert.ErrorAs(t, err, &transitionErr) {
+		assert.Equal(t, domain.WorkoutInOption, transitionErr.From)
+		assert.Equal(t, domain.EventStartOption, transitionErr.Event)
+	}
+
+	_, err = service.StopWorkoutOption(workout.WorkoutID)
+	assert.NoError(t, err)
+	_, err = service.Pause(workout.WorkoutID)
+	assert.NoError(t, err)
+
+	// A paused workout takes no option until it is resumed
+	_, err = service.StartWorkoutOption(workout.WorkoutID, "fight")
+	assert.ErrorIs(t, err, domain.ErrIllegalTransition)
+	_, err = service.Resume(workout.WorkoutID)
+	assert.NoError(t, err)
+
+	stopped, err := service.Stop(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.WorkoutCompleted, stopped.State)
+	_, err = service.Stop(workout.WorkoutID)
+	assert.ErrorIs(t, err, ports.ErrWorkoutAlreadyCompleted)
+
+	transitions, err := service.Transitions(workout.WorkoutID)
+	assert.NoError(t, err)
+	expected := []struct {
+		event domain.WorkoutEvent
+		from  domain.WorkoutState
+		to    domain.WorkoutState
+	}{
+		{domain.EventStart, domain.WorkoutCreated, domain.WorkoutRunning},
+		{domain.EventStartOption, domain.WorkoutRunning, domain.WorkoutInOption},
+		{domain.EventStopOption, domain.WorkoutInOption, domain.WorkoutRunning},
+		{domain.EventPause, domain.WorkoutRunning, domain.WorkoutPaused},
+		{domain.EventResume, domain.WorkoutPaused, domain.WorkoutRunning},
+		{domain.EventStop, domain.WorkoutRunning, domain.WorkoutCompleted},
+	}
+	if assert.Len(t, transitions, len(expected)) {
+		for i, transition := range transitions {
+			assert.Equal(t, workout.WorkoutID, transition.WorkoutID)
+			assert.Equal(t, expected[i].event, transition.Event)
+			assert.Equal(t, expected[i].from, transition.From)
+			assert.Equal(t, expected[i].to, transition.To)
+			if i > 0 {
+				assert.False(t, transition.At.Before(transitions[i-1].At))
+			}
+		}
+	}
+
+	abandonedTransitions, err := service.Transitions(unbound.WorkoutID)
+	assert.NoError(t, err)
+	if assert.Len(t, abandonedTransitions, 1) {
+		assert.Equal(t, domain.EventAbandon, abandonedTransitions[0].Event)
+	}
+
+	// Unknown workouts have no transitions
+	_, err = service.Transitions(uuid.New())
+	assert.ErrorIs(t, err, ports.ErrorWorkoutNotFound)
+}
+
+/*
+TestWorkoutService_AbandonStaleWorkouts:
+
+	This test leaves a workout paused by itself, a workout paused by its player and a workout only
+	read by its heart rate monitor without locations. It checks that the first one is abandoned
+	once it has no update for the timeout, at its last update, with its pause ended, its peripheral
+	unbound and its stats published, that the second one is kept until the longer paused timeout,
+	that the third one goes on until its readings stop too, and that their players can start again.
+*/
+
+func TestWorkoutService_AbandonStaleWorkouts(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	WorkoutEndPublisherMock := amqpsecondaryadapter.NewMockWorkoutEndPublisher()
+	store := memory.NewMemoryRepository()
+
+	autoPause := domain.AutoPause{Speed: 0.5, After: 2 * time.Minute}
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, WorkoutEndPublisherMock, 1, autoPause, domain.MaxHeartRateFormula)
+
+	userClientMock.On("GetWorkoutPreferenceOfUser", mock.Anything).Return("cardio", nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+	peripheralClientMock.On("PausePeripheralData", mock.Anything, mock.Anything).Return(nil)
+
+	timeout := 10 * time.Minute
+	pausedTimeout := 2 * time.Hour
+	start := time.Now()
+
+	// The player stops moving and the peripheral stops publishing once the workout paused by itself
+	stopped, _ := domain.NewWorkout(uuid.New(), uuid.New(), uuid.New(), false, false)
+	_, err := service.Start(&stopped, uuid.New(), false)
+	assert.NoError(t, err)
+	readAt := start.Add(-time.Hour)
+	for minute, latitude := range []float64{40.730610, 40.733610, 40.733610, 40.733610} {
+		assert.NoError(t, service.UpdateDistanceTravelled(stopped.WorkoutID, latitude, -73.935242, nil, readAt.Add(time.Duration(minute)*time.Minute)))
+	}
+	lastLocation := time.Now()
+	current, _ := service.GetWorkout(stopped.WorkoutID)
+	assert.True(t, current.AutoPaused)
+
+	// The player pauses for a break, no update comes while it lasts
+	paused, _ := domain.NewWorkout(uuid.New(), uuid.New(), uuid.New(), false, false)
+	_, err = service.Start(&paused, uuid.New(), false)
+	assert.NoError(t, err)
+	assert.NoError(t, service.UpdateDistanceTravelled(paused.WorkoutID, 40.730610, -73.935242, nil, start))
+	_, err = service.Pause(paused.WorkoutID)
+	assert.NoError(t, err)
+	pausedAt := time.Now()
+
+	// The heart rate monitor is still read after the locations stopped
+	monitored, _ := domain.NewWorkout(uuid.New(), uuid.New(), uuid.New(), true, false)
+	_, err = service.Start(&monitored, uuid.New(), true)
+	assert.NoError(t, err)
+	lastReading := start.Add(12 * time.Minute)
+	peripheralClientMock.On("GetHeartRateSamples", monitored.WorkoutID).Return([]domain.HeartRateSample{
+		{HeartRate: 120, TimeOfReading: start.Add(time.Minute)},
+		{HeartRate: 125, TimeOfReading: lastReading},
+	}, nil)
+
+	// Nothing is stale before the timeout
+	abandoned, err := service.AbandonStaleWorkouts(start.Add(5*time.Minute), timeout, pausedTimeout)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, abandoned)
+
+	// Only the workout paused by itself is abandoned after the timeout
+	abandoned, err = service.AbandonStaleWorkouts(pausedAt.Add(15*time.Minute), timeout, pausedTimeout)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, abandoned)
+
+	stale, _ := service.GetWorkout(stopped.WorkoutID)
+	assert.Equal(t, domain.WorkoutAbandoned, stale.State)
+	assert.True(t, stale.IsCompleted)
+	assert.False(t, stale.IsPaused())
+	assert.False(t, stale.EndedAt.Before(start))
+	assert.False(t, stale.EndedAt.After(lastLocation))
+	peripheralClientMock.AssertCalled(t, "UnbindPeripheralData", stopped.WorkoutID)
+	if assert.Len(t, WorkoutStatsPublisherMock.PublishedWorkouts, 1) {
+		assert.Equal(t, stopped.WorkoutID, WorkoutStatsPublisherMock.PublishedWorkouts[0].WorkoutID)
+		assert.Equal(t, domain.WorkoutAbandoned, WorkoutStatsPublisherMock.PublishedWorkouts[0].State)
+	}
+	pauses, err := store.GetWorkoutPauses(stopped.WorkoutID)
+	assert.NoError(t, err)
+	if assert.Len(t, pauses, 1) {
+		assert.True(t, pauses[0].Auto)
+		assert.Equal(t, stale.EndedAt, pauses[0].EndedAt)
+	}
+	transitions, err := service.Transitions(stopped.WorkoutID)
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, transitions) {
+		assert.Equal(t, domain.EventAbandon, transitions[len(transitions)-1].Event)
+	}
+
+	// The workout read by its heart rate monitor goes on until the readings stop too
+	current, _ = service.GetWorkout(monitored.WorkoutID)
+	assert.Equal(t, domain.WorkoutRunning, current.State)
+	abandoned, err = service.AbandonStaleWorkouts(lastReading.Add(timeout), timeout, pausedTimeout)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, abandoned)
+	current, _ = service.GetWorkout(monitored.WorkoutID)
+	assert.Equal(t, domain.WorkoutAbandoned, current.State)
+	assert.Equal(t, lastReading, current.EndedAt)
+	peripheralClientMock.AssertCalled(t, "UnbindPeripheralData", monitored.WorkoutID)
+
+	// The workout paused by its player is kept until the paused timeout, then ends when it was paused
+	current, _ = service.GetWorkout(paused.WorkoutID)
+	assert.Equal(t, domain.WorkoutPaused, current.State)
+	peripheralClientMock.AssertNotCalled(t, "UnbindPeripheralData", paused.WorkoutID)
+	abandoned, err = service.AbandonStaleWorkouts(pausedAt.Add(pausedTimeout), timeout, pausedTimeout)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, abandoned)
+	current, _ = service.GetWorkout(paused.WorkoutID)
+	assert.Equal(t, domain.WorkoutAbandoned, current.State)
+	assert.False(t, current.EndedAt.Before(start))
+	assert.False(t, current.EndedAt.After(pausedAt))
+	pauses, err = store.GetWorkoutPauses(paused.WorkoutID)
+	assert.NoError(t, err)
+	if assert.Len(t, pauses, 1) {
+		assert.False(t, pauses[0].Auto)
+		assert.Equal(t, current.EndedAt, pauses[0].EndedAt)
+	}
+
+	// Abandoned workouts are not abandoned twice, and their players can start again
+	abandoned, err = service.AbandonStaleWorkouts(pausedAt.Add(2*pausedTimeout), timeout, pausedTimeout)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, abandoned)
+	assert.Len(t, WorkoutStatsPublisherMock.PublishedWorkouts, 3)
+	for _, playerID := range []uuid.UUID{stopped.PlayerID, paused.PlayerID} {
+		again, _ := domain.NewWorkout(playerID, uuid.New(), uuid.New(), false, false)
+		_, err = service.Start(&again, uuid.New(), false)
+		assert.NoError(t, err)
+	}
+}
+
+/*
+TestWorkoutService_MonitorStaleWorkoutsSweep:
+
+	This test starts the stale workout monitor with a zero and a negative sweep interval. It checks
+	that the monitor refuses them with an error instead of panicking.
+*/
+
+func TestWorkoutService_MonitorStaleWorkoutsSweep(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	WorkoutEndPublisherMock := amqpsecondaryadapter.NewMockWorkoutEndPublisher()
+	store := memory.NewMemoryRepository()
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, WorkoutEndPublisherMock, 1, domain.AutoPause{}, domain.MaxHeartRateFormula)
+
+	for _, sweep := range []time.Duration{0, -time.Minute} {
+		assert.NotPanics(t, func() {
+			err := service.MonitorStaleWorkouts(30*time.Minute, 12*time.Hour, sweep)
+			assert.ErrorIs(t, err, ports.ErrorInvalidStaleWorkoutSweep)
+		})
+	}
+}
+
+/*
+TestWorkoutService_HeartRateZones:
+
+	This test reads the heart rate zones of a workout while it is in progress and once it is
+	stopped, and checks that the readings are kept when the peripheral is unbound, that the
+	Karvonen zones use the resting heart rate of the player, and that an unknown formula is
+	refused.
+*/
+
+func TestWorkoutService_HeartRateZones(t *testing.T) {
+	// Initialize the mocks and the service
+	userClientMock := clients.NewUserServiceClientMock()
+	peripheralClientMock := clients.NewPeripheralClientMock()
+	WorkoutStatsPublisherMock := amqpsecondaryadapter.NewMockWorkoutStatsPublisher()
+	ShelterReservationPublisherMock := amqpsecondaryadapter.NewMockShelterReservationPublisher()
+	store := memory.NewMemoryRepository()
+
+	service := services.NewWorkoutService(store, peripheralClientMock, userClientMock, WorkoutStatsPublisherMock, ShelterReservationPublisherMock, amqpsecondaryadapter.NewMockWorkoutEndPublisher(), 1, domain.AutoPause{}, domain.MaxHeartRateFormula)
+
+	playerID := uuid.New()
+	userClientMock.On("GetWorkoutPreferenceOfUser", playerID).Return("cardio", nil)
+	userClientMock.On("GetUserAge", playerID).Return(30, nil)
+	userClientMock.On("GetRestingHeartRateOfUser", playerID).Return(60, nil)
+	peripheralClientMock.On("BindPeripheralData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	peripheralClientMock.On("UnbindPeripheralData", mock.Anything).Return(nil)
+
+	workout, _ := domain.NewWorkout(playerID, uuid.New(), uuid.New(), true, false)
+	_, err := service.Start(&workout, uuid.New(), true)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	samples := []domain.HeartRateSample{
+		{HeartRate: 100, TimeOfReading: start},
+		{HeartRate: 140, TimeOfReading: start.Add(10 * time.Second)},
+		{HeartRate: 160, TimeOfReading: start.Add(20 * time.Second)},
+		{HeartRate: 160, TimeOfReading: start.Add(40 * time.Second)},
+	}
+	// The peripheral is read while the workout is in progress and when it is stopped, never after
+	peripheralClientMock.On("GetHeartRateSamples", workout.WorkoutID).Return(samples, nil).Twice()
+
+	inProgress, err := service.HeartRateZones(workout.WorkoutID, "")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.MaxHeartRateFormula, inProgress.Formula)
+	assert.Equal(t, uint8(190), inProgress.MaxHeartRate)
+	assert.Equal(t, 10.0, inProgress.Zones[0].Time)
+	assert.Equal(t, 10.0, inProgress.Zones[2].Time)
+	assert.Equal(t, 20.0, inProgress.Zones[3].Time)
+
+	_, err = service.Stop(workout.WorkoutID)
+	assert.NoError(t, err)
+	stored, err := store.GetHeartRateSamples(workout.WorkoutID)
+	assert.NoError(t, err)
+	assert.Len(t, stored, len(samples))
+
+	stopped, err := service.HeartRateZones(workout.WorkoutID, "")
+	assert.NoError(t, err)
+	assert.Equal(t, inProgress.Zones, stopped.Zones)
+	peripheralClientMock.AssertNumberOfCalls(t, "GetHeartRateSamples", 2)
+
+	// The Karvonen zones start from the resting heart rate, 60 bpm, 160 bpm is in the third zone
+	karvonen, err := service.HeartRateZones(workout.WorkoutID, "karvonen")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.KarvonenFormula, karvonen.Formula)
+	assert.Equal(t, uint8(60), karvonen.RestingHeartRate)
+	assert.Equal(t, uint8(125), karvonen.Zones[0].MinHeartRate)
+	assert.Equal(t, 10.0, karvonen.BelowZonesTime)
+	assert.Equal(t, 10.0, karvonen.Zones[1].Time)
+	assert.Equal(t, 20.0, karvonen.Zones[2].Time)
+
+	_, err = service.HeartRateZones(workout.WorkoutID, "tanaka")
+	assert.ErrorIs(t, err, domain.ErrInvalidHeartRateFormula)
+	_, err = service.HeartRateZones(uuid.New(), "")
+	assert.ErrorIs(t, err, ports.ErrorWorkoutNotFound)
+}
@@ -6,6 +6,7 @@ import (
 	amqpPrimary "github.com/CAS735-F23/macrun-teamvsl/zone/internal/adapters/primary/amqp"
 	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/adapters/primary/http"
 	amqpSecondary "github.com/CAS735-F23/macrun-teamvsl/zone/internal/adapters/secondary/amqp"
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/adapters/secondary/clients"
 	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/adapters/secondary/repository/postgres"
 	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/services"
 	logger "github.com/CAS735-F23/macrun-teamvsl/zone/log"
@@ -13,6 +14,7 @@ import (
 	_ "github.com/lib/pq"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.uber.org/zap"
 )
 
 var cfg *config.AppConfiguration = config.Config
@@ -40,8 +42,25 @@ func main() {
 	// Initialize shelter distance publisher
 	shelterDistancePublisher := amqpSecondary.NewShelterDistancePublisher(cfg.RabbitMQ)
 
+	// Initialize off trail publisher
+	offTrailPublisher := amqpSecondary.NewOffTrailPublisher(cfg.RabbitMQ)
+
+	// Initialize shelter reservation publisher
+	shelterReservationPublisher := amqpSecondary.NewShelterReservationPublisher(cfg.RabbitMQ)
+
+	// Initialize geofence publisher
+	geofencePublisher := amqpSecondary.NewGeofencePublisher(cfg.RabbitMQ)
+
+	// Initialize the clients of the user and workout services
+	userClient := clients.NewUserServiceClient(cfg.UserClient)
+	workoutClient := clients.NewWorkoutServiceClient(cfg.WorkoutClient)
+
 	// Initialize the zone manager
-	zoneSvc, _ := services.NewZoneService(repo, shelterDistancePublisher)
+	zoneSvc, err := services.NewZoneService(repo, shelterDistancePublisher, offTrailPublisher, shelterReservationPublisher, geofencePublisher, userClient, workoutClient, cfg.OffTrailThreshold, cfg.ShelterReservationTTL, cfg.GeofenceRadius)
+	if err != nil {
+		logger.Fatal("failed to load shelters", zap.Error(err))
+	}
+	go zoneSvc.MonitorShelterReservations(cfg.ShelterReservationSweep)
 	zoneHandler := http.NewZoneHandler(router, zoneSvc)
 	zoneHandler.InitRouter()
 
@@ -49,6 +68,14 @@ func main() {
 	locationConsumer := amqpPrimary.NewLocationConsumer(cfg.RabbitMQ, zoneSvc)
 	locationConsumer.InitAMQP()
 
+	// Initialize shelter reservation consumer
+	shelterReservationConsumer := amqpPrimary.NewShelterReservationConsumer(cfg.RabbitMQ, zoneSvc)
+	shelterReservationConsumer.InitAMQP()
+
+	// Initialize workout end consumer
+	workoutEndConsumer := amqpPrimary.NewWorkoutEndConsumer(cfg.RabbitMQ, zoneSvc)
+	workoutEndConsumer.InitAMQP()
+
 	// Swagger support
 	docs.SwaggerInfo.Host = "localhost:" + cfg.Port
 	docs.SwaggerInfo.BasePath = "/api/v1"
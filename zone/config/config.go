@@ -1,6 +1,10 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"time"
+)
 
 var Config *AppConfiguration
 
@@ -9,6 +13,16 @@ type AppConfiguration struct {
 	Port     string
 	Postgres *Postgres
 	RabbitMQ *RabbitMQ
+	// distance in km from its trail after which a workout is off the trail
+	OffTrailThreshold float64
+	// how long a shelter place is held for a workout that does not release it
+	ShelterReservationTTL time.Duration
+	// how often expired shelter reservations are given back
+	ShelterReservationSweep time.Duration
+	// radius in km of the fences around the ends of trails and around shelters
+	GeofenceRadius float64
+	UserClient     string
+	WorkoutClient  string
 }
 
 type Postgres struct {
@@ -22,12 +36,18 @@ type Postgres struct {
 }
 
 type RabbitMQ struct {
-	Host                     string
-	Port                     string
-	User                     string
-	Password                 string
-	ShelterDistancePublisher string
-	LiveLocationConsumer     string
+	Host                        string
+	Port                        string
+	User                        string
+	Password                    string
+	ShelterDistancePublisher    string
+	OffTrailPublisher           string
+	ShelterReservationPublisher string
+	LiveLocationConsumer        string
+	ShelterReservationConsumer  string
+	WorkoutEndConsumer          string
+	// topic exchange of the geofence events
+	GeofenceExchange string
 }
 
 func init() {
@@ -42,19 +62,30 @@ func init() {
 	}
 
 	rabbitmq := &RabbitMQ{
-		Host:                     getEnv("RABBITMQ_HOSTNAME", "localhost"),
-		Port:                     getEnv("RABBITMQ_PORT", "5672"),
-		User:                     getEnv("RABBITMQ_USER", "guest"),
-		Password:                 getEnv("RABBITMQ_PASSWORD", "guest"),
-		ShelterDistancePublisher: getEnv("RABBITMQ_SHELTER_DISTANCE_PUBLISHER", "shelter_zone_workout_queue"),
-		LiveLocationConsumer:     getEnv("RABBITMQ_LOCATION_CONSUMER", "location_peripheral_zone_queue"),
+		Host:                        getEnv("RABBITMQ_HOSTNAME", "localhost"),
+		Port:                        getEnv("RABBITMQ_PORT", "5672"),
+		User:                        getEnv("RABBITMQ_USER", "guest"),
+		Password:                    getEnv("RABBITMQ_PASSWORD", "guest"),
+		ShelterDistancePublisher:    getEnv("RABBITMQ_SHELTER_DISTANCE_PUBLISHER", "shelter_zone_workout_queue"),
+		OffTrailPublisher:           getEnv("RABBITMQ_OFF_TRAIL_PUBLISHER", "off_trail_zone_workout_queue"),
+		ShelterReservationPublisher: getEnv("RABBITMQ_SHELTER_RESERVATION_PUBLISHER", "shelter_reservation_zone_workout_queue"),
+		LiveLocationConsumer:        getEnv("RABBITMQ_LOCATION_CONSUMER", "location_peripheral_zone_queue"),
+		ShelterReservationConsumer:  getEnv("RABBITMQ_SHELTER_RESERVATION_CONSUMER", "shelter_reservation_workout_zone_queue"),
+		WorkoutEndConsumer:          getEnv("RABBITMQ_WORKOUT_END_CONSUMER", "workout_end_workout_zone_queue"),
+		GeofenceExchange:            getEnv("RABBITMQ_GEOFENCE_EXCHANGE", "geofence_events"),
 	}
 
 	Config = &AppConfiguration{
-		Mode:     getEnv("MODE", "dev"),
-		Port:     getEnv("PORT", "8011"),
-		Postgres: postgres,
-		RabbitMQ: rabbitmq,
+		Mode:                    getEnv("MODE", "dev"),
+		Port:                    getEnv("PORT", "8011"),
+		Postgres:                postgres,
+		RabbitMQ:                rabbitmq,
+		OffTrailThreshold:       getEnvFloat("OFF_TRAIL_THRESHOLD", 0.05),
+		ShelterReservationTTL:   getEnvDuration("SHELTER_RESERVATION_TTL", 30*time.Minute),
+		ShelterReservationSweep: getEnvDuration("SHELTER_RESERVATION_SWEEP", time.Minute),
+		GeofenceRadius:          getEnvFloat("GEOFENCE_RADIUS", 0.03),
+		UserClient:              getEnv("USER_CLIENT_URL", "http://localhost:8010"),
+		WorkoutClient:           getEnv("WORKOUT_CLIENT_URL", "http://localhost:8013"),
 	}
 }
 
@@ -64,3 +95,21 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, exists := os.LookupEnv(key); exists {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
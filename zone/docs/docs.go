@@ -21,7 +21,7 @@ const docTemplate = `{
     "paths": {
         "/api/v1/zone": {
             "post": {
-                "description": "Create a new zone",
+                "description": "Create a new zone, its time_zone is the IANA time zone the opening hours of its shelters are in",
                 "consumes": [
                     "application/json"
                 ],
@@ -39,7 +39,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httphandler.ZoneDTO"
+                            "$ref": "#/definitions/http.ZoneDTO"
                         }
                     }
                 ],
@@ -71,40 +71,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/zone/{zone_id}": {
-            "put": {
-                "description": "Update details of an existing zone",
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/v1/zone/locate": {
+            "get": {
+                "description": "Find the zone whose boundary contains the given latitude and longitude, zones without a boundary are not considered",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "zone"
                 ],
-                "summary": "Update a zone",
+                "summary": "Locate the zone of a location",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Zone ID",
-                        "name": "zone_id",
-                        "in": "path",
+                        "type": "number",
+                        "description": "Latitude",
+                        "name": "lat",
+                        "in": "query",
                         "required": true
                     },
                     {
-                        "description": "Updated Zone Data",
-                        "name": "zone",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/httphandler.ZoneDTO"
-                        }
+                        "type": "number",
+                        "description": "Longitude",
+                        "name": "lon",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "status: error, message: success",
+                        "description": "zone containing the location",
+                        "schema": {
+                            "$ref": "#/definitions/http.ZoneDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: invalid latitude or longitude",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -112,8 +113,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "status: error, message: Failed",
+                    "404": {
+                        "description": "error: no zone contains the location",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -122,7 +123,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "status: error, message: Failed",
+                        "description": "error: Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -131,31 +132,36 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete an existing zone",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/api/v1/zone/session/{workout_id}": {
+            "get": {
+                "description": "Get where the workout is, the trail it follows and the last shelter published to it. The session is opened on the first location of the workout and closed when the workout is stopped.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "zone"
                 ],
-                "summary": "Delete a zone",
+                "summary": "Get the session of a workout",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Zone ID",
-                        "name": "zone_id",
+                        "description": "Workout ID",
+                        "name": "workout_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "status: error, message: success",
+                        "description": "session of the workout",
+                        "schema": {
+                            "$ref": "#/definitions/http.ZoneSessionDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: invalid workout id",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -163,8 +169,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "status: error, message: Failed",
+                    "404": {
+                        "description": "error: trail manager not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -173,7 +179,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "status: error, message: Failed",
+                        "description": "error: Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -184,9 +190,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/zone/{zone_id}/trail": {
-            "get": {
-                "description": "Get the closest trail based on given longitude and latitude in a specific zone",
+        "/api/v1/zone/{zone_id}": {
+            "put": {
+                "description": "Update details of an existing zone, the time zone is kept when time_zone is empty",
                 "consumes": [
                     "application/json"
                 ],
@@ -196,7 +202,7 @@ const docTemplate = `{
                 "tags": [
                     "zone"
                 ],
-                "summary": "Get the closest trail",
+                "summary": "Update a zone",
                 "parameters": [
                     {
                         "type": "string",
@@ -206,23 +212,18 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "number",
-                        "description": "Longitude",
-                        "name": "longitude",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "number",
-                        "description": "Latitude",
-                        "name": "latitude",
-                        "in": "query",
-                        "required": true
+                        "description": "Updated Zone Data",
+                        "name": "zone",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.ZoneDTO"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "status: success, message: closest trail, trail_id: UUID",
+                        "description": "status: error, message: success",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -231,7 +232,7 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "status: error, message: failed to retrieve closest trail",
+                        "description": "status: error, message: Failed",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -240,7 +241,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "status: error, message: Internal Server Error",
+                        "description": "status: error, message: Failed",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -250,8 +251,8 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
-                "description": "Create a trail based on given longitude and latitude within a zone",
+            "delete": {
+                "description": "Delete an existing zone",
                 "consumes": [
                     "application/json"
                 ],
@@ -261,7 +262,7 @@ const docTemplate = `{
                 "tags": [
                     "zone"
                 ],
-                "summary": "Create a trail",
+                "summary": "Delete a zone",
                 "parameters": [
                     {
                         "type": "string",
@@ -269,20 +270,11 @@ const docTemplate = `{
                         "name": "zone_id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Trail Data",
-                        "name": "trail",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/httphandler.TrailDTO"
-                        }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "status: success, message: trail created successfully, trail_id: UUID",
+                    "200": {
+                        "description": "status: error, message: success",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -291,7 +283,7 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "status: error, message: failed to create trail",
+                        "description": "status: error, message: Failed",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -300,7 +292,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "status: error, message: Internal Server Error",
+                        "description": "status: error, message: Failed",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -311,19 +303,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/zone/{zone_id}/trail/{trail_id}": {
+        "/api/v1/zone/{zone_id}/boundary": {
             "get": {
-                "description": "Retrieve detailed location information of a trail by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get the boundary of a zone as a GeoJSON Polygon or MultiPolygon",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "zone"
                 ],
-                "summary": "Get location information of a specific trail",
+                "summary": "Get the boundary of a zone",
                 "parameters": [
                     {
                         "type": "string",
@@ -331,24 +320,18 @@ const docTemplate = `{
                         "name": "zone_id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Trail ID",
-                        "name": "trail_id",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "UUID of the closest trail",
+                        "description": "GeoJSON geometry",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "status: error, message: Failed",
+                        "description": "error: invalid zone id",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -356,8 +339,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "status: error, message: Failed",
+                    "404": {
+                        "description": "error: zone not found, zone has no boundary",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -368,7 +351,7 @@ const docTemplate = `{
                 }
             },
             "put": {
-                "description": "Update details of an existing trail within a zone",
+                "description": "Set the boundary of a zone from a GeoJSON Polygon or MultiPolygon geometry, or a Feature holding one. The trails and shelters of the zone have to be inside it.",
                 "consumes": [
                     "application/json"
                 ],
@@ -378,7 +361,7 @@ const docTemplate = `{
                 "tags": [
                     "zone"
                 ],
-                "summary": "Update a trail",
+                "summary": "Set the boundary of a zone",
                 "parameters": [
                     {
                         "type": "string",
@@ -388,34 +371,26 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Trail ID",
-                        "name": "trail_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Updated Trail Data",
-                        "name": "trail",
+                        "description": "GeoJSON geometry",
+                        "name": "boundary",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httphandler.TrailDTO"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "status: success, message: updated trail",
+                        "description": "message: zone boundary updated, boundary: GeoJSON geometry",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "status: error, message: failed to update trail",
+                        "description": "error: invalid boundary, trails or shelters outside of the boundary",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -423,8 +398,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "status: error, message: Internal Server Error",
+                    "404": {
+                        "description": "error: zone not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -433,19 +408,18 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a trail from the zone by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/api/v1/zone/{zone_id}/geojson": {
+            "get": {
+                "description": "Get the boundary, trails and shelters of a zone as a GeoJSON FeatureCollection. The boundary is a Polygon or MultiPolygon, trails are LineStrings and shelters are Points with their availability.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "zone"
                 ],
-                "summary": "Delete a specific trail",
+                "summary": "Export a zone as GeoJSON",
                 "parameters": [
                     {
                         "type": "string",
@@ -453,27 +427,17 @@ const docTemplate = `{
                         "name": "zone_id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Trail ID",
-                        "name": "trail_id",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "status: success, message: deleted trail",
+                        "description": "GeoJSON FeatureCollection",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/domain.FeatureCollection"
                         }
                     },
                     "400": {
-                        "description": "status: error, message: failed to delete trail",
+                        "description": "error: invalid zone id",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -481,8 +445,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "status: error, message: Internal Server Error",
+                    "404": {
+                        "description": "error: zone not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -491,22 +455,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/zone/{zone_id}/trail/{trail_id}/shelter": {
-            "get": {
-                "description": "Retrieve the closest shelter information based on current longitude and latitude",
-                "consumes": [
-                    "application/json"
-                ],
-                "tags": [
-                    "zone"
-                ],
-                "summary": "Get the closest shelter information",
-                "responses": {}
             },
-            "post": {
-                "description": "Create a new shelter associated with a trail in a zone",
+            "put": {
+                "description": "Create or update the boundary, trails and shelters of a zone from a GeoJSON FeatureCollection, in a single transaction. Polygons and MultiPolygons are the boundary, LineStrings are trails and Points are shelters. Trails and shelters are matched with the 'trail_id' and 'shelter_id' properties, and created when they have none. Shelters need a 'trail_id' and may have an 'availability'. Trails and shelters missing from the collection are kept. Nothing is written if a feature is invalid.",
                 "consumes": [
                     "application/json"
                 ],
@@ -516,7 +467,7 @@ const docTemplate = `{
                 "tags": [
                     "zone"
                 ],
-                "summary": "Create a shelter",
+                "summary": "Import a zone from GeoJSON",
                 "parameters": [
                     {
                         "type": "string",
@@ -526,34 +477,31 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Trail ID",
-                        "name": "trail_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Shelter Data",
-                        "name": "shelter",
+                        "description": "GeoJSON FeatureCollection",
+                        "name": "features",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httphandler.ShelterDTO"
+                            "$ref": "#/definitions/domain.FeatureCollection"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "status: success, message: shelter created successfully, shelter_id: UUID",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/http.ZoneImportDTO"
                         }
                     },
                     "400": {
-                        "description": "status: error, message: failed to create shelter",
+                        "description": "error: invalid FeatureCollection or invalid features, features: the invalid features",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "error: zone not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -562,7 +510,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "status: error, message: Internal Server Error",
+                        "description": "error: failed to import the zone",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -573,19 +521,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/zone/{zone_id}/trail/{trail_id}/shelter/{shelter_id}": {
+        "/api/v1/zone/{zone_id}/route": {
             "get": {
-                "description": "Retrieve detailed location information of a shelter by its ID in a trail",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get the shortest walking path from a location to a shelter along the trails of the zone. Trails are joined where they share a point, cross or end next to each other. The location and the shelter join the trails at their closest point.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "zone"
                 ],
-                "summary": "Get location information of a specific shelter",
+                "summary": "Route to a shelter",
                 "parameters": [
                     {
                         "type": "string",
@@ -595,32 +540,36 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Trail ID",
-                        "name": "trail_id",
-                        "in": "path",
+                        "type": "number",
+                        "description": "Latitude of the location",
+                        "name": "from_lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude of the location",
+                        "name": "from_lon",
+                        "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
                         "description": "Shelter ID",
-                        "name": "shelter_id",
-                        "in": "path",
+                        "name": "to_shelter",
+                        "in": "query",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "status: success, message: shelter location information",
+                        "description": "walking path and its distance in km",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "number"
-                            }
+                            "$ref": "#/definitions/http.RouteDTO"
                         }
                     },
                     "400": {
-                        "description": "status: error, message: failed to retrieve shelter info",
+                        "description": "error: invalid zone id, invalid latitude or longitude, invalid shelter id, shelter belongs to another zone",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -628,8 +577,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "status: error, message: Internal Server Error",
+                    "404": {
+                        "description": "error: shelter not found, no route along the trails",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -638,9 +587,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Update details of an existing shelter in a trail",
+            }
+        },
+        "/api/v1/zone/{zone_id}/trail": {
+            "get": {
+                "description": "Get the closest trail based on given longitude and latitude in a specific zone",
                 "consumes": [
                     "application/json"
                 ],
@@ -650,7 +601,7 @@ const docTemplate = `{
                 "tags": [
                     "zone"
                 ],
-                "summary": "Update a shelter",
+                "summary": "Get the closest trail",
                 "parameters": [
                     {
                         "type": "string",
@@ -660,24 +611,732 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Trail ID",
-                        "name": "trail_id",
-                        "in": "path",
+                        "type": "number",
+                        "description": "Longitude",
+                        "name": "longitude",
+                        "in": "query",
                         "required": true
                     },
                     {
-                        "description": "Updated Shelter Data",
-                        "name": "shelter",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/httphandler.ShelterDTO"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
+                        "type": "number",
+                        "description": "Latitude",
+                        "name": "latitude",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "status: success, message: closest trail, trail_id: UUID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "status: error, message: failed to retrieve closest trail",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "status: error, message: Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a trail based on given longitude and latitude within a zone",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "zone"
+                ],
+                "summary": "Create a trail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone ID",
+                        "name": "zone_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Trail Data",
+                        "name": "trail",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.TrailDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "status: success, message: trail created successfully, trail_id: UUID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "status: error, message: failed to create trail",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "status: error, message: Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/zone/{zone_id}/trail/import": {
+            "post": {
+                "description": "Create a trail within a zone from the track of a GPX or FIT file, its path, length and bounding box are stored",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "zone"
+                ],
+                "summary": "Import a trail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone ID",
+                        "name": "zone_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "GPX or FIT file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trail name, the name in the file is used if empty",
+                        "name": "trail_name",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "File format (gpx/fit), taken from the file extension if empty",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "status: success, message: trail imported successfully, trail_id: UUID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "status: error, message: failed to import trail",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "status: error, message: Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/zone/{zone_id}/trail/recommend": {
+            "get": {
+                "description": "Rank the trails of a zone for a player from their workout preference, their age and the distance of the workouts they completed in the last 90 days. Cardio players get flat trails close to their usual distance, strength players get hilly ones, and trails too hard for the age of the player come last.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "zone"
+                ],
+                "summary": "Recommend trails to a player",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone ID",
+                        "name": "zone_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Player ID",
+                        "name": "player_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "trails, best first",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/http.TrailRecommendationDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error: invalid zone id, invalid player id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error: zone not found, player not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: failed to recommend trails, something went wrong",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/zone/{zone_id}/trail/{trail_id}": {
+            "get": {
+                "description": "Retrieve detailed location information of a trail by its ID, with its difficulty from 0 to 10 computed from its length, climb and shelters",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "zone"
+                ],
+                "summary": "Get location information of a specific trail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone ID",
+                        "name": "zone_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trail ID",
+                        "name": "trail_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "UUID of the closest trail",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "status: error, message: Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "status: error, message: Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update details of an existing trail within a zone",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "zone"
+                ],
+                "summary": "Update a trail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone ID",
+                        "name": "zone_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trail ID",
+                        "name": "trail_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated Trail Data",
+                        "name": "trail",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.TrailDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "status: success, message: updated trail",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "status: error, message: failed to update trail",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "status: error, message: Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a trail from the zone by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "zone"
+                ],
+                "summary": "Delete a specific trail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone ID",
+                        "name": "zone_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trail ID",
+                        "name": "trail_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "status: success, message: deleted trail",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "status: error, message: failed to delete trail",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "status: error, message: Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/zone/{zone_id}/trail/{trail_id}/elevation": {
+            "get": {
+                "description": "Get the total climb and drop of a trail in metres and the grade of each segment of its path. Trails imported without elevation have no segments.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "zone"
+                ],
+                "summary": "Get the elevation profile of a trail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone ID",
+                        "name": "zone_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trail ID",
+                        "name": "trail_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "elevation profile",
+                        "schema": {
+                            "$ref": "#/definitions/http.ElevationProfileDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: invalid zone id, invalid trail id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error: trail not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/zone/{zone_id}/trail/{trail_id}/shelter": {
+            "get": {
+                "description": "Retrieve the closest shelter open at the given time to the current longitude and latitude, looking on the trail first, then in the zone and then anywhere. When every shelter is closed the closest one is returned as unavailable.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "zone"
+                ],
+                "summary": "Get the closest shelter information",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone ID",
+                        "name": "zone_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trail ID",
+                        "name": "trail_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude",
+                        "name": "longitude",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude",
+                        "name": "latitude",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time the shelter has to be open at, now by default",
+                        "name": "time",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "distance_to_shelter: closest shelter and the scope it was found in",
+                        "schema": {
+                            "$ref": "#/definitions/http.ShelterAvailable"
+                        }
+                    },
+                    "400": {
+                        "description": "error: invalid zone id, invalid trail id, invalid time",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error: shelter not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new shelter associated with a trail in a zone. A shelter with a capacity is available while workouts hold fewer places than its capacity.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "zone"
+                ],
+                "summary": "Create a shelter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone ID",
+                        "name": "zone_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trail ID",
+                        "name": "trail_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Shelter Data",
+                        "name": "shelter",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.ShelterDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "status: success, message: shelter created successfully, shelter_id: UUID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "status: error, message: failed to create shelter",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "status: error, message: Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/zone/{zone_id}/trail/{trail_id}/shelter/{shelter_id}": {
+            "get": {
+                "description": "Retrieve detailed location information of a shelter by its ID in a trail",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "zone"
+                ],
+                "summary": "Get location information of a specific shelter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone ID",
+                        "name": "zone_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trail ID",
+                        "name": "trail_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Shelter ID",
+                        "name": "shelter_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "longitude, latitude, shelter_availability, shelter_capacity, shelter_occupancy",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "status: error, message: failed to retrieve shelter info",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "status: error, message: Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update details of an existing shelter in a trail. The availability of a shelter with a capacity follows its occupancy.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "zone"
+                ],
+                "summary": "Update a shelter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone ID",
+                        "name": "zone_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trail ID",
+                        "name": "trail_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated Shelter Data",
+                        "name": "shelter",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.ShelterDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
                         "description": "status: success, message: shelter updated successfully",
                         "schema": {
                             "type": "object",
@@ -686,8 +1345,364 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "status: error, message: failed to update shelter",
+                    "400": {
+                        "description": "status: error, message: failed to update shelter",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "status: error, message: Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete an existing shelter from a trail in a zone",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "zone"
+                ],
+                "summary": "Delete a shelter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone ID",
+                        "name": "zone_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trail ID",
+                        "name": "trail_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Shelter ID",
+                        "name": "shelter_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "status: success, message: shelter deleted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "status: error, message: failed to delete shelter",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "status: error, message: Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/zone/{zone_id}/trail/{trail_id}/shelter/{shelter_id}/schedule": {
+            "get": {
+                "description": "Get the weekly opening hours and the dated closures of a shelter, in the time zone of its zone, and whether it is open now",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "zone"
+                ],
+                "summary": "Get the schedule of a shelter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone ID",
+                        "name": "zone_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trail ID",
+                        "name": "trail_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Shelter ID",
+                        "name": "shelter_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "schedule of the shelter",
+                        "schema": {
+                            "$ref": "#/definitions/http.ShelterScheduleDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: invalid zone id, invalid trail id, invalid shelter id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error: shelter not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace the weekly opening hours and the dated closures of a shelter. Opening hours are in the time zone of the zone and close after midnight when they close before they open. A shelter without opening hours is always open outside of its closures.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "zone"
+                ],
+                "summary": "Set the schedule of a shelter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone ID",
+                        "name": "zone_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trail ID",
+                        "name": "trail_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Shelter ID",
+                        "name": "shelter_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Schedule of the shelter",
+                        "name": "schedule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.ShelterScheduleDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "updated schedule",
+                        "schema": {
+                            "$ref": "#/definitions/http.ShelterScheduleDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: invalid opening hours or closures",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error: shelter not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: failed to update shelter schedule",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove the opening hours and the closures of a shelter, it is then always open",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "zone"
+                ],
+                "summary": "Delete the schedule of a shelter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone ID",
+                        "name": "zone_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trail ID",
+                        "name": "trail_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Shelter ID",
+                        "name": "shelter_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "empty schedule",
+                        "schema": {
+                            "$ref": "#/definitions/http.ShelterScheduleDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: invalid zone id, invalid trail id, invalid shelter id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error: shelter not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: failed to update shelter schedule",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/zone/{zone_id}/trail/{trail_id}/shelter/{shelter_id}/schedule/closure": {
+            "post": {
+                "description": "Add a dated closure to a shelter, for instance for maintenance. The shelter is closed from starts_at until ends_at whatever its opening hours.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "zone"
+                ],
+                "summary": "Close a shelter for a period",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone ID",
+                        "name": "zone_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trail ID",
+                        "name": "trail_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Shelter ID",
+                        "name": "shelter_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Closure, its closure_id is ignored",
+                        "name": "closure",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.ShelterClosureDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "updated schedule",
+                        "schema": {
+                            "$ref": "#/definitions/http.ShelterScheduleDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: closure must end after it starts",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error: shelter not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -696,7 +1711,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "status: error, message: Internal Server Error",
+                        "description": "error: failed to update shelter schedule",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -705,19 +1720,18 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/v1/zone/{zone_id}/trail/{trail_id}/shelter/{shelter_id}/schedule/closure/{closure_id}": {
             "delete": {
-                "description": "Delete an existing shelter from a trail in a zone",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Remove a dated closure from a shelter",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "zone"
                 ],
-                "summary": "Delete a shelter",
+                "summary": "Cancel a closure of a shelter",
                 "parameters": [
                     {
                         "type": "string",
@@ -739,11 +1753,24 @@ const docTemplate = `{
                         "name": "shelter_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Closure ID",
+                        "name": "closure_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "status: success, message: shelter deleted successfully",
+                        "description": "updated schedule",
+                        "schema": {
+                            "$ref": "#/definitions/http.ShelterScheduleDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: invalid closure id",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -751,8 +1778,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "status: error, message: failed to delete shelter",
+                    "404": {
+                        "description": "error: shelter not found, closure not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -761,7 +1788,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "status: error, message: Internal Server Error",
+                        "description": "error: failed to update shelter schedule",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -774,7 +1801,169 @@ const docTemplate = `{
         }
     },
     "definitions": {
-        "httphandler.ShelterDTO": {
+        "domain.Feature": {
+            "type": "object",
+            "properties": {
+                "geometry": {
+                    "type": "object"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "properties": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.FeatureCollection": {
+            "type": "object",
+            "properties": {
+                "features": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Feature"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.ElevationProfileDTO": {
+            "type": "object",
+            "properties": {
+                "ascent": {
+                    "description": "total climb and drop in metres",
+                    "type": "number"
+                },
+                "descent": {
+                    "type": "number"
+                },
+                "max_grade": {
+                    "type": "number"
+                },
+                "segments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.ElevationSegmentDTO"
+                    }
+                },
+                "trail_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.ElevationSegmentDTO": {
+            "type": "object",
+            "properties": {
+                "distance": {
+                    "description": "distance in km from the start of the trail",
+                    "type": "number"
+                },
+                "end_elevation": {
+                    "type": "number"
+                },
+                "grade": {
+                    "description": "grade in percent, negative going down",
+                    "type": "number"
+                },
+                "length": {
+                    "type": "number"
+                },
+                "start_elevation": {
+                    "type": "number"
+                }
+            }
+        },
+        "http.OpeningHoursDTO": {
+            "type": "object",
+            "properties": {
+                "closes": {
+                    "description": "closing time HH:MM, before the opening time when the shelter closes after midnight",
+                    "type": "string"
+                },
+                "opens": {
+                    "description": "opening time HH:MM in the time zone of the zone",
+                    "type": "string"
+                },
+                "weekday": {
+                    "description": "day the period starts on, 'monday' to 'sunday'",
+                    "type": "string"
+                }
+            }
+        },
+        "http.RouteDTO": {
+            "type": "object",
+            "properties": {
+                "distance": {
+                    "description": "length of the walk in km, along the trails and to and from them",
+                    "type": "number"
+                },
+                "path": {
+                    "description": "points from the location to the shelter",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.TrailPointDTO"
+                    }
+                },
+                "shelter_id": {
+                    "type": "string"
+                },
+                "zone_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.ShelterAvailable": {
+            "type": "object",
+            "properties": {
+                "distance_to_shelter": {
+                    "description": "Distance to Shelter",
+                    "type": "number"
+                },
+                "scope": {
+                    "description": "Scope the shelter was found in: 'trail', 'zone' or 'global'",
+                    "type": "string"
+                },
+                "shelter_available": {
+                    "type": "boolean"
+                },
+                "shelter_check_time": {
+                    "type": "string"
+                },
+                "shelter_id": {
+                    "description": "ShelterAvailable or not",
+                    "type": "string"
+                },
+                "workout_id": {
+                    "description": "WorkoutID for which the Shelter Availability is there or not",
+                    "type": "string"
+                }
+            }
+        },
+        "http.ShelterClosureDTO": {
+            "type": "object",
+            "properties": {
+                "closure_id": {
+                    "description": "generated when the closure is added",
+                    "type": "string"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "starts_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.ShelterDTO": {
             "type": "object",
             "properties": {
                 "latitude": {
@@ -786,18 +1975,55 @@ const docTemplate = `{
                 "shelter_availability": {
                     "type": "boolean"
                 },
+                "shelter_capacity": {
+                    "description": "places in the shelter, 0 when availability is set by hand",
+                    "type": "integer"
+                },
                 "shelter_id": {
                     "type": "string"
                 },
                 "shelter_name": {
                     "type": "string"
                 },
+                "shelter_occupancy": {
+                    "description": "places reserved by workouts, ignored on create and update",
+                    "type": "integer"
+                },
                 "trail_id": {
                     "type": "string"
                 }
             }
         },
-        "httphandler.TrailDTO": {
+        "http.ShelterScheduleDTO": {
+            "type": "object",
+            "properties": {
+                "closures": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.ShelterClosureDTO"
+                    }
+                },
+                "open_now": {
+                    "description": "whether the shelter is open now, ignored on update",
+                    "type": "boolean"
+                },
+                "opening_hours": {
+                    "description": "the shelter is always open when there are none",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.OpeningHoursDTO"
+                    }
+                },
+                "shelter_id": {
+                    "type": "string"
+                },
+                "time_zone": {
+                    "description": "time zone of the opening hours, the one of the zone, ignored on update",
+                    "type": "string"
+                }
+            }
+        },
+        "http.TrailDTO": {
             "type": "object",
             "properties": {
                 "end_latitude": {
@@ -823,9 +2049,57 @@ const docTemplate = `{
                 }
             }
         },
-        "httphandler.ZoneDTO": {
+        "http.TrailPointDTO": {
+            "type": "object",
+            "properties": {
+                "elevation": {
+                    "description": "elevation in metres, left out when it is not known",
+                    "type": "number"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                }
+            }
+        },
+        "http.TrailRecommendationDTO": {
+            "type": "object",
+            "properties": {
+                "ascent": {
+                    "type": "number"
+                },
+                "difficulty": {
+                    "description": "difficulty from 0 to 10 and its level: easy, moderate or hard",
+                    "type": "number"
+                },
+                "difficulty_level": {
+                    "type": "string"
+                },
+                "length": {
+                    "description": "length in km, 0 for straight trails, and climb in metres",
+                    "type": "number"
+                },
+                "score": {
+                    "description": "how well the trail suits the player from 0 to 1",
+                    "type": "number"
+                },
+                "trail_id": {
+                    "type": "string"
+                },
+                "trail_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.ZoneDTO": {
             "type": "object",
             "properties": {
+                "time_zone": {
+                    "description": "IANA time zone of the shelters' opening hours, such as 'America/Toronto', UTC when empty",
+                    "type": "string"
+                },
                 "zone_id": {
                     "type": "string"
                 },
@@ -833,6 +2107,71 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "http.ZoneImportDTO": {
+            "type": "object",
+            "properties": {
+                "boundary_set": {
+                    "type": "boolean"
+                },
+                "shelter_count": {
+                    "type": "integer"
+                },
+                "trail_count": {
+                    "type": "integer"
+                },
+                "zone_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.ZoneSessionDTO": {
+            "type": "object",
+            "properties": {
+                "closed": {
+                    "description": "whether the workout was stopped, and when",
+                    "type": "boolean"
+                },
+                "closed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "description": "last location of the workout",
+                    "type": "number"
+                },
+                "location_time": {
+                    "type": "string"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "shelter_distance": {
+                    "type": "number"
+                },
+                "shelter_id": {
+                    "description": "last shelter published to the workout, nil until one is published, with its distance in km and scope",
+                    "type": "string"
+                },
+                "shelter_scope": {
+                    "type": "string"
+                },
+                "trail_id": {
+                    "type": "string"
+                },
+                "workout_id": {
+                    "type": "string"
+                },
+                "zone_id": {
+                    "description": "zone and trail the workout follows, nil when it follows none",
+                    "type": "string"
+                },
+                "zone_manager_id": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,93 @@
+package amqp
+
+import (
+	"fmt"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/config"
+	logger "github.com/CAS735-F23/macrun-teamvsl/zone/log"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// dial connects to RabbitMQ, the zone manager does not start without it
+func dial(cfg *config.RabbitMQ) *amqp.Connection {
+	conn := fmt.Sprintf(
+		"amqp://%s:%s@%s:%s/",
+		cfg.User,
+		cfg.Password,
+		cfg.Host,
+		cfg.Port,
+	)
+
+	amqpConn, err := amqp.Dial(conn)
+	if err != nil {
+		logger.Fatal("unable to dial connection to RabbitMQ")
+	}
+	return amqpConn
+}
+
+// consumeQueue declares the queue and hands the body of each message to handle in workerPoolSize workers
+// until the channel is closed. The messages handle cannot read are rejected, the others are acknowledged.
+func consumeQueue(amqpConn *amqp.Connection, workerPoolSize int, queueName string, handle func(body []byte) error) error {
+	ch, err := amqpConn.Channel()
+	if err != nil {
+		return fmt.Errorf("error amqpConn.Channel %w", err)
+	}
+
+	queue, err := ch.QueueDeclare(
+		queueName,
+		queueDurable,
+		queueAutoDelete,
+		queueExclusive,
+		queueNoWait,
+		nil,
+	)
+	if err != nil {
+		ch.Close()
+		return fmt.Errorf("error ch.QueueDeclare %w", err)
+	}
+	logger.Debug("queue declared",
+		zap.String("queue_name", queue.Name),
+		zap.Int("message_count", queue.Messages),
+		zap.Int("consumer_count", queue.Consumers),
+	)
+
+	err = ch.Qos(
+		prefetchCount,  // prefetch count
+		prefetchSize,   // prefetch size
+		prefetchGlobal, // global
+	)
+	if err != nil {
+		ch.Close()
+		return fmt.Errorf("error ch.Qos %w", err)
+	}
+
+	deliveries, err := ch.Consume(
+		queue.Name,
+		"",
+		consumeAutoAck,
+		consumeExclusive,
+		consumeNoLocal,
+		consumeNoWait,
+		nil,
+	)
+	if err != nil {
+		ch.Close()
+		return fmt.Errorf("consume error %w", err)
+	}
+
+	for i := 0; i < workerPoolSize; i++ {
+		logger.Debug("Starting worker", zap.String("queue_name", queue.Name), zap.Int("worker number", i))
+		go func() {
+			for d := range deliveries {
+				if err := handle(d.Body); err != nil {
+					logger.Error("Failed to read message", zap.String("queue_name", queue.Name), zap.Error(err))
+					d.Nack(false, false)
+					continue
+				}
+				d.Ack(false)
+			}
+		}()
+	}
+	return nil
+}
@@ -9,6 +9,8 @@ import (
 type LocationDTO struct {
 	// WorkoutID for which the Shelter Availability is there or not
 	WorkoutID uuid.UUID `json:"workout_id"`
+	// Trail the workout is following, nil if it is not on a trail
+	TrailID uuid.UUID `json:"trail_id"`
 	// Latitude of the Player
 	Latitude float64 `json:"latitude"`
 	// Longitude of the Player
@@ -16,3 +18,25 @@ type LocationDTO struct {
 	// Time of location
 	TimeOfLocation time.Time `json:"time_of_location"`
 }
+
+// Actions a workout asks for on its shelter place
+const (
+	ShelterReservationReserve = "reserve"
+	ShelterReservationRelease = "release"
+)
+
+type ShelterReservationDTO struct {
+	// Workout taking or giving back a place
+	WorkoutID uuid.UUID `json:"workout_id"`
+	// Shelter to reserve, ignored on release
+	ShelterID uuid.UUID `json:"shelter_id"`
+	// 'reserve' or 'release'
+	Action string `json:"action"`
+}
+
+type WorkoutEndDTO struct {
+	// Workout that was stopped
+	WorkoutID uuid.UUID `json:"workout_id"`
+	// Time the workout was stopped
+	EndedAt time.Time `json:"ended_at"`
+}
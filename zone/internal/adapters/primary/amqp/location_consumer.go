@@ -180,7 +180,7 @@ func (lc *LocationConsumer) worker(deliveries <-chan amqp.Delivery) {
 
 		logger.Debug("Received a message and unmarshalled successfully", zap.Any("location", lastLocation))
 		// Process the message...
-		err = lc.svc.UpdateCurrentLocation(lastLocation.WorkoutID, lastLocation.Latitude, lastLocation.Longitude, lastLocation.TimeOfLocation)
+		err = lc.svc.UpdateCurrentLocation(lastLocation.WorkoutID, lastLocation.TrailID, lastLocation.Latitude, lastLocation.Longitude, lastLocation.TimeOfLocation)
 		if err != nil {
 			logger.Error("Failed to update current location", zap.Error(err))
 		}
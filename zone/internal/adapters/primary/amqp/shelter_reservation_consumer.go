@@ -0,0 +1,55 @@
+package amqp
+
+import (
+	"encoding/json"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/config"
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/services"
+	logger "github.com/CAS735-F23/macrun-teamvsl/zone/log"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// Shelter reservation AMQP consumer
+type ShelterReservationConsumer struct {
+	amqpConn *amqp.Connection
+	svc      *services.ZoneService
+	config   *config.RabbitMQ
+}
+
+func NewShelterReservationConsumer(cfg *config.RabbitMQ, zoneSvc *services.ZoneService) *ShelterReservationConsumer {
+	return &ShelterReservationConsumer{
+		amqpConn: dial(cfg),
+		svc:      zoneSvc,
+		config:   cfg,
+	}
+}
+
+func (rc *ShelterReservationConsumer) InitAMQP() {
+	if err := consumeQueue(rc.amqpConn, 1, rc.config.ShelterReservationConsumer, rc.handle); err != nil {
+		logger.Error("Failed to consume shelter reservation queue", zap.Error(err))
+	}
+}
+
+// handle reserves or releases the place asked by a workout, it fails only when the request cannot be read
+func (rc *ShelterReservationConsumer) handle(body []byte) error {
+	var request ShelterReservationDTO
+	if err := json.Unmarshal(body, &request); err != nil {
+		return err
+	}
+
+	logger.Debug("Received a message and unmarshalled successfully", zap.Any("reservation", request))
+	var err error
+	switch request.Action {
+	case ShelterReservationReserve:
+		_, err = rc.svc.ReserveShelter(request.WorkoutID, request.ShelterID)
+	case ShelterReservationRelease:
+		err = rc.svc.ReleaseShelter(request.WorkoutID)
+	default:
+		logger.Error("Unknown shelter reservation action", zap.String("action", request.Action))
+	}
+	if err != nil {
+		logger.Error("Failed to handle shelter reservation", zap.Error(err))
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package amqp
+
+import (
+	"encoding/json"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/config"
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/services"
+	logger "github.com/CAS735-F23/macrun-teamvsl/zone/log"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// Workout end AMQP consumer
+type WorkoutEndConsumer struct {
+	amqpConn *amqp.Connection
+	svc      *services.ZoneService
+	config   *config.RabbitMQ
+}
+
+func NewWorkoutEndConsumer(cfg *config.RabbitMQ, zoneSvc *services.ZoneService) *WorkoutEndConsumer {
+	return &WorkoutEndConsumer{
+		amqpConn: dial(cfg),
+		svc:      zoneSvc,
+		config:   cfg,
+	}
+}
+
+func (wc *WorkoutEndConsumer) InitAMQP() {
+	if err := consumeQueue(wc.amqpConn, 1, wc.config.WorkoutEndConsumer, wc.handle); err != nil {
+		logger.Error("Failed to consume workout end queue", zap.Error(err))
+	}
+}
+
+// handle closes the zone manager session of the workout that ended, it fails only when the message cannot
+// be read
+func (wc *WorkoutEndConsumer) handle(body []byte) error {
+	var request WorkoutEndDTO
+	if err := json.Unmarshal(body, &request); err != nil {
+		return err
+	}
+
+	logger.Debug("Received a message and unmarshalled successfully", zap.Any("workout_end", request))
+	if err := wc.svc.CloseZoneManager(request.WorkoutID, request.EndedAt); err != nil {
+		logger.Error("Failed to close the zone manager session", zap.Error(err))
+	}
+	return nil
+}
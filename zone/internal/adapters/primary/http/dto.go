@@ -15,6 +15,8 @@ type ShelterAvailable struct {
 	// Distance to Shelter
 	DistanceToShelter float64   `json:"distance_to_shelter"`
 	ShelterCheckTime  time.Time `json:"shelter_check_time"`
+	// Scope the shelter was found in: 'trail', 'zone' or 'global'
+	Scope string `json:"scope"`
 }
 
 type LocationDTO struct {
@@ -36,6 +38,8 @@ type UserData struct {
 type ZoneDTO struct {
 	ZoneID   uuid.UUID `json:"zone_id"`
 	ZoneName string    `json:"zone_name"`
+	// IANA time zone of the shelters' opening hours, such as 'America/Toronto', UTC when empty
+	TimeZone string `json:"time_zone"`
 }
 
 type ShelterDTO struct {
@@ -45,6 +49,38 @@ type ShelterDTO struct {
 	ShelterAvailability bool      `json:"shelter_availability"`
 	Longitude           float64   `json:"longitude"`
 	Latitude            float64   `json:"latitude"`
+	// places in the shelter, 0 when availability is set by hand
+	ShelterCapacity uint16 `json:"shelter_capacity"`
+	// places reserved by workouts, ignored on create and update
+	ShelterOccupancy uint16 `json:"shelter_occupancy"`
+}
+
+type OpeningHoursDTO struct {
+	// day the period starts on, 'monday' to 'sunday'
+	Weekday string `json:"weekday"`
+	// opening time HH:MM in the time zone of the zone
+	Opens string `json:"opens"`
+	// closing time HH:MM, before the opening time when the shelter closes after midnight
+	Closes string `json:"closes"`
+}
+
+type ShelterClosureDTO struct {
+	// generated when the closure is added
+	ClosureID uuid.UUID `json:"closure_id"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Reason    string    `json:"reason"`
+}
+
+type ShelterScheduleDTO struct {
+	ShelterID uuid.UUID `json:"shelter_id"`
+	// time zone of the opening hours, the one of the zone, ignored on update
+	TimeZone string `json:"time_zone"`
+	// the shelter is always open when there are none
+	OpeningHours []OpeningHoursDTO   `json:"opening_hours"`
+	Closures     []ShelterClosureDTO `json:"closures"`
+	// whether the shelter is open now, ignored on update
+	OpenNow bool `json:"open_now"`
 }
 
 type TrailDTO struct {
@@ -56,3 +92,91 @@ type TrailDTO struct {
 	EndLongitude   float64   `json:"end_longitude"`
 	EndLatitude    float64   `json:"end_latitude"`
 }
+
+type TrailPointDTO struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// elevation in metres, left out when it is not known
+	Elevation *float64 `json:"elevation,omitempty"`
+}
+
+type ElevationSegmentDTO struct {
+	// distance in km from the start of the trail
+	Distance       float64 `json:"distance"`
+	Length         float64 `json:"length"`
+	StartElevation float64 `json:"start_elevation"`
+	EndElevation   float64 `json:"end_elevation"`
+	// grade in percent, negative going down
+	Grade float64 `json:"grade"`
+}
+
+type ElevationProfileDTO struct {
+	TrailID uuid.UUID `json:"trail_id"`
+	// total climb and drop in metres
+	Ascent   float64               `json:"ascent"`
+	Descent  float64               `json:"descent"`
+	MaxGrade float64               `json:"max_grade"`
+	Segments []ElevationSegmentDTO `json:"segments"`
+}
+
+type TrailRecommendationDTO struct {
+	TrailID   uuid.UUID `json:"trail_id"`
+	TrailName string    `json:"trail_name"`
+	// length in km, 0 for straight trails, and climb in metres
+	Length float64 `json:"length"`
+	Ascent float64 `json:"ascent"`
+	// difficulty from 0 to 10 and its level: easy, moderate or hard
+	Difficulty      float64 `json:"difficulty"`
+	DifficultyLevel string  `json:"difficulty_level"`
+	// how well the trail suits the player from 0 to 1
+	Score float64 `json:"score"`
+}
+
+type BoundingBoxDTO struct {
+	MinLatitude  float64 `json:"min_latitude"`
+	MinLongitude float64 `json:"min_longitude"`
+	MaxLatitude  float64 `json:"max_latitude"`
+	MaxLongitude float64 `json:"max_longitude"`
+}
+
+type RouteDTO struct {
+	ZoneID    uuid.UUID `json:"zone_id"`
+	ShelterID uuid.UUID `json:"shelter_id"`
+	// length of the walk in km, along the trails and to and from them
+	Distance float64 `json:"distance"`
+	// points from the location to the shelter
+	Path []TrailPointDTO `json:"path"`
+}
+
+type FeatureErrorDTO struct {
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+type ZoneImportDTO struct {
+	ZoneID       uuid.UUID `json:"zone_id"`
+	BoundarySet  bool      `json:"boundary_set"`
+	TrailCount   int       `json:"trail_count"`
+	ShelterCount int       `json:"shelter_count"`
+}
+
+type ZoneSessionDTO struct {
+	ZoneManagerID uuid.UUID `json:"zone_manager_id"`
+	WorkoutID     uuid.UUID `json:"workout_id"`
+	// zone and trail the workout follows, nil when it follows none
+	ZoneID  uuid.UUID `json:"zone_id"`
+	TrailID uuid.UUID `json:"trail_id"`
+	// last location of the workout
+	Latitude     float64   `json:"latitude"`
+	Longitude    float64   `json:"longitude"`
+	LocationTime time.Time `json:"location_time"`
+	// last shelter published to the workout, nil until one is published, with its distance in km and scope
+	ShelterID       uuid.UUID `json:"shelter_id"`
+	ShelterDistance float64   `json:"shelter_distance"`
+	ShelterScope    string    `json:"shelter_scope,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	// whether the workout was stopped, and when
+	Closed   bool       `json:"closed"`
+	ClosedAt *time.Time `json:"closed_at,omitempty"`
+}
@@ -1,10 +1,16 @@
 package http
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/ports"
 	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/services"
 	"github.com/google/uuid"
 
@@ -29,7 +35,10 @@ func (handler *ZoneHandler) InitRouter() {
 
 	router.GET("zone/:zone_id/trail", handler.GetClosestTrail)
 	router.GET("zone/:zone_id/trail/:trail_id", handler.GetTrailLocationInfo)
+	router.GET("/zone/:zone_id/trail/recommend", handler.RecommendTrails)
+	router.GET("/zone/:zone_id/trail/:trail_id/elevation", handler.GetTrailElevation)
 	router.POST("/zone/:zone_id/trail", handler.CreateTrail)
+	router.POST("/zone/:zone_id/trail/import", handler.ImportTrail)
 	router.PUT("/zone/:zone_id/trail/:trail_id", handler.UpdateTrail)
 	router.DELETE("/zone/:zone_id/trail/:trail_id", handler.DeleteTrail)
 
@@ -40,6 +49,19 @@ func (handler *ZoneHandler) InitRouter() {
 	router.POST("/zone/:zone_id/trail/:trail_id/shelter", handler.CreateShelter)
 	router.PUT("/zone/:zone_id/trail/:trail_id/shelter/:shelter_id", handler.UpdateShelter)
 	router.DELETE("/zone/:zone_id/trail/:trail_id/shelter/:shelter_id", handler.DeleteShelter)
+	router.GET("/zone/:zone_id/trail/:trail_id/shelter/:shelter_id/schedule", handler.GetShelterSchedule)
+	router.PUT("/zone/:zone_id/trail/:trail_id/shelter/:shelter_id/schedule", handler.SetShelterSchedule)
+	router.DELETE("/zone/:zone_id/trail/:trail_id/shelter/:shelter_id/schedule", handler.DeleteShelterSchedule)
+	router.POST("/zone/:zone_id/trail/:trail_id/shelter/:shelter_id/schedule/closure", handler.AddShelterClosure)
+	router.DELETE("/zone/:zone_id/trail/:trail_id/shelter/:shelter_id/schedule/closure/:closure_id", handler.DeleteShelterClosure)
+
+	router.GET("/zone/locate", handler.LocateZone)
+	router.GET("/zone/session/:workout_id", handler.GetZoneSession)
+	router.GET("/zone/:zone_id/route", handler.RouteToShelter)
+	router.GET("/zone/:zone_id/boundary", handler.GetZoneBoundary)
+	router.PUT("/zone/:zone_id/boundary", handler.SetZoneBoundary)
+	router.GET("/zone/:zone_id/geojson", handler.ExportZoneGeoJSON)
+	router.PUT("/zone/:zone_id/geojson", handler.ImportZoneGeoJSON)
 
 	router.POST("/zone", handler.CreateZone)
 	router.PUT("/zone/:zone_id", handler.UpdateZone)
@@ -50,9 +72,18 @@ func (handler *ZoneHandler) InitRouter() {
 // GetClosestShelterInfo
 //
 //	@Summary		Get the closest shelter information
-//	@Description	Retrieve the closest shelter information based on current longitude and latitude
+//	@Description	Retrieve the closest shelter open at the given time to the current longitude and latitude, looking on the trail first, then in the zone and then anywhere. When every shelter is closed the closest one is returned as unavailable.
 //	@Tags			zone
 //	@Accept			json
+//	@Produce		json
+//	@Param			zone_id		path		string				true	"Zone ID"
+//	@Param			trail_id	path		string				true	"Trail ID"
+//	@Param			longitude	query		float64				true	"Longitude"
+//	@Param			latitude	query		float64				true	"Latitude"
+//	@Param			time		query		string				false	"RFC 3339 time the shelter has to be open at, now by default"
+//	@Success		200			{object}	ShelterAvailable	"distance_to_shelter: closest shelter and the scope it was found in"
+//	@Failure		400			{object}	map[string]string	"error: invalid zone id, invalid trail id, invalid time"
+//	@Failure		404			{object}	map[string]string	"error: shelter not found"
 //	@Router			/api/v1/zone/{zone_id}/trail/{trail_id}/shelter [get]
 func (s *ZoneHandler) GetClosestShelterInfo(ctx *gin.Context) {
 
@@ -62,7 +93,7 @@ func (s *ZoneHandler) GetClosestShelterInfo(ctx *gin.Context) {
 	longitude, _ := strconv.ParseFloat(longitudeStr, 64)
 	latitude, _ := strconv.ParseFloat(latitudeStr, 64)
 
-	_, err := uuid.Parse(zoneIdStr)
+	zId, err := uuid.Parse(zoneIdStr)
 	if err != nil {
 
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid zone id"})
@@ -71,35 +102,35 @@ func (s *ZoneHandler) GetClosestShelterInfo(ctx *gin.Context) {
 	}
 
 	trailIdStr := ctx.Param("trail_id")
-	_, err = uuid.Parse(trailIdStr)
+	tId, err := uuid.Parse(trailIdStr)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid zone id"})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid trail id"})
 		return
 
 	}
 
-	shelterIdStr := ctx.Param("shelter_id")
-	var id uuid.UUID
-	id, err = uuid.Parse(shelterIdStr)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid trail id"})
-		return
-
+	at := time.Now()
+	if timeStr := ctx.Query("time"); timeStr != "" {
+		at, err = time.Parse(time.RFC3339, timeStr)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid time"})
+			return
+		}
 	}
 
-	sId, distance, availability, time, err := s.tvc.GetClosestShelter(longitude, latitude, time.Now())
-	var shelterDataInstance ShelterAvailable
-	shelterDataInstance.WorkoutID = id
-	shelterDataInstance.ShelterID = sId
-	shelterDataInstance.ShelterAvailable = availability
-	shelterDataInstance.ShelterAvailable = (sId == uuid.Nil)
-	shelterDataInstance.DistanceToShelter = distance
-	shelterDataInstance.ShelterCheckTime = time
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "shelter not found"})
+	closest, scope, err := s.tvc.GetClosestShelterInScope(tId, zId, latitude, longitude, at)
+	if err != nil || closest == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "shelter not found"})
 		return
 	}
 
+	var shelterDataInstance ShelterAvailable
+	shelterDataInstance.ShelterID = closest.Shelter.ShelterID
+	shelterDataInstance.ShelterAvailable = closest.Shelter.ShelterAvailability
+	shelterDataInstance.DistanceToShelter = closest.Distance
+	shelterDataInstance.ShelterCheckTime = at
+	shelterDataInstance.Scope = scope
+
 	ctx.JSON(http.StatusOK, gin.H{"distance_to_shelter": shelterDataInstance})
 
 }
@@ -140,6 +171,10 @@ func (s *ZoneHandler) CreateTrail(ctx *gin.Context) {
 	endLatitude := trailDataInstance.EndLatitude
 
 	tId, err := s.tvc.CreateTrail(name, zId, startLatitude, startLongitude, endLatitude, endLongitude)
+	if errors.Is(err, domain.ErrOutsideZone) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create trail, something went wrong"})
 		return
@@ -187,6 +222,10 @@ func (s *ZoneHandler) UpdateTrail(ctx *gin.Context) {
 	endLatitude, _ := strconv.ParseFloat(ctx.Query("end_latitude"), 64)
 
 	err := s.tvc.UpdateTrail(id, name, zId, startLatitude, startLongitude, endLatitude, endLongitude)
+	if errors.Is(err, domain.ErrOutsideZone) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update trail, something went wrong"})
 		return
@@ -288,7 +327,7 @@ func (t *ZoneHandler) GetClosestTrail(ctx *gin.Context) {
 // GetTrailLocationInfo
 //
 //	@Summary		Get location information of a specific trail
-//	@Description	Retrieve detailed location information of a trail by its ID
+//	@Description	Retrieve detailed location information of a trail by its ID, with its difficulty from 0 to 10 computed from its length, climb and shelters
 //	@Tags			zone
 //	@Accept			json
 //	@Produce		json
@@ -312,7 +351,7 @@ func (t *ZoneHandler) GetTrailLocationInfo(ctx *gin.Context) {
 	tId, errT := uuid.Parse(trailIdStr)
 	if errT != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid trail id"})
-
+		return
 	}
 
 	// Assuming you have a method to find the closest trails by coordinates
@@ -321,16 +360,208 @@ func (t *ZoneHandler) GetTrailLocationInfo(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve trail info, something went wrong"})
 		return
 	}
+	difficulty, err := t.tvc.GetTrailDifficulty(trail)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve trail info, something went wrong"})
+		return
+	}
 
 	// Respond with the ID of the closest trail
 	ctx.JSON(http.StatusOK, gin.H{"start_longitude": trail.StartLongitude, "start_latitude": trail.StartLatitude,
-		"end_longitude": trail.EndLongitude, "end_latitude": trail.EndLatitude})
+		"end_longitude": trail.EndLongitude, "end_latitude": trail.EndLatitude,
+		"length": trail.Length, "ascent": trail.Ascent, "descent": trail.Descent, "max_grade": trail.MaxGrade,
+		"difficulty": difficulty, "difficulty_level": domain.DifficultyLevel(difficulty),
+		"bounding_box": toBoundingBoxDTO(trail.BoundingBox), "path": toTrailPathDTO(trail.Path)})
+}
+
+// ImportTrail
+//
+//	@Summary		Import a trail
+//	@Description	Create a trail within a zone from the track of a GPX or FIT file, its path, length and bounding box are stored
+//	@Tags			zone
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			zone_id		path		string				true	"Zone ID"
+//	@Param			file		formData	file				true	"GPX or FIT file"
+//	@Param			trail_name	formData	string				false	"Trail name, the name in the file is used if empty"
+//	@Param			format		query		string				false	"File format (gpx/fit), taken from the file extension if empty"
+//	@Success		201			{object}	map[string]string	"status: success, message: trail imported successfully, trail_id: UUID"
+//	@Failure		400			{object}	map[string]string	"status: error, message: failed to import trail"
+//	@Failure		500			{object}	map[string]string	"status: error, message: Internal Server Error"
+//	@Router			/api/v1/zone/{zone_id}/trail/import [post]
+func (s *ZoneHandler) ImportTrail(ctx *gin.Context) {
+
+	zoneIdStr := ctx.Param("zone_id")
+	zId, errZ := uuid.Parse(zoneIdStr)
+	if errZ != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid zone id"})
+		return
+
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "trail file is missing"})
+		return
+	}
+
+	format := ctx.Query("format")
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(fileHeader.Filename), ".")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "trail file could not be read"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "trail file could not be read"})
+		return
+	}
+
+	trail, err := s.tvc.ImportTrail(zId, ctx.PostForm("trail_name"), format, data)
+	if err != nil {
+		if errors.Is(err, domain.ErrUnsupportedTrailFormat) || errors.Is(err, domain.ErrInvalidTrailFile) || errors.Is(err, domain.ErrInvalidTrailPath) || errors.Is(err, domain.ErrOutsideZone) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import trail, something went wrong"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "trail imported successfully", "trail_id": trail.TrailID, "trail_name": trail.TrailName,
+		"length": trail.Length, "ascent": trail.Ascent, "descent": trail.Descent, "max_grade": trail.MaxGrade,
+		"bounding_box": toBoundingBoxDTO(trail.BoundingBox), "points": len(trail.Path)})
+}
+
+func toBoundingBoxDTO(box domain.BoundingBox) BoundingBoxDTO {
+	return BoundingBoxDTO{
+		MinLatitude:  box.MinLatitude,
+		MinLongitude: box.MinLongitude,
+		MaxLatitude:  box.MaxLatitude,
+		MaxLongitude: box.MaxLongitude,
+	}
+}
+
+// GetTrailElevation
+//
+//	@Summary		Get the elevation profile of a trail
+//	@Description	Get the total climb and drop of a trail in metres and the grade of each segment of its path. Trails imported without elevation have no segments.
+//	@Tags			zone
+//	@Produce		json
+//	@Param			zone_id		path		string				true	"Zone ID"
+//	@Param			trail_id	path		string				true	"Trail ID"
+//	@Success		200			{object}	ElevationProfileDTO	"elevation profile"
+//	@Failure		400			{object}	map[string]string	"error: invalid zone id, invalid trail id"
+//	@Failure		404			{object}	map[string]string	"error: trail not found"
+//	@Router			/api/v1/zone/{zone_id}/trail/{trail_id}/elevation [get]
+func (h *ZoneHandler) GetTrailElevation(ctx *gin.Context) {
+	if _, err := uuid.Parse(ctx.Param("zone_id")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid zone id"})
+		return
+	}
+	tId, err := uuid.Parse(ctx.Param("trail_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid trail id"})
+		return
+	}
+
+	trail, err := h.tvc.GetTrailByID(tId)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "trail not found"})
+		return
+	}
+
+	profile := trail.ElevationProfile()
+	segments := make([]ElevationSegmentDTO, len(profile))
+	for i, segment := range profile {
+		segments[i] = ElevationSegmentDTO{
+			Distance:       segment.Distance,
+			Length:         segment.Length,
+			StartElevation: segment.StartElevation,
+			EndElevation:   segment.EndElevation,
+			Grade:          segment.Grade,
+		}
+	}
+	ctx.JSON(http.StatusOK, ElevationProfileDTO{
+		TrailID:  trail.TrailID,
+		Ascent:   trail.Ascent,
+		Descent:  trail.Descent,
+		MaxGrade: trail.MaxGrade,
+		Segments: segments,
+	})
+}
+
+// RecommendTrails
+//
+//	@Summary		Recommend trails to a player
+//	@Description	Rank the trails of a zone for a player from their workout preference, their age and the distance of the workouts they completed in the last 90 days. Cardio players get flat trails close to their usual distance, strength players get hilly ones, and trails too hard for the age of the player come last.
+//	@Tags			zone
+//	@Produce		json
+//	@Param			zone_id		path		string					true	"Zone ID"
+//	@Param			player_id	query		string					true	"Player ID"
+//	@Success		200			{array}		TrailRecommendationDTO	"trails, best first"
+//	@Failure		400			{object}	map[string]string		"error: invalid zone id, invalid player id"
+//	@Failure		404			{object}	map[string]string		"error: zone not found, player not found"
+//	@Failure		500			{object}	map[string]string		"error: failed to recommend trails, something went wrong"
+//	@Router			/api/v1/zone/{zone_id}/trail/recommend [get]
+func (h *ZoneHandler) RecommendTrails(ctx *gin.Context) {
+	zId, err := uuid.Parse(ctx.Param("zone_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid zone id"})
+		return
+	}
+	playerID, err := uuid.Parse(ctx.Query("player_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid player id"})
+		return
+	}
+
+	if err := h.tvc.CheckZone(zId); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "zone not found"})
+		return
+	}
+	recommendations, err := h.tvc.RecommendTrails(zId, playerID)
+	if errors.Is(err, ports.ErrorPlayerNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to recommend trails, something went wrong"})
+		return
+	}
+
+	response := make([]TrailRecommendationDTO, len(recommendations))
+	for i, recommendation := range recommendations {
+		response[i] = TrailRecommendationDTO{
+			TrailID:         recommendation.Trail.TrailID,
+			TrailName:       recommendation.Trail.TrailName,
+			Length:          recommendation.Trail.Length,
+			Ascent:          recommendation.Trail.Ascent,
+			Difficulty:      recommendation.Difficulty,
+			DifficultyLevel: domain.DifficultyLevel(recommendation.Difficulty),
+			Score:           recommendation.Score,
+		}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+func toTrailPathDTO(path []domain.TrailPoint) []TrailPointDTO {
+	pathDTO := make([]TrailPointDTO, len(path))
+	for i, point := range path {
+		pathDTO[i] = TrailPointDTO{Latitude: point.Latitude, Longitude: point.Longitude, Elevation: point.Elevation}
+	}
+	return pathDTO
 }
 
 // CreateShelter
 //
 //	@Summary		Create a shelter
-//	@Description	Create a new shelter associated with a trail in a zone
+//	@Description	Create a new shelter associated with a trail in a zone. A shelter with a capacity is available while workouts hold fewer places than its capacity.
 //	@Tags			zone
 //	@Accept			json
 //	@Produce		json
@@ -368,7 +599,11 @@ func (t *ZoneHandler) CreateShelter(ctx *gin.Context) {
 	longitude := shelterDataInstance.Longitude
 	latitude := shelterDataInstance.Latitude
 
-	sId, err := t.tvc.CreateShelter(name, tId, true, longitude, latitude)
+	sId, err := t.tvc.CreateShelter(name, tId, true, shelterDataInstance.ShelterCapacity, latitude, longitude)
+	if errors.Is(err, domain.ErrOutsideZone) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create shelter, something went wrong"})
 		return
@@ -380,7 +615,7 @@ func (t *ZoneHandler) CreateShelter(ctx *gin.Context) {
 // UpdateShelter
 //
 //	@Summary		Update a shelter
-//	@Description	Update details of an existing shelter in a trail
+//	@Description	Update details of an existing shelter in a trail. The availability of a shelter with a capacity follows its occupancy.
 //	@Tags			zone
 //	@Accept			json
 //	@Produce		json
@@ -428,7 +663,11 @@ func (t *ZoneHandler) UpdateShelter(ctx *gin.Context) {
 	latitude := shelterDataInstance.Latitude
 	availability := shelterDataInstance.ShelterAvailability
 
-	err := t.tvc.UpdateShelter(sId, name, tId, availability, longitude, latitude)
+	err := t.tvc.UpdateShelter(sId, name, tId, availability, shelterDataInstance.ShelterCapacity, latitude, longitude)
+	if errors.Is(err, domain.ErrOutsideZone) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update shelter, something went wrong"})
 		return
@@ -498,12 +737,12 @@ func (t *ZoneHandler) DeleteShelter(ctx *gin.Context) {
 //	@Tags			zone
 //	@Accept			json
 //	@Produce		json
-//	@Param			zone_id		path		string				true	"Zone ID"
-//	@Param			trail_id	path		string				true	"Trail ID"
-//	@Param			shelter_id	path		string				true	"Shelter ID"
-//	@Success		200			{object}	map[string]float64	"status: success, message: shelter location information"
-//	@Failure		400			{object}	map[string]string	"status: error, message: failed to retrieve shelter info"
-//	@Failure		500			{object}	map[string]string	"status: error, message: Internal Server Error"
+//	@Param			zone_id		path		string					true	"Zone ID"
+//	@Param			trail_id	path		string					true	"Trail ID"
+//	@Param			shelter_id	path		string					true	"Shelter ID"
+//	@Success		200			{object}	map[string]interface{}	"longitude, latitude, shelter_availability, shelter_capacity, shelter_occupancy"
+//	@Failure		400			{object}	map[string]string		"status: error, message: failed to retrieve shelter info"
+//	@Failure		500			{object}	map[string]string		"status: error, message: Internal Server Error"
 //	@Router			/api/v1/zone/{zone_id}/trail/{trail_id}/shelter/{shelter_id} [get]
 func (t *ZoneHandler) GetShelterLocationInfo(ctx *gin.Context) {
 
@@ -539,13 +778,19 @@ func (t *ZoneHandler) GetShelterLocationInfo(ctx *gin.Context) {
 	}
 
 	// Respond with the ID of the closest trail
-	ctx.JSON(http.StatusOK, gin.H{"longitude": shelter.Longitude, "latitude": shelter.Latitude})
+	ctx.JSON(http.StatusOK, gin.H{
+		"longitude":            shelter.Longitude,
+		"latitude":             shelter.Latitude,
+		"shelter_availability": shelter.ShelterAvailability,
+		"shelter_capacity":     shelter.Capacity,
+		"shelter_occupancy":    shelter.Occupancy,
+	})
 }
 
 // CreateZone
 //
 //	@Summary		Create a zone
-//	@Description	Create a new zone
+//	@Description	Create a new zone, its time_zone is the IANA time zone the opening hours of its shelters are in
 //	@Tags			zone
 //	@Accept			json
 //	@Produce		json
@@ -560,7 +805,14 @@ func (h *ZoneHandler) CreateZone(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request parameters"})
 		return
 	}
+	if _, err := domain.LoadTimeZone(zoneDataInstance.TimeZone); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	zId, err := h.tvc.CreateZone(zoneDataInstance.ZoneName)
+	if err == nil && zoneDataInstance.TimeZone != "" {
+		err = h.tvc.SetZoneTimeZone(zId, zoneDataInstance.TimeZone)
+	}
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "error while creating the zone, something went wrong"})
 	} else {
@@ -574,7 +826,7 @@ func (h *ZoneHandler) CreateZone(ctx *gin.Context) {
 // UpdateZone
 //
 //	@Summary		Update a zone
-//	@Description	Update details of an existing zone
+//	@Description	Update details of an existing zone, the time zone is kept when time_zone is empty
 //	@Tags			zone
 //	@Accept			json
 //	@Produce		json
@@ -593,6 +845,16 @@ func (h *ZoneHandler) UpdateZone(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request parameters"})
 		return
 	}
+	if zoneDataInstance.TimeZone != "" {
+		if err := h.tvc.SetZoneTimeZone(id, zoneDataInstance.TimeZone); err != nil {
+			if errors.Is(err, domain.ErrInvalidTimeZone) {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update zone, something went wrong"})
+			return
+		}
+	}
 	err := h.tvc.UpdateZone(id, zoneDataInstance.ZoneName)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update shelter, something went wrong"})
@@ -635,3 +897,556 @@ func (h *ZoneHandler) DeleteZone(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "deleted zone"})
 }
+
+// LocateZone
+//
+//	@Summary		Locate the zone of a location
+//	@Description	Find the zone whose boundary contains the given latitude and longitude, zones without a boundary are not considered
+//	@Tags			zone
+//	@Produce		json
+//	@Param			lat	query		float64				true	"Latitude"
+//	@Param			lon	query		float64				true	"Longitude"
+//	@Success		200	{object}	ZoneDTO				"zone containing the location"
+//	@Failure		400	{object}	map[string]string	"error: invalid latitude or longitude"
+//	@Failure		404	{object}	map[string]string	"error: no zone contains the location"
+//	@Failure		500	{object}	map[string]string	"error: Internal Server Error"
+//	@Router			/api/v1/zone/locate [get]
+func (h *ZoneHandler) LocateZone(ctx *gin.Context) {
+	latitude, errLat := strconv.ParseFloat(ctx.Query("lat"), 64)
+	longitude, errLon := strconv.ParseFloat(ctx.Query("lon"), 64)
+	if errLat != nil || errLon != nil || latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid latitude or longitude"})
+		return
+	}
+
+	zone, err := h.tvc.LocateZone(latitude, longitude)
+	if errors.Is(err, ports.ErrorZoneNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to locate zone, something went wrong"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ZoneDTO{ZoneID: zone.ZoneID, ZoneName: zone.ZoneName, TimeZone: zone.TimeZone})
+}
+
+// GetZoneSession
+//
+//	@Summary		Get the session of a workout
+//	@Description	Get where the workout is, the trail it follows and the last shelter published to it. The session is opened on the first location of the workout and closed when the workout is stopped.
+//	@Tags			zone
+//	@Produce		json
+//	@Param			workout_id	path		string				true	"Workout ID"
+//	@Success		200			{object}	ZoneSessionDTO		"session of the workout"
+//	@Failure		400			{object}	map[string]string	"error: invalid workout id"
+//	@Failure		404			{object}	map[string]string	"error: trail manager not found"
+//	@Failure		500			{object}	map[string]string	"error: Internal Server Error"
+//	@Router			/api/v1/zone/session/{workout_id} [get]
+func (h *ZoneHandler) GetZoneSession(ctx *gin.Context) {
+	wId, err := uuid.Parse(ctx.Param("workout_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid workout id"})
+		return
+	}
+
+	session, err := h.tvc.GetZoneManager(wId)
+	if errors.Is(err, ports.ErrorZoneManagerlNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get session, something went wrong"})
+		return
+	}
+
+	response := ZoneSessionDTO{
+		ZoneManagerID:   session.ZoneManagerID,
+		WorkoutID:       session.CurrentWorkoutID,
+		ZoneID:          session.ZoneID,
+		TrailID:         session.CurrentTrailID,
+		Latitude:        session.CurrentLatitude,
+		Longitude:       session.CurrentLongitude,
+		LocationTime:    session.CurrentTime,
+		ShelterID:       session.ShelterID,
+		ShelterDistance: session.ShelterDistance,
+		ShelterScope:    session.ShelterScope,
+		CreatedAt:       session.CreatedAt,
+		Closed:          session.IsClosed(),
+	}
+	if session.IsClosed() {
+		response.ClosedAt = &session.ClosedAt
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// RouteToShelter
+//
+//	@Summary		Route to a shelter
+//	@Description	Get the shortest walking path from a location to a shelter along the trails of the zone. Trails are joined where they share a point, cross or end next to each other. The location and the shelter join the trails at their closest point.
+//	@Tags			zone
+//	@Produce		json
+//	@Param			zone_id		path		string				true	"Zone ID"
+//	@Param			from_lat	query		float64				true	"Latitude of the location"
+//	@Param			from_lon	query		float64				true	"Longitude of the location"
+//	@Param			to_shelter	query		string				true	"Shelter ID"
+//	@Success		200			{object}	RouteDTO			"walking path and its distance in km"
+//	@Failure		400			{object}	map[string]string	"error: invalid zone id, invalid latitude or longitude, invalid shelter id, shelter belongs to another zone"
+//	@Failure		404			{object}	map[string]string	"error: shelter not found, no route along the trails"
+//	@Router			/api/v1/zone/{zone_id}/route [get]
+func (h *ZoneHandler) RouteToShelter(ctx *gin.Context) {
+	zId, err := uuid.Parse(ctx.Param("zone_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid zone id"})
+		return
+	}
+	latitude, errLat := strconv.ParseFloat(ctx.Query("from_lat"), 64)
+	longitude, errLon := strconv.ParseFloat(ctx.Query("from_lon"), 64)
+	if errLat != nil || errLon != nil || latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid latitude or longitude"})
+		return
+	}
+	sId, err := uuid.Parse(ctx.Query("to_shelter"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid shelter id"})
+		return
+	}
+
+	route, err := h.tvc.RouteToShelter(zId, latitude, longitude, sId)
+	if errors.Is(err, ports.ErrorShelterInOtherZone) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, domain.ErrNoRoute) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "shelter not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, RouteDTO{
+		ZoneID:    zId,
+		ShelterID: sId,
+		Distance:  route.Distance,
+		Path:      toTrailPathDTO(route.Path),
+	})
+}
+
+// GetZoneBoundary
+//
+//	@Summary		Get the boundary of a zone
+//	@Description	Get the boundary of a zone as a GeoJSON Polygon or MultiPolygon
+//	@Tags			zone
+//	@Produce		json
+//	@Param			zone_id	path		string					true	"Zone ID"
+//	@Success		200		{object}	map[string]interface{}	"GeoJSON geometry"
+//	@Failure		400		{object}	map[string]string		"error: invalid zone id"
+//	@Failure		404		{object}	map[string]string		"error: zone not found, zone has no boundary"
+//	@Router			/api/v1/zone/{zone_id}/boundary [get]
+func (h *ZoneHandler) GetZoneBoundary(ctx *gin.Context) {
+	zId, err := uuid.Parse(ctx.Param("zone_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid zone id"})
+		return
+	}
+
+	zone, err := h.tvc.GetZoneByID(zId)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "zone not found"})
+		return
+	}
+	if zone.Boundary == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "zone has no boundary"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, zone.Boundary.GeoJSON())
+}
+
+// SetZoneBoundary
+//
+//	@Summary		Set the boundary of a zone
+//	@Description	Set the boundary of a zone from a GeoJSON Polygon or MultiPolygon geometry, or a Feature holding one. The trails and shelters of the zone have to be inside it.
+//	@Tags			zone
+//	@Accept			json
+//	@Produce		json
+//	@Param			zone_id		path		string					true	"Zone ID"
+//	@Param			boundary	body		map[string]interface{}	true	"GeoJSON geometry"
+//	@Success		200			{object}	map[string]interface{}	"message: zone boundary updated, boundary: GeoJSON geometry"
+//	@Failure		400			{object}	map[string]string		"error: invalid boundary, trails or shelters outside of the boundary"
+//	@Failure		404			{object}	map[string]string		"error: zone not found"
+//	@Router			/api/v1/zone/{zone_id}/boundary [put]
+func (h *ZoneHandler) SetZoneBoundary(ctx *gin.Context) {
+	zId, err := uuid.Parse(ctx.Param("zone_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid zone id"})
+		return
+	}
+
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request parameters"})
+		return
+	}
+
+	boundary, err := h.tvc.SetZoneBoundary(zId, body)
+	if errors.Is(err, domain.ErrInvalidBoundary) || errors.Is(err, domain.ErrOutsideZone) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "zone not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "zone boundary updated", "boundary": boundary.GeoJSON()})
+}
+
+// ExportZoneGeoJSON
+//
+//	@Summary		Export a zone as GeoJSON
+//	@Description	Get the boundary, trails and shelters of a zone as a GeoJSON FeatureCollection. The boundary is a Polygon or MultiPolygon, trails are LineStrings and shelters are Points with their availability.
+//	@Tags			zone
+//	@Produce		json
+//	@Param			zone_id	path		string						true	"Zone ID"
+//	@Success		200		{object}	domain.FeatureCollection	"GeoJSON FeatureCollection"
+//	@Failure		400		{object}	map[string]string			"error: invalid zone id"
+//	@Failure		404		{object}	map[string]string			"error: zone not found"
+//	@Router			/api/v1/zone/{zone_id}/geojson [get]
+func (h *ZoneHandler) ExportZoneGeoJSON(ctx *gin.Context) {
+	zId, err := uuid.Parse(ctx.Param("zone_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid zone id"})
+		return
+	}
+
+	collection, err := h.tvc.ExportZoneGeoJSON(zId)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "zone not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, collection)
+}
+
+// ImportZoneGeoJSON
+//
+//	@Summary		Import a zone from GeoJSON
+//	@Description	Create or update the boundary, trails and shelters of a zone from a GeoJSON FeatureCollection, in a single transaction. Polygons and MultiPolygons are the boundary, LineStrings are trails and Points are shelters. Trails and shelters are matched with the 'trail_id' and 'shelter_id' properties, and created when they have none. Shelters need a 'trail_id' and may have an 'availability'. Trails and shelters missing from the collection are kept. Nothing is written if a feature is invalid.
+//	@Tags			zone
+//	@Accept			json
+//	@Produce		json
+//	@Param			zone_id		path		string						true	"Zone ID"
+//	@Param			features	body		domain.FeatureCollection	true	"GeoJSON FeatureCollection"
+//	@Success		200			{object}	ZoneImportDTO
+//	@Failure		400			{object}	map[string]interface{}	"error: invalid FeatureCollection or invalid features, features: the invalid features"
+//	@Failure		404			{object}	map[string]string		"error: zone not found"
+//	@Failure		500			{object}	map[string]string		"error: failed to import the zone"
+//	@Router			/api/v1/zone/{zone_id}/geojson [put]
+func (h *ZoneHandler) ImportZoneGeoJSON(ctx *gin.Context) {
+	zId, err := uuid.Parse(ctx.Param("zone_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid zone id"})
+		return
+	}
+
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request parameters"})
+		return
+	}
+
+	if _, err := h.tvc.GetZoneByID(zId); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "zone not found"})
+		return
+	}
+
+	features, featureErrors, err := h.tvc.ImportZoneGeoJSON(zId, body)
+	if errors.Is(err, domain.ErrInvalidFeatureCollection) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, domain.ErrInvalidFeatures) {
+		errorsDTO := make([]FeatureErrorDTO, 0, len(featureErrors))
+		for _, featureError := range featureErrors {
+			errorsDTO = append(errorsDTO, FeatureErrorDTO{
+				Index: featureError.Index,
+				ID:    featureError.ID,
+				Error: featureError.Err.Error(),
+			})
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "features": errorsDTO})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import the zone"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ZoneImportDTO{
+		ZoneID:       zId,
+		BoundarySet:  features.Boundary != nil,
+		TrailCount:   len(features.Trails),
+		ShelterCount: len(features.Shelters),
+	})
+}
+
+// GetShelterSchedule
+//
+//	@Summary		Get the schedule of a shelter
+//	@Description	Get the weekly opening hours and the dated closures of a shelter, in the time zone of its zone, and whether it is open now
+//	@Tags			zone
+//	@Produce		json
+//	@Param			zone_id		path		string				true	"Zone ID"
+//	@Param			trail_id	path		string				true	"Trail ID"
+//	@Param			shelter_id	path		string				true	"Shelter ID"
+//	@Success		200			{object}	ShelterScheduleDTO	"schedule of the shelter"
+//	@Failure		400			{object}	map[string]string	"error: invalid zone id, invalid trail id, invalid shelter id"
+//	@Failure		404			{object}	map[string]string	"error: shelter not found"
+//	@Router			/api/v1/zone/{zone_id}/trail/{trail_id}/shelter/{shelter_id}/schedule [get]
+func (t *ZoneHandler) GetShelterSchedule(ctx *gin.Context) {
+	sId, ok := shelterPathID(ctx)
+	if !ok {
+		return
+	}
+
+	shelter, err := t.tvc.GetShelterByID(sId)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "shelter not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, t.toShelterScheduleDTO(shelter))
+}
+
+// SetShelterSchedule
+//
+//	@Summary		Set the schedule of a shelter
+//	@Description	Replace the weekly opening hours and the dated closures of a shelter. Opening hours are in the time zone of the zone and close after midnight when they close before they open. A shelter without opening hours is always open outside of its closures.
+//	@Tags			zone
+//	@Accept			json
+//	@Produce		json
+//	@Param			zone_id		path		string				true	"Zone ID"
+//	@Param			trail_id	path		string				true	"Trail ID"
+//	@Param			shelter_id	path		string				true	"Shelter ID"
+//	@Param			schedule	body		ShelterScheduleDTO	true	"Schedule of the shelter"
+//	@Success		200			{object}	ShelterScheduleDTO	"updated schedule"
+//	@Failure		400			{object}	map[string]string	"error: invalid opening hours or closures"
+//	@Failure		404			{object}	map[string]string	"error: shelter not found"
+//	@Failure		500			{object}	map[string]string	"error: failed to update shelter schedule"
+//	@Router			/api/v1/zone/{zone_id}/trail/{trail_id}/shelter/{shelter_id}/schedule [put]
+func (t *ZoneHandler) SetShelterSchedule(ctx *gin.Context) {
+	sId, ok := shelterPathID(ctx)
+	if !ok {
+		return
+	}
+
+	var scheduleDataInstance ShelterScheduleDTO
+	if err := ctx.ShouldBindJSON(&scheduleDataInstance); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request parameters"})
+		return
+	}
+
+	hours := make([]domain.OpeningHours, 0, len(scheduleDataInstance.OpeningHours))
+	for _, hoursDTO := range scheduleDataInstance.OpeningHours {
+		h, err := toOpeningHours(hoursDTO)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		hours = append(hours, h)
+	}
+	closures := make([]domain.ShelterClosure, 0, len(scheduleDataInstance.Closures))
+	for _, closureDTO := range scheduleDataInstance.Closures {
+		closure, err := domain.NewShelterClosure(closureDTO.StartsAt, closureDTO.EndsAt, closureDTO.Reason)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		closures = append(closures, closure)
+	}
+
+	t.updateShelterSchedule(ctx, sId, func() (*domain.Shelter, error) {
+		return t.tvc.SetShelterSchedule(sId, hours, closures)
+	})
+}
+
+// DeleteShelterSchedule
+//
+//	@Summary		Delete the schedule of a shelter
+//	@Description	Remove the opening hours and the closures of a shelter, it is then always open
+//	@Tags			zone
+//	@Produce		json
+//	@Param			zone_id		path		string				true	"Zone ID"
+//	@Param			trail_id	path		string				true	"Trail ID"
+//	@Param			shelter_id	path		string				true	"Shelter ID"
+//	@Success		200			{object}	ShelterScheduleDTO	"empty schedule"
+//	@Failure		400			{object}	map[string]string	"error: invalid zone id, invalid trail id, invalid shelter id"
+//	@Failure		404			{object}	map[string]string	"error: shelter not found"
+//	@Failure		500			{object}	map[string]string	"error: failed to update shelter schedule"
+//	@Router			/api/v1/zone/{zone_id}/trail/{trail_id}/shelter/{shelter_id}/schedule [delete]
+func (t *ZoneHandler) DeleteShelterSchedule(ctx *gin.Context) {
+	sId, ok := shelterPathID(ctx)
+	if !ok {
+		return
+	}
+
+	t.updateShelterSchedule(ctx, sId, func() (*domain.Shelter, error) {
+		return t.tvc.SetShelterSchedule(sId, nil, nil)
+	})
+}
+
+// AddShelterClosure
+//
+//	@Summary		Close a shelter for a period
+//	@Description	Add a dated closure to a shelter, for instance for maintenance. The shelter is closed from starts_at until ends_at whatever its opening hours.
+//	@Tags			zone
+//	@Accept			json
+//	@Produce		json
+//	@Param			zone_id		path		string				true	"Zone ID"
+//	@Param			trail_id	path		string				true	"Trail ID"
+//	@Param			shelter_id	path		string				true	"Shelter ID"
+//	@Param			closure		body		ShelterClosureDTO	true	"Closure, its closure_id is ignored"
+//	@Success		200			{object}	ShelterScheduleDTO	"updated schedule"
+//	@Failure		400			{object}	map[string]string	"error: closure must end after it starts"
+//	@Failure		404			{object}	map[string]string	"error: shelter not found"
+//	@Failure		500			{object}	map[string]string	"error: failed to update shelter schedule"
+//	@Router			/api/v1/zone/{zone_id}/trail/{trail_id}/shelter/{shelter_id}/schedule/closure [post]
+func (t *ZoneHandler) AddShelterClosure(ctx *gin.Context) {
+	sId, ok := shelterPathID(ctx)
+	if !ok {
+		return
+	}
+
+	var closureDataInstance ShelterClosureDTO
+	if err := ctx.ShouldBindJSON(&closureDataInstance); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request parameters"})
+		return
+	}
+	closure, err := domain.NewShelterClosure(closureDataInstance.StartsAt, closureDataInstance.EndsAt, closureDataInstance.Reason)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t.updateShelterSchedule(ctx, sId, func() (*domain.Shelter, error) {
+		return t.tvc.AddShelterClosure(sId, closure)
+	})
+}
+
+// DeleteShelterClosure
+//
+//	@Summary		Cancel a closure of a shelter
+//	@Description	Remove a dated closure from a shelter
+//	@Tags			zone
+//	@Produce		json
+//	@Param			zone_id		path		string				true	"Zone ID"
+//	@Param			trail_id	path		string				true	"Trail ID"
+//	@Param			shelter_id	path		string				true	"Shelter ID"
+//	@Param			closure_id	path		string				true	"Closure ID"
+//	@Success		200			{object}	ShelterScheduleDTO	"updated schedule"
+//	@Failure		400			{object}	map[string]string	"error: invalid closure id"
+//	@Failure		404			{object}	map[string]string	"error: shelter not found, closure not found"
+//	@Failure		500			{object}	map[string]string	"error: failed to update shelter schedule"
+//	@Router			/api/v1/zone/{zone_id}/trail/{trail_id}/shelter/{shelter_id}/schedule/closure/{closure_id} [delete]
+func (t *ZoneHandler) DeleteShelterClosure(ctx *gin.Context) {
+	sId, ok := shelterPathID(ctx)
+	if !ok {
+		return
+	}
+	cId, err := uuid.Parse(ctx.Param("closure_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid closure id"})
+		return
+	}
+
+	t.updateShelterSchedule(ctx, sId, func() (*domain.Shelter, error) {
+		return t.tvc.RemoveShelterClosure(sId, cId)
+	})
+}
+
+// updateShelterSchedule answers a change of the schedule of a shelter with its new schedule
+func (t *ZoneHandler) updateShelterSchedule(ctx *gin.Context, sId uuid.UUID, update func() (*domain.Shelter, error)) {
+	if _, err := t.tvc.GetShelterByID(sId); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "shelter not found"})
+		return
+	}
+
+	shelter, err := update()
+	if errors.Is(err, domain.ErrClosureNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, domain.ErrInvalidOpeningHours) || errors.Is(err, domain.ErrInvalidClosure) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update shelter schedule"})
+		return
+	}
+	ctx.JSON(http.StatusOK, t.toShelterScheduleDTO(shelter))
+}
+
+// shelterPathID reads the shelter id of a shelter route, answering bad requests itself
+func shelterPathID(ctx *gin.Context) (uuid.UUID, bool) {
+	if _, err := uuid.Parse(ctx.Param("zone_id")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid zone id"})
+		return uuid.Nil, false
+	}
+	if _, err := uuid.Parse(ctx.Param("trail_id")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid trail id"})
+		return uuid.Nil, false
+	}
+	sId, err := uuid.Parse(ctx.Param("shelter_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid shelter id"})
+		return uuid.Nil, false
+	}
+	return sId, true
+}
+
+func toOpeningHours(hoursDTO OpeningHoursDTO) (domain.OpeningHours, error) {
+	weekday, err := domain.ParseWeekday(hoursDTO.Weekday)
+	if err != nil {
+		return domain.OpeningHours{}, err
+	}
+	opens, err := domain.ParseClock(hoursDTO.Opens)
+	if err != nil {
+		return domain.OpeningHours{}, err
+	}
+	closes, err := domain.ParseClock(hoursDTO.Closes)
+	if err != nil {
+		return domain.OpeningHours{}, err
+	}
+	return domain.NewOpeningHours(weekday, opens, closes)
+}
+
+func (t *ZoneHandler) toShelterScheduleDTO(shelter *domain.Shelter) ShelterScheduleDTO {
+	scheduleDTO := ShelterScheduleDTO{
+		ShelterID:    shelter.ShelterID,
+		TimeZone:     t.tvc.ShelterLocation(shelter).String(),
+		OpeningHours: make([]OpeningHoursDTO, 0, len(shelter.OpeningHours)),
+		Closures:     make([]ShelterClosureDTO, 0, len(shelter.Closures)),
+		OpenNow:      t.tvc.IsShelterOpen(shelter, time.Now()),
+	}
+	for _, h := range shelter.OpeningHours {
+		scheduleDTO.OpeningHours = append(scheduleDTO.OpeningHours, OpeningHoursDTO{
+			Weekday: strings.ToLower(h.Weekday.String()),
+			Opens:   domain.FormatClock(h.Opens),
+			Closes:  domain.FormatClock(h.Closes),
+		})
+	}
+	for _, closure := range shelter.Closures {
+		scheduleDTO.Closures = append(scheduleDTO.Closures, ShelterClosureDTO{
+			ClosureID: closure.ClosureID,
+			StartsAt:  closure.StartsAt,
+			EndsAt:    closure.EndsAt,
+			Reason:    closure.Reason,
+		})
+	}
+	return scheduleDTO
+}
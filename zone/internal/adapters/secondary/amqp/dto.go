@@ -1,6 +1,10 @@
 package amqp
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type ShelterDTO struct {
 	WorkoutID           uuid.UUID `json:"workout_id"`
@@ -13,4 +17,46 @@ type ShelterDTO struct {
 	ShelterAvailable bool `json:"shelter_available"`
 	// Distance to Shelter
 	DistanceToShelter float64 `json:"distance_to_shelter"`
+	// Scope the shelter was found in: 'trail', 'zone' or 'global'
+	Scope string `json:"scope"`
+}
+
+type OffTrailDTO struct {
+	WorkoutID uuid.UUID `json:"workout_id"`
+	TrailID   uuid.UUID `json:"trail_id"`
+	// true when the workout left the trail, false when it is back on it
+	OffTrail bool `json:"off_trail"`
+	// Distance to the closest point of the trail in km
+	DistanceFromTrail float64 `json:"distance_from_trail"`
+	// Location of the Player
+	Latitude       float64   `json:"latitude"`
+	Longitude      float64   `json:"longitude"`
+	TimeOfLocation time.Time `json:"time_of_location"`
+}
+
+type ShelterReservationDTO struct {
+	WorkoutID uuid.UUID `json:"workout_id"`
+	ShelterID uuid.UUID `json:"shelter_id"`
+	// true when the place is held, false when it was refused, expired or given back
+	Reserved bool `json:"reserved"`
+	// why the place is not held: 'unavailable', 'not_found' or 'expired'
+	Reason string `json:"reason,omitempty"`
+	// time after which the place is given back
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type GeofenceEventDTO struct {
+	// 'trail_start_entered', 'trail_end_reached', 'shelter_arrived' or 'shelter_left'
+	Event     string    `json:"event"`
+	WorkoutID uuid.UUID `json:"workout_id"`
+	// Trail of the workout, or of the shelter for shelter events
+	TrailID uuid.UUID `json:"trail_id"`
+	// Shelter of the event, nil for trail events
+	ShelterID uuid.UUID `json:"shelter_id"`
+	// Distance to the centre of the fence in km
+	Distance float64 `json:"distance"`
+	// Location of the Player
+	Latitude       float64   `json:"latitude"`
+	Longitude      float64   `json:"longitude"`
+	TimeOfLocation time.Time `json:"time_of_location"`
 }
@@ -0,0 +1,113 @@
+package amqp
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/config"
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
+	logger "github.com/CAS735-F23/macrun-teamvsl/zone/log"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+const (
+	exchangeKind       = "topic"
+	exchangeDurable    = true
+	exchangeAutoDelete = false
+	exchangeInternal   = false
+	exchangeNoWait     = false
+
+	// routing keys of the geofence events are the prefix followed by the event type
+	GeofenceRoutingKeyPrefix = "geofence."
+)
+
+type GeofencePublisher struct {
+	amqpConn *amqp.Connection
+	config   *config.RabbitMQ
+}
+
+// NewGeofencePublisher initializes a new GeofencePublisher with a RabbitMQ connection and declares the topic
+// exchange the events are published on
+func NewGeofencePublisher(cfg *config.RabbitMQ) *GeofencePublisher {
+	conn := fmt.Sprintf(
+		"amqp://%s:%s@%s:%s/",
+		cfg.User,
+		cfg.Password,
+		cfg.Host,
+		cfg.Port,
+	)
+
+	amqpConn, err := amqp.Dial(conn)
+	if err != nil {
+		logger.Fatal("unable to dial connection to RabbitMQ", zap.Error(err))
+		return nil
+	}
+
+	ch, err := amqpConn.Channel()
+	if err != nil {
+		logger.Fatal("unable to open a channel to RabbitMQ", zap.Error(err))
+		return nil
+	}
+	defer ch.Close()
+	err = ch.ExchangeDeclare(
+		cfg.GeofenceExchange,
+		exchangeKind,
+		exchangeDurable,
+		exchangeAutoDelete,
+		exchangeInternal,
+		exchangeNoWait,
+		nil,
+	)
+	if err != nil {
+		logger.Fatal("unable to declare the geofence exchange", zap.String("exchange_name", cfg.GeofenceExchange), zap.Error(err))
+		return nil
+	}
+
+	return &GeofencePublisher{
+		config:   cfg,
+		amqpConn: amqpConn,
+	}
+}
+
+// PublishGeofenceEvent publishes the event with the routing key geofence.<type>, so that consumers can bind to
+// the events they need
+func (pub *GeofencePublisher) PublishGeofenceEvent(event *domain.GeofenceEvent) error {
+	ch, err := pub.amqpConn.Channel()
+	if err != nil {
+		logger.Error("publish geofence event: failed to open a channel", zap.Error(err))
+		return fmt.Errorf("failed to open a channel: %w", err)
+	}
+	defer ch.Close()
+
+	body, err := json.Marshal(GeofenceEventDTO{
+		Event:          event.Type,
+		WorkoutID:      event.WorkoutID,
+		TrailID:        event.TrailID,
+		ShelterID:      event.ShelterID,
+		Distance:       event.Distance,
+		Latitude:       event.Latitude,
+		Longitude:      event.Longitude,
+		TimeOfLocation: event.Time,
+	})
+	if err != nil {
+		logger.Error("publish geofence event: failed to convert to json data", zap.Error(err))
+		return fmt.Errorf("failed to serialize geofence event: %w", err)
+	}
+	err = ch.Publish(
+		pub.config.GeofenceExchange,         // exchange
+		GeofenceRoutingKeyPrefix+event.Type, // routing key
+		false,                               // mandatory
+		false,                               // immediate
+		amqp.Publishing{
+			ContentType: "application/json",
+			Body:        body,
+		},
+	)
+	if err != nil {
+		logger.Error("publish geofence event: failed to push data", zap.Error(err))
+		return fmt.Errorf("failed to publish a message: %w", err)
+	}
+
+	return nil
+}
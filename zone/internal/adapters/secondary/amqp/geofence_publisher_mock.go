@@ -0,0 +1,19 @@
+package amqp
+
+import (
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+type GeofencePublisherMock struct {
+	mock.Mock
+}
+
+func NewGeofencePublisherMock() *GeofencePublisherMock {
+	return &GeofencePublisherMock{}
+}
+
+func (m *GeofencePublisherMock) PublishGeofenceEvent(event *domain.GeofenceEvent) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
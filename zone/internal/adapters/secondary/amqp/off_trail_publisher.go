@@ -0,0 +1,81 @@
+package amqp
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/config"
+	logger "github.com/CAS735-F23/macrun-teamvsl/zone/log"
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+type OffTrailPublisher struct {
+	amqpConn *amqp.Connection
+	config   *config.RabbitMQ
+}
+
+// NewOffTrailPublisher initializes a new OffTrailPublisher with a RabbitMQ connection
+func NewOffTrailPublisher(cfg *config.RabbitMQ) *OffTrailPublisher {
+	conn := fmt.Sprintf(
+		"amqp://%s:%s@%s:%s/",
+		cfg.User,
+		cfg.Password,
+		cfg.Host,
+		cfg.Port,
+	)
+
+	amqpConn, err := amqp.Dial(conn)
+	if err != nil {
+		logger.Fatal("unable to dial connection to RabbitMQ", zap.Error(err))
+		return nil
+	}
+
+	return &OffTrailPublisher{
+		config:   cfg,
+		amqpConn: amqpConn,
+	}
+}
+
+// PublishOffTrail tells the workout that it left its trail, or that it is back on it
+func (pub *OffTrailPublisher) PublishOffTrail(wId uuid.UUID, tId uuid.UUID, offTrail bool, distance float64, latitude float64, longitude float64, time time.Time) error {
+	ch, err := pub.amqpConn.Channel()
+	if err != nil {
+		logger.Error("publish off trail: failed to open a channel", zap.Error(err))
+		return fmt.Errorf("failed to open a channel: %w", err)
+	}
+	defer ch.Close()
+
+	body, err := json.Marshal(OffTrailDTO{
+		WorkoutID:         wId,
+		TrailID:           tId,
+		OffTrail:          offTrail,
+		DistanceFromTrail: distance,
+		Latitude:          latitude,
+		Longitude:         longitude,
+		TimeOfLocation:    time,
+	})
+	if err != nil {
+		logger.Error("publish off trail: failed to convert to json data", zap.Error(err))
+		return fmt.Errorf("failed to serialize off trail event: %w", err)
+	}
+	logger.Debug("off trail status changed", zap.Any("workout_id", wId), zap.Bool("off_trail", offTrail), zap.Float64("distance", distance))
+	err = ch.Publish(
+		"",                           // exchange
+		pub.config.OffTrailPublisher, // queue name
+		false,                        // mandatory
+		false,                        // immediate
+		amqp.Publishing{
+			ContentType: "application/json",
+			Body:        body,
+		},
+	)
+	if err != nil {
+		logger.Error("publish off trail: failed to push data", zap.Error(err))
+		return fmt.Errorf("failed to publish a message: %w", err)
+	}
+
+	return nil
+}
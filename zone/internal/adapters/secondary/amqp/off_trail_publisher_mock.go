@@ -0,0 +1,21 @@
+package amqp
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type OffTrailPublisherMock struct {
+	mock.Mock
+}
+
+func NewOffTrailPublisherMock() *OffTrailPublisherMock {
+	return &OffTrailPublisherMock{}
+}
+
+func (m *OffTrailPublisherMock) PublishOffTrail(wId uuid.UUID, tId uuid.UUID, offTrail bool, distance float64, latitude float64, longitude float64, time time.Time) error {
+	args := m.Called(wId, tId, offTrail, distance, latitude, longitude, time)
+	return args.Error(0)
+}
@@ -40,7 +40,7 @@ func NewShelterDistancePublisher(cfg *config.RabbitMQ) *ShelterDistancePublisher
 }
 
 // PublishWorkoutStats publishes workout stats to the specified RabbitMQ queue
-func (pub *ShelterDistancePublisher) PublishShelterDistance(wId uuid.UUID, sId uuid.UUID, name string, availability bool, distance float64) error {
+func (pub *ShelterDistancePublisher) PublishShelterDistance(wId uuid.UUID, sId uuid.UUID, name string, availability bool, distance float64, scope string) error {
 	ch, err := pub.amqpConn.Channel()
 	if err != nil {
 		logger.Error("publish shelter: failed to open a channel", zap.Error(err))
@@ -53,12 +53,13 @@ func (pub *ShelterDistancePublisher) PublishShelterDistance(wId uuid.UUID, sId u
 	shelter.ShelterName = name
 	shelter.ShelterID = sId
 	shelter.ShelterAvailability = availability
+	shelter.Scope = scope
 	body, err := json.Marshal(shelter)
 	if err != nil {
 		logger.Error("publish shelter: failed to convert to json data", zap.Error(err))
 		return fmt.Errorf("failed to serialize workoutStats: %w", err)
 	}
-	logger.Debug("distance to shelter", zap.Float64("distance", distance))
+	logger.Debug("distance to shelter", zap.Float64("distance", distance), zap.String("scope", scope))
 	err = ch.Publish(
 		"",                                  // exchange
 		pub.config.ShelterDistancePublisher, // queue name
@@ -13,7 +13,7 @@ func NewShelterDistancePublisherMock() *ShelterDistancePublisherMock {
 	return &ShelterDistancePublisherMock{}
 }
 
-func (m *ShelterDistancePublisherMock) PublishShelterDistance(wId uuid.UUID, sId uuid.UUID, name string, availability bool, distance float64) error {
-	args := m.Called(wId, sId, name, availability, distance)
+func (m *ShelterDistancePublisherMock) PublishShelterDistance(wId uuid.UUID, sId uuid.UUID, name string, availability bool, distance float64, scope string) error {
+	args := m.Called(wId, sId, name, availability, distance, scope)
 	return args.Error(0)
 }
@@ -0,0 +1,79 @@
+package amqp
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/config"
+	logger "github.com/CAS735-F23/macrun-teamvsl/zone/log"
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+type ShelterReservationPublisher struct {
+	amqpConn *amqp.Connection
+	config   *config.RabbitMQ
+}
+
+// NewShelterReservationPublisher initializes a new ShelterReservationPublisher with a RabbitMQ connection
+func NewShelterReservationPublisher(cfg *config.RabbitMQ) *ShelterReservationPublisher {
+	conn := fmt.Sprintf(
+		"amqp://%s:%s@%s:%s/",
+		cfg.User,
+		cfg.Password,
+		cfg.Host,
+		cfg.Port,
+	)
+
+	amqpConn, err := amqp.Dial(conn)
+	if err != nil {
+		logger.Fatal("unable to dial connection to RabbitMQ", zap.Error(err))
+		return nil
+	}
+
+	return &ShelterReservationPublisher{
+		config:   cfg,
+		amqpConn: amqpConn,
+	}
+}
+
+// PublishShelterReservation tells the workout whether its shelter place is held, refused or given back
+func (pub *ShelterReservationPublisher) PublishShelterReservation(wId uuid.UUID, sId uuid.UUID, reserved bool, reason string, expiresAt time.Time) error {
+	ch, err := pub.amqpConn.Channel()
+	if err != nil {
+		logger.Error("publish shelter reservation: failed to open a channel", zap.Error(err))
+		return fmt.Errorf("failed to open a channel: %w", err)
+	}
+	defer ch.Close()
+
+	body, err := json.Marshal(ShelterReservationDTO{
+		WorkoutID: wId,
+		ShelterID: sId,
+		Reserved:  reserved,
+		Reason:    reason,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		logger.Error("publish shelter reservation: failed to convert to json data", zap.Error(err))
+		return fmt.Errorf("failed to serialize shelter reservation: %w", err)
+	}
+	logger.Debug("shelter reservation changed", zap.Any("workout_id", wId), zap.Any("shelter_id", sId), zap.Bool("reserved", reserved), zap.String("reason", reason))
+	err = ch.Publish(
+		"",                                     // exchange
+		pub.config.ShelterReservationPublisher, // queue name
+		false,                                  // mandatory
+		false,                                  // immediate
+		amqp.Publishing{
+			ContentType: "application/json",
+			Body:        body,
+		},
+	)
+	if err != nil {
+		logger.Error("publish shelter reservation: failed to push data", zap.Error(err))
+		return fmt.Errorf("failed to publish a message: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package amqp
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type ShelterReservationPublisherMock struct {
+	mock.Mock
+}
+
+func NewShelterReservationPublisherMock() *ShelterReservationPublisherMock {
+	return &ShelterReservationPublisherMock{}
+}
+
+func (m *ShelterReservationPublisherMock) PublishShelterReservation(wId uuid.UUID, sId uuid.UUID, reserved bool, reason string, expiresAt time.Time) error {
+	args := m.Called(wId, sId, reserved, reason, expiresAt)
+	return args.Error(0)
+}
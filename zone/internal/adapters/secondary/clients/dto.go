@@ -0,0 +1,32 @@
+package clients
+
+import (
+	"github.com/google/uuid"
+)
+
+type userDTO struct {
+	// ID is the identifier of the Entity, the ID is shared for all sub domains
+	ID uuid.UUID `json:"id"`
+	// Name of the user
+	Name string `json:"name"`
+	// DoB
+	DateOfBirth string `json:"dob"`
+}
+
+type playerDTO struct {
+	// ID of the player
+	ID uuid.UUID `json:"id"`
+	// User is the root entity of player
+	User userDTO `json:"user"`
+	// Preference of the player
+	Preference string `json:"preference"`
+}
+
+type workoutDistanceDTO struct {
+	// PlayerID the distance was asked for
+	PlayerID uuid.UUID `json:"player_id"`
+	// Distance covered in the period in km
+	DistanceCovered float64 `json:"distance_covered"`
+	// Workouts completed in the period
+	WorkoutsCompleted uint16 `json:"workouts_completed"`
+}
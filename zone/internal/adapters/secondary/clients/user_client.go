@@ -0,0 +1,62 @@
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+type UserServiceClient struct {
+	clientURL string
+}
+
+// Factory for creating a new UserServiceClient
+func NewUserServiceClient(cfg string) *UserServiceClient {
+	return &UserServiceClient{
+		clientURL: cfg,
+	}
+}
+
+// GetPlayer returns the workout preference and the age of the player, the age is 0 when the player has no
+// date of birth
+func (u *UserServiceClient) GetPlayer(playerID uuid.UUID) (string, uint8, error) {
+	url := u.clientURL + "/api/v1/players/" + playerID.String()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	// the user service answers 400 for players it cannot fetch
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest {
+		return "", 0, ports.ErrorPlayerNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("user service returned status code: %d", resp.StatusCode)
+	}
+
+	var player playerDTO
+	if err := json.NewDecoder(resp.Body).Decode(&player); err != nil {
+		return "", 0, err
+	}
+	return player.Preference, calculateAge(player.User.DateOfBirth, time.Now()), nil
+}
+
+func calculateAge(dob string, now time.Time) uint8 {
+	birthdate, err := time.Parse("2006-01-02", dob)
+	if err != nil || birthdate.After(now) {
+		return 0
+	}
+
+	age := now.Year() - birthdate.Year()
+	// Adjust if this year's birthday has not occurred yet
+	if now.Month() < birthdate.Month() || (now.Month() == birthdate.Month() && now.Day() < birthdate.Day()) {
+		age--
+	}
+	return uint8(age)
+}
@@ -0,0 +1,22 @@
+package clients
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// UserServiceClientMock is a mock type for UserServiceClient
+type UserServiceClientMock struct {
+	mock.Mock
+}
+
+// NewUserServiceClientMock creates a new instance of UserServiceClientMock.
+func NewUserServiceClientMock() *UserServiceClientMock {
+	return &UserServiceClientMock{}
+}
+
+// GetPlayer provides a mock function with given fields
+func (m *UserServiceClientMock) GetPlayer(playerID uuid.UUID) (string, uint8, error) {
+	args := m.Called(playerID)
+	return args.String(0), uint8(args.Int(1)), args.Error(2)
+}
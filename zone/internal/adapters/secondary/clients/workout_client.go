@@ -0,0 +1,49 @@
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WorkoutServiceClient struct {
+	clientURL string
+}
+
+// Factory for creating a new WorkoutServiceClient
+func NewWorkoutServiceClient(cfg string) *WorkoutServiceClient {
+	return &WorkoutServiceClient{
+		clientURL: cfg,
+	}
+}
+
+// GetDistanceCovered returns the distance covered by the player in km and the number of workouts they completed
+// between the two dates
+func (w *WorkoutServiceClient) GetDistanceCovered(playerID uuid.UUID, startDate time.Time, endDate time.Time) (float64, uint16, error) {
+	query := url.Values{}
+	query.Set("playerID", playerID.String())
+	query.Set("startDate", startDate.Format(time.RFC3339))
+	query.Set("endDate", endDate.Format(time.RFC3339))
+	// trails are measured in km, whatever the player prefers
+	query.Set("units", "km")
+
+	resp, err := http.Get(w.clientURL + "/api/v1/workout/distance?" + query.Encode())
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return 0, 0, fmt.Errorf("workout service returned status code: %d", resp.StatusCode)
+	}
+
+	var distance workoutDistanceDTO
+	if err := json.NewDecoder(resp.Body).Decode(&distance); err != nil {
+		return 0, 0, err
+	}
+	return distance.DistanceCovered, distance.WorkoutsCompleted, nil
+}
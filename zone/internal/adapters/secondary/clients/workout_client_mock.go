@@ -0,0 +1,24 @@
+package clients
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// WorkoutServiceClientMock is a mock type for WorkoutServiceClient
+type WorkoutServiceClientMock struct {
+	mock.Mock
+}
+
+// NewWorkoutServiceClientMock creates a new instance of WorkoutServiceClientMock.
+func NewWorkoutServiceClientMock() *WorkoutServiceClientMock {
+	return &WorkoutServiceClientMock{}
+}
+
+// GetDistanceCovered provides a mock function with given fields
+func (m *WorkoutServiceClientMock) GetDistanceCovered(playerID uuid.UUID, startDate time.Time, endDate time.Time) (float64, uint16, error) {
+	args := m.Called(playerID, startDate, endDate)
+	return args.Get(0).(float64), uint16(args.Int(1)), args.Error(2)
+}
@@ -21,6 +21,18 @@ type postgresShelter struct {
 	TrailID             uuid.UUID
 	Longitude           float64
 	Latitude            float64
+	Capacity            uint16
+	Occupancy           uint16
+	OpeningHours        []domain.OpeningHours   `gorm:"serializer:json"`
+	Closures            []domain.ShelterClosure `gorm:"serializer:json"`
+}
+
+type postgresShelterReservation struct {
+	ReservationID uuid.UUID `gorm:"type:uuid;primaryKey;"`
+	ShelterID     uuid.UUID `gorm:"type:uuid;not null;index"`
+	WorkoutID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
+	ReservedAt    time.Time `gorm:"type:timestamp"`
+	ExpiresAt     time.Time `gorm:"type:timestamp;index"`
 }
 type postgresTrail struct {
 	TrailID        uuid.UUID `gorm:"type:uuid;primaryKey;"`
@@ -30,12 +42,23 @@ type postgresTrail struct {
 	StartLatitude  float64
 	EndLongitude   float64
 	EndLatitude    float64
+	Path           []domain.TrailPoint `gorm:"serializer:json"`
+	Length         float64
+	Ascent         float64
+	Descent        float64
+	MaxGrade       float64
+	MinLatitude    float64
+	MinLongitude   float64
+	MaxLatitude    float64
+	MaxLongitude   float64
 	CreatedAt      time.Time `gorm:"type:timestamp"`
 }
 
 type postgresZone struct {
-	ZoneID   uuid.UUID `gorm:"type:uuid;primaryKey;unique"`
-	ZoneName string    `gorm:"type:string;not null;unique"`
+	ZoneID   uuid.UUID        `gorm:"type:uuid;primaryKey;unique"`
+	ZoneName string           `gorm:"type:string;not null;unique"`
+	Boundary *domain.Boundary `gorm:"serializer:json"`
+	TimeZone string
 }
 
 type Repository struct {
@@ -61,7 +84,7 @@ func NewRepository(cfg *config.Postgres) *Repository {
 	if err != nil {
 		logger.Fatal("failed to connect to database", zap.Error(err))
 	}
-	db.AutoMigrate(&postgresTrail{}, &postgresShelter{}, &postgresZone{})
+	db.AutoMigrate(&postgresTrail{}, &postgresShelter{}, &postgresZone{}, &postgresShelterReservation{}, &postgresZoneManager{})
 	return &Repository{db: db}
 }
 
@@ -75,7 +98,18 @@ func (ptrail *postgresTrail) toAggregate() *domain.Trail {
 		StartLatitude:  ptrail.StartLatitude,
 		EndLongitude:   ptrail.EndLongitude,
 		EndLatitude:    ptrail.EndLatitude,
-		CreatedAt:      ptrail.CreatedAt,
+		Path:           ptrail.Path,
+		Length:         ptrail.Length,
+		Ascent:         ptrail.Ascent,
+		Descent:        ptrail.Descent,
+		MaxGrade:       ptrail.MaxGrade,
+		BoundingBox: domain.BoundingBox{
+			MinLatitude:  ptrail.MinLatitude,
+			MinLongitude: ptrail.MinLongitude,
+			MaxLatitude:  ptrail.MaxLatitude,
+			MaxLongitude: ptrail.MaxLongitude,
+		},
+		CreatedAt: ptrail.CreatedAt,
 	}
 }
 func (pshelter *postgresShelter) toAggregate() *domain.Shelter {
@@ -87,6 +121,37 @@ func (pshelter *postgresShelter) toAggregate() *domain.Shelter {
 		ShelterAvailability: pshelter.ShelterAvailability,
 		Longitude:           pshelter.Longitude,
 		Latitude:            pshelter.Latitude,
+		Capacity:            pshelter.Capacity,
+		Occupancy:           pshelter.Occupancy,
+		OpeningHours:        pshelter.OpeningHours,
+		Closures:            pshelter.Closures,
+	}
+}
+
+func toShelterPostgres(shelter *domain.Shelter) *postgresShelter {
+
+	return &postgresShelter{
+		ShelterID:           shelter.ShelterID,
+		ShelterName:         shelter.ShelterName,
+		TrailID:             shelter.TrailID,
+		ShelterAvailability: shelter.ShelterAvailability,
+		Longitude:           shelter.Longitude,
+		Latitude:            shelter.Latitude,
+		Capacity:            shelter.Capacity,
+		Occupancy:           shelter.Occupancy,
+		OpeningHours:        shelter.OpeningHours,
+		Closures:            shelter.Closures,
+	}
+}
+
+func (preservation *postgresShelterReservation) toAggregate() *domain.ShelterReservation {
+
+	return &domain.ShelterReservation{
+		ReservationID: preservation.ReservationID,
+		ShelterID:     preservation.ShelterID,
+		WorkoutID:     preservation.WorkoutID,
+		ReservedAt:    preservation.ReservedAt,
+		ExpiresAt:     preservation.ExpiresAt,
 	}
 }
 
@@ -95,6 +160,8 @@ func (pzone *postgresZone) toAggregate() *domain.Zone {
 	return &domain.Zone{
 		ZoneID:   pzone.ZoneID,
 		ZoneName: pzone.ZoneName,
+		Boundary: pzone.Boundary,
+		TimeZone: pzone.TimeZone,
 	}
 }
 
@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"errors"
+	"time"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Override the TableName method to specify the custom table name for the ShelterReservation model
+func (postgresShelterReservation) TableName() string {
+	return "shelter_reservation"
+}
+
+// ReserveShelter takes a place in a shelter for a workout. The shelter row is held until the place is
+// taken so that concurrent workouts cannot overfill it. A workout holding a place in another shelter
+// gives it back first and that shelter is returned too, reserving the same shelter again only extends
+// the reservation.
+func (repo *Repository) ReserveShelter(reservation *domain.ShelterReservation) (*domain.Shelter, *domain.Shelter, error) {
+	var reserved, released *domain.Shelter
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		var previous postgresShelterReservation
+		err := tx.Where("workout_id = ?", reservation.WorkoutID).First(&previous).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			if previous.ShelterID == reservation.ShelterID {
+				reservation.ReservationID = previous.ReservationID
+				reservation.ReservedAt = previous.ReservedAt
+				if err := tx.Model(&previous).Update("expires_at", reservation.ExpiresAt).Error; err != nil {
+					return err
+				}
+				shelter, err := lockShelter(tx, reservation.ShelterID)
+				reserved = shelter
+				return err
+			}
+			if released, err = releaseReservation(tx, &previous); err != nil {
+				return err
+			}
+		}
+
+		shelter, err := lockShelter(tx, reservation.ShelterID)
+		if err != nil {
+			return err
+		}
+		if err := shelter.Reserve(); err != nil {
+			return err
+		}
+		if err := tx.Save(toShelterPostgres(shelter)).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&postgresShelterReservation{
+			ReservationID: reservation.ReservationID,
+			ShelterID:     reservation.ShelterID,
+			WorkoutID:     reservation.WorkoutID,
+			ReservedAt:    reservation.ReservedAt,
+			ExpiresAt:     reservation.ExpiresAt,
+		}).Error; err != nil {
+			return err
+		}
+		reserved = shelter
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return reserved, released, nil
+}
+
+// ReleaseShelterReservation gives back the place held by a workout, it returns nil values when the
+// workout holds no place
+func (repo *Repository) ReleaseShelterReservation(wId uuid.UUID) (*domain.ShelterReservation, *domain.Shelter, error) {
+	return repo.release("workout_id = ?", wId)
+}
+
+// ReleaseExpiredShelterReservation gives back the place of a reservation if it is still expired at the
+// given time, it returns nil values when it was released or extended meanwhile
+func (repo *Repository) ReleaseExpiredShelterReservation(rId uuid.UUID, now time.Time) (*domain.ShelterReservation, *domain.Shelter, error) {
+	return repo.release("reservation_id = ? AND expires_at <= ?", rId, now)
+}
+
+func (repo *Repository) release(query string, args ...interface{}) (*domain.ShelterReservation, *domain.Shelter, error) {
+	var released *domain.ShelterReservation
+	var shelter *domain.Shelter
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		var preservation postgresShelterReservation
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(query, args...).First(&preservation).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if shelter, err = releaseReservation(tx, &preservation); err != nil {
+			return err
+		}
+		released = preservation.toAggregate()
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return released, shelter, nil
+}
+
+// ListExpiredShelterReservations returns the reservations that expired at the given time
+func (repo *Repository) ListExpiredShelterReservations(now time.Time) ([]*domain.ShelterReservation, error) {
+	var preservations []postgresShelterReservation
+	if err := repo.db.Where("expires_at <= ?", now).Find(&preservations).Error; err != nil {
+		return nil, err
+	}
+
+	reservations := make([]*domain.ShelterReservation, len(preservations))
+	for i, preservation := range preservations {
+		reservations[i] = preservation.toAggregate()
+	}
+	return reservations, nil
+}
+
+func lockShelter(tx *gorm.DB, id uuid.UUID) (*domain.Shelter, error) {
+	var pshelter postgresShelter
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("shelter_id = ?", id).First(&pshelter).Error; err != nil {
+		return nil, err
+	}
+	return pshelter.toAggregate(), nil
+}
+
+// releaseReservation deletes a reservation and gives its place back, shelters deleted since are skipped
+func releaseReservation(tx *gorm.DB, preservation *postgresShelterReservation) (*domain.Shelter, error) {
+	if err := tx.Delete(&postgresShelterReservation{}, "reservation_id = ?", preservation.ReservationID).Error; err != nil {
+		return nil, err
+	}
+	shelter, err := lockShelter(tx, preservation.ShelterID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	shelter.Release()
+	if err := tx.Save(toShelterPostgres(shelter)).Error; err != nil {
+		return nil, err
+	}
+	return shelter, nil
+}
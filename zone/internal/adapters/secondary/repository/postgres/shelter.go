@@ -3,6 +3,8 @@ package postgres
 import (
 	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Override the TableName method to specify the custom table name for the Shelter model
@@ -11,33 +13,58 @@ func (postgresShelter) TableName() string {
 }
 
 // Shelters
-func (repo *Repository) CreateShelter(name string, tId uuid.UUID, availability bool, lat, long float64) (uuid.UUID, error) {
-	shelter := postgresShelter{
+func (repo *Repository) CreateShelter(name string, tId uuid.UUID, availability bool, capacity uint16, lat, long float64) (uuid.UUID, error) {
+	shelter := &domain.Shelter{
 		ShelterID:           uuid.New(),
 		ShelterName:         name,
 		TrailID:             tId,
 		ShelterAvailability: availability,
 		Latitude:            lat,
 		Longitude:           long,
+		Capacity:            capacity,
 	}
-	if err := repo.db.Create(&shelter).Error; err != nil {
+	shelter.UpdateAvailability()
+	if err := repo.db.Create(toShelterPostgres(shelter)).Error; err != nil {
 		return uuid.Nil, err
 	}
 	return shelter.ShelterID, nil
 }
 
-func (repo *Repository) UpdateShelterByID(id uuid.UUID, tId uuid.UUID, name string, availability bool, lat, long float64) error {
-	return repo.db.Model(&postgresShelter{}).Where("shelter_id = ?", id).Updates(postgresShelter{
-		ShelterName:         name,
-		ShelterAvailability: availability,
-		Latitude:            lat,
-		Longitude:           long,
-		TrailID:             tId,
+// UpdateShelterByID updates a shelter while holding its row, so that its availability follows the
+// occupancy left by reservations
+func (repo *Repository) UpdateShelterByID(id uuid.UUID, tId uuid.UUID, name string, availability bool, capacity uint16, lat, long float64) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		var pshelter postgresShelter
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("shelter_id = ?", id).First(&pshelter).Error; err != nil {
+			return err
+		}
+		shelter := pshelter.toAggregate()
+		shelter.ShelterName = name
+		shelter.TrailID = tId
+		shelter.ShelterAvailability = availability
+		shelter.Capacity = capacity
+		shelter.Latitude = lat
+		shelter.Longitude = long
+		shelter.UpdateAvailability()
+		return tx.Save(toShelterPostgres(shelter)).Error
+	})
+}
+
+// UpdateShelterSchedule replaces the opening hours and closures of a shelter
+func (repo *Repository) UpdateShelterSchedule(id uuid.UUID, hours []domain.OpeningHours, closures []domain.ShelterClosure) error {
+	return repo.db.Model(&postgresShelter{}).Where("shelter_id = ?", id).Select("OpeningHours", "Closures").Updates(postgresShelter{
+		OpeningHours: hours,
+		Closures:     closures,
 	}).Error
 }
 
 func (repo *Repository) DeleteShelterByID(id uuid.UUID) error {
-	return repo.db.Delete(&postgresShelter{}, "shelter_id = ?", id).Error
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&postgresShelterReservation{}, "shelter_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&postgresShelter{}, "shelter_id = ?", id).Error
+	})
 }
 
 func (repo *Repository) GetShelterByID(id uuid.UUID) (*domain.Shelter, error) {
@@ -31,14 +31,53 @@ func (repo *Repository) CreateTrail(name string, zId uuid.UUID, startLat, startL
 	return trail.TrailID, nil
 }
 
-func (repo *Repository) UpdateTrailByID(id uuid.UUID, name string, zId uuid.UUID, startLat, startLong, endLat, endLong float64) error {
-	return repo.db.Model(&postgresTrail{}).Where("trail_id = ?", id).Updates(postgresTrail{
-		TrailName:      name,
-		ZoneID:         zId,
-		StartLatitude:  startLat,
-		StartLongitude: startLong,
-		EndLatitude:    endLat,
-		EndLongitude:   endLong,
+func (repo *Repository) CreateTrailWithPath(t *domain.Trail) (uuid.UUID, error) {
+	trail := postgresTrail{
+		TrailID:        uuid.New(),
+		TrailName:      t.TrailName,
+		ZoneID:         t.ZoneID,
+		StartLatitude:  t.StartLatitude,
+		StartLongitude: t.StartLongitude,
+		EndLatitude:    t.EndLatitude,
+		EndLongitude:   t.EndLongitude,
+		Path:           t.Path,
+		Length:         t.Length,
+		Ascent:         t.Ascent,
+		Descent:        t.Descent,
+		MaxGrade:       t.MaxGrade,
+		MinLatitude:    t.BoundingBox.MinLatitude,
+		MinLongitude:   t.BoundingBox.MinLongitude,
+		MaxLatitude:    t.BoundingBox.MaxLatitude,
+		MaxLongitude:   t.BoundingBox.MaxLongitude,
+		CreatedAt:      time.Now(),
+	}
+	if err := repo.db.Create(&trail).Error; err != nil {
+		return uuid.Nil, err
+	}
+	return trail.TrailID, nil
+}
+
+// UpdateTrailByID updates the name, zone and geometry of a trail, the columns following its path are
+// written along with it
+func (repo *Repository) UpdateTrailByID(t *domain.Trail) error {
+	return repo.db.Model(&postgresTrail{}).Where("trail_id = ?", t.TrailID).Select("TrailName", "ZoneID",
+		"StartLongitude", "StartLatitude", "EndLongitude", "EndLatitude", "Path", "Length", "Ascent", "Descent", "MaxGrade",
+		"MinLatitude", "MinLongitude", "MaxLatitude", "MaxLongitude").Updates(postgresTrail{
+		TrailName:      t.TrailName,
+		ZoneID:         t.ZoneID,
+		StartLatitude:  t.StartLatitude,
+		StartLongitude: t.StartLongitude,
+		EndLatitude:    t.EndLatitude,
+		EndLongitude:   t.EndLongitude,
+		Path:           t.Path,
+		Length:         t.Length,
+		Ascent:         t.Ascent,
+		Descent:        t.Descent,
+		MaxGrade:       t.MaxGrade,
+		MinLatitude:    t.BoundingBox.MinLatitude,
+		MinLongitude:   t.BoundingBox.MinLongitude,
+		MaxLatitude:    t.BoundingBox.MaxLatitude,
+		MaxLongitude:   t.BoundingBox.MaxLongitude,
 	}).Error
 }
 
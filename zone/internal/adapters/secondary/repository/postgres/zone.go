@@ -1,8 +1,12 @@
 package postgres
 
 import (
+	"time"
+
 	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Override the TableName method to specify the custom table name for the Zone model
@@ -45,6 +49,82 @@ func (repo *Repository) UpdateZone(id uuid.UUID, name string) error {
 	}).Error
 }
 
+// UpdateZoneBoundary sets the boundary of a zone, a nil boundary removes it
+func (repo *Repository) UpdateZoneBoundary(id uuid.UUID, boundary *domain.Boundary) error {
+	return repo.db.Model(&postgresZone{}).Where("zone_id = ?", id).Select("Boundary").Updates(postgresZone{
+		Boundary: boundary,
+	}).Error
+}
+
+// UpdateZoneTimeZone sets the time zone of a zone, an empty one is UTC
+func (repo *Repository) UpdateZoneTimeZone(id uuid.UUID, timeZone string) error {
+	return repo.db.Model(&postgresZone{}).Where("zone_id = ?", id).Select("TimeZone").Updates(postgresZone{
+		TimeZone: timeZone,
+	}).Error
+}
+
+// UpsertZoneFeatures creates or updates the trails and shelters of a zone, and its boundary when one is
+// given, in a single transaction. Nothing is written if any of them fails.
+func (repo *Repository) UpsertZoneFeatures(id uuid.UUID, boundary *domain.Boundary, trails []*domain.Trail, shelters []*domain.Shelter) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		if boundary != nil {
+			if err := tx.Model(&postgresZone{}).Where("zone_id = ?", id).Select("Boundary").Updates(postgresZone{
+				Boundary: boundary,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, t := range trails {
+			trail := postgresTrail{
+				TrailID:        t.TrailID,
+				TrailName:      t.TrailName,
+				ZoneID:         id,
+				StartLatitude:  t.StartLatitude,
+				StartLongitude: t.StartLongitude,
+				EndLatitude:    t.EndLatitude,
+				EndLongitude:   t.EndLongitude,
+				Path:           t.Path,
+				Length:         t.Length,
+				Ascent:         t.Ascent,
+				Descent:        t.Descent,
+				MaxGrade:       t.MaxGrade,
+				MinLatitude:    t.BoundingBox.MinLatitude,
+				MinLongitude:   t.BoundingBox.MinLongitude,
+				MaxLatitude:    t.BoundingBox.MaxLatitude,
+				MaxLongitude:   t.BoundingBox.MaxLongitude,
+				CreatedAt:      time.Now(),
+			}
+			// an existing trail keeps its creation time
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "trail_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"trail_name", "zone_id", "start_longitude", "start_latitude",
+					"end_longitude", "end_latitude", "path", "length", "ascent", "descent", "max_grade", "min_latitude", "min_longitude", "max_latitude", "max_longitude"}),
+			}).Create(&trail).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, s := range shelters {
+			shelter := toShelterPostgres(s)
+			shelter.Occupancy = 0
+			if shelter.Capacity > 0 {
+				shelter.ShelterAvailability = true
+			}
+			// an existing shelter keeps its occupancy, its availability follows it when it has a capacity
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "shelter_id"}},
+				DoUpdates: append(clause.AssignmentColumns([]string{"shelter_name", "trail_id", "longitude", "latitude", "capacity"}),
+					clause.Assignment{Column: clause.Column{Name: "shelter_availability"}, Value: gorm.Expr(
+						"CASE WHEN excluded.capacity > 0 THEN shelter.occupancy < excluded.capacity ELSE excluded.shelter_availability END")}),
+			}).Create(shelter).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (repo *Repository) DeleteZone(id uuid.UUID) error {
 	return repo.db.Delete(&postgresZone{}, "zone_id = ?", id).Error
 }
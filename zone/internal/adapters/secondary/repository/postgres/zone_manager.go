@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"errors"
+	"time"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type postgresZoneManager struct {
+	ZoneManagerID    uuid.UUID `gorm:"type:uuid;primaryKey;"`
+	CurrentWorkoutID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
+	ZoneID           uuid.UUID `gorm:"type:uuid"`
+	CurrentTrailID   uuid.UUID `gorm:"type:uuid"`
+	CurrentLongitude float64
+	CurrentLatitude  float64
+	CurrentTime      time.Time `gorm:"type:timestamp"`
+	ShelterID        uuid.UUID `gorm:"type:uuid"`
+	ShelterDistance  float64
+	ShelterScope     string
+	CreatedAt        time.Time `gorm:"type:timestamp"`
+	ClosedAt         time.Time `gorm:"type:timestamp"`
+}
+
+// Override the TableName method to specify the custom table name for the ZoneManager model
+func (postgresZoneManager) TableName() string {
+	return "zone_manager"
+}
+
+func (pzm *postgresZoneManager) toAggregate() *domain.ZoneManager {
+	return &domain.ZoneManager{
+		ZoneManagerID:    pzm.ZoneManagerID,
+		CurrentWorkoutID: pzm.CurrentWorkoutID,
+		ZoneID:           pzm.ZoneID,
+		CurrentTrailID:   pzm.CurrentTrailID,
+		CurrentLongitude: pzm.CurrentLongitude,
+		CurrentLatitude:  pzm.CurrentLatitude,
+		CurrentTime:      pzm.CurrentTime,
+		ShelterID:        pzm.ShelterID,
+		ShelterDistance:  pzm.ShelterDistance,
+		ShelterScope:     pzm.ShelterScope,
+		CreatedAt:        pzm.CreatedAt,
+		ClosedAt:         pzm.ClosedAt,
+	}
+}
+
+func toZoneManagerPostgres(zm *domain.ZoneManager) *postgresZoneManager {
+	return &postgresZoneManager{
+		ZoneManagerID:    zm.ZoneManagerID,
+		CurrentWorkoutID: zm.CurrentWorkoutID,
+		ZoneID:           zm.ZoneID,
+		CurrentTrailID:   zm.CurrentTrailID,
+		CurrentLongitude: zm.CurrentLongitude,
+		CurrentLatitude:  zm.CurrentLatitude,
+		CurrentTime:      zm.CurrentTime,
+		ShelterID:        zm.ShelterID,
+		ShelterDistance:  zm.ShelterDistance,
+		ShelterScope:     zm.ShelterScope,
+		CreatedAt:        zm.CreatedAt,
+		ClosedAt:         zm.ClosedAt,
+	}
+}
+
+// GetZoneManagerByWorkoutID returns the session of the workout, it returns nil when the workout sent no
+// location yet
+func (repo *Repository) GetZoneManagerByWorkoutID(wId uuid.UUID) (*domain.ZoneManager, error) {
+	var zoneManager postgresZoneManager
+	err := repo.db.Where("current_workout_id = ?", wId).First(&zoneManager).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return zoneManager.toAggregate(), nil
+}
+
+// SaveZoneManager creates or updates the session of a workout
+func (repo *Repository) SaveZoneManager(zm *domain.ZoneManager) error {
+	return repo.db.Save(toZoneManagerPostgres(zm)).Error
+}
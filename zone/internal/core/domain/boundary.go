@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// GeoJSON geometry types accepted for zone boundaries
+const (
+	GeoJSONPolygon      = "Polygon"
+	GeoJSONMultiPolygon = "MultiPolygon"
+)
+
+var (
+	ErrInvalidBoundary = errors.New("boundary must be a GeoJSON Polygon or MultiPolygon with closed rings")
+	ErrOutsideZone     = errors.New("location is outside of the zone boundary")
+)
+
+// Polygon is an outer ring followed by its holes, every ring is closed (its last point is its first)
+type Polygon [][]TrailPoint
+
+// Boundary is the area of a zone, made of one or more polygons
+type Boundary struct {
+	Polygons []Polygon
+}
+
+// geoJSONGeometry is a GeoJSON geometry or feature, positions are [longitude, latitude]
+type geoJSONGeometry struct {
+	Type        string           `json:"type"`
+	Coordinates json.RawMessage  `json:"coordinates,omitempty"`
+	Geometry    *geoJSONGeometry `json:"geometry,omitempty"`
+}
+
+// ParseBoundary reads a zone boundary from a GeoJSON Polygon or MultiPolygon geometry, or a Feature holding one
+func ParseBoundary(data []byte) (*Boundary, error) {
+	var geometry geoJSONGeometry
+	if err := json.Unmarshal(data, &geometry); err != nil {
+		return nil, ErrInvalidBoundary
+	}
+	if geometry.Type == "Feature" {
+		if geometry.Geometry == nil {
+			return nil, ErrInvalidBoundary
+		}
+		geometry = *geometry.Geometry
+	}
+
+	var polygons [][][][]float64
+	switch geometry.Type {
+	case GeoJSONPolygon:
+		var polygon [][][]float64
+		if err := json.Unmarshal(geometry.Coordinates, &polygon); err != nil {
+			return nil, ErrInvalidBoundary
+		}
+		polygons = append(polygons, polygon)
+	case GeoJSONMultiPolygon:
+		if err := json.Unmarshal(geometry.Coordinates, &polygons); err != nil {
+			return nil, ErrInvalidBoundary
+		}
+	default:
+		return nil, ErrInvalidBoundary
+	}
+
+	boundary := &Boundary{}
+	for _, coordinates := range polygons {
+		if len(coordinates) == 0 {
+			return nil, ErrInvalidBoundary
+		}
+		var polygon Polygon
+		for _, positions := range coordinates {
+			ring, err := toRing(positions)
+			if err != nil {
+				return nil, err
+			}
+			polygon = append(polygon, ring)
+		}
+		boundary.Polygons = append(boundary.Polygons, polygon)
+	}
+	if len(boundary.Polygons) == 0 {
+		return nil, ErrInvalidBoundary
+	}
+	return boundary, nil
+}
+
+// toRing converts GeoJSON positions to a closed ring of at least three distinct points
+func toRing(positions [][]float64) ([]TrailPoint, error) {
+	if len(positions) < 4 {
+		return nil, ErrInvalidBoundary
+	}
+	ring := make([]TrailPoint, 0, len(positions))
+	for _, position := range positions {
+		if len(position) < 2 || position[0] < -180 || position[0] > 180 || position[1] < -90 || position[1] > 90 {
+			return nil, ErrInvalidBoundary
+		}
+		ring = append(ring, TrailPoint{Latitude: position[1], Longitude: position[0]})
+	}
+	if ring[0] != ring[len(ring)-1] {
+		return nil, ErrInvalidBoundary
+	}
+	return ring, nil
+}
+
+// GeoJSON renders the boundary as a GeoJSON geometry, a Polygon when there is a single one
+func (b *Boundary) GeoJSON() map[string]interface{} {
+	polygons := make([][][][]float64, 0, len(b.Polygons))
+	for _, polygon := range b.Polygons {
+		rings := make([][][]float64, 0, len(polygon))
+		for _, ring := range polygon {
+			positions := make([][]float64, 0, len(ring))
+			for _, point := range ring {
+				positions = append(positions, []float64{point.Longitude, point.Latitude})
+			}
+			rings = append(rings, positions)
+		}
+		polygons = append(polygons, rings)
+	}
+
+	if len(polygons) == 1 {
+		return map[string]interface{}{"type": GeoJSONPolygon, "coordinates": polygons[0]}
+	}
+	return map[string]interface{}{"type": GeoJSONMultiPolygon, "coordinates": polygons}
+}
+
+// Contains tells whether a location is inside one of the polygons and outside of its holes.
+// Locations on an edge may be on either side.
+func (b *Boundary) Contains(latitude, longitude float64) bool {
+	for _, polygon := range b.Polygons {
+		if !ringContains(polygon[0], latitude, longitude) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if ringContains(hole, latitude, longitude) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// ringContains casts a ray east of the location and counts the edges of the ring it crosses
+func ringContains(ring []TrailPoint, latitude, longitude float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Latitude > latitude) != (b.Latitude > latitude) {
+			crossing := a.Longitude + (latitude-a.Latitude)*(b.Longitude-a.Longitude)/(b.Latitude-a.Latitude)
+			if longitude < crossing {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// Contains tells whether a location is inside the zone, zones without a boundary contain every location
+func (z *Zone) Contains(latitude, longitude float64) bool {
+	return z.Boundary == nil || z.Boundary.Contains(latitude, longitude)
+}
+
+// ContainsTrail tells whether the start, end and every point of the path of a trail are inside the zone
+func (z *Zone) ContainsTrail(trail *Trail) bool {
+	if !z.Contains(trail.StartLatitude, trail.StartLongitude) || !z.Contains(trail.EndLatitude, trail.EndLongitude) {
+		return false
+	}
+	for _, point := range trail.Path {
+		if !z.Contains(point.Latitude, point.Longitude) {
+			return false
+		}
+	}
+	return true
+}
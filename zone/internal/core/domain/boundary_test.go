@@ -0,0 +1,114 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
+)
+
+// a square around Hamilton with a hole over the harbour
+const testBoundary = `{
+	"type": "Polygon",
+	"coordinates": [
+		[[-80.0, 43.2], [-79.8, 43.2], [-79.8, 43.3], [-80.0, 43.3], [-80.0, 43.2]],
+		[[-79.9, 43.26], [-79.85, 43.26], [-79.85, 43.29], [-79.9, 43.29], [-79.9, 43.26]]
+	]
+}`
+
+func TestParseBoundary(t *testing.T) {
+	type testCase struct {
+		test        string
+		geoJSON     string
+		polygons    int
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{test: "Polygon", geoJSON: testBoundary, polygons: 1},
+		{test: "Feature", geoJSON: `{"type": "Feature", "properties": {}, "geometry": ` + testBoundary + `}`, polygons: 1},
+		{test: "MultiPolygon", geoJSON: `{"type": "MultiPolygon", "coordinates": [
+			[[[0, 0], [1, 0], [1, 1], [0, 0]]],
+			[[[5, 5], [6, 5], [6, 6], [5, 5]]]
+		]}`, polygons: 2},
+		{test: "Unsupported type", geoJSON: `{"type": "LineString", "coordinates": [[0, 0], [1, 1]]}`, expectedErr: domain.ErrInvalidBoundary},
+		{test: "Open ring", geoJSON: `{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 1]]]}`, expectedErr: domain.ErrInvalidBoundary},
+		{test: "Too few points", geoJSON: `{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [0, 0]]]}`, expectedErr: domain.ErrInvalidBoundary},
+		{test: "Out of range", geoJSON: `{"type": "Polygon", "coordinates": [[[0, 0], [200, 0], [1, 1], [0, 0]]]}`, expectedErr: domain.ErrInvalidBoundary},
+		{test: "Feature without geometry", geoJSON: `{"type": "Feature"}`, expectedErr: domain.ErrInvalidBoundary},
+		{test: "Malformed", geoJSON: `{"type": "Polygon", "coordinates": [`, expectedErr: domain.ErrInvalidBoundary},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.test, func(t *testing.T) {
+			boundary, err := domain.ParseBoundary([]byte(tc.geoJSON))
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if err == nil && len(boundary.Polygons) != tc.polygons {
+				t.Errorf("expected %d polygons, got %d", tc.polygons, len(boundary.Polygons))
+			}
+		})
+	}
+}
+
+func TestBoundary_Contains(t *testing.T) {
+	boundary, err := domain.ParseBoundary([]byte(testBoundary))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	locations := []struct {
+		name      string
+		latitude  float64
+		longitude float64
+		inside    bool
+	}{
+		{name: "inside", latitude: 43.22, longitude: -79.95, inside: true},
+		{name: "in the hole", latitude: 43.27, longitude: -79.87, inside: false},
+		{name: "north", latitude: 43.35, longitude: -79.9, inside: false},
+		{name: "west", latitude: 43.25, longitude: -80.1, inside: false},
+		{name: "longitude swapped with latitude", latitude: -79.95, longitude: 43.22, inside: false},
+	}
+	for _, location := range locations {
+		if got := boundary.Contains(location.latitude, location.longitude); got != location.inside {
+			t.Errorf("%s: expected inside to be %v, got %v", location.name, location.inside, got)
+		}
+	}
+
+	// A zone without boundary contains everything
+	zone := domain.Zone{}
+	if !zone.Contains(-79.95, 43.22) {
+		t.Errorf("expected a zone without boundary to contain every location")
+	}
+
+	zone.Boundary = boundary
+	inside := domain.Trail{StartLatitude: 43.21, StartLongitude: -79.99, EndLatitude: 43.29, EndLongitude: -79.95}
+	if !zone.ContainsTrail(&inside) {
+		t.Errorf("expected the trail to be inside the zone")
+	}
+	crossing := inside
+	if err := crossing.SetPath([]domain.TrailPoint{{Latitude: 43.21, Longitude: -79.99}, {Latitude: 43.27, Longitude: -79.87}, {Latitude: 43.29, Longitude: -79.95}}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if zone.ContainsTrail(&crossing) {
+		t.Errorf("expected a trail going through the hole to be outside the zone")
+	}
+}
+
+func TestBoundary_GeoJSON(t *testing.T) {
+	boundary, _ := domain.ParseBoundary([]byte(testBoundary))
+	data, err := json.Marshal(boundary.GeoJSON())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	parsed, err := domain.ParseBoundary(data)
+	if err != nil {
+		t.Fatalf("expected the rendered boundary to be parsed, got %v", err)
+	}
+	if len(parsed.Polygons) != 1 || len(parsed.Polygons[0]) != 2 || parsed.Polygons[0][1][1] != boundary.Polygons[0][1][1] {
+		t.Errorf("expected the boundary to round trip, got %+v", parsed)
+	}
+}
@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/umahmood/haversine"
+)
+
+const (
+	DifficultyEasy     = "easy"
+	DifficultyModerate = "moderate"
+	DifficultyHard     = "hard"
+
+	PreferenceCardio   = "cardio"
+	PreferenceStrength = "strength"
+
+	// length in km, and climb in metres, from which a trail gets the full difficulty for them
+	hardTrailLength = 20.0
+	hardTrailAscent = 800.0
+	// climb in metres per km from which a trail is as hilly as it gets for a recommendation
+	hillyTrailClimb = 50.0
+	// length in km of the run of a player with no past workouts
+	defaultTargetLength = 5.0
+)
+
+// TrailDifficulty rates a trail from 0 to 10 from its length, its climb and how few shelters it has per km
+func TrailDifficulty(trail *Trail, shelters int) float64 {
+	length := trailLength(trail)
+	difficulty := math.Min(length/hardTrailLength, 1) * 4
+	difficulty += math.Min(trail.Ascent/hardTrailAscent, 1) * 4
+
+	// a trail with a shelter every km or less is not made harder by its shelters
+	sparsity := 1.0
+	if length > 0 {
+		sparsity -= math.Min(float64(shelters)/length, 1)
+	} else if shelters > 0 {
+		sparsity = 0
+	}
+	difficulty += sparsity * 2
+	return difficulty
+}
+
+// DifficultyLevel returns the level of a difficulty given by TrailDifficulty
+func DifficultyLevel(difficulty float64) string {
+	switch {
+	case difficulty < 3.5:
+		return DifficultyEasy
+	case difficulty < 6.5:
+		return DifficultyModerate
+	default:
+		return DifficultyHard
+	}
+}
+
+// trailLength returns the length of the path of the trail, or the distance from its start to its end for
+// straight trails
+func trailLength(trail *Trail) float64 {
+	if trail.Length > 0 {
+		return trail.Length
+	}
+	_, km := haversine.Distance(
+		haversine.Coord{Lat: trail.StartLatitude, Lon: trail.StartLongitude},
+		haversine.Coord{Lat: trail.EndLatitude, Lon: trail.EndLongitude},
+	)
+	return km
+}
+
+// PlayerProfile is what is known about a player to recommend trails
+type PlayerProfile struct {
+	// cardio or strength
+	Preference string
+	// age in years, 0 when unknown
+	Age uint8
+	// average distance in km of the recent workouts of the player, 0 when there are none
+	AverageDistance float64
+}
+
+// TargetLength returns the length in km of a trail that suits the player, a bit longer than their usual
+// workouts and shorter for older players
+func (p PlayerProfile) TargetLength() float64 {
+	target := defaultTargetLength
+	if p.AverageDistance > 0 {
+		target = p.AverageDistance * 1.1
+	}
+	switch {
+	case p.Age >= 60:
+		target = math.Min(target, 5)
+	case p.Age >= 45:
+		target = math.Min(target, 10)
+	}
+	return math.Max(1, math.Min(target, 42.2))
+}
+
+// MaxDifficulty returns the highest difficulty recommended to the player
+func (p PlayerProfile) MaxDifficulty() float64 {
+	switch {
+	case p.Age >= 60:
+		return 5
+	case p.Age >= 45:
+		return 7
+	default:
+		return 10
+	}
+}
+
+type TrailRecommendation struct {
+	Trail *Trail
+	// difficulty of the trail from 0 to 10
+	Difficulty float64
+	// how well the trail suits the player from 0 to 1
+	Score float64
+}
+
+// RecommendTrails ranks the trails for the player, best first. Cardio players get trails close to their
+// usual distance and flat, strength players get hilly ones. Trails harder than the player should run are
+// kept but pushed down. shelters has the number of shelters of each trail.
+func RecommendTrails(profile PlayerProfile, trails []*Trail, shelters map[uuid.UUID]int) []TrailRecommendation {
+	target := profile.TargetLength()
+	recommendations := make([]TrailRecommendation, 0, len(trails))
+	for _, trail := range trails {
+		length := trailLength(trail)
+		lengthFit := 1 / (1 + math.Abs(length-target)/target)
+		hilliness := 0.0
+		if length > 0 {
+			hilliness = math.Min(trail.Ascent/length/hillyTrailClimb, 1)
+		}
+
+		var score float64
+		if profile.Preference == PreferenceStrength {
+			score = 0.4*lengthFit + 0.6*hilliness
+		} else {
+			score = 0.6*lengthFit + 0.4*(1-hilliness)
+		}
+
+		difficulty := TrailDifficulty(trail, shelters[trail.TrailID])
+		if difficulty > profile.MaxDifficulty() {
+			score /= 2
+		}
+		recommendations = append(recommendations, TrailRecommendation{Trail: trail, Difficulty: difficulty, Score: score})
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Score > recommendations[j].Score
+	})
+	return recommendations
+}
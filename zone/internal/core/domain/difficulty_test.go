@@ -0,0 +1,103 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+// straightTrail returns a trail going east along the equator for about km kilometres
+func straightTrail(km float64, ascent float64) *domain.Trail {
+	return &domain.Trail{
+		TrailID:      uuid.New(),
+		EndLongitude: km / 111.195,
+		Ascent:       ascent,
+	}
+}
+
+func TestTrailDifficulty(t *testing.T) {
+	short := straightTrail(2, 0)
+	long := straightTrail(20, 0)
+	hilly := straightTrail(20, 1000)
+
+	// A short flat trail with a shelter every km is easy
+	difficulty := domain.TrailDifficulty(short, 2)
+	if level := domain.DifficultyLevel(difficulty); level != domain.DifficultyEasy {
+		t.Errorf("expected short trail to be easy, got %s (%f)", level, difficulty)
+	}
+
+	// Length, climb and missing shelters each make a trail harder
+	if domain.TrailDifficulty(long, 20) <= domain.TrailDifficulty(short, 2) {
+		t.Error("expected long trail to be harder than short trail")
+	}
+	if domain.TrailDifficulty(hilly, 20) <= domain.TrailDifficulty(long, 20) {
+		t.Error("expected hilly trail to be harder than flat trail")
+	}
+	if domain.TrailDifficulty(long, 0) <= domain.TrailDifficulty(long, 20) {
+		t.Error("expected trail without shelters to be harder")
+	}
+
+	// A long hilly trail without shelters gets the full difficulty
+	difficulty = domain.TrailDifficulty(hilly, 0)
+	if difficulty < 9.99 || difficulty > 10 {
+		t.Errorf("expected difficulty of 10, got %f", difficulty)
+	}
+	if level := domain.DifficultyLevel(difficulty); level != domain.DifficultyHard {
+		t.Errorf("expected hilly trail to be hard, got %s", level)
+	}
+}
+
+func TestPlayerProfile_TargetLength(t *testing.T) {
+	cases := []struct {
+		profile  domain.PlayerProfile
+		expected float64
+	}{
+		{domain.PlayerProfile{}, 5},
+		{domain.PlayerProfile{AverageDistance: 10, Age: 30}, 11},
+		{domain.PlayerProfile{AverageDistance: 20, Age: 50}, 10},
+		{domain.PlayerProfile{AverageDistance: 20, Age: 65}, 5},
+		{domain.PlayerProfile{AverageDistance: 0.2}, 1},
+	}
+	for _, c := range cases {
+		if target := c.profile.TargetLength(); target < c.expected-1e-9 || target > c.expected+1e-9 {
+			t.Errorf("expected target of %f km for %+v, got %f", c.expected, c.profile, target)
+		}
+	}
+}
+
+func TestRecommendTrails(t *testing.T) {
+	flat := straightTrail(5, 0)
+	hilly := straightTrail(5, 300)
+	long := straightTrail(30, 0)
+	trails := []*domain.Trail{long, hilly, flat}
+	shelters := map[uuid.UUID]int{flat.TrailID: 5, hilly.TrailID: 5}
+
+	// A cardio player who runs about 5 km gets the flat trail of that length first
+	cardio := domain.RecommendTrails(domain.PlayerProfile{Preference: domain.PreferenceCardio, AverageDistance: 4.5}, trails, shelters)
+	if len(cardio) != 3 {
+		t.Fatalf("expected 3 recommendations, got %d", len(cardio))
+	}
+	if cardio[0].Trail != flat || cardio[2].Trail != long {
+		t.Errorf("expected flat, hilly then long trail for cardio, got %v", cardio)
+	}
+
+	// A strength player gets the hilly trail first
+	strength := domain.RecommendTrails(domain.PlayerProfile{Preference: domain.PreferenceStrength, AverageDistance: 4.5}, trails, shelters)
+	if strength[0].Trail != hilly {
+		t.Errorf("expected hilly trail first for strength, got %v", strength)
+	}
+	for i := 1; i < len(strength); i++ {
+		if strength[i].Score > strength[i-1].Score {
+			t.Errorf("expected recommendations sorted by score, got %v", strength)
+		}
+	}
+
+	// Trails too hard for an older player are pushed down
+	hard := straightTrail(10, 1000)
+	easy := straightTrail(4, 60)
+	older := domain.RecommendTrails(domain.PlayerProfile{Preference: domain.PreferenceStrength, Age: 65}, []*domain.Trail{hard, easy}, nil)
+	if older[0].Trail != easy {
+		t.Errorf("expected easy trail first for an older player, got %v", older)
+	}
+}
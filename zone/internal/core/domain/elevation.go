@@ -0,0 +1,70 @@
+package domain
+
+import "math"
+
+// ElevationSegment is the part of a trail between two consecutive points of its path
+type ElevationSegment struct {
+	// distance in km from the start of the trail to the start of the segment
+	Distance float64
+	// length of the segment in km
+	Length float64
+	// elevation at the start and the end of the segment in metres
+	StartElevation float64
+	EndElevation   float64
+	// climb over the length of the segment in percent, negative when it goes down
+	Grade float64
+}
+
+// HasElevation returns whether every point of the path of the trail has an elevation
+func (t *Trail) HasElevation() bool {
+	if len(t.Path) < 2 {
+		return false
+	}
+	for _, point := range t.Path {
+		if point.Elevation == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// ElevationProfile returns the segments of the path of the trail with their grade, it is empty when the
+// path has no elevation
+func (t *Trail) ElevationProfile() []ElevationSegment {
+	if !t.HasElevation() {
+		return nil
+	}
+
+	segments := make([]ElevationSegment, 0, len(t.Path)-1)
+	distance := 0.0
+	for i := 1; i < len(t.Path); i++ {
+		a, b := t.Path[i-1], t.Path[i]
+		segment := ElevationSegment{
+			Distance:       distance,
+			Length:         pointDistance(a, b),
+			StartElevation: *a.Elevation,
+			EndElevation:   *b.Elevation,
+		}
+		// the grade of points on top of each other is left at 0
+		if segment.Length > 0 {
+			segment.Grade = (segment.EndElevation - segment.StartElevation) / (segment.Length * 1000) * 100
+		}
+		segments = append(segments, segment)
+		distance += segment.Length
+	}
+	return segments
+}
+
+// setElevation sets the ascent, descent and steepest grade of the trail from its path
+func (t *Trail) setElevation() {
+	t.Ascent, t.Descent, t.MaxGrade = 0, 0, 0
+	for _, segment := range t.ElevationProfile() {
+		climb := segment.EndElevation - segment.StartElevation
+		if climb > 0 {
+			t.Ascent += climb
+		} else {
+			t.Descent -= climb
+		}
+		t.MaxGrade = math.Max(t.MaxGrade, math.Abs(segment.Grade))
+	}
+}
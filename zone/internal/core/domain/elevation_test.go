@@ -0,0 +1,104 @@
+package domain_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
+)
+
+const testGPXElevation = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk>
+    <name>Hill</name>
+    <trkseg>
+      <trkpt lat="0" lon="0"><ele>100</ele></trkpt>
+      <trkpt lat="0" lon="0.009"><ele>150</ele></trkpt>
+      <trkpt lat="0" lon="0.018"><ele>120.5</ele></trkpt>
+    </trkseg>
+  </trk>
+</gpx>`
+
+func elevation(metres float64) *float64 {
+	return &metres
+}
+
+func TestParseTrailFile_Elevation(t *testing.T) {
+	expected := []float64{100, 150, 120.5}
+
+	_, path, err := domain.ParseTrailFile(domain.TrailFormatGPX, []byte(testGPXElevation))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for i, point := range path {
+		if point.Elevation == nil || *point.Elevation != expected[i] {
+			t.Errorf("expected point %d at %f m, got %v", i, expected[i], point.Elevation)
+		}
+	}
+
+	fitPath := []domain.TrailPoint{
+		{Latitude: 0, Longitude: 0, Elevation: elevation(100)},
+		{Latitude: 0, Longitude: 0.009, Elevation: elevation(150)},
+		{Latitude: 0, Longitude: 0.018, Elevation: elevation(120.4)},
+	}
+	_, path, err = domain.ParseTrailFile(domain.TrailFormatFIT, fitFile("Hill", fitPath))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for i, point := range path {
+		if point.Elevation == nil || math.Abs(*point.Elevation-*fitPath[i].Elevation) > 0.2 {
+			t.Errorf("expected point %d at %f m, got %v", i, *fitPath[i].Elevation, point.Elevation)
+		}
+	}
+
+	// Files without elevation leave it unknown
+	_, path, _ = domain.ParseTrailFile(domain.TrailFormatFIT, fitFile("Flat", testTrailPath))
+	for i, point := range path {
+		if point.Elevation != nil {
+			t.Errorf("expected point %d without elevation, got %f", i, *point.Elevation)
+		}
+	}
+}
+
+func TestTrail_ElevationProfile(t *testing.T) {
+	var trail domain.Trail
+	err := trail.SetPath([]domain.TrailPoint{
+		{Latitude: 0, Longitude: 0, Elevation: elevation(100)},
+		{Latitude: 0, Longitude: 0.009, Elevation: elevation(150)},
+		{Latitude: 0, Longitude: 0.018, Elevation: elevation(120)},
+		{Latitude: 0, Longitude: 0.027, Elevation: elevation(130)},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if trail.Ascent != 60 || trail.Descent != 30 {
+		t.Errorf("expected 60 m up and 30 m down, got %f and %f", trail.Ascent, trail.Descent)
+	}
+
+	// 0.009 degrees of longitude on the equator are about 1 km
+	profile := trail.ElevationProfile()
+	if len(profile) != 3 {
+		t.Fatalf("expected 3 segments, got %d", len(profile))
+	}
+	expectedGrades := []float64{5, -3, 1}
+	for i, segment := range profile {
+		if math.Abs(segment.Grade-expectedGrades[i]) > 0.05 {
+			t.Errorf("expected segment %d at %f %%, got %f", i, expectedGrades[i], segment.Grade)
+		}
+	}
+	if math.Abs(profile[2].Distance-profile[0].Length-profile[1].Length) > 1e-9 {
+		t.Errorf("expected the last segment to start after the first two, got %f km", profile[2].Distance)
+	}
+	if math.Abs(trail.MaxGrade-profile[0].Grade) > 1e-9 {
+		t.Errorf("expected the steepest grade to be %f, got %f", profile[0].Grade, trail.MaxGrade)
+	}
+
+	// A path missing an elevation has no profile
+	if err := trail.SetPath(append(testTrailPath[:2:2], domain.TrailPoint{Latitude: 43.2630, Longitude: -79.9201, Elevation: elevation(90)})); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if trail.HasElevation() || trail.ElevationProfile() != nil || trail.Ascent != 0 || trail.MaxGrade != 0 {
+		t.Errorf("expected no elevation, got %+v", trail)
+	}
+}
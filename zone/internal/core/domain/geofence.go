@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Geofence events, a workout enters the fence around the start and the end of its trail and around shelters
+const (
+	GeofenceTrailStartEntered = "trail_start_entered"
+	GeofenceTrailEndReached   = "trail_end_reached"
+	GeofenceShelterArrived    = "shelter_arrived"
+	GeofenceShelterLeft       = "shelter_left"
+)
+
+const (
+	// radius in km of the fences
+	DefaultGeofenceRadius = 0.03
+	// a workout has to get this many times the radius away to leave a fence, so that a location jumping around
+	// its edge is not taken in and out of it
+	GeofenceExitFactor = 1.5
+)
+
+type GeofenceEvent struct {
+	// what happened
+	Type      string
+	WorkoutID uuid.UUID
+	// trail of the workout, or of the shelter for shelter events
+	TrailID uuid.UUID
+	// shelter of the event, nil for trail events
+	ShelterID uuid.UUID
+	// distance in km from the centre of the fence
+	Distance  float64
+	Latitude  float64
+	Longitude float64
+	Time      time.Time
+}
+
+// GeofenceState is what fences a workout is in
+type GeofenceState struct {
+	WorkoutID uuid.UUID
+	// trail the start and end fences are for
+	TrailID      uuid.UUID
+	AtTrailStart bool
+	AtTrailEnd   bool
+	// shelter the workout is at, nil when it is at none
+	Shelter *Shelter
+}
+
+func NewGeofenceState(wId uuid.UUID) *GeofenceState {
+	return &GeofenceState{WorkoutID: wId}
+}
+
+// Update moves the workout to the location and returns the events of the fences it entered or left. trail is
+// nil when the workout is not following one, and shelters are the shelters around the location closest first,
+// any shelter further than the exit radius can be left out.
+func (s *GeofenceState) Update(trail *Trail, shelters []ShelterDistance, radius float64, latitude float64, longitude float64, at time.Time) []GeofenceEvent {
+	var events []GeofenceEvent
+	location := TrailPoint{Latitude: latitude, Longitude: longitude}
+	event := func(eventType string, tId uuid.UUID, sId uuid.UUID, distance float64) {
+		events = append(events, GeofenceEvent{
+			Type:      eventType,
+			WorkoutID: s.WorkoutID,
+			TrailID:   tId,
+			ShelterID: sId,
+			Distance:  distance,
+			Latitude:  latitude,
+			Longitude: longitude,
+			Time:      at,
+		})
+	}
+
+	// the fences of another trail are entered from the outside
+	tId := uuid.Nil
+	if trail != nil {
+		tId = trail.TrailID
+	}
+	if tId != s.TrailID {
+		s.TrailID = tId
+		s.AtTrailStart, s.AtTrailEnd = false, false
+	}
+	if trail != nil {
+		distance := pointDistance(location, TrailPoint{Latitude: trail.StartLatitude, Longitude: trail.StartLongitude})
+		if inFence(s.AtTrailStart, distance, radius) != s.AtTrailStart {
+			s.AtTrailStart = !s.AtTrailStart
+			if s.AtTrailStart {
+				event(GeofenceTrailStartEntered, tId, uuid.Nil, distance)
+			}
+		}
+		distance = pointDistance(location, TrailPoint{Latitude: trail.EndLatitude, Longitude: trail.EndLongitude})
+		if inFence(s.AtTrailEnd, distance, radius) != s.AtTrailEnd {
+			s.AtTrailEnd = !s.AtTrailEnd
+			if s.AtTrailEnd {
+				event(GeofenceTrailEndReached, tId, uuid.Nil, distance)
+			}
+		}
+	}
+
+	if s.Shelter != nil {
+		distance := pointDistance(location, TrailPoint{Latitude: s.Shelter.Latitude, Longitude: s.Shelter.Longitude})
+		if inFence(true, distance, radius) {
+			return events
+		}
+		event(GeofenceShelterLeft, s.Shelter.TrailID, s.Shelter.ShelterID, distance)
+		s.Shelter = nil
+	}
+	for _, shelter := range shelters {
+		if inFence(false, shelter.Distance, radius) {
+			arrived := *shelter.Shelter
+			s.Shelter = &arrived
+			event(GeofenceShelterArrived, shelter.Shelter.TrailID, shelter.Shelter.ShelterID, shelter.Distance)
+			break
+		}
+	}
+	return events
+}
+
+// inFence returns whether a location at the distance from the centre of a fence is in it, knowing whether it
+// was in it before
+func inFence(inside bool, distance float64, radius float64) bool {
+	if inside {
+		return distance <= radius*GeofenceExitFactor
+	}
+	return distance <= radius
+}
@@ -0,0 +1,115 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+// about 10 m of longitude along the equator
+const tenMetres = 0.01 / 111.195
+
+func eventTypes(events []domain.GeofenceEvent) []string {
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func expectEvents(t *testing.T, step string, events []domain.GeofenceEvent, expected ...string) {
+	t.Helper()
+	types := eventTypes(events)
+	if len(types) != len(expected) {
+		t.Errorf("%s: expected events %v, got %v", step, expected, types)
+		return
+	}
+	for i := range expected {
+		if types[i] != expected[i] {
+			t.Errorf("%s: expected events %v, got %v", step, expected, types)
+			return
+		}
+	}
+}
+
+func TestGeofenceState_Trail(t *testing.T) {
+	trail := &domain.Trail{TrailID: uuid.New(), EndLongitude: 100 * tenMetres}
+	state := domain.NewGeofenceState(uuid.New())
+	radius := domain.DefaultGeofenceRadius
+	now := time.Now()
+
+	// Starting at the start of the trail enters it once
+	events := state.Update(trail, nil, radius, 0, 0, now)
+	expectEvents(t, "at start", events, domain.GeofenceTrailStartEntered)
+	if events[0].TrailID != trail.TrailID || events[0].WorkoutID != state.WorkoutID || !events[0].Time.Equal(now) {
+		t.Errorf("expected event of the workout on the trail, got %+v", events[0])
+	}
+	expectEvents(t, "still at start", state.Update(trail, nil, radius, 0, 2*tenMetres, now))
+
+	// Just past the radius the workout is still in the fence, further out it has left it
+	expectEvents(t, "edge of start", state.Update(trail, nil, radius, 0, 4*tenMetres, now))
+	if !state.AtTrailStart {
+		t.Error("expected workout to still be at the start")
+	}
+	expectEvents(t, "left start", state.Update(trail, nil, radius, 0, 10*tenMetres, now))
+	if state.AtTrailStart {
+		t.Error("expected workout to have left the start")
+	}
+	expectEvents(t, "back at start", state.Update(trail, nil, radius, 0, 0, now), domain.GeofenceTrailStartEntered)
+
+	// Reaching the end is reported once
+	expectEvents(t, "at end", state.Update(trail, nil, radius, 0, 99*tenMetres, now), domain.GeofenceTrailEndReached)
+	expectEvents(t, "still at end", state.Update(trail, nil, radius, 0, 100*tenMetres, now))
+
+	// Another trail starting here is entered from the outside
+	other := &domain.Trail{TrailID: uuid.New(), StartLongitude: 100 * tenMetres, EndLongitude: 200 * tenMetres}
+	expectEvents(t, "other trail", state.Update(other, nil, radius, 0, 100*tenMetres, now), domain.GeofenceTrailStartEntered)
+
+	// Without a trail only shelters are fenced
+	expectEvents(t, "no trail", state.Update(nil, nil, radius, 0, 0, now))
+}
+
+func TestGeofenceState_Shelters(t *testing.T) {
+	first := &domain.Shelter{ShelterID: uuid.New(), TrailID: uuid.New(), Longitude: 0}
+	second := &domain.Shelter{ShelterID: uuid.New(), TrailID: uuid.New(), Longitude: 5 * tenMetres}
+	state := domain.NewGeofenceState(uuid.New())
+	radius := domain.DefaultGeofenceRadius
+	index := domain.NewShelterIndex(domain.DefaultShelterIndexCellSize)
+	index.Insert(*first)
+	index.Insert(*second)
+	update := func(longitude float64) []domain.GeofenceEvent {
+		shelters := index.WithinRadius(0, longitude, radius*domain.GeofenceExitFactor)
+		return state.Update(nil, shelters, radius, 0, longitude, time.Now())
+	}
+
+	// Far from both shelters nothing happens
+	expectEvents(t, "away", update(-20*tenMetres))
+
+	// Arriving at the first shelter
+	events := update(-tenMetres)
+	expectEvents(t, "arrived", events, domain.GeofenceShelterArrived)
+	if events[0].ShelterID != first.ShelterID || events[0].TrailID != first.TrailID {
+		t.Errorf("expected arrival at the first shelter, got %+v", events[0])
+	}
+
+	// The second shelter gets closer but the workout has not left the first one yet
+	expectEvents(t, "between", update(3*tenMetres))
+	if state.Shelter == nil || state.Shelter.ShelterID != first.ShelterID {
+		t.Errorf("expected workout to still be at the first shelter, got %+v", state.Shelter)
+	}
+
+	// Past the exit radius of the first shelter it leaves it and arrives at the second one
+	events = update(5 * tenMetres)
+	expectEvents(t, "moved", events, domain.GeofenceShelterLeft, domain.GeofenceShelterArrived)
+	if events[0].ShelterID != first.ShelterID || events[1].ShelterID != second.ShelterID {
+		t.Errorf("expected to leave the first shelter for the second, got %+v", events)
+	}
+
+	// Leaving every shelter
+	expectEvents(t, "left", update(20*tenMetres), domain.GeofenceShelterLeft)
+	if state.Shelter != nil {
+		t.Errorf("expected workout to be at no shelter, got %+v", state.Shelter)
+	}
+}
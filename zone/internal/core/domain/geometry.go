@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"math"
+
+	"github.com/umahmood/haversine"
+)
+
+// DistanceFrom returns the distance in km from a location to the closest point of the trail.
+// Trails without a path are treated as a straight line from start to end.
+func (t *Trail) DistanceFrom(latitude, longitude float64) float64 {
+	path := t.Path
+	if len(path) < 2 {
+		path = []TrailPoint{
+			{Latitude: t.StartLatitude, Longitude: t.StartLongitude},
+			{Latitude: t.EndLatitude, Longitude: t.EndLongitude},
+		}
+	}
+
+	point := TrailPoint{Latitude: latitude, Longitude: longitude}
+	minDistance := math.MaxFloat64
+	for i := 1; i < len(path); i++ {
+		distance := DistanceToSegment(point, path[i-1], path[i])
+		if distance < minDistance {
+			minDistance = distance
+		}
+	}
+	return minDistance
+}
+
+// DistanceToSegment returns the distance in km from p to the segment a-b.
+// The closest point is found on a local equirectangular projection around p, which is accurate
+// for segments of a trail, the distance to it is then measured on the sphere.
+func DistanceToSegment(p, a, b TrailPoint) float64 {
+	closest, _ := closestOnSegment(p, a, b)
+	return pointDistance(p, closest)
+}
+
+// closestOnSegment returns the point of the segment a-b closest to p and the fraction of the segment
+// it is at, from 0 at a to 1 at b
+func closestOnSegment(p, a, b TrailPoint) (TrailPoint, float64) {
+	cosLat := math.Cos(p.Latitude * math.Pi / 180)
+	project := func(q TrailPoint) (float64, float64) {
+		return (q.Longitude - p.Longitude) * cosLat, q.Latitude - p.Latitude
+	}
+
+	ax, ay := project(a)
+	bx, by := project(b)
+	dx, dy := bx-ax, by-ay
+
+	// fraction of the segment where the perpendicular from p lands, clamped to the segment ends
+	f := 0.0
+	if lengthSquared := dx*dx + dy*dy; lengthSquared > 0 {
+		f = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/lengthSquared))
+	}
+	return interpolate(a, b, f), f
+}
+
+// interpolate returns the point at fraction f of the segment a-b, its elevation is only known when it
+// is known at both ends
+func interpolate(a, b TrailPoint, f float64) TrailPoint {
+	point := TrailPoint{
+		Latitude:  a.Latitude + f*(b.Latitude-a.Latitude),
+		Longitude: a.Longitude + f*(b.Longitude-a.Longitude),
+	}
+	if a.Elevation != nil && b.Elevation != nil {
+		elevation := *a.Elevation + f*(*b.Elevation-*a.Elevation)
+		point.Elevation = &elevation
+	}
+	return point
+}
+
+// samePosition returns whether two points are at the same place, whatever their elevation
+func samePosition(a, b TrailPoint) bool {
+	return a.Latitude == b.Latitude && a.Longitude == b.Longitude
+}
+
+// pointDistance returns the distance in km between two points on the sphere
+func pointDistance(a, b TrailPoint) float64 {
+	_, km := haversine.Distance(haversine.Coord{Lat: a.Latitude, Lon: a.Longitude}, haversine.Coord{Lat: b.Latitude, Lon: b.Longitude})
+	return km
+}
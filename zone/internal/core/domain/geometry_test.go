@@ -0,0 +1,58 @@
+package domain_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
+	"github.com/umahmood/haversine"
+)
+
+func TestDistanceToSegment(t *testing.T) {
+	a := domain.TrailPoint{Latitude: 43.26, Longitude: -79.92}
+	b := domain.TrailPoint{Latitude: 43.27, Longitude: -79.92}
+
+	// Beside the segment the distance is measured perpendicular to it
+	p := domain.TrailPoint{Latitude: 43.265, Longitude: -79.91}
+	_, expected := haversine.Distance(haversine.Coord{Lat: p.Latitude, Lon: p.Longitude}, haversine.Coord{Lat: 43.265, Lon: -79.92})
+	if d := domain.DistanceToSegment(p, a, b); math.Abs(d-expected) > 1e-3 {
+		t.Errorf("expected distance %f, got %f", expected, d)
+	}
+
+	// Past the end the distance is to the end point
+	p = domain.TrailPoint{Latitude: 43.28, Longitude: -79.92}
+	_, expected = haversine.Distance(haversine.Coord{Lat: p.Latitude, Lon: p.Longitude}, haversine.Coord{Lat: b.Latitude, Lon: b.Longitude})
+	if d := domain.DistanceToSegment(p, a, b); math.Abs(d-expected) > 1e-9 {
+		t.Errorf("expected distance %f, got %f", expected, d)
+	}
+
+	// A segment of a single point is that point
+	if d := domain.DistanceToSegment(a, a, a); d != 0 {
+		t.Errorf("expected no distance, got %f", d)
+	}
+}
+
+func TestTrail_DistanceFrom(t *testing.T) {
+	trail := domain.Trail{}
+	if err := trail.SetPath([]domain.TrailPoint{
+		{Latitude: 43.26, Longitude: -79.92},
+		{Latitude: 43.27, Longitude: -79.92},
+		{Latitude: 43.27, Longitude: -79.90},
+	}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Next to the corner of the path, far from the start and the straight line between the ends
+	if d := trail.DistanceFrom(43.2699, -79.9190); d > 0.02 {
+		t.Errorf("expected the location to be on the trail, got %f km", d)
+	}
+	if d := trail.DistanceFrom(43.265, -79.91); d < 0.4 {
+		t.Errorf("expected the location to be off the trail, got %f km", d)
+	}
+
+	// Without a path the trail is a straight line
+	straight := domain.Trail{StartLatitude: 43.26, StartLongitude: -79.92, EndLatitude: 43.27, EndLongitude: -79.90}
+	if d := straight.DistanceFrom(43.265, -79.91); d > 1e-3 {
+		t.Errorf("expected the location to be on the straight trail, got %f km", d)
+	}
+}
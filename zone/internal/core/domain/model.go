@@ -2,15 +2,19 @@ package domain
 
 import (
 	"errors"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
+	"github.com/umahmood/haversine"
 )
 
 var (
 	ErrInvalidTrail       = errors.New("no trail_id matched")
 	ErrInvalidZoneManager = errors.New("no trail_manager_id matched")
+	ErrInvalidTrailPath   = errors.New("trail path needs at least two points")
+	ErrZoneManagerClosed  = errors.New("workout of the trail manager was stopped")
 )
 
 type Shelter struct {
@@ -26,6 +30,14 @@ type Shelter struct {
 	Longitude float64
 	// latitude of the shelter
 	Latitude float64
+	// places in the shelter, 0 when it is not tracked and availability is set by hand
+	Capacity uint16
+	// places currently reserved by workouts
+	Occupancy uint16
+	// weekly periods the shelter is open, it is always open when there are none
+	OpeningHours []OpeningHours
+	// dated periods the shelter is closed
+	Closures []ShelterClosure
 }
 
 type Trail struct {
@@ -43,15 +55,106 @@ type Trail struct {
 	EndLongitude float64
 	// end latitude
 	EndLatitude float64
+	// points of the trail from start to end, empty for straight trails
+	Path []TrailPoint
+	// length of the trail in km
+	Length float64
+	// total climb along the path in metres, 0 when the path has no elevation
+	Ascent float64
+	// total drop along the path in metres
+	Descent float64
+	// steepest grade of a segment of the path in percent, climbing or descending
+	MaxGrade float64
+	// area covered by the trail
+	BoundingBox BoundingBox
 	// created time
 	CreatedAt time.Time
 }
 
+type TrailPoint struct {
+	// latitude of the point
+	Latitude float64
+	// longitude of the point
+	Longitude float64
+	// elevation above sea level in metres, nil when it is not known
+	Elevation *float64
+}
+
+type BoundingBox struct {
+	// south edge
+	MinLatitude float64
+	// west edge
+	MinLongitude float64
+	// north edge
+	MaxLatitude float64
+	// east edge
+	MaxLongitude float64
+}
+
 type Zone struct {
 	// id the zone
 	ZoneID uuid.UUID
 	// name of the zone
 	ZoneName string
+	// area of the zone, nil if the zone has no boundary
+	Boundary *Boundary
+	// IANA time zone the opening hours of its shelters are in, UTC when empty
+	TimeZone string
+}
+
+// SetPath sets the geometry of the trail, its start, end, length and bounding box follow the path
+func (t *Trail) SetPath(path []TrailPoint) error {
+	if len(path) < 2 {
+		return ErrInvalidTrailPath
+	}
+
+	t.Path = path
+	t.StartLatitude = path[0].Latitude
+	t.StartLongitude = path[0].Longitude
+	t.EndLatitude = path[len(path)-1].Latitude
+	t.EndLongitude = path[len(path)-1].Longitude
+	t.Length = 0
+	t.BoundingBox = BoundingBox{
+		MinLatitude:  path[0].Latitude,
+		MinLongitude: path[0].Longitude,
+		MaxLatitude:  path[0].Latitude,
+		MaxLongitude: path[0].Longitude,
+	}
+
+	for i := 1; i < len(path); i++ {
+		_, km := haversine.Distance(
+			haversine.Coord{Lat: path[i-1].Latitude, Lon: path[i-1].Longitude},
+			haversine.Coord{Lat: path[i].Latitude, Lon: path[i].Longitude},
+		)
+		t.Length += km
+		t.BoundingBox.MinLatitude = math.Min(t.BoundingBox.MinLatitude, path[i].Latitude)
+		t.BoundingBox.MinLongitude = math.Min(t.BoundingBox.MinLongitude, path[i].Longitude)
+		t.BoundingBox.MaxLatitude = math.Max(t.BoundingBox.MaxLatitude, path[i].Latitude)
+		t.BoundingBox.MaxLongitude = math.Max(t.BoundingBox.MaxLongitude, path[i].Longitude)
+	}
+	t.setElevation()
+	return nil
+}
+
+// MoveEnds moves the start and end of the trail. The ends of its path move with them, a moved end loses
+// its elevation, and the length, bounding box and climb follow the new path. Trails without a path stay
+// straight.
+func (t *Trail) MoveEnds(startLatitude, startLongitude, endLatitude, endLongitude float64) {
+	if len(t.Path) < 2 {
+		t.StartLatitude, t.StartLongitude = startLatitude, startLongitude
+		t.EndLatitude, t.EndLongitude = endLatitude, endLongitude
+		return
+	}
+
+	path := append([]TrailPoint(nil), t.Path...)
+	moveTo := func(point *TrailPoint, latitude, longitude float64) {
+		if point.Latitude != latitude || point.Longitude != longitude {
+			*point = TrailPoint{Latitude: latitude, Longitude: longitude}
+		}
+	}
+	moveTo(&path[0], startLatitude, startLongitude)
+	moveTo(&path[len(path)-1], endLatitude, endLongitude)
+	t.SetPath(path)
 }
 
 // func (t *Trail) CheckTrailShelterAvailable() (bool, error) {
@@ -102,7 +205,8 @@ func newShelter(sId uuid.UUID, tId uuid.UUID, availability bool, sName string, l
 
 }
 
-// Workout is a entity that represents a workout in all Domains
+// ZoneManager is the session of a workout in the zone manager, it follows the workout from its first
+// location until the workout is stopped
 type ZoneManager struct {
 	// ID is the identifier of the Entity, the ID is shared for all sub domains
 	ZoneManagerID uuid.UUID
@@ -118,12 +222,21 @@ type ZoneManager struct {
 	CurrentLatitude float64
 	// record of current time
 	CurrentTime time.Time
-	// current shelter that is the cloest
+	// last shelter published to the workout, nil until one is published
+	ShelterID uuid.UUID
+	// distance in km to the last published shelter and the scope it was found in
+	ShelterDistance float64
+	ShelterScope    string
 	// CreatedAt is the time when the trail manager was started
 	CreatedAt time.Time
+	// ClosedAt is the time when the workout was stopped, zero while it is running
+	ClosedAt time.Time
 }
 
 func NewZoneManager(wId uuid.UUID) (ZoneManager, error) {
+	if wId == uuid.Nil {
+		return ZoneManager{}, ErrInvalidZoneManager
+	}
 
 	return ZoneManager{
 		ZoneManagerID:    uuid.New(),
@@ -136,3 +249,40 @@ func NewZoneManager(wId uuid.UUID) (ZoneManager, error) {
 		CreatedAt:        time.Now(),
 	}, nil
 }
+
+// IsClosed returns whether the workout of the session was stopped
+func (zm *ZoneManager) IsClosed() bool {
+	return !zm.ClosedAt.IsZero()
+}
+
+// SetLocation records where the workout is and the trail it follows, trail is nil when it follows none
+func (zm *ZoneManager) SetLocation(trail *Trail, latitude float64, longitude float64, at time.Time) error {
+	if zm.IsClosed() {
+		return ErrZoneManagerClosed
+	}
+	zm.CurrentTrailID = uuid.Nil
+	if trail != nil {
+		zm.CurrentTrailID = trail.TrailID
+		zm.ZoneID = trail.ZoneID
+	}
+	zm.CurrentLatitude = latitude
+	zm.CurrentLongitude = longitude
+	zm.CurrentTime = at
+	return nil
+}
+
+// SetShelter records the shelter published to the workout
+func (zm *ZoneManager) SetShelter(sId uuid.UUID, distance float64, scope string) {
+	zm.ShelterID = sId
+	zm.ShelterDistance = distance
+	zm.ShelterScope = scope
+}
+
+// Close ends the session when the workout is stopped
+func (zm *ZoneManager) Close(at time.Time) error {
+	if zm.IsClosed() {
+		return ErrZoneManagerClosed
+	}
+	zm.ClosedAt = at
+	return nil
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultShelterReservationTTL is how long a shelter place is held for a workout unless it is released
+const DefaultShelterReservationTTL = 30 * time.Minute
+
+// Reasons a workout does not hold a shelter place
+const (
+	ReservationReasonUnavailable = "unavailable"
+	ReservationReasonNotFound    = "not_found"
+	ReservationReasonExpired     = "expired"
+	ReservationReasonClosed      = "closed"
+)
+
+var (
+	ErrShelterUnavailable = errors.New("shelter has no place left")
+)
+
+// ShelterReservation is a place in a shelter held for a workout that started the shelter option
+type ShelterReservation struct {
+	// id of the reservation
+	ReservationID uuid.UUID
+	// shelter the place is in
+	ShelterID uuid.UUID
+	// workout holding the place, a workout holds at most one place
+	WorkoutID uuid.UUID
+	// time the place was taken
+	ReservedAt time.Time
+	// time after which the place is given back if the workout did not release it
+	ExpiresAt time.Time
+}
+
+func NewShelterReservation(sId uuid.UUID, wId uuid.UUID, ttl time.Duration) *ShelterReservation {
+	if ttl <= 0 {
+		ttl = DefaultShelterReservationTTL
+	}
+	now := time.Now()
+	return &ShelterReservation{
+		ReservationID: uuid.New(),
+		ShelterID:     sId,
+		WorkoutID:     wId,
+		ReservedAt:    now,
+		ExpiresAt:     now.Add(ttl),
+	}
+}
+
+// Reserve takes a place in the shelter, shelters without capacity only need to be available
+func (s *Shelter) Reserve() error {
+	if !s.ShelterAvailability || (s.Capacity > 0 && s.Occupancy >= s.Capacity) {
+		return ErrShelterUnavailable
+	}
+	s.Occupancy++
+	s.UpdateAvailability()
+	return nil
+}
+
+// Release gives a place of the shelter back
+func (s *Shelter) Release() {
+	if s.Occupancy > 0 {
+		s.Occupancy--
+	}
+	s.UpdateAvailability()
+}
+
+// UpdateAvailability derives the availability of a shelter with a capacity from its occupancy,
+// the availability of shelters without capacity is left as set
+func (s *Shelter) UpdateAvailability() {
+	if s.Capacity > 0 {
+		s.ShelterAvailability = s.Occupancy < s.Capacity
+	}
+}
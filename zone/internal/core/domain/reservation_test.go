@@ -0,0 +1,72 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+func TestShelter_ReserveRelease(t *testing.T) {
+	shelter := domain.Shelter{ShelterID: uuid.New(), ShelterAvailability: true, Capacity: 2}
+
+	if err := shelter.Reserve(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !shelter.ShelterAvailability || shelter.Occupancy != 1 {
+		t.Errorf("expected 1 place taken and the shelter available, got %+v", shelter)
+	}
+	if err := shelter.Reserve(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if shelter.ShelterAvailability {
+		t.Errorf("expected a full shelter to be unavailable")
+	}
+	if err := shelter.Reserve(); !errors.Is(err, domain.ErrShelterUnavailable) {
+		t.Errorf("expected error %v, got %v", domain.ErrShelterUnavailable, err)
+	}
+	if shelter.Occupancy != 2 {
+		t.Errorf("expected a refused reservation to leave 2 places taken, got %d", shelter.Occupancy)
+	}
+
+	shelter.Release()
+	if !shelter.ShelterAvailability || shelter.Occupancy != 1 {
+		t.Errorf("expected a released place to make the shelter available, got %+v", shelter)
+	}
+	shelter.Release()
+	shelter.Release()
+	if shelter.Occupancy != 0 {
+		t.Errorf("expected the occupancy to stop at 0, got %d", shelter.Occupancy)
+	}
+}
+
+func TestShelter_ReserveWithoutCapacity(t *testing.T) {
+	shelter := domain.Shelter{ShelterID: uuid.New(), ShelterAvailability: true}
+	for i := 0; i < 3; i++ {
+		if err := shelter.Reserve(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if !shelter.ShelterAvailability || shelter.Occupancy != 3 {
+		t.Errorf("expected a shelter without capacity to stay available, got %+v", shelter)
+	}
+
+	closed := domain.Shelter{ShelterID: uuid.New(), ShelterAvailability: false}
+	if err := closed.Reserve(); !errors.Is(err, domain.ErrShelterUnavailable) {
+		t.Errorf("expected error %v, got %v", domain.ErrShelterUnavailable, err)
+	}
+}
+
+func TestNewShelterReservation(t *testing.T) {
+	reservation := domain.NewShelterReservation(uuid.New(), uuid.New(), time.Hour)
+	if reservation.ExpiresAt.Sub(reservation.ReservedAt) != time.Hour {
+		t.Errorf("expected the reservation to expire after an hour, got %v", reservation.ExpiresAt.Sub(reservation.ReservedAt))
+	}
+
+	reservation = domain.NewShelterReservation(uuid.New(), uuid.New(), 0)
+	if reservation.ExpiresAt.Sub(reservation.ReservedAt) != domain.DefaultShelterReservationTTL {
+		t.Errorf("expected the default expiry, got %v", reservation.ExpiresAt.Sub(reservation.ReservedAt))
+	}
+}
@@ -0,0 +1,341 @@
+package domain
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+	"sort"
+)
+
+// DefaultJunctionTolerance is how close in km the end of a trail has to be to another trail to join it
+const DefaultJunctionTolerance = 0.025
+
+var (
+	ErrNoRoute = errors.New("no route along the trails")
+)
+
+// Route is a walking path along the trails of a zone
+type Route struct {
+	// points from the start to the destination, the first and last legs join the trails
+	Path []TrailPoint
+	// length of the path in km
+	Distance float64
+}
+
+type routeEdge struct {
+	to       int
+	distance float64
+}
+
+// routeSegment is a part of a trail between two nodes of the graph
+type routeSegment struct {
+	a, b int
+}
+
+// RouteGraph is the network of the trails of a zone, to walk along them. Its nodes are the points of the
+// trails: trails sharing a point or crossing each other are joined there, and a trail ending less than the
+// junction tolerance from another one is joined to it. It is not modified once built.
+type RouteGraph struct {
+	nodes    []TrailPoint
+	edges    [][]routeEdge
+	segments []routeSegment
+}
+
+// junction is a node on a segment of a trail, at fraction f of it
+type junction struct {
+	node int
+	f    float64
+}
+
+type trailSegment struct {
+	a, b      int
+	trail     int
+	junctions []junction
+}
+
+type trailEnd struct {
+	node  int
+	trail int
+}
+
+func NewRouteGraph(trails []*Trail, tolerance float64) *RouteGraph {
+	g := &RouteGraph{}
+	// points are nodes by position, the same point may carry an elevation on one trail and not on another
+	type position struct{ latitude, longitude float64 }
+	nodeOf := make(map[position]int)
+	addNode := func(point TrailPoint) int {
+		key := position{point.Latitude, point.Longitude}
+		if node, ok := nodeOf[key]; ok {
+			return node
+		}
+		g.nodes = append(g.nodes, point)
+		g.edges = append(g.edges, nil)
+		nodeOf[key] = len(g.nodes) - 1
+		return len(g.nodes) - 1
+	}
+
+	// The segments of every trail, trails sharing a point share its node
+	var segments []*trailSegment
+	var ends []trailEnd
+	for i, trail := range trails {
+		path := trail.Path
+		if len(path) < 2 {
+			path = []TrailPoint{
+				{Latitude: trail.StartLatitude, Longitude: trail.StartLongitude},
+				{Latitude: trail.EndLatitude, Longitude: trail.EndLongitude},
+			}
+		}
+		previous := addNode(path[0])
+		for _, point := range path[1:] {
+			node := addNode(point)
+			if node != previous {
+				segments = append(segments, &trailSegment{a: previous, b: node, trail: i})
+			}
+			previous = node
+		}
+		ends = append(ends, trailEnd{node: addNode(path[0]), trail: i}, trailEnd{node: previous, trail: i})
+	}
+
+	// nodeAt returns the node at fraction f of a segment, a junction is added inside it
+	nodeAt := func(s *trailSegment, f float64) int {
+		if f <= 0 {
+			return s.a
+		}
+		if f >= 1 {
+			return s.b
+		}
+		node := addNode(interpolate(g.nodes[s.a], g.nodes[s.b], f))
+		if node != s.a && node != s.b {
+			s.junctions = append(s.junctions, junction{node: node, f: f})
+		}
+		return node
+	}
+
+	// joinAt joins a node to the point at fraction f of a segment
+	joinAt := func(s *trailSegment, f float64, node int) {
+		switch {
+		case f <= 0:
+			if node != s.a {
+				g.link(node, s.a)
+			}
+		case f >= 1:
+			if node != s.b {
+				g.link(node, s.b)
+			}
+		case node != s.a && node != s.b:
+			s.junctions = append(s.junctions, junction{node: node, f: f})
+		}
+	}
+
+	// Trails crossing each other are joined where they cross
+	for i, s := range segments {
+		for _, t := range segments[i+1:] {
+			if s.trail == t.trail || s.a == t.a || s.a == t.b || s.b == t.a || s.b == t.b {
+				continue
+			}
+			fs, ft, ok := intersect(g.nodes[s.a], g.nodes[s.b], g.nodes[t.a], g.nodes[t.b])
+			if !ok {
+				continue
+			}
+			joinAt(t, ft, nodeAt(s, fs))
+		}
+	}
+
+	// Trails ending close to another trail are joined to it
+	for _, end := range ends {
+		for _, s := range segments {
+			if s.trail == end.trail || s.a == end.node || s.b == end.node {
+				continue
+			}
+			closest, f := closestOnSegment(g.nodes[end.node], g.nodes[s.a], g.nodes[s.b])
+			if pointDistance(g.nodes[end.node], closest) > tolerance {
+				continue
+			}
+			if node := nodeAt(s, f); node != end.node {
+				g.link(end.node, node)
+			}
+		}
+	}
+
+	// Segments are walked from junction to junction
+	for _, s := range segments {
+		sort.Slice(s.junctions, func(i, j int) bool {
+			return s.junctions[i].f < s.junctions[j].f
+		})
+		previous := s.a
+		for _, j := range s.junctions {
+			if j.node != previous {
+				g.link(previous, j.node)
+				previous = j.node
+			}
+		}
+		g.link(previous, s.b)
+	}
+	return g
+}
+
+// link joins two nodes both ways, the segment between them is where locations join the graph
+func (g *RouteGraph) link(a, b int) {
+	distance := pointDistance(g.nodes[a], g.nodes[b])
+	g.edges[a] = append(g.edges[a], routeEdge{to: b, distance: distance})
+	g.edges[b] = append(g.edges[b], routeEdge{to: a, distance: distance})
+	g.segments = append(g.segments, routeSegment{a: a, b: b})
+}
+
+// Len returns the number of nodes of the graph
+func (g *RouteGraph) Len() int {
+	return len(g.nodes)
+}
+
+// attachment is where a location joins the graph: the closest point of the trails
+type attachment struct {
+	segment  routeSegment
+	point    TrailPoint
+	distance float64
+}
+
+func (g *RouteGraph) attach(point TrailPoint) (attachment, bool) {
+	best := attachment{distance: math.MaxFloat64}
+	for _, s := range g.segments {
+		closest, _ := closestOnSegment(point, g.nodes[s.a], g.nodes[s.b])
+		if distance := pointDistance(point, closest); distance < best.distance {
+			best = attachment{segment: s, point: closest, distance: distance}
+		}
+	}
+	return best, best.distance < math.MaxFloat64
+}
+
+// Route returns the shortest walking path between two locations along the trails, found with A*. Both
+// locations join the trails at their closest point, the legs to those points are part of the route.
+func (g *RouteGraph) Route(from TrailPoint, to TrailPoint) (*Route, error) {
+	start, okStart := g.attach(from)
+	end, okEnd := g.attach(to)
+	if !okStart || !okEnd {
+		return nil, ErrNoRoute
+	}
+
+	// the joining points of both locations are added as two more nodes
+	source, target := len(g.nodes), len(g.nodes)+1
+	pointOf := func(node int) TrailPoint {
+		switch node {
+		case source:
+			return start.point
+		case target:
+			return end.point
+		}
+		return g.nodes[node]
+	}
+	neighbours := func(node int) []routeEdge {
+		var edges []routeEdge
+		if node == source {
+			edges = []routeEdge{
+				{to: start.segment.a, distance: pointDistance(start.point, g.nodes[start.segment.a])},
+				{to: start.segment.b, distance: pointDistance(start.point, g.nodes[start.segment.b])},
+			}
+			if start.segment == end.segment {
+				edges = append(edges, routeEdge{to: target, distance: pointDistance(start.point, end.point)})
+			}
+			return edges
+		}
+		edges = append(edges, g.edges[node]...)
+		if node == end.segment.a || node == end.segment.b {
+			edges = append(edges, routeEdge{to: target, distance: pointDistance(g.nodes[node], end.point)})
+		}
+		return edges
+	}
+
+	// A* with the distance on the sphere to the target, which no path along the trails can beat
+	distances := map[int]float64{source: 0}
+	previous := make(map[int]int)
+	visited := make(map[int]bool)
+	queue := &routeQueue{{node: source, priority: pointDistance(start.point, end.point)}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(routeItem)
+		if visited[item.node] {
+			continue
+		}
+		visited[item.node] = true
+		if item.node == target {
+			break
+		}
+		for _, edge := range neighbours(item.node) {
+			distance := distances[item.node] + edge.distance
+			if known, ok := distances[edge.to]; ok && known <= distance {
+				continue
+			}
+			distances[edge.to] = distance
+			previous[edge.to] = item.node
+			heap.Push(queue, routeItem{node: edge.to, priority: distance + pointDistance(pointOf(edge.to), end.point)})
+		}
+	}
+	if !visited[target] {
+		return nil, ErrNoRoute
+	}
+
+	nodes := []int{target}
+	for node := target; node != source; {
+		node = previous[node]
+		nodes = append(nodes, node)
+	}
+	path := []TrailPoint{from}
+	for i := len(nodes) - 1; i >= 0; i-- {
+		path = appendPoint(path, pointOf(nodes[i]))
+	}
+	path = appendPoint(path, to)
+
+	return &Route{
+		Path:     path,
+		Distance: start.distance + distances[target] + end.distance,
+	}, nil
+}
+
+func appendPoint(path []TrailPoint, point TrailPoint) []TrailPoint {
+	if len(path) > 0 && samePosition(path[len(path)-1], point) {
+		return path
+	}
+	return append(path, point)
+}
+
+// intersect returns where the segments p1-p2 and q1-q2 cross as fractions of each of them, on an
+// equirectangular projection around p1
+func intersect(p1, p2, q1, q2 TrailPoint) (float64, float64, bool) {
+	cosLat := math.Cos(p1.Latitude * math.Pi / 180)
+	project := func(q TrailPoint) (float64, float64) {
+		return (q.Longitude - p1.Longitude) * cosLat, q.Latitude - p1.Latitude
+	}
+
+	rx, ry := project(p2)
+	ax, ay := project(q1)
+	bx, by := project(q2)
+	sx, sy := bx-ax, by-ay
+
+	denominator := rx*sy - ry*sx
+	if denominator == 0 {
+		return 0, 0, false
+	}
+	t := (ax*sy - ay*sx) / denominator
+	u := (ax*ry - ay*rx) / denominator
+	if t < 0 || t > 1 || u < 0 || u > 1 {
+		return 0, 0, false
+	}
+	return t, u, true
+}
+
+type routeItem struct {
+	node     int
+	priority float64
+}
+
+// routeQueue is a min-heap of nodes by priority
+type routeQueue []routeItem
+
+func (q routeQueue) Len() int            { return len(q) }
+func (q routeQueue) Less(i, j int) bool  { return q[i].priority < q[j].priority }
+func (q routeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *routeQueue) Push(x interface{}) { *q = append(*q, x.(routeItem)) }
+func (q *routeQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,109 @@
+package domain_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
+	"github.com/umahmood/haversine"
+)
+
+func pathTrail(t *testing.T, points ...domain.TrailPoint) *domain.Trail {
+	trail := &domain.Trail{}
+	if err := trail.SetPath(points); err != nil {
+		t.Fatalf("expected a valid path, got %v", err)
+	}
+	return trail
+}
+
+func kmBetween(a, b domain.TrailPoint) float64 {
+	_, km := haversine.Distance(haversine.Coord{Lat: a.Latitude, Lon: a.Longitude}, haversine.Coord{Lat: b.Latitude, Lon: b.Longitude})
+	return km
+}
+
+func TestRouteGraph_SharedPoint(t *testing.T) {
+	corner := domain.TrailPoint{Latitude: 0, Longitude: 0.01}
+	east := pathTrail(t, domain.TrailPoint{Latitude: 0, Longitude: 0}, corner)
+	north := pathTrail(t, corner, domain.TrailPoint{Latitude: 0.01, Longitude: 0.01})
+	graph := domain.NewRouteGraph([]*domain.Trail{east, north}, domain.DefaultJunctionTolerance)
+
+	// The shelter is north of the start, the route turns at the corner instead of cutting across
+	from := domain.TrailPoint{Latitude: 0, Longitude: 0}
+	to := domain.TrailPoint{Latitude: 0.01, Longitude: 0.01}
+	route, err := graph.Route(from, to)
+	if err != nil {
+		t.Fatalf("expected a route, got %v", err)
+	}
+	expected := kmBetween(from, corner) + kmBetween(corner, to)
+	if math.Abs(route.Distance-expected) > 1e-6 {
+		t.Errorf("expected %f km, got %f", expected, route.Distance)
+	}
+	if len(route.Path) != 3 || route.Path[1] != corner {
+		t.Errorf("expected the route to go through the corner, got %+v", route.Path)
+	}
+	if route.Distance <= kmBetween(from, to) {
+		t.Errorf("expected the route to be longer than the straight line")
+	}
+}
+
+func TestRouteGraph_Crossing(t *testing.T) {
+	// Two trails crossing at (0, 0) without sharing a point
+	westEast := pathTrail(t, domain.TrailPoint{Latitude: 0, Longitude: -0.01}, domain.TrailPoint{Latitude: 0, Longitude: 0.01})
+	southNorth := pathTrail(t, domain.TrailPoint{Latitude: -0.01, Longitude: 0}, domain.TrailPoint{Latitude: 0.01, Longitude: 0})
+	graph := domain.NewRouteGraph([]*domain.Trail{westEast, southNorth}, domain.DefaultJunctionTolerance)
+
+	from := domain.TrailPoint{Latitude: 0, Longitude: -0.01}
+	to := domain.TrailPoint{Latitude: 0.01, Longitude: 0}
+	route, err := graph.Route(from, to)
+	if err != nil {
+		t.Fatalf("expected a route, got %v", err)
+	}
+	crossing := domain.TrailPoint{Latitude: 0, Longitude: 0}
+	expected := kmBetween(from, crossing) + kmBetween(crossing, to)
+	if math.Abs(route.Distance-expected) > 1e-6 {
+		t.Errorf("expected %f km, got %f", expected, route.Distance)
+	}
+}
+
+func TestRouteGraph_JunctionAndShortestPath(t *testing.T) {
+	// A long loop and a short cut both lead to the end, the cut starts 10 m away from the main trail
+	main := pathTrail(t,
+		domain.TrailPoint{Latitude: 0, Longitude: 0},
+		domain.TrailPoint{Latitude: 0.02, Longitude: 0},
+		domain.TrailPoint{Latitude: 0.02, Longitude: 0.02},
+		domain.TrailPoint{Latitude: 0, Longitude: 0.02},
+	)
+	cut := pathTrail(t, domain.TrailPoint{Latitude: 0.0001, Longitude: 0.0001}, domain.TrailPoint{Latitude: 0.0001, Longitude: 0.0199})
+	graph := domain.NewRouteGraph([]*domain.Trail{main, cut}, domain.DefaultJunctionTolerance)
+
+	from := domain.TrailPoint{Latitude: 0, Longitude: 0}
+	to := domain.TrailPoint{Latitude: 0, Longitude: 0.02}
+	route, err := graph.Route(from, to)
+	if err != nil {
+		t.Fatalf("expected a route, got %v", err)
+	}
+	if route.Distance > 2.3 {
+		t.Errorf("expected the route to take the cut of about 2.2 km, got %f km", route.Distance)
+	}
+
+	// Without the junction the cut cannot be reached and the loop is taken
+	graph = domain.NewRouteGraph([]*domain.Trail{main, cut}, 0.001)
+	route, err = graph.Route(from, to)
+	if err != nil {
+		t.Fatalf("expected a route, got %v", err)
+	}
+	if route.Distance < 6.6 {
+		t.Errorf("expected the route to take the loop of about 6.7 km, got %f km", route.Distance)
+	}
+
+	// Trails far from each other are not joined
+	far := pathTrail(t, domain.TrailPoint{Latitude: 1, Longitude: 1}, domain.TrailPoint{Latitude: 1.01, Longitude: 1})
+	graph = domain.NewRouteGraph([]*domain.Trail{main, far}, domain.DefaultJunctionTolerance)
+	if _, err := graph.Route(from, domain.TrailPoint{Latitude: 1.01, Longitude: 1}); !errors.Is(err, domain.ErrNoRoute) {
+		t.Errorf("expected error %v, got %v", domain.ErrNoRoute, err)
+	}
+	if _, err := domain.NewRouteGraph(nil, domain.DefaultJunctionTolerance).Route(from, to); !errors.Is(err, domain.ErrNoRoute) {
+		t.Errorf("expected error %v without trails, got %v", domain.ErrNoRoute, err)
+	}
+}
@@ -0,0 +1,247 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/umahmood/haversine"
+)
+
+// DefaultShelterIndexCellSize is the side of a cell of the shelter index in degrees, about 1.1 km of latitude
+const DefaultShelterIndexCellSize = 0.01
+
+// Scopes in which the closest shelter of a workout was found, from the narrowest to the widest
+const (
+	ShelterScopeTrail  = "trail"
+	ShelterScopeZone   = "zone"
+	ShelterScopeGlobal = "global"
+)
+
+// kilometres per radian on the sphere used by haversine
+const earthRadiusKm = 6371.0
+
+// ShelterDistance is a shelter found by the index with its distance in km from the queried location
+type ShelterDistance struct {
+	Shelter  *Shelter
+	Distance float64
+}
+
+type shelterCell struct {
+	x, y int
+}
+
+// ShelterIndex buckets shelters in a grid of latitude/longitude cells so that lookups only look at the
+// cells around a location instead of every shelter. It is safe for concurrent use.
+type ShelterIndex struct {
+	mu       sync.RWMutex
+	cellSize float64
+	columns  int
+	rows     int
+	cells    map[shelterCell]map[uuid.UUID]Shelter
+	shelters map[uuid.UUID]shelterCell
+}
+
+func NewShelterIndex(cellSize float64) *ShelterIndex {
+	if cellSize <= 0 {
+		cellSize = DefaultShelterIndexCellSize
+	}
+	return &ShelterIndex{
+		cellSize: cellSize,
+		columns:  int(math.Ceil(360 / cellSize)),
+		rows:     int(math.Ceil(180 / cellSize)),
+		cells:    make(map[shelterCell]map[uuid.UUID]Shelter),
+		shelters: make(map[uuid.UUID]shelterCell),
+	}
+}
+
+// Len returns the number of shelters in the index
+func (idx *ShelterIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.shelters)
+}
+
+// Insert adds a shelter to the index, or moves it if it is already there
+func (idx *ShelterIndex) Insert(shelter Shelter) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(shelter.ShelterID)
+	cell := idx.cellOf(shelter.Latitude, shelter.Longitude)
+	if idx.cells[cell] == nil {
+		idx.cells[cell] = make(map[uuid.UUID]Shelter)
+	}
+	idx.cells[cell][shelter.ShelterID] = shelter
+	idx.shelters[shelter.ShelterID] = cell
+}
+
+// Remove deletes a shelter from the index, unknown shelters are ignored
+func (idx *ShelterIndex) Remove(id uuid.UUID) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+}
+
+func (idx *ShelterIndex) remove(id uuid.UUID) {
+	cell, ok := idx.shelters[id]
+	if !ok {
+		return
+	}
+	delete(idx.cells[cell], id)
+	if len(idx.cells[cell]) == 0 {
+		delete(idx.cells, cell)
+	}
+	delete(idx.shelters, id)
+}
+
+// Nearest returns up to k shelters closest to the location, closest first
+func (idx *ShelterIndex) Nearest(latitude, longitude float64, k int) []ShelterDistance {
+	return idx.NearestMatching(latitude, longitude, k, nil)
+}
+
+// NearestMatching returns up to k shelters closest to the location for which match is true, closest first.
+// A nil match accepts every shelter.
+func (idx *ShelterIndex) NearestMatching(latitude, longitude float64, k int, match func(shelter *Shelter) bool) []ShelterDistance {
+	if k <= 0 {
+		return nil
+	}
+
+	var found []ShelterDistance
+	idx.search(latitude, longitude, func(candidates []ShelterDistance, bound float64) bool {
+		for _, candidate := range candidates {
+			if match == nil || match(candidate.Shelter) {
+				found = append(found, candidate)
+			}
+		}
+		sortByDistance(found)
+		if len(found) > k {
+			found = found[:k]
+		}
+		// shelters in further rings are at least bound away
+		return len(found) < k || found[k-1].Distance > bound
+	})
+	return found
+}
+
+// WithinRadius returns the shelters at most radius km from the location, closest first
+func (idx *ShelterIndex) WithinRadius(latitude, longitude, radius float64) []ShelterDistance {
+	var found []ShelterDistance
+	idx.search(latitude, longitude, func(candidates []ShelterDistance, bound float64) bool {
+		for _, candidate := range candidates {
+			if candidate.Distance <= radius {
+				found = append(found, candidate)
+			}
+		}
+		return bound <= radius
+	})
+	sortByDistance(found)
+	return found
+}
+
+// search visits rings of cells around the location, starting with its own cell. visit is given the
+// shelters of each ring and a lower bound of the distance to any shelter outside the rings seen so far,
+// it returns whether the search should go on. The search stops once every shelter was seen.
+func (idx *ShelterIndex) search(latitude, longitude float64, visit func(candidates []ShelterDistance, bound float64) bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	origin := idx.cellOf(latitude, longitude)
+	location := haversine.Coord{Lat: latitude, Lon: longitude}
+	seenCells := make(map[shelterCell]bool)
+	seen := 0
+
+	var candidates []ShelterDistance
+	addCell := func(cell shelterCell) {
+		shelters, ok := idx.cells[cell]
+		if !ok || seenCells[cell] {
+			return
+		}
+		seenCells[cell] = true
+		for _, shelter := range shelters {
+			shelter := shelter
+			_, distance := haversine.Distance(location, haversine.Coord{Lat: shelter.Latitude, Lon: shelter.Longitude})
+			candidates = append(candidates, ShelterDistance{Shelter: &shelter, Distance: distance})
+		}
+	}
+
+	for ring := 0; seen < len(idx.shelters); ring++ {
+		candidates = nil
+		bound := idx.ringBound(latitude, ring)
+
+		// Far from any shelter the rings are mostly empty, past the number of occupied cells it is
+		// cheaper to look at all the remaining ones
+		if 8*ring > len(idx.cells) {
+			for cell := range idx.cells {
+				addCell(cell)
+			}
+			bound = math.MaxFloat64
+		} else {
+			for _, cell := range idx.ring(origin, ring) {
+				addCell(cell)
+			}
+		}
+
+		seen += len(candidates)
+		if !visit(candidates, bound) {
+			return
+		}
+	}
+}
+
+// ring returns the cells at exactly ring cells from the origin, longitude wraps around
+func (idx *ShelterIndex) ring(origin shelterCell, ring int) []shelterCell {
+	if ring == 0 {
+		return []shelterCell{origin}
+	}
+
+	var cells []shelterCell
+	add := func(dx, dy int) {
+		y := origin.y + dy
+		if y < 0 || y >= idx.rows {
+			return
+		}
+		x := ((origin.x+dx)%idx.columns + idx.columns) % idx.columns
+		cells = append(cells, shelterCell{x: x, y: y})
+	}
+	for d := -ring; d <= ring; d++ {
+		add(d, -ring)
+		add(d, ring)
+	}
+	for d := -ring + 1; d < ring; d++ {
+		add(-ring, d)
+		add(ring, d)
+	}
+	return cells
+}
+
+// ringBound is a lower bound in km of the distance from a location to any point outside the first
+// rings of cells around it. Such a point is at least ring cells away in latitude or in longitude, and
+// in the latter case its latitude is in the band covered by the rings.
+func (idx *ShelterIndex) ringBound(latitude float64, ring int) float64 {
+	gap := float64(ring) * idx.cellSize * math.Pi / 180
+	if gap >= math.Pi {
+		return math.MaxFloat64
+	}
+
+	maxLatitude := math.Min(90, math.Abs(latitude)+float64(ring+1)*idx.cellSize)
+	minCos := math.Cos(maxLatitude * math.Pi / 180)
+	longitudeBound := 2 * earthRadiusKm * math.Asin(math.Min(1, minCos*math.Sin(gap/2)))
+	return math.Min(earthRadiusKm*gap, longitudeBound)
+}
+
+func (idx *ShelterIndex) cellOf(latitude, longitude float64) shelterCell {
+	x := int(math.Floor((longitude + 180) / idx.cellSize))
+	y := int(math.Floor((latitude + 90) / idx.cellSize))
+	return shelterCell{
+		x: ((x % idx.columns) + idx.columns) % idx.columns,
+		y: int(math.Max(0, math.Min(float64(idx.rows-1), float64(y)))),
+	}
+}
+
+func sortByDistance(shelters []ShelterDistance) {
+	sort.SliceStable(shelters, func(i, j int) bool {
+		return shelters[i].Distance < shelters[j].Distance
+	})
+}
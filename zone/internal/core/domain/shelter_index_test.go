@@ -0,0 +1,190 @@
+package domain_test
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
+	"github.com/google/uuid"
+	"github.com/umahmood/haversine"
+)
+
+// randomShelters spreads shelters around Hamilton, with a few far away ones and one past the date line
+func randomShelters(r *rand.Rand, n int) []domain.Shelter {
+	shelters := make([]domain.Shelter, 0, n+2)
+	for i := 0; i < n; i++ {
+		shelters = append(shelters, domain.Shelter{
+			ShelterID: uuid.New(),
+			Latitude:  43.0 + r.Float64(),
+			Longitude: -80.5 + r.Float64(),
+		})
+	}
+	shelters = append(shelters,
+		domain.Shelter{ShelterID: uuid.New(), Latitude: 51.5, Longitude: -0.12},
+		domain.Shelter{ShelterID: uuid.New(), Latitude: 64.8, Longitude: 179.99},
+	)
+	return shelters
+}
+
+// linearScan is the lookup the index replaces
+func linearScan(shelters []domain.Shelter, latitude, longitude float64) []domain.ShelterDistance {
+	result := make([]domain.ShelterDistance, 0, len(shelters))
+	for i := range shelters {
+		_, distance := haversine.Distance(haversine.Coord{Lat: latitude, Lon: longitude}, haversine.Coord{Lat: shelters[i].Latitude, Lon: shelters[i].Longitude})
+		result = append(result, domain.ShelterDistance{Shelter: &shelters[i], Distance: distance})
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Distance < result[j].Distance })
+	return result
+}
+
+func newTestIndex(shelters []domain.Shelter) *domain.ShelterIndex {
+	index := domain.NewShelterIndex(domain.DefaultShelterIndexCellSize)
+	for _, shelter := range shelters {
+		index.Insert(shelter)
+	}
+	return index
+}
+
+func TestShelterIndex_MatchesLinearScan(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	shelters := randomShelters(r, 500)
+	index := newTestIndex(shelters)
+
+	locations := [][2]float64{{43.5, -80.0}, {43.26, -79.92}, {45.0, -75.0}, {64.8, -179.99}, {-33.9, 151.2}}
+	for i := 0; i < 20; i++ {
+		locations = append(locations, [2]float64{42.8 + 1.4*r.Float64(), -80.7 + 1.4*r.Float64()})
+	}
+
+	for _, location := range locations {
+		expected := linearScan(shelters, location[0], location[1])
+
+		nearest := index.Nearest(location[0], location[1], 5)
+		if len(nearest) != 5 {
+			t.Fatalf("expected 5 shelters, got %d", len(nearest))
+		}
+		for i := range nearest {
+			if math.Abs(nearest[i].Distance-expected[i].Distance) > 1e-9 {
+				t.Errorf("at %v expected shelter %d at %f km, got %f km", location, i, expected[i].Distance, nearest[i].Distance)
+			}
+		}
+
+		radius := 5.0
+		within := index.WithinRadius(location[0], location[1], radius)
+		count := 0
+		for _, candidate := range expected {
+			if candidate.Distance <= radius {
+				count++
+			}
+		}
+		if len(within) != count {
+			t.Errorf("at %v expected %d shelters within %f km, got %d", location, count, radius, len(within))
+		}
+	}
+}
+
+func TestShelterIndex_InsertMoveRemove(t *testing.T) {
+	index := domain.NewShelterIndex(domain.DefaultShelterIndexCellSize)
+	if nearest := index.Nearest(43.26, -79.92, 1); len(nearest) != 0 {
+		t.Errorf("expected no shelter in an empty index, got %d", len(nearest))
+	}
+
+	shelter := domain.Shelter{ShelterID: uuid.New(), ShelterName: "Cootes", Latitude: 43.26, Longitude: -79.92}
+	other := domain.Shelter{ShelterID: uuid.New(), ShelterName: "Bayfront", Latitude: 43.27, Longitude: -79.87}
+	index.Insert(shelter)
+	index.Insert(other)
+
+	nearest := index.Nearest(43.2601, -79.9201, 1)
+	if len(nearest) != 1 || nearest[0].Shelter.ShelterID != shelter.ShelterID {
+		t.Fatalf("expected %s to be the closest shelter, got %+v", shelter.ShelterName, nearest)
+	}
+
+	// Moving a shelter replaces its previous position
+	shelter.Latitude, shelter.Longitude = 44.0, -79.0
+	index.Insert(shelter)
+	if index.Len() != 2 {
+		t.Errorf("expected 2 shelters, got %d", index.Len())
+	}
+	nearest = index.Nearest(43.2601, -79.9201, 1)
+	if nearest[0].Shelter.ShelterID != other.ShelterID {
+		t.Errorf("expected %s to be the closest shelter after the move, got %s", other.ShelterName, nearest[0].Shelter.ShelterName)
+	}
+
+	index.Remove(other.ShelterID)
+	index.Remove(uuid.New())
+	nearest = index.Nearest(43.2601, -79.9201, 3)
+	if len(nearest) != 1 || nearest[0].Shelter.ShelterID != shelter.ShelterID {
+		t.Errorf("expected only %s to be left, got %+v", shelter.ShelterName, nearest)
+	}
+}
+
+func TestShelterIndex_NearestMatching(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	shelters := randomShelters(r, 200)
+	trailID := uuid.New()
+	for i := 0; i < len(shelters); i += 20 {
+		shelters[i].TrailID = trailID
+	}
+	index := newTestIndex(shelters)
+
+	onTrail := func(shelter *domain.Shelter) bool { return shelter.TrailID == trailID }
+	var expected []domain.ShelterDistance
+	for _, candidate := range linearScan(shelters, 43.5, -80.0) {
+		if onTrail(candidate.Shelter) {
+			expected = append(expected, candidate)
+		}
+	}
+
+	nearest := index.NearestMatching(43.5, -80.0, 3, onTrail)
+	if len(nearest) != 3 {
+		t.Fatalf("expected 3 shelters, got %d", len(nearest))
+	}
+	for i := range nearest {
+		if nearest[i].Shelter.ShelterID != expected[i].Shelter.ShelterID {
+			t.Errorf("expected shelter %d to be %s, got %s", i, expected[i].Shelter.ShelterID, nearest[i].Shelter.ShelterID)
+		}
+	}
+
+	none := index.NearestMatching(43.5, -80.0, 1, func(shelter *domain.Shelter) bool { return false })
+	if len(none) != 0 {
+		t.Errorf("expected no shelter, got %d", len(none))
+	}
+}
+
+func benchmarkLocations(r *rand.Rand) [][2]float64 {
+	locations := make([][2]float64, 1024)
+	for i := range locations {
+		locations[i] = [2]float64{43.0 + r.Float64(), -80.5 + r.Float64()}
+	}
+	return locations
+}
+
+func BenchmarkShelterIndex_Nearest(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	index := newTestIndex(randomShelters(r, 10000))
+	locations := benchmarkLocations(r)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		location := locations[i%len(locations)]
+		index.Nearest(location[0], location[1], 1)
+	}
+}
+
+func BenchmarkShelterIndex_LinearScan(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	shelters := randomShelters(r, 10000)
+	locations := benchmarkLocations(r)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		location := locations[i%len(locations)]
+		point := haversine.Coord{Lat: location[0], Lon: location[1]}
+		minDistance := math.MaxFloat64
+		for _, shelter := range shelters {
+			_, distance := haversine.Distance(point, haversine.Coord{Lat: shelter.Latitude, Lon: shelter.Longitude})
+			minDistance = math.Min(minDistance, distance)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	// zones name their time zone, the database is embedded so that it does not depend on the host
+	_ "time/tzdata"
+
+	"github.com/google/uuid"
+)
+
+// minutes in a day, the latest time a shelter can close
+const minutesPerDay = 24 * 60
+
+var (
+	ErrInvalidOpeningHours = errors.New("opening hours need a weekday and opening and closing times between 00:00 and 24:00 that differ")
+	ErrInvalidClosure      = errors.New("closure must end after it starts")
+	ErrClosureNotFound     = errors.New("closure not found")
+	ErrInvalidTimeZone     = errors.New("unknown time zone")
+	ErrShelterClosed       = errors.New("shelter is closed")
+)
+
+// OpeningHours is a weekly period in which a shelter is open, in the time zone of its zone. Times are
+// minutes after midnight, a period closing before it opens runs past midnight into the next day.
+type OpeningHours struct {
+	// day the period starts on
+	Weekday time.Weekday
+	// opening time in minutes after midnight
+	Opens uint16
+	// closing time in minutes after midnight, 1440 for midnight at the end of the day
+	Closes uint16
+}
+
+// ShelterClosure is a dated period in which a shelter is closed whatever its opening hours, for instance
+// for maintenance
+type ShelterClosure struct {
+	// id of the closure
+	ClosureID uuid.UUID
+	// start of the closure
+	StartsAt time.Time
+	// end of the closure, the shelter opens again at this time
+	EndsAt time.Time
+	// why the shelter is closed
+	Reason string
+}
+
+func NewOpeningHours(weekday time.Weekday, opens uint16, closes uint16) (OpeningHours, error) {
+	hours := OpeningHours{Weekday: weekday, Opens: opens, Closes: closes}
+	if err := hours.validate(); err != nil {
+		return OpeningHours{}, err
+	}
+	return hours, nil
+}
+
+func (h OpeningHours) validate() error {
+	if h.Weekday < time.Sunday || h.Weekday > time.Saturday || h.Opens >= minutesPerDay || h.Closes > minutesPerDay || h.Opens == h.Closes {
+		return ErrInvalidOpeningHours
+	}
+	return nil
+}
+
+// contains returns whether the period covers the minute of the given weekday
+func (h OpeningHours) contains(weekday time.Weekday, minute uint16) bool {
+	if h.Opens < h.Closes {
+		return h.Weekday == weekday && minute >= h.Opens && minute < h.Closes
+	}
+	next := (h.Weekday + 1) % 7
+	return (h.Weekday == weekday && minute >= h.Opens) || (next == weekday && minute < h.Closes)
+}
+
+func NewShelterClosure(startsAt time.Time, endsAt time.Time, reason string) (ShelterClosure, error) {
+	if !endsAt.After(startsAt) {
+		return ShelterClosure{}, ErrInvalidClosure
+	}
+	return ShelterClosure{
+		ClosureID: uuid.New(),
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		Reason:    reason,
+	}, nil
+}
+
+// SetOpeningHours replaces the weekly opening hours of the shelter, no hours means it is always open
+func (s *Shelter) SetOpeningHours(hours []OpeningHours) error {
+	for _, h := range hours {
+		if err := h.validate(); err != nil {
+			return err
+		}
+	}
+	s.OpeningHours = hours
+	return nil
+}
+
+// AddClosure adds a dated closure to the shelter
+func (s *Shelter) AddClosure(closure ShelterClosure) error {
+	if !closure.EndsAt.After(closure.StartsAt) {
+		return ErrInvalidClosure
+	}
+	s.Closures = append(s.Closures, closure)
+	return nil
+}
+
+// RemoveClosure removes a dated closure of the shelter
+func (s *Shelter) RemoveClosure(id uuid.UUID) error {
+	for i, closure := range s.Closures {
+		if closure.ClosureID == id {
+			s.Closures = append(s.Closures[:i:i], s.Closures[i+1:]...)
+			return nil
+		}
+	}
+	return ErrClosureNotFound
+}
+
+// HasOpeningHours returns whether the shelter is only open at some times of the week, in which case
+// the time zone of its zone is needed to tell whether it is open
+func (s *Shelter) HasOpeningHours() bool {
+	return len(s.OpeningHours) > 0
+}
+
+// IsOpen returns whether the shelter is open at the given time. Opening hours are read in the given
+// location, a shelter without opening hours is open unless it has a closure at that time.
+func (s *Shelter) IsOpen(at time.Time, location *time.Location) bool {
+	for _, closure := range s.Closures {
+		if !at.Before(closure.StartsAt) && at.Before(closure.EndsAt) {
+			return false
+		}
+	}
+	if !s.HasOpeningHours() {
+		return true
+	}
+
+	if location == nil {
+		location = time.UTC
+	}
+	local := at.In(location)
+	minute := uint16(local.Hour()*60 + local.Minute())
+	for _, h := range s.OpeningHours {
+		if h.contains(local.Weekday(), minute) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadTimeZone returns the location of an IANA time zone name such as 'America/Toronto', an empty name
+// is UTC
+func LoadTimeZone(name string) (*time.Location, error) {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTimeZone, name)
+	}
+	return location, nil
+}
+
+// Location returns the time zone of the zone, UTC when it has none
+func (z *Zone) Location() *time.Location {
+	location, err := LoadTimeZone(z.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// ParseWeekday reads an English weekday name, 'monday' or 'Mon'
+func ParseWeekday(name string) (time.Weekday, error) {
+	name = strings.ToLower(name)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || name == full[:3] {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unknown weekday %q", ErrInvalidOpeningHours, name)
+}
+
+// ParseClock reads a time of day written HH:MM into minutes after midnight, 24:00 is the end of the day
+func ParseClock(clock string) (uint16, error) {
+	var hours, minutes int
+	if _, err := fmt.Sscanf(clock, "%d:%d", &hours, &minutes); err != nil || len(clock) != 5 ||
+		hours < 0 || minutes < 0 || minutes >= 60 || hours*60+minutes > minutesPerDay {
+		return 0, fmt.Errorf("%w: invalid time %q", ErrInvalidOpeningHours, clock)
+	}
+	return uint16(hours*60 + minutes), nil
+}
+
+// FormatClock writes minutes after midnight as HH:MM
+func FormatClock(minutes uint16) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
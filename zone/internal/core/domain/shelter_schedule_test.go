@@ -0,0 +1,119 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+func TestShelter_IsOpen(t *testing.T) {
+	toronto, err := domain.LoadTimeZone("America/Toronto")
+	if err != nil {
+		t.Fatalf("expected the time zone to load, got %v", err)
+	}
+
+	// Open on Mondays from 08:00 to 20:00, and on Fridays from 22:00 to 02:00 on Saturday
+	shelter := domain.Shelter{ShelterID: uuid.New()}
+	monday, _ := domain.NewOpeningHours(time.Monday, 8*60, 20*60)
+	friday, _ := domain.NewOpeningHours(time.Friday, 22*60, 2*60)
+	if err := shelter.SetOpeningHours([]domain.OpeningHours{monday, friday}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		open bool
+	}{
+		// 2023-11-06 is a Monday
+		{"monday morning", time.Date(2023, 11, 6, 9, 0, 0, 0, toronto), true},
+		{"monday at closing time", time.Date(2023, 11, 6, 20, 0, 0, 0, toronto), false},
+		{"monday before opening", time.Date(2023, 11, 6, 7, 59, 0, 0, toronto), false},
+		{"tuesday", time.Date(2023, 11, 7, 9, 0, 0, 0, toronto), false},
+		{"friday night", time.Date(2023, 11, 10, 23, 0, 0, 0, toronto), true},
+		{"saturday past midnight", time.Date(2023, 11, 11, 1, 30, 0, 0, toronto), true},
+		{"saturday morning", time.Date(2023, 11, 11, 2, 0, 0, 0, toronto), false},
+		// 13:30 UTC is 08:30 in Toronto, 18:00 UTC on Saturday is not a Friday night there
+		{"monday morning in utc", time.Date(2023, 11, 6, 13, 30, 0, 0, time.UTC), true},
+		{"monday in utc before opening in toronto", time.Date(2023, 11, 6, 12, 30, 0, 0, time.UTC), false},
+	}
+	for _, test := range tests {
+		if open := shelter.IsOpen(test.at, toronto); open != test.open {
+			t.Errorf("%s: expected open %v, got %v", test.name, test.open, open)
+		}
+	}
+
+	// The same hours are read in UTC when the zone has no time zone
+	if !shelter.IsOpen(time.Date(2023, 11, 6, 19, 30, 0, 0, time.UTC), nil) {
+		t.Errorf("expected the shelter to be open at 19:30 UTC on a Monday without a time zone")
+	}
+}
+
+func TestShelter_Closures(t *testing.T) {
+	shelter := domain.Shelter{ShelterID: uuid.New()}
+	start := time.Date(2023, 11, 6, 0, 0, 0, 0, time.UTC)
+	if !shelter.IsOpen(start, time.UTC) {
+		t.Fatalf("expected a shelter without schedule to be open")
+	}
+
+	closure, err := domain.NewShelterClosure(start, start.Add(48*time.Hour), "maintenance")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := shelter.AddClosure(closure); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if shelter.IsOpen(start.Add(time.Hour), time.UTC) {
+		t.Errorf("expected the shelter to be closed during its closure")
+	}
+	if !shelter.IsOpen(start.Add(48*time.Hour), time.UTC) {
+		t.Errorf("expected the shelter to open again at the end of its closure")
+	}
+
+	if err := shelter.RemoveClosure(uuid.New()); !errors.Is(err, domain.ErrClosureNotFound) {
+		t.Errorf("expected error %v, got %v", domain.ErrClosureNotFound, err)
+	}
+	if err := shelter.RemoveClosure(closure.ClosureID); err != nil || len(shelter.Closures) != 0 {
+		t.Errorf("expected the closure to be removed, got %v and %+v", err, shelter.Closures)
+	}
+
+	if _, err := domain.NewShelterClosure(start, start, ""); !errors.Is(err, domain.ErrInvalidClosure) {
+		t.Errorf("expected error %v, got %v", domain.ErrInvalidClosure, err)
+	}
+}
+
+func TestOpeningHours_Parse(t *testing.T) {
+	if _, err := domain.NewOpeningHours(time.Monday, 600, 600); !errors.Is(err, domain.ErrInvalidOpeningHours) {
+		t.Errorf("expected empty opening hours to be refused, got %v", err)
+	}
+	if _, err := domain.NewOpeningHours(time.Monday, 0, 24*60); err != nil {
+		t.Errorf("expected a whole day to be valid, got %v", err)
+	}
+
+	for clock, minutes := range map[string]uint16{"00:00": 0, "08:30": 510, "24:00": 1440} {
+		if parsed, err := domain.ParseClock(clock); err != nil || parsed != minutes {
+			t.Errorf("expected %s to be %d minutes, got %d and %v", clock, minutes, parsed, err)
+		}
+		if formatted := domain.FormatClock(minutes); formatted != clock {
+			t.Errorf("expected %d minutes to be written %s, got %s", minutes, clock, formatted)
+		}
+	}
+	for _, clock := range []string{"8:30", "24:01", "12:60", "noon"} {
+		if _, err := domain.ParseClock(clock); !errors.Is(err, domain.ErrInvalidOpeningHours) {
+			t.Errorf("expected %q to be refused, got %v", clock, err)
+		}
+	}
+
+	if day, err := domain.ParseWeekday("Fri"); err != nil || day != time.Friday {
+		t.Errorf("expected Fri to be a Friday, got %v and %v", day, err)
+	}
+	if _, err := domain.ParseWeekday("someday"); err == nil {
+		t.Errorf("expected an unknown weekday to be refused")
+	}
+	if _, err := domain.LoadTimeZone("Mars/Olympus"); !errors.Is(err, domain.ErrInvalidTimeZone) {
+		t.Errorf("expected error %v, got %v", domain.ErrInvalidTimeZone, err)
+	}
+}
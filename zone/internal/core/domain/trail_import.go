@@ -0,0 +1,267 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Supported trail import formats
+const (
+	TrailFormatGPX = "gpx"
+	TrailFormatFIT = "fit"
+)
+
+var (
+	ErrUnsupportedTrailFormat = errors.New("unsupported trail file format")
+	ErrInvalidTrailFile       = errors.New("trail file could not be parsed")
+)
+
+// ParseTrailFile reads the name and path of a trail from a GPX or FIT file
+func ParseTrailFile(format string, data []byte) (string, []TrailPoint, error) {
+	var name string
+	var path []TrailPoint
+	var err error
+
+	switch strings.ToLower(format) {
+	case TrailFormatGPX:
+		name, path, err = parseGPXTrail(data)
+	case TrailFormatFIT:
+		name, path, err = parseFITTrail(data)
+	default:
+		return "", nil, ErrUnsupportedTrailFormat
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(path) < 2 {
+		return "", nil, ErrInvalidTrailPath
+	}
+	return name, path, nil
+}
+
+type gpxFile struct {
+	Metadata struct {
+		Name string `xml:"name"`
+	} `xml:"metadata"`
+	Tracks []struct {
+		Name     string `xml:"name"`
+		Segments []struct {
+			Points []gpxPoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+	Routes []struct {
+		Name   string     `xml:"name"`
+		Points []gpxPoint `xml:"rtept"`
+	} `xml:"rte"`
+}
+
+type gpxPoint struct {
+	Latitude  float64  `xml:"lat,attr"`
+	Longitude float64  `xml:"lon,attr"`
+	Elevation *float64 `xml:"ele"`
+}
+
+// parseGPXTrail uses the first track of the file, or the first route if there are no tracks
+func parseGPXTrail(data []byte) (string, []TrailPoint, error) {
+	var gpx gpxFile
+	if err := xml.Unmarshal(data, &gpx); err != nil {
+		return "", nil, ErrInvalidTrailFile
+	}
+
+	name := gpx.Metadata.Name
+	var points []gpxPoint
+	if len(gpx.Tracks) > 0 {
+		if gpx.Tracks[0].Name != "" {
+			name = gpx.Tracks[0].Name
+		}
+		for _, segment := range gpx.Tracks[0].Segments {
+			points = append(points, segment.Points...)
+		}
+	} else if len(gpx.Routes) > 0 {
+		if gpx.Routes[0].Name != "" {
+			name = gpx.Routes[0].Name
+		}
+		points = gpx.Routes[0].Points
+	}
+
+	path := make([]TrailPoint, 0, len(points))
+	for _, p := range points {
+		path = append(path, TrailPoint{Latitude: p.Latitude, Longitude: p.Longitude, Elevation: p.Elevation})
+	}
+	return name, path, nil
+}
+
+// FIT messages and fields used for trails
+const (
+	fitMessageCourse = 31
+	fitMessageRecord = 20
+
+	fitFieldCourseName       = 5
+	fitFieldPositionLat      = 0
+	fitFieldPositionLong     = 1
+	fitFieldAltitude         = 2
+	fitFieldEnhancedAltitude = 78
+	fitInvalidSemicircles    = 0x7FFFFFFF
+	fitSemicirclesToDegree   = 180.0 / (1 << 31)
+
+	// altitudes are stored in fifths of a metre from 500 m below sea level
+	fitAltitudeScale  = 5
+	fitAltitudeOffset = 500
+)
+
+type fitFieldDefinition struct {
+	num  byte
+	size byte
+}
+
+type fitDefinition struct {
+	byteOrder binary.ByteOrder
+	global    uint16
+	fields    []fitFieldDefinition
+	devSize   int
+}
+
+// parseFITTrail reads the positions of the record messages of a FIT activity or course file.
+// The trailing CRC is not checked.
+func parseFITTrail(data []byte) (string, []TrailPoint, error) {
+	if len(data) < 12 {
+		return "", nil, ErrInvalidTrailFile
+	}
+	headerSize := int(data[0])
+	if (headerSize != 12 && headerSize != 14) || len(data) < headerSize || string(data[8:12]) != ".FIT" {
+		return "", nil, ErrInvalidTrailFile
+	}
+	dataSize := int(binary.LittleEndian.Uint32(data[4:8]))
+	if len(data) < headerSize+dataSize {
+		return "", nil, ErrInvalidTrailFile
+	}
+
+	r := bytes.NewReader(data[headerSize : headerSize+dataSize])
+	definitions := make(map[byte]*fitDefinition)
+	var name string
+	var path []TrailPoint
+
+	for r.Len() > 0 {
+		header, _ := r.ReadByte()
+
+		var def *fitDefinition
+		if header&0x80 != 0 {
+			// Compressed timestamp header, always a data message
+			def = definitions[(header>>5)&0x03]
+		} else if header&0x40 != 0 {
+			d, err := readFITDefinition(r, header&0x20 != 0)
+			if err != nil {
+				return "", nil, err
+			}
+			definitions[header&0x0F] = d
+			continue
+		} else {
+			def = definitions[header&0x0F]
+		}
+
+		if def == nil {
+			return "", nil, ErrInvalidTrailFile
+		}
+		values, err := readFITData(r, def)
+		if err != nil {
+			return "", nil, err
+		}
+
+		switch def.global {
+		case fitMessageCourse:
+			if v, ok := values[fitFieldCourseName]; ok {
+				name = strings.TrimRight(string(v), "\x00")
+			}
+		case fitMessageRecord:
+			lat, okLat := values[fitFieldPositionLat]
+			long, okLong := values[fitFieldPositionLong]
+			if !okLat || !okLong || len(lat) != 4 || len(long) != 4 {
+				continue
+			}
+			latSemicircles := int32(def.byteOrder.Uint32(lat))
+			longSemicircles := int32(def.byteOrder.Uint32(long))
+			if latSemicircles == fitInvalidSemicircles || longSemicircles == fitInvalidSemicircles {
+				continue
+			}
+			path = append(path, TrailPoint{
+				Latitude:  float64(latSemicircles) * fitSemicirclesToDegree,
+				Longitude: float64(longSemicircles) * fitSemicirclesToDegree,
+				Elevation: fitAltitude(values, def.byteOrder),
+			})
+		}
+	}
+
+	return name, path, nil
+}
+
+// fitAltitude reads the altitude of a record in metres, the enhanced field is preferred. It is nil when
+// the record has none.
+func fitAltitude(values map[byte][]byte, byteOrder binary.ByteOrder) *float64 {
+	var raw uint32
+	if v, ok := values[fitFieldEnhancedAltitude]; ok && len(v) == 4 && byteOrder.Uint32(v) != 0xFFFFFFFF {
+		raw = byteOrder.Uint32(v)
+	} else if v, ok := values[fitFieldAltitude]; ok && len(v) == 2 && byteOrder.Uint16(v) != 0xFFFF {
+		raw = uint32(byteOrder.Uint16(v))
+	} else {
+		return nil
+	}
+	altitude := float64(raw)/fitAltitudeScale - fitAltitudeOffset
+	return &altitude
+}
+
+func readFITDefinition(r *bytes.Reader, hasDevFields bool) (*fitDefinition, error) {
+	fixed := make([]byte, 5)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, ErrInvalidTrailFile
+	}
+
+	def := &fitDefinition{byteOrder: binary.LittleEndian}
+	if fixed[1] == 1 {
+		def.byteOrder = binary.BigEndian
+	}
+	def.global = def.byteOrder.Uint16(fixed[2:4])
+
+	fields := make([]byte, 3*int(fixed[4]))
+	if _, err := io.ReadFull(r, fields); err != nil {
+		return nil, ErrInvalidTrailFile
+	}
+	for i := 0; i < len(fields); i += 3 {
+		def.fields = append(def.fields, fitFieldDefinition{num: fields[i], size: fields[i+1]})
+	}
+
+	if hasDevFields {
+		count, err := r.ReadByte()
+		if err != nil {
+			return nil, ErrInvalidTrailFile
+		}
+		devFields := make([]byte, 3*int(count))
+		if _, err := io.ReadFull(r, devFields); err != nil {
+			return nil, ErrInvalidTrailFile
+		}
+		for i := 0; i < len(devFields); i += 3 {
+			def.devSize += int(devFields[i+1])
+		}
+	}
+	return def, nil
+}
+
+func readFITData(r *bytes.Reader, def *fitDefinition) (map[byte][]byte, error) {
+	values := make(map[byte][]byte, len(def.fields))
+	for _, field := range def.fields {
+		value := make([]byte, field.size)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return nil, ErrInvalidTrailFile
+		}
+		values[field.num] = value
+	}
+	if def.devSize > r.Len() {
+		return nil, ErrInvalidTrailFile
+	}
+	r.Seek(int64(def.devSize), io.SeekCurrent)
+	return values, nil
+}
@@ -0,0 +1,217 @@
+package domain_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
+	"github.com/umahmood/haversine"
+)
+
+const testGPXTrail = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <metadata><name>Metadata Name</name></metadata>
+  <trk>
+    <name>Cootes Loop</name>
+    <trkseg>
+      <trkpt lat="43.2609" lon="-79.9192"></trkpt>
+      <trkpt lat="43.2619" lon="-79.9180"></trkpt>
+    </trkseg>
+    <trkseg>
+      <trkpt lat="43.2630" lon="-79.9201"></trkpt>
+    </trkseg>
+  </trk>
+</gpx>`
+
+var testTrailPath = []domain.TrailPoint{
+	{Latitude: 43.2609, Longitude: -79.9192},
+	{Latitude: 43.2619, Longitude: -79.9180},
+	{Latitude: 43.2630, Longitude: -79.9201},
+}
+
+// fitFile encodes a FIT course with the given name and positions, the last record is sent with
+// a compressed timestamp header and a record without position is added in between. Points without
+// elevation get an invalid altitude.
+func fitFile(name string, path []domain.TrailPoint) []byte {
+	var records bytes.Buffer
+	toSemicircles := func(degrees float64) int32 {
+		return int32(math.Round(degrees * (1 << 31) / 180.0))
+	}
+
+	// course definition (local 0), name field of 16 bytes
+	records.Write([]byte{0x40, 0, 0, 31, 0, 1, 5, 16, 7})
+	nameField := make([]byte, 16)
+	copy(nameField, name)
+	records.WriteByte(0x00)
+	records.Write(nameField)
+
+	// record definition (local 1), timestamp, position_lat, position_long and altitude
+	records.Write([]byte{0x41, 0, 0, 20, 0, 4, 253, 4, 134, 0, 4, 133, 1, 4, 133, 2, 2, 132})
+	writeRecord := func(header byte, timestamp uint32, lat, long int32, altitude uint16) {
+		records.WriteByte(header)
+		binary.Write(&records, binary.LittleEndian, timestamp)
+		binary.Write(&records, binary.LittleEndian, lat)
+		binary.Write(&records, binary.LittleEndian, long)
+		binary.Write(&records, binary.LittleEndian, altitude)
+	}
+	for i, point := range path {
+		header := byte(0x01)
+		if i == len(path)-1 {
+			header = 0x80 | 0x20 | 0x05
+		}
+		altitude := uint16(0xFFFF)
+		if point.Elevation != nil {
+			altitude = uint16(math.Round((*point.Elevation + 500) * 5))
+		}
+		writeRecord(header, uint32(1000+i), toSemicircles(point.Latitude), toSemicircles(point.Longitude), altitude)
+		if i == 0 {
+			writeRecord(0x01, 1000, 0x7FFFFFFF, 0x7FFFFFFF, 0xFFFF)
+		}
+	}
+
+	var file bytes.Buffer
+	file.Write([]byte{14, 0x20, 0x08, 0x08})
+	binary.Write(&file, binary.LittleEndian, uint32(records.Len()))
+	file.WriteString(".FIT")
+	file.Write([]byte{0, 0})
+	file.Write(records.Bytes())
+	file.Write([]byte{0, 0})
+	return file.Bytes()
+}
+
+func TestParseTrailFile_GPX(t *testing.T) {
+	name, path, err := domain.ParseTrailFile(domain.TrailFormatGPX, []byte(testGPXTrail))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if name != "Cootes Loop" {
+		t.Errorf("expected the track name, got %q", name)
+	}
+	if len(path) != len(testTrailPath) {
+		t.Fatalf("expected %d points, got %d", len(testTrailPath), len(path))
+	}
+	for i := range path {
+		if path[i] != testTrailPath[i] {
+			t.Errorf("expected point %d to be %+v, got %+v", i, testTrailPath[i], path[i])
+		}
+	}
+}
+
+func TestParseTrailFile_FIT(t *testing.T) {
+	name, path, err := domain.ParseTrailFile(domain.TrailFormatFIT, fitFile("Cootes Loop", testTrailPath))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if name != "Cootes Loop" {
+		t.Errorf("expected the course name, got %q", name)
+	}
+	if len(path) != len(testTrailPath) {
+		t.Fatalf("expected %d points, got %d", len(testTrailPath), len(path))
+	}
+	for i := range path {
+		if math.Abs(path[i].Latitude-testTrailPath[i].Latitude) > 1e-6 || math.Abs(path[i].Longitude-testTrailPath[i].Longitude) > 1e-6 {
+			t.Errorf("expected point %d to be %+v, got %+v", i, testTrailPath[i], path[i])
+		}
+	}
+}
+
+func TestParseTrailFile_Invalid(t *testing.T) {
+	type testCase struct {
+		test        string
+		format      string
+		data        []byte
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{test: "Unsupported format", format: "kml", data: []byte(testGPXTrail), expectedErr: domain.ErrUnsupportedTrailFormat},
+		{test: "Malformed gpx", format: "gpx", data: []byte("<gpx><trk>"), expectedErr: domain.ErrInvalidTrailFile},
+		{test: "Truncated fit", format: "fit", data: fitFile("Cootes Loop", testTrailPath)[:30], expectedErr: domain.ErrInvalidTrailFile},
+		{test: "Single point", format: "fit", data: fitFile("Cootes Loop", testTrailPath[:1]), expectedErr: domain.ErrInvalidTrailPath},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.test, func(t *testing.T) {
+			_, _, err := domain.ParseTrailFile(tc.format, tc.data)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestTrail_SetPath(t *testing.T) {
+	var trail domain.Trail
+	if err := trail.SetPath(testTrailPath[:1]); !errors.Is(err, domain.ErrInvalidTrailPath) {
+		t.Errorf("expected error %v, got %v", domain.ErrInvalidTrailPath, err)
+	}
+
+	if err := trail.SetPath(testTrailPath); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expectedLength := 0.0
+	for i := 1; i < len(testTrailPath); i++ {
+		_, km := haversine.Distance(
+			haversine.Coord{Lat: testTrailPath[i-1].Latitude, Lon: testTrailPath[i-1].Longitude},
+			haversine.Coord{Lat: testTrailPath[i].Latitude, Lon: testTrailPath[i].Longitude},
+		)
+		expectedLength += km
+	}
+	if math.Abs(trail.Length-expectedLength) > 1e-9 {
+		t.Errorf("expected length %f, got %f", expectedLength, trail.Length)
+	}
+
+	expectedBox := domain.BoundingBox{MinLatitude: 43.2609, MinLongitude: -79.9201, MaxLatitude: 43.2630, MaxLongitude: -79.9180}
+	if trail.BoundingBox != expectedBox {
+		t.Errorf("expected bounding box %+v, got %+v", expectedBox, trail.BoundingBox)
+	}
+	if trail.StartLatitude != 43.2609 || trail.EndLongitude != -79.9201 {
+		t.Errorf("expected start and end to follow the path, got %+v", trail)
+	}
+}
+
+func TestTrail_MoveEnds(t *testing.T) {
+	elevation := func(metres float64) *float64 { return &metres }
+	path := []domain.TrailPoint{
+		{Latitude: 43.2609, Longitude: -79.9192, Elevation: elevation(90)},
+		{Latitude: 43.2619, Longitude: -79.9180, Elevation: elevation(110)},
+		{Latitude: 43.2630, Longitude: -79.9201, Elevation: elevation(105)},
+	}
+	var trail domain.Trail
+	if err := trail.SetPath(path); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// the end moves further north, the start stays where it was
+	length := trail.Length
+	trail.MoveEnds(43.2609, -79.9192, 43.2650, -79.9201)
+
+	var expected domain.Trail
+	expected.SetPath([]domain.TrailPoint{path[0], path[1], {Latitude: 43.2650, Longitude: -79.9201}})
+	if len(trail.Path) != 3 || trail.Path[0].Elevation == nil || trail.Path[2].Elevation != nil {
+		t.Fatalf("expected the end of the path to move and lose its elevation, got %+v", trail.Path)
+	}
+	if math.Abs(trail.Length-expected.Length) > 1e-9 || trail.Length <= length {
+		t.Errorf("expected length %f, got %f", expected.Length, trail.Length)
+	}
+	if trail.BoundingBox.MaxLatitude != 43.2650 || trail.EndLatitude != 43.2650 {
+		t.Errorf("expected the bounding box and end to follow the path, got %+v", trail)
+	}
+	if trail.Ascent != 0 || trail.Descent != 0 || trail.HasElevation() {
+		t.Errorf("expected no climb without the elevation of the end, got %f and %f", trail.Ascent, trail.Descent)
+	}
+	if path[2].Latitude != 43.2630 {
+		t.Errorf("expected the previous path to be left as it was, got %+v", path[2])
+	}
+
+	// a trail without a path stays straight
+	straight := domain.Trail{StartLatitude: 1, StartLongitude: 1, EndLatitude: 2, EndLongitude: 2}
+	straight.MoveEnds(0, 0, 1, 1)
+	if straight.Path != nil || straight.Length != 0 || straight.StartLatitude != 0 || straight.EndLongitude != 1 {
+		t.Errorf("expected only the ends of a straight trail to move, got %+v", straight)
+	}
+}
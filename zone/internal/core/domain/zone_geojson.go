@@ -0,0 +1,321 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of the features of a zone, the kind of a feature is given by its geometry
+const (
+	FeatureKindZone    = "zone"
+	FeatureKindTrail   = "trail"
+	FeatureKindShelter = "shelter"
+)
+
+var (
+	ErrInvalidFeatureCollection = errors.New("body must be a GeoJSON FeatureCollection")
+	ErrInvalidFeatures          = errors.New("some features are invalid")
+)
+
+// FeatureCollection is a GeoJSON FeatureCollection, see RFC 7946
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id,omitempty"`
+	Geometry   json.RawMessage        `json:"geometry" swaggertype:"object"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// FeatureError is the reason a feature of a FeatureCollection was refused
+type FeatureError struct {
+	// position of the feature in the collection
+	Index int
+	// id of the trail or shelter if the feature has one
+	ID  string
+	Err error
+}
+
+func (e *FeatureError) Error() string {
+	return fmt.Sprintf("feature %d: %v", e.Index, e.Err)
+}
+
+func (e *FeatureError) Unwrap() error {
+	return e.Err
+}
+
+// ZoneFeatures are the boundary, trails and shelters read from a FeatureCollection. Features holds the
+// position in the collection of each trail and shelter, and of the boundary under the zone id, so that
+// later checks can report them
+type ZoneFeatures struct {
+	Boundary *Boundary
+	Trails   []*Trail
+	Shelters []*Shelter
+	Features map[uuid.UUID]int
+}
+
+// NewZoneFeatureCollection builds the FeatureCollection of a zone: its boundary, trails and shelters
+func NewZoneFeatureCollection(zone *Zone, trails []*Trail, shelters []*Shelter) *FeatureCollection {
+	collection := &FeatureCollection{Type: "FeatureCollection", Features: []Feature{}}
+	add := func(id string, geometry interface{}, properties map[string]interface{}) {
+		raw, _ := json.Marshal(geometry)
+		collection.Features = append(collection.Features, Feature{Type: "Feature", ID: id, Geometry: raw, Properties: properties})
+	}
+
+	if zone.Boundary != nil {
+		add(zone.ZoneID.String(), zone.Boundary.GeoJSON(), map[string]interface{}{
+			"kind": FeatureKindZone,
+			"name": zone.ZoneName,
+		})
+	}
+
+	for _, trail := range trails {
+		path := trail.Path
+		if len(path) < 2 {
+			path = []TrailPoint{
+				{Latitude: trail.StartLatitude, Longitude: trail.StartLongitude},
+				{Latitude: trail.EndLatitude, Longitude: trail.EndLongitude},
+			}
+		}
+		positions := make([][]float64, 0, len(path))
+		for _, point := range path {
+			position := []float64{point.Longitude, point.Latitude}
+			if point.Elevation != nil {
+				position = append(position, *point.Elevation)
+			}
+			positions = append(positions, position)
+		}
+		add(trail.TrailID.String(), map[string]interface{}{"type": "LineString", "coordinates": positions}, map[string]interface{}{
+			"kind":     FeatureKindTrail,
+			"trail_id": trail.TrailID.String(),
+			"name":     trail.TrailName,
+			"length":   trail.Length,
+			"ascent":   trail.Ascent,
+			"descent":  trail.Descent,
+		})
+	}
+
+	for _, shelter := range shelters {
+		add(shelter.ShelterID.String(), map[string]interface{}{"type": "Point", "coordinates": []float64{shelter.Longitude, shelter.Latitude}}, map[string]interface{}{
+			"kind":         FeatureKindShelter,
+			"shelter_id":   shelter.ShelterID.String(),
+			"trail_id":     shelter.TrailID.String(),
+			"name":         shelter.ShelterName,
+			"availability": shelter.ShelterAvailability,
+			"capacity":     shelter.Capacity,
+			"occupancy":    shelter.Occupancy,
+		})
+	}
+	return collection
+}
+
+// ParseZoneFeatures reads the features of a zone from a FeatureCollection. Polygons are the boundary,
+// LineStrings are trails and Points are shelters. Trails and shelters keep the id in their 'trail_id'
+// and 'shelter_id' properties, or get a new one. Shelters name their trail with 'trail_id' and may have
+// an 'availability' and a 'capacity', their occupancy is not read.
+// Trails and shelters have to be inside the boundary of the collection, or the zone's one if there is none.
+// Every invalid feature is reported, the features are only returned if they are all valid.
+func ParseZoneFeatures(zone *Zone, data []byte) (*ZoneFeatures, []*FeatureError, error) {
+	var collection FeatureCollection
+	if err := json.Unmarshal(data, &collection); err != nil || collection.Type != "FeatureCollection" {
+		return nil, nil, ErrInvalidFeatureCollection
+	}
+
+	result := &ZoneFeatures{Features: make(map[uuid.UUID]int)}
+	var featureErrors []*FeatureError
+	fail := func(index int, id string, err error) {
+		featureErrors = append(featureErrors, &FeatureError{Index: index, ID: id, Err: err})
+	}
+
+	// The boundary comes first as trails and shelters are checked against it
+	bounds := zone
+	for i, feature := range collection.Features {
+		if geometryType(feature.Geometry) != GeoJSONPolygon && geometryType(feature.Geometry) != GeoJSONMultiPolygon {
+			continue
+		}
+		boundary, err := ParseBoundary(feature.Geometry)
+		if err != nil {
+			fail(i, feature.ID, err)
+			continue
+		}
+		if result.Boundary != nil {
+			fail(i, feature.ID, errors.New("a zone has a single boundary"))
+			continue
+		}
+		result.Boundary = boundary
+		result.Features[zone.ZoneID] = i
+		bounds = &Zone{ZoneID: zone.ZoneID, ZoneName: zone.ZoneName, Boundary: boundary}
+	}
+
+	for i, feature := range collection.Features {
+		if feature.Type != "Feature" {
+			fail(i, feature.ID, errors.New("not a GeoJSON Feature"))
+			continue
+		}
+
+		switch geometryType(feature.Geometry) {
+		case GeoJSONPolygon, GeoJSONMultiPolygon:
+			// already read
+		case "LineString":
+			trail, err := parseTrailFeature(zone.ZoneID, feature)
+			if err != nil {
+				fail(i, feature.ID, err)
+				continue
+			}
+			if !bounds.ContainsTrail(trail) {
+				fail(i, trail.TrailID.String(), ErrOutsideZone)
+				continue
+			}
+			if _, ok := result.Features[trail.TrailID]; ok {
+				fail(i, trail.TrailID.String(), errors.New("duplicated trail_id"))
+				continue
+			}
+			result.Trails = append(result.Trails, trail)
+			result.Features[trail.TrailID] = i
+		case "Point":
+			shelter, err := parseShelterFeature(feature)
+			if err != nil {
+				fail(i, feature.ID, err)
+				continue
+			}
+			if !bounds.Contains(shelter.Latitude, shelter.Longitude) {
+				fail(i, shelter.ShelterID.String(), ErrOutsideZone)
+				continue
+			}
+			if _, ok := result.Features[shelter.ShelterID]; ok {
+				fail(i, shelter.ShelterID.String(), errors.New("duplicated shelter_id"))
+				continue
+			}
+			result.Shelters = append(result.Shelters, shelter)
+			result.Features[shelter.ShelterID] = i
+		default:
+			fail(i, feature.ID, errors.New("geometry must be a Polygon, MultiPolygon, LineString or Point"))
+		}
+	}
+
+	if len(featureErrors) > 0 {
+		return nil, featureErrors, ErrInvalidFeatures
+	}
+	return result, nil, nil
+}
+
+func geometryType(geometry json.RawMessage) string {
+	var g struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(geometry, &g); err != nil {
+		return ""
+	}
+	return g.Type
+}
+
+func parseTrailFeature(zId uuid.UUID, feature Feature) (*Trail, error) {
+	var geometry struct {
+		Coordinates [][]float64 `json:"coordinates"`
+	}
+	if err := json.Unmarshal(feature.Geometry, &geometry); err != nil {
+		return nil, errors.New("invalid LineString coordinates")
+	}
+
+	path := make([]TrailPoint, 0, len(geometry.Coordinates))
+	for _, position := range geometry.Coordinates {
+		if !validPosition(position) {
+			return nil, errors.New("invalid LineString coordinates")
+		}
+		point := TrailPoint{Latitude: position[1], Longitude: position[0]}
+		// a third value of the position is the elevation in metres
+		if len(position) > 2 {
+			elevation := position[2]
+			point.Elevation = &elevation
+		}
+		path = append(path, point)
+	}
+
+	id, err := featureID(feature, "trail_id")
+	if err != nil {
+		return nil, err
+	}
+	name, _ := feature.Properties["name"].(string)
+	if name == "" {
+		return nil, errors.New("trail name is missing")
+	}
+
+	trail := &Trail{TrailID: id, TrailName: name, ZoneID: zId}
+	if err := trail.SetPath(path); err != nil {
+		return nil, err
+	}
+	return trail, nil
+}
+
+func parseShelterFeature(feature Feature) (*Shelter, error) {
+	var geometry struct {
+		Coordinates []float64 `json:"coordinates"`
+	}
+	if err := json.Unmarshal(feature.Geometry, &geometry); err != nil || !validPosition(geometry.Coordinates) {
+		return nil, errors.New("invalid Point coordinates")
+	}
+
+	id, err := featureID(feature, "shelter_id")
+	if err != nil {
+		return nil, err
+	}
+	trailIdStr, _ := feature.Properties["trail_id"].(string)
+	tId, err := uuid.Parse(trailIdStr)
+	if err != nil {
+		return nil, errors.New("shelter trail_id is missing or invalid")
+	}
+	name, _ := feature.Properties["name"].(string)
+	if name == "" {
+		return nil, errors.New("shelter name is missing")
+	}
+	availability := true
+	if value, ok := feature.Properties["availability"]; ok {
+		if availability, ok = value.(bool); !ok {
+			return nil, errors.New("shelter availability must be a boolean")
+		}
+	}
+
+	var capacity uint16
+	if value, ok := feature.Properties["capacity"]; ok && value != nil {
+		number, ok := value.(float64)
+		if !ok || number < 0 || number > math.MaxUint16 || number != math.Trunc(number) {
+			return nil, errors.New("shelter capacity must be a positive integer")
+		}
+		capacity = uint16(number)
+	}
+
+	return &Shelter{
+		ShelterID:           id,
+		TrailID:             tId,
+		ShelterAvailability: availability,
+		ShelterName:         name,
+		Latitude:            geometry.Coordinates[1],
+		Longitude:           geometry.Coordinates[0],
+		Capacity:            capacity,
+	}, nil
+}
+
+// featureID reads the id of a trail or shelter from its properties, a new one is given if there is none
+func featureID(feature Feature, property string) (uuid.UUID, error) {
+	value, ok := feature.Properties[property]
+	if !ok || value == nil || value == "" {
+		return uuid.New(), nil
+	}
+	idStr, _ := value.(string)
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid %s", property)
+	}
+	return id, nil
+}
+
+func validPosition(position []float64) bool {
+	return len(position) >= 2 && position[0] >= -180 && position[0] <= 180 && position[1] >= -90 && position[1] <= 90
+}
@@ -0,0 +1,131 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+const testTrailID = "6f1c2f7e-3a1b-4c59-9d54-0b7a9f6d2e11"
+
+// a boundary, a trail with elevations along its south edge and a shelter on the trail
+const testFeatureCollection = `{
+	"type": "FeatureCollection",
+	"features": [
+		{"type": "Feature", "properties": {"name": "Hamilton"}, "geometry": ` + testBoundary + `},
+		{"type": "Feature", "properties": {"trail_id": "` + testTrailID + `", "name": "Escarpment"},
+			"geometry": {"type": "LineString", "coordinates": [[-79.99, 43.21, 180], [-79.95, 43.22, 200], [-79.81, 43.21, 150]]}},
+		{"type": "Feature", "properties": {"trail_id": "` + testTrailID + `", "name": "Lookout", "availability": false},
+			"geometry": {"type": "Point", "coordinates": [-79.95, 43.22]}}
+	]
+}`
+
+func TestParseZoneFeatures(t *testing.T) {
+	zone := &domain.Zone{ZoneID: uuid.New(), ZoneName: "Hamilton"}
+
+	features, featureErrors, err := domain.ParseZoneFeatures(zone, []byte(testFeatureCollection))
+	if err != nil {
+		t.Fatalf("expected no error, got %v %v", err, featureErrors)
+	}
+	if features.Boundary == nil || len(features.Trails) != 1 || len(features.Shelters) != 1 {
+		t.Fatalf("expected a boundary, a trail and a shelter, got %+v", features)
+	}
+
+	trail, shelter := features.Trails[0], features.Shelters[0]
+	if trail.TrailID.String() != testTrailID || trail.ZoneID != zone.ZoneID || len(trail.Path) != 3 || trail.Length == 0 {
+		t.Errorf("unexpected trail %+v", trail)
+	}
+	if trail.Ascent != 20 || trail.Descent != 50 {
+		t.Errorf("expected 20 m up and 50 m down, got %f and %f", trail.Ascent, trail.Descent)
+	}
+	if shelter.ShelterID == uuid.Nil || shelter.TrailID != trail.TrailID || shelter.ShelterAvailability || shelter.Latitude != 43.22 {
+		t.Errorf("unexpected shelter %+v", shelter)
+	}
+	if features.Features[zone.ZoneID] != 0 || features.Features[trail.TrailID] != 1 || features.Features[shelter.ShelterID] != 2 {
+		t.Errorf("unexpected feature positions %v", features.Features)
+	}
+}
+
+func TestParseZoneFeatures_Errors(t *testing.T) {
+	zone := &domain.Zone{ZoneID: uuid.New(), ZoneName: "Hamilton"}
+
+	_, _, err := domain.ParseZoneFeatures(zone, []byte(testBoundary))
+	if !errors.Is(err, domain.ErrInvalidFeatureCollection) {
+		t.Fatalf("expected error %v, got %v", domain.ErrInvalidFeatureCollection, err)
+	}
+
+	collection := `{
+		"type": "FeatureCollection",
+		"features": [
+			{"type": "Feature", "properties": {}, "geometry": ` + testBoundary + `},
+			{"type": "Feature", "properties": {"name": "Outside"}, "geometry": {"type": "LineString", "coordinates": [[-79.99, 43.21], [-79.5, 43.21]]}},
+			{"type": "Feature", "properties": {"name": "Lookout"}, "geometry": {"type": "Point", "coordinates": [-79.95, 43.22]}},
+			{"type": "Feature", "properties": {"trail_id": "not-a-uuid", "name": "Broken"}, "geometry": {"type": "LineString", "coordinates": [[-79.99, 43.21], [-79.95, 43.22]]}},
+			{"type": "Feature", "properties": {}, "geometry": {"type": "MultiPoint", "coordinates": [[-79.95, 43.22]]}},
+			{"type": "Feature", "properties": {"trail_id": "` + testTrailID + `", "name": "Harbour"}, "geometry": {"type": "Point", "coordinates": [-79.87, 43.27]}},
+			{"type": "Feature", "properties": {"trail_id": "` + testTrailID + `", "name": "Ok"}, "geometry": {"type": "Point", "coordinates": [-79.95, 43.22]}}
+		]
+	}`
+
+	_, featureErrors, err := domain.ParseZoneFeatures(zone, []byte(collection))
+	if !errors.Is(err, domain.ErrInvalidFeatures) {
+		t.Fatalf("expected error %v, got %v", domain.ErrInvalidFeatures, err)
+	}
+
+	// the trail leaves the boundary, the first shelter has no trail, the id is invalid, the geometry is
+	// unsupported and the last but one shelter is in the hole of the boundary
+	expected := []int{1, 2, 3, 4, 5}
+	if len(featureErrors) != len(expected) {
+		t.Fatalf("expected %d feature errors, got %v", len(expected), featureErrors)
+	}
+	for i, featureError := range featureErrors {
+		if featureError.Index != expected[i] {
+			t.Errorf("expected feature %d to be invalid, got %d", expected[i], featureError.Index)
+		}
+	}
+	if !errors.Is(featureErrors[0], domain.ErrOutsideZone) || !errors.Is(featureErrors[4], domain.ErrOutsideZone) {
+		t.Errorf("expected features 1 and 5 to be outside of the zone, got %v and %v", featureErrors[0], featureErrors[4])
+	}
+}
+
+func TestNewZoneFeatureCollection(t *testing.T) {
+	zone := &domain.Zone{ZoneID: uuid.New(), ZoneName: "Hamilton"}
+	features, _, err := domain.ParseZoneFeatures(zone, []byte(testFeatureCollection))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	zone.Boundary = features.Boundary
+
+	// a trail without a path is exported as a straight line
+	straight := &domain.Trail{TrailID: uuid.New(), TrailName: "Straight", ZoneID: zone.ZoneID,
+		StartLatitude: 43.21, StartLongitude: -79.99, EndLatitude: 43.22, EndLongitude: -79.98}
+	collection := domain.NewZoneFeatureCollection(zone, append(features.Trails, straight), features.Shelters)
+
+	data, err := json.Marshal(collection)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// what is exported can be imported back
+	again, _, err := domain.ParseZoneFeatures(&domain.Zone{ZoneID: zone.ZoneID}, data)
+	if err != nil {
+		t.Fatalf("expected the export to be imported back, got %v", err)
+	}
+	if again.Boundary == nil || len(again.Trails) != 2 || len(again.Shelters) != 1 {
+		t.Fatalf("expected a boundary, 2 trails and a shelter, got %+v", again)
+	}
+	if again.Trails[0].TrailID != features.Trails[0].TrailID || again.Trails[0].Length != features.Trails[0].Length ||
+		again.Trails[0].Ascent != features.Trails[0].Ascent {
+		t.Errorf("expected trail %+v, got %+v", features.Trails[0], again.Trails[0])
+	}
+	if again.Trails[1].TrailID != straight.TrailID || len(again.Trails[1].Path) != 2 {
+		t.Errorf("expected the straight trail with 2 points, got %+v", again.Trails[1])
+	}
+	if !reflect.DeepEqual(again.Shelters[0], features.Shelters[0]) {
+		t.Errorf("expected shelter %+v, got %+v", features.Shelters[0], again.Shelters[0])
+	}
+}
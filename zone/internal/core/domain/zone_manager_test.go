@@ -0,0 +1,73 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+func TestNewZoneManager(t *testing.T) {
+	workoutID := uuid.New()
+	session, err := domain.NewZoneManager(workoutID)
+	if err != nil {
+		t.Fatalf("expected session to be created, got %v", err)
+	}
+	if session.CurrentWorkoutID != workoutID || session.IsClosed() {
+		t.Errorf("expected open session of the workout, got %+v", session)
+	}
+
+	// A session needs a workout
+	if _, err := domain.NewZoneManager(uuid.Nil); !errors.Is(err, domain.ErrInvalidZoneManager) {
+		t.Errorf("expected ErrInvalidZoneManager, got %v", err)
+	}
+}
+
+func TestZoneManager_Lifecycle(t *testing.T) {
+	session, _ := domain.NewZoneManager(uuid.New())
+	trail := &domain.Trail{TrailID: uuid.New(), ZoneID: uuid.New()}
+	now := time.Now()
+
+	// Locations on a trail follow the trail and its zone
+	if err := session.SetLocation(trail, 43.25, -79.91, now); err != nil {
+		t.Fatalf("expected location to be set, got %v", err)
+	}
+	if session.CurrentTrailID != trail.TrailID || session.ZoneID != trail.ZoneID {
+		t.Errorf("expected session on the trail, got %+v", session)
+	}
+	if session.CurrentLatitude != 43.25 || session.CurrentLongitude != -79.91 || !session.CurrentTime.Equal(now) {
+		t.Errorf("expected location to be recorded, got %+v", session)
+	}
+
+	// Leaving the trail keeps the zone
+	session.SetLocation(nil, 43.26, -79.92, now.Add(time.Minute))
+	if session.CurrentTrailID != uuid.Nil || session.ZoneID != trail.ZoneID {
+		t.Errorf("expected session off the trail in the same zone, got %+v", session)
+	}
+
+	shelterID := uuid.New()
+	session.SetShelter(shelterID, 1.5, "zone")
+	if session.ShelterID != shelterID || session.ShelterDistance != 1.5 || session.ShelterScope != "zone" {
+		t.Errorf("expected shelter to be recorded, got %+v", session)
+	}
+
+	// A closed session takes no more locations and cannot be closed again
+	end := now.Add(time.Hour)
+	if err := session.Close(end); err != nil {
+		t.Fatalf("expected session to be closed, got %v", err)
+	}
+	if !session.IsClosed() || !session.ClosedAt.Equal(end) {
+		t.Errorf("expected session closed at %v, got %+v", end, session)
+	}
+	if err := session.SetLocation(trail, 0, 0, end); !errors.Is(err, domain.ErrZoneManagerClosed) {
+		t.Errorf("expected ErrZoneManagerClosed on location, got %v", err)
+	}
+	if err := session.Close(end); !errors.Is(err, domain.ErrZoneManagerClosed) {
+		t.Errorf("expected ErrZoneManagerClosed on close, got %v", err)
+	}
+	if session.CurrentLatitude != 43.26 {
+		t.Errorf("expected location of a closed session to stay, got %+v", session)
+	}
+}
@@ -2,6 +2,7 @@ package ports
 
 import (
 	"errors"
+	"time"
 
 	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
 	"github.com/gin-gonic/gin"
@@ -14,6 +15,11 @@ var (
 	ErrorInvalidShelter        = errors.New("invalid shelter")
 	ErrorZoneManagerlNotFound  = errors.New("trail manager not found")
 	ErrorListZoneManagerFailed = errors.New("listing trails manager failed")
+	ErrorZoneNotFound          = errors.New("no zone contains the location")
+	ErrorTrailInOtherZone      = errors.New("trail belongs to another zone")
+	ErrorShelterInOtherZone    = errors.New("shelter belongs to another zone")
+	ErrorShelterTrailNotInZone = errors.New("shelter trail is neither in the zone nor in the collection")
+	ErrorPlayerNotFound        = errors.New("player not found")
 )
 
 type ZoneService interface {
@@ -22,9 +28,10 @@ type ZoneService interface {
 	CalculateDistance(Longitude1 float64, Latitude1, Longitude2 float64, Latitude2 float64) (float64, error)
 	GetShelterDistance(wId uuid.UUID, tId uuid.UUID, sId uuid.UUID) (float64, error)
 	GetTrailDistance(wId uuid.UUID, tId uuid.UUID, sId uuid.UUID) (float64, error)
-	GetClosestShelter(currentLongitude, currentLatitude float64) (uuid.UUID, error)
 	GetClosestTrail(zId uuid.UUID, currentLongitude float64, currentLatitude float64) (uuid.UUID, error)
-	SetCurrentLocation(wId uuid.UUID, longitude float64, latitude float64)
+	SetCurrentLocation(wId uuid.UUID, tId uuid.UUID, latitude float64, longitude float64, time time.Time) (*domain.ZoneManager, error)
+	GetZoneManager(wId uuid.UUID) (*domain.ZoneManager, error)
+	CloseZoneManager(wId uuid.UUID, endedAt time.Time) error
 	CreateTrail(tid uuid.UUID, name string, startLatitude float64, startLongitude float64, endLatitude float64, endLongitude float64, shelterId uuid.UUID) (uuid.UUID, error)
 	GetTrailInfo(ctx *gin.Context)
 }
@@ -33,10 +40,14 @@ type ZoneManagerRepository interface {
 	ZoneRepository
 	TrailRepository
 	ShelterRepository
+	SessionRepository
 }
 type ZoneRepository interface {
 	CreateZone(name string) (uuid.UUID, error)
 	UpdateZone(id uuid.UUID, name string) error
+	UpdateZoneBoundary(id uuid.UUID, boundary *domain.Boundary) error
+	UpdateZoneTimeZone(id uuid.UUID, timeZone string) error
+	UpsertZoneFeatures(id uuid.UUID, boundary *domain.Boundary, trails []*domain.Trail, shelters []*domain.Shelter) error
 	DeleteZone(id uuid.UUID) error
 	GetZoneByID(id uuid.UUID) (*domain.Zone, error)
 	GetZoneByName(name string) (*domain.Zone, error)
@@ -45,7 +56,8 @@ type ZoneRepository interface {
 
 type TrailRepository interface {
 	CreateTrail(name string, zId uuid.UUID, startLat, startLong, endLat, endLong float64) (uuid.UUID, error)
-	UpdateTrailByID(id uuid.UUID, name string, zId uuid.UUID, startLat, startLong, endLat, endLong float64) error
+	CreateTrailWithPath(trail *domain.Trail) (uuid.UUID, error)
+	UpdateTrailByID(trail *domain.Trail) error
 	DeleteTrailByID(id uuid.UUID) error
 	GetTrailByID(id uuid.UUID) (*domain.Trail, error)
 	ListTrails() ([]*domain.Trail, error)
@@ -53,15 +65,46 @@ type TrailRepository interface {
 }
 
 type ShelterRepository interface {
-	CreateShelter(name string, tId uuid.UUID, availability bool, lat, long float64) (uuid.UUID, error)
-	UpdateShelterByID(id uuid.UUID, tId uuid.UUID, name string, availability bool, lat, long float64) error
+	CreateShelter(name string, tId uuid.UUID, availability bool, capacity uint16, lat, long float64) (uuid.UUID, error)
+	UpdateShelterByID(id uuid.UUID, tId uuid.UUID, name string, availability bool, capacity uint16, lat, long float64) error
+	UpdateShelterSchedule(id uuid.UUID, hours []domain.OpeningHours, closures []domain.ShelterClosure) error
 	DeleteShelterByID(id uuid.UUID) error
 	GetShelterByID(id uuid.UUID) (*domain.Shelter, error)
 	ListShelters() ([]*domain.Shelter, error)
 	ListSheltersByTrailId(tId uuid.UUID) ([]*domain.Shelter, error)
+
+	ReserveShelter(reservation *domain.ShelterReservation) (*domain.Shelter, *domain.Shelter, error)
+	ReleaseShelterReservation(wId uuid.UUID) (*domain.ShelterReservation, *domain.Shelter, error)
+	ReleaseExpiredShelterReservation(rId uuid.UUID, now time.Time) (*domain.ShelterReservation, *domain.Shelter, error)
+	ListExpiredShelterReservations(now time.Time) ([]*domain.ShelterReservation, error)
+}
+
+type SessionRepository interface {
+	GetZoneManagerByWorkoutID(wId uuid.UUID) (*domain.ZoneManager, error)
+	SaveZoneManager(zm *domain.ZoneManager) error
 }
 
 // VRTODO: Fix Name
 type ShelterDistancePublisher interface {
-	PublishShelterDistance(wId uuid.UUID, sId uuid.UUID, name string, availability bool, distance float64) error
+	PublishShelterDistance(wId uuid.UUID, sId uuid.UUID, name string, availability bool, distance float64, scope string) error
+}
+
+type ShelterReservationPublisher interface {
+	PublishShelterReservation(wId uuid.UUID, sId uuid.UUID, reserved bool, reason string, expiresAt time.Time) error
+}
+
+type GeofencePublisher interface {
+	PublishGeofenceEvent(event *domain.GeofenceEvent) error
+}
+
+type OffTrailPublisher interface {
+	PublishOffTrail(wId uuid.UUID, tId uuid.UUID, offTrail bool, distance float64, latitude float64, longitude float64, time time.Time) error
+}
+
+type UserServiceClient interface {
+	GetPlayer(playerID uuid.UUID) (preference string, age uint8, err error)
+}
+
+type WorkoutServiceClient interface {
+	GetDistanceCovered(playerID uuid.UUID, startDate time.Time, endDate time.Time) (distance float64, workouts uint16, err error)
 }
@@ -1,43 +1,148 @@
 package services
 
 import (
+	"errors"
+	"fmt"
 	"math"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
 	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/ports"
 	logger "github.com/CAS735-F23/macrun-teamvsl/zone/log"
 	"github.com/google/uuid"
-	"github.com/umahmood/haversine"
 	"go.uber.org/zap"
 )
 
+// how far back the workouts of a player are looked at to recommend trails
+const recommendationHistory = 90 * 24 * time.Hour
+
 type ZoneService struct {
 	repo                     ports.ZoneManagerRepository
 	shelterDistancePublisher ports.ShelterDistancePublisher
+	offTrailPublisher        ports.OffTrailPublisher
+	reservationPublisher     ports.ShelterReservationPublisher
+	geofencePublisher        ports.GeofencePublisher
+	userClient               ports.UserServiceClient
+	workoutClient            ports.WorkoutServiceClient
+	// shelters by location, kept in sync with the repository
+	shelterIndex *domain.ShelterIndex
+	// distance in km from its trail after which a workout is off the trail
+	offTrailThreshold float64
+	// how long a shelter place is held for a workout
+	reservationTTL time.Duration
+	// radius in km of the fences around the ends of trails and around shelters
+	geofenceRadius float64
+
+	// workouts currently off their trail, so that only changes are published
+	offTrailMu       sync.Mutex
+	offTrailWorkouts map[uuid.UUID]bool
+
+	// routing graphs of the trails by zone, built on first use and dropped when a trail changes
+	routeMu     sync.Mutex
+	routeGraphs map[uuid.UUID]*domain.RouteGraph
+
+	// fences each workout is in, so that only entering and leaving them is published
+	geofenceMu sync.Mutex
+	geofences  map[uuid.UUID]*domain.GeofenceState
 }
 
-func NewZoneService(repo ports.ZoneManagerRepository, shelterDistancePublisher ports.ShelterDistancePublisher) (*ZoneService, error) {
+func NewZoneService(repo ports.ZoneManagerRepository, shelterDistancePublisher ports.ShelterDistancePublisher, offTrailPublisher ports.OffTrailPublisher, reservationPublisher ports.ShelterReservationPublisher, geofencePublisher ports.GeofencePublisher, userClient ports.UserServiceClient, workoutClient ports.WorkoutServiceClient, offTrailThreshold float64, reservationTTL time.Duration, geofenceRadius float64) (*ZoneService, error) {
+	shelters, err := repo.ListShelters()
+	if err != nil {
+		return nil, err
+	}
+	shelterIndex := domain.NewShelterIndex(domain.DefaultShelterIndexCellSize)
+	for _, shelter := range shelters {
+		shelterIndex.Insert(*shelter)
+	}
+
 	return &ZoneService{
 		repo:                     repo,
 		shelterDistancePublisher: shelterDistancePublisher,
+		offTrailPublisher:        offTrailPublisher,
+		reservationPublisher:     reservationPublisher,
+		geofencePublisher:        geofencePublisher,
+		userClient:               userClient,
+		workoutClient:            workoutClient,
+		shelterIndex:             shelterIndex,
+		offTrailThreshold:        offTrailThreshold,
+		reservationTTL:           reservationTTL,
+		geofenceRadius:           geofenceRadius,
+		offTrailWorkouts:         make(map[uuid.UUID]bool),
+		routeGraphs:              make(map[uuid.UUID]*domain.RouteGraph),
+		geofences:                make(map[uuid.UUID]*domain.GeofenceState),
 	}, nil
 }
 
 func (zs *ZoneService) CreateTrail(name string, zId uuid.UUID, startLatitude float64, startLongitude float64, endLatitude float64, endLongitude float64) (uuid.UUID, error) {
+	trail := &domain.Trail{StartLatitude: startLatitude, StartLongitude: startLongitude, EndLatitude: endLatitude, EndLongitude: endLongitude}
+	if err := zs.checkTrailInZone(zId, trail); err != nil {
+		return uuid.Nil, err
+	}
+
 	res, err := zs.repo.CreateTrail(name, zId, startLatitude, startLongitude, endLatitude, endLongitude)
 	if err != nil {
 		return uuid.Nil, err
 	}
+	zs.resetRouteGraphs()
 	logger.Info("trail created successfully", zap.Any("trail_id", res))
 	return res, nil
 }
 
+// ImportTrail creates a trail from the path of a GPX or FIT file, the name from the file is used if none is given
+func (zs *ZoneService) ImportTrail(zId uuid.UUID, name string, format string, data []byte) (*domain.Trail, error) {
+	if _, err := zs.repo.GetZoneByID(zId); err != nil {
+		logger.Debug("zone not found for trail import", zap.Any("zone_id", zId), zap.Error(err))
+		return nil, err
+	}
+
+	fileName, path, err := domain.ParseTrailFile(format, data)
+	if err != nil {
+		logger.Debug("failed to parse trail file", zap.String("format", format), zap.Error(err))
+		return nil, err
+	}
+	if name == "" {
+		name = fileName
+	}
+
+	trail := &domain.Trail{
+		TrailName: name,
+		ZoneID:    zId,
+	}
+	if err := trail.SetPath(path); err != nil {
+		return nil, err
+	}
+	if err := zs.checkTrailInZone(zId, trail); err != nil {
+		return nil, err
+	}
+
+	trail.TrailID, err = zs.repo.CreateTrailWithPath(trail)
+	if err != nil {
+		return nil, err
+	}
+	zs.resetRouteGraphs()
+	logger.Info("trail imported successfully", zap.Any("trail_id", trail.TrailID), zap.Int("points", len(trail.Path)), zap.Float64("length", trail.Length))
+	return trail, nil
+}
+
 func (zs *ZoneService) UpdateTrail(tid uuid.UUID, name string, zId uuid.UUID, startLatitude float64, startLongitude float64, endLatitude float64, endLongitude float64) error {
-	err := zs.repo.UpdateTrailByID(tid, name, zId, startLatitude, startLongitude, endLatitude, endLongitude)
+	trail, err := zs.repo.GetTrailByID(tid)
 	if err != nil {
 		return err
 	}
+	trail.TrailName = name
+	trail.ZoneID = zId
+	trail.MoveEnds(startLatitude, startLongitude, endLatitude, endLongitude)
+	if err := zs.checkTrailInZone(zId, trail); err != nil {
+		return err
+	}
+
+	if err := zs.repo.UpdateTrailByID(trail); err != nil {
+		return err
+	}
+	zs.resetRouteGraphs()
 	return nil
 }
 
@@ -46,6 +151,7 @@ func (zs *ZoneService) DeleteTrail(tId uuid.UUID) error {
 	if err != nil {
 		return err
 	}
+	zs.resetRouteGraphs()
 	return nil
 }
 
@@ -54,6 +160,53 @@ func (zs *ZoneService) GetTrailByID(id uuid.UUID) (*domain.Trail, error) {
 	return trail, err
 }
 
+// GetTrailDifficulty rates the trail from 0 to 10 from its length, its climb and its shelters
+func (zs *ZoneService) GetTrailDifficulty(trail *domain.Trail) (float64, error) {
+	shelters, err := zs.repo.ListSheltersByTrailId(trail.TrailID)
+	if err != nil {
+		return 0, err
+	}
+	return domain.TrailDifficulty(trail, len(shelters)), nil
+}
+
+// RecommendTrails ranks the trails of the zone for the player from their preference, their age and the
+// distance of the workouts they completed recently
+func (zs *ZoneService) RecommendTrails(zId uuid.UUID, playerID uuid.UUID) ([]domain.TrailRecommendation, error) {
+	if _, err := zs.repo.GetZoneByID(zId); err != nil {
+		return nil, err
+	}
+
+	preference, age, err := zs.userClient.GetPlayer(playerID)
+	if err != nil {
+		logger.Debug("failed to get player for trail recommendation", zap.Any("player_id", playerID), zap.Error(err))
+		return nil, err
+	}
+	profile := domain.PlayerProfile{Preference: preference, Age: age}
+
+	// the trails still get ranked on the preference and age alone when the workouts are not available
+	now := time.Now()
+	distance, workouts, err := zs.workoutClient.GetDistanceCovered(playerID, now.Add(-recommendationHistory), now)
+	if err != nil {
+		logger.Warn("failed to get past workouts for trail recommendation", zap.Any("player_id", playerID), zap.Error(err))
+	} else if workouts > 0 {
+		profile.AverageDistance = distance / float64(workouts)
+	}
+
+	trails, err := zs.repo.ListTrailsByZoneId(zId)
+	if err != nil {
+		return nil, err
+	}
+	shelters := make(map[uuid.UUID]int, len(trails))
+	for _, trail := range trails {
+		trailShelters, err := zs.repo.ListSheltersByTrailId(trail.TrailID)
+		if err != nil {
+			return nil, err
+		}
+		shelters[trail.TrailID] = len(trailShelters)
+	}
+	return domain.RecommendTrails(profile, trails, shelters), nil
+}
+
 func (zs *ZoneService) CheckTrail(id uuid.UUID) error {
 	trail, err := zs.repo.GetTrailByID(id)
 	if err != nil || trail.TrailID != id {
@@ -79,11 +232,8 @@ func (zs *ZoneService) GetClosestTrail(zId uuid.UUID, currentLongitude float64,
 	}
 	var closestTrail *domain.Trail
 	minDistance := math.MaxFloat64 // Initialize with the maximum float value
-	distance := math.MaxFloat64
 	for _, trail := range trails {
-		point1 := haversine.Coord{Lat: currentLatitude, Lon: currentLongitude}
-		point2 := haversine.Coord{Lat: trail.StartLatitude, Lon: trail.StartLongitude}
-		_, distance = haversine.Distance(point1, point2)
+		distance := trail.DistanceFrom(currentLatitude, currentLongitude)
 		if distance < minDistance {
 			minDistance = distance
 			closestTrail = trail
@@ -98,29 +248,47 @@ func (zs *ZoneService) GetClosestTrail(zId uuid.UUID, currentLongitude float64,
 	return uuid.Nil, nil // Or return an appropriate error if necessary
 }
 
-func (zs *ZoneService) CreateShelter(name string, tId uuid.UUID, availability bool, lat, long float64) (uuid.UUID, error) {
-	sId, err := zs.repo.CreateShelter(name, tId, availability, lat, long)
+func (zs *ZoneService) CreateShelter(name string, tId uuid.UUID, availability bool, capacity uint16, lat, long float64) (uuid.UUID, error) {
+	if err := zs.checkShelterInZone(tId, lat, long); err != nil {
+		return uuid.Nil, err
+	}
+
+	sId, err := zs.repo.CreateShelter(name, tId, availability, capacity, lat, long)
 	if err != nil {
 		return uuid.Nil, err
 	} else {
+		shelter := domain.Shelter{ShelterID: sId, TrailID: tId, ShelterAvailability: availability, ShelterName: name, Latitude: lat, Longitude: long, Capacity: capacity}
+		shelter.UpdateAvailability()
+		zs.shelterIndex.Insert(shelter)
 		logger.Info("shelter created successfully", zap.Any("shelter_id", sId))
 		return sId, nil
 	}
 }
 
-func (zs *ZoneService) UpdateShelter(id uuid.UUID, name string, tId uuid.UUID, availability bool, lat, long float64) error {
+func (zs *ZoneService) UpdateShelter(id uuid.UUID, name string, tId uuid.UUID, availability bool, capacity uint16, lat, long float64) error {
+	if err := zs.checkShelterInZone(tId, lat, long); err != nil {
+		return err
+	}
 
-	err := zs.repo.UpdateShelterByID(id, tId, name, availability, lat, long)
+	err := zs.repo.UpdateShelterByID(id, tId, name, availability, capacity, lat, long)
 	if err != nil {
 		logger.Error("Zone: failed to updater shelter", zap.Error(err))
 		return err
 	}
+	// the availability follows the occupancy, so the index takes the shelter as stored
+	if shelter, err := zs.repo.GetShelterByID(id); err == nil {
+		zs.shelterIndex.Insert(*shelter)
+	}
 	return err
 
 }
 
 func (zs *ZoneService) DeleteShelter(id uuid.UUID) error {
-	return zs.repo.DeleteShelterByID(id)
+	if err := zs.repo.DeleteShelterByID(id); err != nil {
+		return err
+	}
+	zs.shelterIndex.Remove(id)
+	return nil
 }
 
 func (zs *ZoneService) GetShelterByID(id uuid.UUID) (*domain.Shelter, error) {
@@ -173,6 +341,17 @@ func (zs *ZoneService) UpdateZone(zId uuid.UUID, zName string) error {
 	return nil
 }
 
+// SetZoneTimeZone sets the IANA time zone the opening hours of the zone's shelters are in, an empty one is UTC
+func (zs *ZoneService) SetZoneTimeZone(zId uuid.UUID, timeZone string) error {
+	if _, err := domain.LoadTimeZone(timeZone); err != nil {
+		return err
+	}
+	if err := zs.CheckZone(zId); err != nil {
+		return err
+	}
+	return zs.repo.UpdateZoneTimeZone(zId, timeZone)
+}
+
 func (zs *ZoneService) DeleteZone(zId uuid.UUID) error {
 
 	err := zs.CheckZone(zId)
@@ -184,81 +363,688 @@ func (zs *ZoneService) DeleteZone(zId uuid.UUID) error {
 	if err != nil {
 		return err
 	}
+	zs.resetRouteGraphs()
 	return nil
 }
 
-func (zs *ZoneService) UpdateCurrentLocation(wId uuid.UUID, latitude float64, longitude float64, time time.Time) error {
+func (zs *ZoneService) UpdateCurrentLocation(wId uuid.UUID, tId uuid.UUID, latitude float64, longitude float64, time time.Time) error {
+
+	// locations still queued when the workout was stopped are dropped
+	session, err := zs.SetCurrentLocation(wId, tId, latitude, longitude, time)
+	if errors.Is(err, domain.ErrZoneManagerClosed) {
+		logger.Debug("ignoring location of a stopped workout", zap.Any("workout_id", wId))
+		return nil
+	}
+	if err != nil {
+		logger.Error("error when saving the session of the workout", zap.Error(err))
+	}
+
+	if tId != uuid.Nil {
+		if _, _, err := zs.CheckOffTrail(wId, tId, latitude, longitude, time); err != nil {
+			logger.Error("error when checking distance from trail", zap.Error(err))
+		}
+	}
+	if _, err := zs.CheckGeofences(wId, tId, latitude, longitude, time); err != nil {
+		logger.Error("error when checking geofences", zap.Error(err))
+	}
 
 	// Now push the shelter data data to the queue to the workout
-	shelterId, distance, availability, _, err := zs.GetClosestShelter(longitude, latitude, time)
+	closest, scope, err := zs.GetClosestShelterInScope(tId, uuid.Nil, latitude, longitude, time)
 	if err != nil {
 		logger.Error("error when getting cloest shelter info", zap.Error(err))
 		return err
 	}
-	closestShelter, _ := zs.repo.GetShelterByID(shelterId)
-	err = zs.shelterDistancePublisher.PublishShelterDistance(wId, shelterId, closestShelter.ShelterName, availability, distance)
+	if closest == nil {
+		logger.Debug("no shelter to publish to workout", zap.Any("workout_id", wId))
+		return nil
+	}
+	// the workout is told how far it has to walk along the trails, or the straight distance when the
+	// shelter cannot be reached through them
+	distance := closest.Distance
+	if route, err := zs.routeToShelter(closest.Shelter, latitude, longitude); err == nil {
+		distance = route.Distance
+	} else {
+		logger.Debug("no route to shelter, publishing the straight distance", zap.Any("shelter_id", closest.Shelter.ShelterID), zap.Error(err))
+	}
+	err = zs.shelterDistancePublisher.PublishShelterDistance(wId, closest.Shelter.ShelterID, closest.Shelter.ShelterName, closest.Shelter.ShelterAvailability, distance, scope)
 
 	if err != nil {
 		logger.Error("error when publishing shelter info", zap.Error(err))
+		return nil
 	}
 	logger.Debug("publishing shelter data to workout thru queue")
 
+	if session != nil {
+		session.SetShelter(closest.Shelter.ShelterID, distance, scope)
+		if err := zs.repo.SaveZoneManager(session); err != nil {
+			logger.Error("error when saving the shelter of the session", zap.Error(err))
+		}
+	}
+
 	return nil
 }
 
-func (zs *ZoneService) GetClosestShelter(longitude float64, latitude float64, time time.Time) (uuid.UUID, float64, bool, time.Time, error) {
+// SetCurrentLocation records the location of the workout and the trail it follows in its session, the
+// session is opened on the first location of the workout
+func (zs *ZoneService) SetCurrentLocation(wId uuid.UUID, tId uuid.UUID, latitude float64, longitude float64, time time.Time) (*domain.ZoneManager, error) {
+	session, err := zs.repo.GetZoneManagerByWorkoutID(wId)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		zoneManager, err := domain.NewZoneManager(wId)
+		if err != nil {
+			return nil, err
+		}
+		session = &zoneManager
+		logger.Info("zone manager session opened", zap.Any("workout_id", wId))
+	}
+
+	var trail *domain.Trail
+	if tId != uuid.Nil {
+		if trail, err = zs.repo.GetTrailByID(tId); err != nil {
+			return nil, err
+		}
+	}
+	if err := session.SetLocation(trail, latitude, longitude, time); err != nil {
+		return nil, err
+	}
+	if err := zs.repo.SaveZoneManager(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
 
-	shelters, err := zs.repo.ListShelters()
+// GetZoneManager returns the session of the workout
+func (zs *ZoneService) GetZoneManager(wId uuid.UUID) (*domain.ZoneManager, error) {
+	session, err := zs.repo.GetZoneManagerByWorkoutID(wId)
 	if err != nil {
-		return uuid.Nil, math.MaxFloat64, false, time, err
+		return nil, err
+	}
+	if session == nil {
+		return nil, ports.ErrorZoneManagerlNotFound
 	}
+	return session, nil
+}
 
-	var closestShelter *domain.Shelter
-	minDistance := math.MaxFloat64 // Initialize with the maximum float value
+// CloseZoneManager closes the session of a stopped workout, forgets what is kept in memory about it and gives
+// back its shelter place
+func (zs *ZoneService) CloseZoneManager(wId uuid.UUID, endedAt time.Time) error {
+	zs.offTrailMu.Lock()
+	delete(zs.offTrailWorkouts, wId)
+	zs.offTrailMu.Unlock()
+	zs.geofenceMu.Lock()
+	delete(zs.geofences, wId)
+	zs.geofenceMu.Unlock()
 
-	for _, shelter := range shelters {
+	if err := zs.ReleaseShelter(wId); err != nil {
+		logger.Error("error when releasing the shelter of a stopped workout", zap.Any("workout_id", wId), zap.Error(err))
+	}
 
-		distance := 0.0
-		point1 := haversine.Coord{Lat: latitude, Lon: longitude}
-		point2 := haversine.Coord{Lat: shelter.Latitude, Lon: shelter.Longitude}
-		_, distance = haversine.Distance(point1, point2)
+	session, err := zs.GetZoneManager(wId)
+	if err != nil {
+		return err
+	}
+	if err := session.Close(endedAt); err != nil {
+		return err
+	}
+	if err := zs.repo.SaveZoneManager(session); err != nil {
+		return err
+	}
+	logger.Info("zone manager session closed", zap.Any("workout_id", wId))
+	return nil
+}
 
-		if distance < minDistance {
-			minDistance = distance
-			closestShelter = shelter
+// CheckOffTrail returns whether the workout is further than the threshold from its trail and the distance to it.
+// An event is published to the workout only when the workout leaves or comes back to the trail.
+func (zs *ZoneService) CheckOffTrail(wId uuid.UUID, tId uuid.UUID, latitude float64, longitude float64, time time.Time) (bool, float64, error) {
+	trail, err := zs.repo.GetTrailByID(tId)
+	if err != nil {
+		return false, math.MaxFloat64, err
+	}
+
+	distance := trail.DistanceFrom(latitude, longitude)
+	offTrail := distance > zs.offTrailThreshold
+
+	zs.offTrailMu.Lock()
+	changed := zs.offTrailWorkouts[wId] != offTrail
+	if offTrail {
+		zs.offTrailWorkouts[wId] = true
+	} else {
+		delete(zs.offTrailWorkouts, wId)
+	}
+	zs.offTrailMu.Unlock()
+
+	if changed {
+		logger.Info("workout off trail status changed", zap.Any("workout_id", wId), zap.Bool("off_trail", offTrail), zap.Float64("distance", distance))
+		if err := zs.offTrailPublisher.PublishOffTrail(wId, tId, offTrail, distance, latitude, longitude, time); err != nil {
+			logger.Error("error when publishing off trail event", zap.Error(err))
 		}
 	}
+	return offTrail, distance, nil
+}
 
-	// If a closest trail is found, update the ZoneManager
-	if closestShelter != nil {
-		return closestShelter.ShelterID, minDistance, closestShelter.ShelterAvailability, time, nil
+// CheckGeofences moves the workout to the location and publishes the fences it entered or left: the start
+// and the end of its trail and the shelters around it
+func (zs *ZoneService) CheckGeofences(wId uuid.UUID, tId uuid.UUID, latitude float64, longitude float64, time time.Time) ([]domain.GeofenceEvent, error) {
+	var trail *domain.Trail
+	if tId != uuid.Nil {
+		var err error
+		trail, err = zs.repo.GetTrailByID(tId)
+		if err != nil {
+			return nil, err
+		}
 	}
+	shelters := zs.shelterIndex.WithinRadius(latitude, longitude, zs.geofenceRadius*domain.GeofenceExitFactor)
 
-	return uuid.Nil, math.MaxFloat64, false, time, nil // Or return an appropriate error if necessary
+	zs.geofenceMu.Lock()
+	state, ok := zs.geofences[wId]
+	if !ok {
+		state = domain.NewGeofenceState(wId)
+		zs.geofences[wId] = state
+	}
+	events := state.Update(trail, shelters, zs.geofenceRadius, latitude, longitude, time)
+	zs.geofenceMu.Unlock()
+
+	for i := range events {
+		logger.Info("workout geofence event", zap.Any("workout_id", wId), zap.String("event", events[i].Type), zap.Any("trail_id", events[i].TrailID), zap.Any("shelter_id", events[i].ShelterID))
+		if err := zs.geofencePublisher.PublishGeofenceEvent(&events[i]); err != nil {
+			logger.Error("error when publishing geofence event", zap.Error(err))
+		}
+	}
+	return events, nil
 }
 
-func (zs *ZoneService) GetClosestShelterInfo(latitude float64, longitude float64) (uuid.UUID, float64, error) {
-	shelters, err := zs.repo.ListShelters()
-	if err != nil || len(shelters) == 0 {
-		return uuid.Nil, math.MaxFloat64, err
+// GetClosestShelterInScope looks for the closest shelter open at the given time on the trail first, then
+// in the zone and then anywhere, and returns the scope it was found in. The zone of the trail is used when
+// no zone is given. When every shelter is closed the closest one is returned flagged as unavailable, no
+// shelter is returned if there are none at all.
+func (zs *ZoneService) GetClosestShelterInScope(tId uuid.UUID, zId uuid.UUID, latitude float64, longitude float64, at time.Time) (*domain.ShelterDistance, string, error) {
+	open := zs.openAt(at)
+	if tId != uuid.Nil {
+		closest := zs.shelterIndex.NearestMatching(latitude, longitude, 1, func(shelter *domain.Shelter) bool {
+			return shelter.TrailID == tId && open(shelter)
+		})
+		if len(closest) > 0 {
+			return &closest[0], domain.ShelterScopeTrail, nil
+		}
+
+		if zId == uuid.Nil {
+			trail, err := zs.repo.GetTrailByID(tId)
+			if err != nil {
+				return nil, "", err
+			}
+			zId = trail.ZoneID
+		}
 	}
-	var closestShelter *domain.Shelter
-	minDistance := math.MaxFloat64 // Initialize with the maximum float value
 
-	for _, shelter := range shelters {
+	if zId != uuid.Nil {
+		trails, err := zs.repo.ListTrailsByZoneId(zId)
+		if err != nil {
+			return nil, "", err
+		}
+		zoneTrails := make(map[uuid.UUID]bool, len(trails))
+		for _, trail := range trails {
+			zoneTrails[trail.TrailID] = true
+		}
+		closest := zs.shelterIndex.NearestMatching(latitude, longitude, 1, func(shelter *domain.Shelter) bool {
+			return zoneTrails[shelter.TrailID] && open(shelter)
+		})
+		if len(closest) > 0 {
+			return &closest[0], domain.ShelterScopeZone, nil
+		}
+	}
 
-		distance := 0.0
-		point1 := haversine.Coord{Lat: latitude, Lon: longitude}
-		point2 := haversine.Coord{Lat: shelter.Latitude, Lon: shelter.Longitude}
-		_, distance = haversine.Distance(point1, point2)
+	closest := zs.shelterIndex.NearestMatching(latitude, longitude, 1, open)
+	if len(closest) > 0 {
+		return &closest[0], domain.ShelterScopeGlobal, nil
+	}
 
-		if distance < minDistance {
-			minDistance = distance
-			closestShelter = shelter
+	closest = zs.shelterIndex.Nearest(latitude, longitude, 1)
+	if len(closest) == 0 {
+		return nil, "", nil
+	}
+	closest[0].Shelter.ShelterAvailability = false
+	return &closest[0], domain.ShelterScopeGlobal, nil
+}
+
+// RouteToShelter returns the walking route along the trails of a zone from a location to a shelter of the zone
+func (zs *ZoneService) RouteToShelter(zId uuid.UUID, latitude float64, longitude float64, sId uuid.UUID) (*domain.Route, error) {
+	shelter, err := zs.repo.GetShelterByID(sId)
+	if err != nil {
+		return nil, err
+	}
+	trail, err := zs.repo.GetTrailByID(shelter.TrailID)
+	if err != nil {
+		return nil, err
+	}
+	if trail.ZoneID != zId {
+		return nil, ports.ErrorShelterInOtherZone
+	}
+	return zs.routeToShelter(shelter, latitude, longitude)
+}
+
+// routeToShelter returns the walking route from a location to a shelter along the trails of its zone
+func (zs *ZoneService) routeToShelter(shelter *domain.Shelter, latitude float64, longitude float64) (*domain.Route, error) {
+	trail, err := zs.repo.GetTrailByID(shelter.TrailID)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := zs.routeGraph(trail.ZoneID)
+	if err != nil {
+		return nil, err
+	}
+	return graph.Route(
+		domain.TrailPoint{Latitude: latitude, Longitude: longitude},
+		domain.TrailPoint{Latitude: shelter.Latitude, Longitude: shelter.Longitude},
+	)
+}
+
+// routeGraph returns the routing graph of the trails of a zone
+func (zs *ZoneService) routeGraph(zId uuid.UUID) (*domain.RouteGraph, error) {
+	zs.routeMu.Lock()
+	defer zs.routeMu.Unlock()
+
+	if graph, ok := zs.routeGraphs[zId]; ok {
+		return graph, nil
+	}
+	trails, err := zs.repo.ListTrailsByZoneId(zId)
+	if err != nil {
+		return nil, err
+	}
+	graph := domain.NewRouteGraph(trails, domain.DefaultJunctionTolerance)
+	zs.routeGraphs[zId] = graph
+	logger.Debug("route graph built", zap.Any("zone_id", zId), zap.Int("trails", len(trails)), zap.Int("nodes", graph.Len()))
+	return graph, nil
+}
+
+// resetRouteGraphs drops the routing graphs after a trail changed, they are built again when needed
+func (zs *ZoneService) resetRouteGraphs() {
+	zs.routeMu.Lock()
+	defer zs.routeMu.Unlock()
+	zs.routeGraphs = make(map[uuid.UUID]*domain.RouteGraph)
+}
+
+// openAt returns a filter keeping the shelters open at the given time. The time zone of a shelter is the one
+// of the zone of its trail, it is only looked up once per trail and for shelters with opening hours.
+func (zs *ZoneService) openAt(at time.Time) func(shelter *domain.Shelter) bool {
+	locations := make(map[uuid.UUID]*time.Location)
+	return func(shelter *domain.Shelter) bool {
+		if !shelter.HasOpeningHours() {
+			return shelter.IsOpen(at, time.UTC)
+		}
+		location, ok := locations[shelter.TrailID]
+		if !ok {
+			location = zs.ShelterLocation(shelter)
+			locations[shelter.TrailID] = location
+		}
+		return shelter.IsOpen(at, location)
+	}
+}
+
+// IsShelterOpen returns whether the shelter is open at the given time in the time zone of its zone
+func (zs *ZoneService) IsShelterOpen(shelter *domain.Shelter, at time.Time) bool {
+	return zs.openAt(at)(shelter)
+}
+
+// ShelterLocation returns the time zone of the zone of the shelter's trail, UTC when it is not known
+func (zs *ZoneService) ShelterLocation(shelter *domain.Shelter) *time.Location {
+	trail, err := zs.repo.GetTrailByID(shelter.TrailID)
+	if err != nil {
+		return time.UTC
+	}
+	zone, err := zs.repo.GetZoneByID(trail.ZoneID)
+	if err != nil {
+		return time.UTC
+	}
+	return zone.Location()
+}
+
+// SetShelterSchedule replaces the weekly opening hours and the dated closures of a shelter, a shelter
+// without either is always open
+func (zs *ZoneService) SetShelterSchedule(sId uuid.UUID, hours []domain.OpeningHours, closures []domain.ShelterClosure) (*domain.Shelter, error) {
+	shelter, err := zs.repo.GetShelterByID(sId)
+	if err != nil {
+		return nil, err
+	}
+	if err := shelter.SetOpeningHours(hours); err != nil {
+		return nil, err
+	}
+	shelter.Closures = nil
+	for _, closure := range closures {
+		if err := shelter.AddClosure(closure); err != nil {
+			return nil, err
+		}
+	}
+	return shelter, zs.saveShelterSchedule(shelter)
+}
+
+// AddShelterClosure closes a shelter for a dated period
+func (zs *ZoneService) AddShelterClosure(sId uuid.UUID, closure domain.ShelterClosure) (*domain.Shelter, error) {
+	shelter, err := zs.repo.GetShelterByID(sId)
+	if err != nil {
+		return nil, err
+	}
+	if err := shelter.AddClosure(closure); err != nil {
+		return nil, err
+	}
+	return shelter, zs.saveShelterSchedule(shelter)
+}
+
+// RemoveShelterClosure cancels a dated closure of a shelter
+func (zs *ZoneService) RemoveShelterClosure(sId uuid.UUID, cId uuid.UUID) (*domain.Shelter, error) {
+	shelter, err := zs.repo.GetShelterByID(sId)
+	if err != nil {
+		return nil, err
+	}
+	if err := shelter.RemoveClosure(cId); err != nil {
+		return nil, err
+	}
+	return shelter, zs.saveShelterSchedule(shelter)
+}
+
+func (zs *ZoneService) saveShelterSchedule(shelter *domain.Shelter) error {
+	if err := zs.repo.UpdateShelterSchedule(shelter.ShelterID, shelter.OpeningHours, shelter.Closures); err != nil {
+		return err
+	}
+	zs.shelterIndex.Insert(*shelter)
+	logger.Info("shelter schedule updated", zap.Any("shelter_id", shelter.ShelterID), zap.Int("opening_hours", len(shelter.OpeningHours)), zap.Int("closures", len(shelter.Closures)))
+	return nil
+}
+
+// GetSheltersWithinRadius returns the shelters at most radius km from the location, closest first
+func (zs *ZoneService) GetSheltersWithinRadius(latitude float64, longitude float64, radius float64) []domain.ShelterDistance {
+	return zs.shelterIndex.WithinRadius(latitude, longitude, radius)
+}
+
+// SetZoneBoundary sets the boundary of a zone from a GeoJSON Polygon or MultiPolygon, the trails and
+// shelters already in the zone have to be inside it
+func (zs *ZoneService) SetZoneBoundary(zId uuid.UUID, geoJSON []byte) (*domain.Boundary, error) {
+	boundary, err := domain.ParseBoundary(geoJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	zone, err := zs.repo.GetZoneByID(zId)
+	if err != nil {
+		return nil, err
+	}
+	zone.Boundary = boundary
+
+	trails, err := zs.repo.ListTrailsByZoneId(zId)
+	if err != nil {
+		return nil, err
+	}
+	for _, trail := range trails {
+		if !zone.ContainsTrail(trail) {
+			logger.Debug("trail outside of the new zone boundary", zap.Any("trail_id", trail.TrailID))
+			return nil, domain.ErrOutsideZone
+		}
+		shelters, err := zs.repo.ListSheltersByTrailId(trail.TrailID)
+		if err != nil {
+			return nil, err
+		}
+		for _, shelter := range shelters {
+			if !zone.Contains(shelter.Latitude, shelter.Longitude) {
+				logger.Debug("shelter outside of the new zone boundary", zap.Any("shelter_id", shelter.ShelterID))
+				return nil, domain.ErrOutsideZone
+			}
+		}
+	}
+
+	if err := zs.repo.UpdateZoneBoundary(zId, boundary); err != nil {
+		return nil, err
+	}
+	logger.Info("zone boundary updated", zap.Any("zone_id", zId), zap.Int("polygons", len(boundary.Polygons)))
+	return boundary, nil
+}
+
+func (zs *ZoneService) GetZoneByID(zId uuid.UUID) (*domain.Zone, error) {
+	return zs.repo.GetZoneByID(zId)
+}
+
+// LocateZone returns the zone whose boundary contains the location, zones without a boundary are not considered
+func (zs *ZoneService) LocateZone(latitude float64, longitude float64) (*domain.Zone, error) {
+	zones, err := zs.repo.ListZones()
+	if err != nil {
+		return nil, err
+	}
+	for _, zone := range zones {
+		if zone.Boundary != nil && zone.Boundary.Contains(latitude, longitude) {
+			return zone, nil
+		}
+	}
+	return nil, ports.ErrorZoneNotFound
+}
+
+// ReserveShelter holds a place in a shelter for a workout until it is released or expires, and tells the
+// workout whether it got it
+func (zs *ZoneService) ReserveShelter(wId uuid.UUID, sId uuid.UUID) (*domain.ShelterReservation, error) {
+	reservation := domain.NewShelterReservation(sId, wId, zs.reservationTTL)
+	if shelter, err := zs.repo.GetShelterByID(sId); err == nil && !zs.IsShelterOpen(shelter, reservation.ReservedAt) {
+		logger.Info("shelter reservation refused", zap.Any("workout_id", wId), zap.Any("shelter_id", sId), zap.String("reason", domain.ReservationReasonClosed))
+		zs.reservationPublisher.PublishShelterReservation(wId, sId, false, domain.ReservationReasonClosed, time.Time{})
+		return nil, domain.ErrShelterClosed
+	}
+	reserved, released, err := zs.repo.ReserveShelter(reservation)
+	if released != nil {
+		zs.shelterIndex.Insert(*released)
+	}
+	if err != nil {
+		reason := domain.ReservationReasonUnavailable
+		if !errors.Is(err, domain.ErrShelterUnavailable) {
+			if _, getErr := zs.repo.GetShelterByID(sId); getErr != nil {
+				reason = domain.ReservationReasonNotFound
+			}
+		}
+		logger.Info("shelter reservation refused", zap.Any("workout_id", wId), zap.Any("shelter_id", sId), zap.String("reason", reason), zap.Error(err))
+		zs.reservationPublisher.PublishShelterReservation(wId, sId, false, reason, time.Time{})
+		return nil, err
+	}
+
+	zs.shelterIndex.Insert(*reserved)
+	logger.Info("shelter reserved", zap.Any("workout_id", wId), zap.Any("shelter_id", sId), zap.Uint16("occupancy", reserved.Occupancy), zap.Uint16("capacity", reserved.Capacity))
+	zs.reservationPublisher.PublishShelterReservation(wId, sId, true, "", reservation.ExpiresAt)
+	return reservation, nil
+}
+
+// ReleaseShelter gives back the shelter place held by a workout, workouts without a place are ignored
+func (zs *ZoneService) ReleaseShelter(wId uuid.UUID) error {
+	reservation, shelter, err := zs.repo.ReleaseShelterReservation(wId)
+	if err != nil {
+		return err
+	}
+	if shelter != nil {
+		zs.shelterIndex.Insert(*shelter)
+	}
+	if reservation != nil {
+		logger.Info("shelter released", zap.Any("workout_id", wId), zap.Any("shelter_id", reservation.ShelterID))
+	}
+	return nil
+}
+
+// ExpireShelterReservations gives back the places whose reservation expired at the given time and tells
+// their workouts, it returns the number of places given back
+func (zs *ZoneService) ExpireShelterReservations(now time.Time) (int, error) {
+	reservations, err := zs.repo.ListExpiredShelterReservations(now)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, reservation := range reservations {
+		released, shelter, err := zs.repo.ReleaseExpiredShelterReservation(reservation.ReservationID, now)
+		if err != nil {
+			logger.Error("failed to release expired shelter reservation", zap.Any("workout_id", reservation.WorkoutID), zap.Error(err))
+			continue
+		}
+		// released or extended meanwhile
+		if released == nil {
+			continue
+		}
+		if shelter != nil {
+			zs.shelterIndex.Insert(*shelter)
+		}
+		expired++
+		logger.Info("shelter reservation expired", zap.Any("workout_id", reservation.WorkoutID), zap.Any("shelter_id", reservation.ShelterID))
+		zs.reservationPublisher.PublishShelterReservation(reservation.WorkoutID, reservation.ShelterID, false, domain.ReservationReasonExpired, reservation.ExpiresAt)
+	}
+	return expired, nil
+}
+
+// MonitorShelterReservations gives back expired shelter places every interval
+func (zs *ZoneService) MonitorShelterReservations(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		if _, err := zs.ExpireShelterReservations(now); err != nil {
+			logger.Error("failed to expire shelter reservations", zap.Error(err))
+		}
+	}
+}
+
+// ExportZoneGeoJSON returns the boundary, trails and shelters of a zone as a GeoJSON FeatureCollection
+func (zs *ZoneService) ExportZoneGeoJSON(zId uuid.UUID) (*domain.FeatureCollection, error) {
+	zone, err := zs.repo.GetZoneByID(zId)
+	if err != nil {
+		return nil, err
+	}
+	trails, err := zs.repo.ListTrailsByZoneId(zId)
+	if err != nil {
+		return nil, err
+	}
+	var shelters []*domain.Shelter
+	for _, trail := range trails {
+		trailShelters, err := zs.repo.ListSheltersByTrailId(trail.TrailID)
+		if err != nil {
+			return nil, err
+		}
+		shelters = append(shelters, trailShelters...)
+	}
+	return domain.NewZoneFeatureCollection(zone, trails, shelters), nil
+}
+
+// ImportZoneGeoJSON creates or updates the boundary, trails and shelters of a zone from a GeoJSON
+// FeatureCollection. Trails and shelters of the zone missing from the collection are kept. Nothing is
+// written unless every feature is valid, the invalid ones are returned with ErrInvalidFeatures.
+func (zs *ZoneService) ImportZoneGeoJSON(zId uuid.UUID, data []byte) (*domain.ZoneFeatures, []*domain.FeatureError, error) {
+	zone, err := zs.repo.GetZoneByID(zId)
+	if err != nil {
+		return nil, nil, err
+	}
+	features, featureErrors, err := domain.ParseZoneFeatures(zone, data)
+	if err != nil {
+		return nil, featureErrors, err
+	}
+
+	existingTrails, err := zs.repo.ListTrailsByZoneId(zId)
+	if err != nil {
+		return nil, nil, err
+	}
+	zoneTrails := make(map[uuid.UUID]bool)
+	for _, trail := range existingTrails {
+		zoneTrails[trail.TrailID] = true
+	}
+
+	fail := func(id uuid.UUID, err error) {
+		featureErrors = append(featureErrors, &domain.FeatureError{Index: features.Features[id], ID: id.String(), Err: err})
+	}
+
+	// Trails and shelters cannot be moved from another zone
+	for _, trail := range features.Trails {
+		if zoneTrails[trail.TrailID] {
+			continue
+		}
+		if _, err := zs.repo.GetTrailByID(trail.TrailID); err == nil {
+			fail(trail.TrailID, ports.ErrorTrailInOtherZone)
+		}
+	}
+	for _, shelter := range features.Shelters {
+		if _, ok := features.Features[shelter.TrailID]; !ok && !zoneTrails[shelter.TrailID] {
+			fail(shelter.ShelterID, ports.ErrorShelterTrailNotInZone)
+			continue
+		}
+		if existing, err := zs.repo.GetShelterByID(shelter.ShelterID); err == nil {
+			if _, ok := features.Features[existing.TrailID]; !ok && !zoneTrails[existing.TrailID] {
+				fail(shelter.ShelterID, ports.ErrorShelterInOtherZone)
+			}
+		}
+	}
+
+	// A new boundary has to contain what is already in the zone
+	if features.Boundary != nil {
+		bounds := &domain.Zone{ZoneID: zId, Boundary: features.Boundary}
+		for _, trail := range existingTrails {
+			if _, ok := features.Features[trail.TrailID]; !ok && !bounds.ContainsTrail(trail) {
+				fail(zId, fmt.Errorf("trail %s: %w", trail.TrailID, domain.ErrOutsideZone))
+				continue
+			}
+			shelters, err := zs.repo.ListSheltersByTrailId(trail.TrailID)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, shelter := range shelters {
+				if _, ok := features.Features[shelter.ShelterID]; !ok && !bounds.Contains(shelter.Latitude, shelter.Longitude) {
+					fail(zId, fmt.Errorf("shelter %s: %w", shelter.ShelterID, domain.ErrOutsideZone))
+				}
+			}
+		}
+	}
+
+	if len(featureErrors) > 0 {
+		sort.SliceStable(featureErrors, func(i, j int) bool { return featureErrors[i].Index < featureErrors[j].Index })
+		return nil, featureErrors, domain.ErrInvalidFeatures
+	}
+
+	defer zs.resetRouteGraphs()
+	if err := zs.repo.UpsertZoneFeatures(zId, features.Boundary, features.Trails, features.Shelters); err != nil {
+		return nil, nil, err
+	}
+	// existing shelters keep their occupancy and schedule, so the index takes the shelters as stored
+	for i, shelter := range features.Shelters {
+		stored, err := zs.repo.GetShelterByID(shelter.ShelterID)
+		if err != nil {
+			logger.Error("failed to read imported shelter", zap.Any("shelter_id", shelter.ShelterID), zap.Error(err))
+			continue
 		}
+		features.Shelters[i] = stored
+		zs.shelterIndex.Insert(*stored)
 	}
-	return closestShelter.ShelterID, minDistance, nil
+	logger.Info("zone features imported", zap.Any("zone_id", zId), zap.Int("trails", len(features.Trails)), zap.Int("shelters", len(features.Shelters)))
+	return features, nil, nil
+}
 
+// checkTrailInZone returns ErrOutsideZone if the trail leaves the boundary of the zone.
+// Zones that cannot be found are not checked.
+func (zs *ZoneService) checkTrailInZone(zId uuid.UUID, trail *domain.Trail) error {
+	zone, err := zs.repo.GetZoneByID(zId)
+	if err != nil {
+		return nil
+	}
+	if !zone.ContainsTrail(trail) {
+		logger.Debug("trail outside of the zone boundary", zap.Any("zone_id", zId))
+		return domain.ErrOutsideZone
+	}
+	return nil
+}
+
+// checkShelterInZone returns ErrOutsideZone if the shelter is outside the boundary of the zone of its trail.
+// Trails and zones that cannot be found are not checked.
+func (zs *ZoneService) checkShelterInZone(tId uuid.UUID, latitude float64, longitude float64) error {
+	trail, err := zs.repo.GetTrailByID(tId)
+	if err != nil {
+		return nil
+	}
+	zone, err := zs.repo.GetZoneByID(trail.ZoneID)
+	if err != nil {
+		return nil
+	}
+	if !zone.Contains(latitude, longitude) {
+		logger.Debug("shelter outside of the zone boundary", zap.Any("zone_id", zone.ZoneID))
+		return domain.ErrOutsideZone
+	}
+	return nil
 }
 
 // Add this function to the ZoneService type
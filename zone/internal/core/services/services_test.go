@@ -1,16 +1,23 @@
 package services_test
 
 import (
+	"encoding/json"
+	"errors"
 	"math/rand"
 	"testing"
 	"time"
 
 	"github.com/CAS735-F23/macrun-teamvsl/zone/config"
 	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/adapters/secondary/amqp"
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/adapters/secondary/clients"
 	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/adapters/secondary/repository/postgres"
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/domain"
+	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/ports"
 	"github.com/CAS735-F23/macrun-teamvsl/zone/internal/core/services"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/umahmood/haversine"
 )
 
 var cfg *config.AppConfiguration = config.Config
@@ -34,7 +41,7 @@ func TestZoneService_CreateTrail(t *testing.T) {
 	// zoneManagerRepo := repository.NewMemoryRepository()
 	publisherMock := amqp.NewShelterDistancePublisherMock()
 
-	service, _ := services.NewZoneService(repo, publisherMock)
+	service, _ := services.NewZoneService(repo, publisherMock, amqp.NewOffTrailPublisherMock(), amqp.NewShelterReservationPublisherMock(), amqp.NewGeofencePublisherMock(), clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), cfg.OffTrailThreshold, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
 
 	trailName := randomString(10)
 	zoneID := uuid.New()
@@ -53,14 +60,14 @@ func TestZoneService_CreateShelter(t *testing.T) {
 	// Initialize repositories and service as above
 	repo := postgres.NewRepository(cfg.Postgres)
 	publisherMock := amqp.NewShelterDistancePublisherMock()
-	service, _ := services.NewZoneService(repo, publisherMock)
+	service, _ := services.NewZoneService(repo, publisherMock, amqp.NewOffTrailPublisherMock(), amqp.NewShelterReservationPublisherMock(), amqp.NewGeofencePublisherMock(), clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), cfg.OffTrailThreshold, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
 
 	shelterName := randomString(10)
 	trailID := uuid.New() // Assuming this trail already exists in your test setup
 	availability := true
 	lat, long := 40.7128, -74.0060
 
-	shelterID, err := service.CreateShelter(shelterName, trailID, availability, lat, long)
+	shelterID, err := service.CreateShelter(shelterName, trailID, availability, 0, lat, long)
 
 	assert.NoError(t, err)
 	assert.NotEqual(t, uuid.Nil, shelterID)
@@ -72,7 +79,7 @@ func TestZoneService_UpdateTrail(t *testing.T) {
 	// Initialize repositories and service as above
 	repo := postgres.NewRepository(cfg.Postgres)
 	publisherMock := amqp.NewShelterDistancePublisherMock()
-	service, _ := services.NewZoneService(repo, publisherMock)
+	service, _ := services.NewZoneService(repo, publisherMock, amqp.NewOffTrailPublisherMock(), amqp.NewShelterReservationPublisherMock(), amqp.NewGeofencePublisherMock(), clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), cfg.OffTrailThreshold, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
 
 	// Create a trail first
 	trailName := "Original Trail Name " + randomString(5)
@@ -94,7 +101,7 @@ func TestZoneService_DeleteTrail(t *testing.T) {
 	// Initialize repositories and service as above
 	repo := postgres.NewRepository(cfg.Postgres)
 	publisherMock := amqp.NewShelterDistancePublisherMock()
-	service, _ := services.NewZoneService(repo, publisherMock)
+	service, _ := services.NewZoneService(repo, publisherMock, amqp.NewOffTrailPublisherMock(), amqp.NewShelterReservationPublisherMock(), amqp.NewGeofencePublisherMock(), clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), cfg.OffTrailThreshold, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
 
 	// Create a trail first
 	trailName := "Test Trail " + randomString(5)
@@ -114,7 +121,7 @@ func TestZoneService_GetTrailByID(t *testing.T) {
 	// Initialize repositories and service as above
 	repo := postgres.NewRepository(cfg.Postgres)
 	publisherMock := amqp.NewShelterDistancePublisherMock()
-	service, _ := services.NewZoneService(repo, publisherMock)
+	service, _ := services.NewZoneService(repo, publisherMock, amqp.NewOffTrailPublisherMock(), amqp.NewShelterReservationPublisherMock(), amqp.NewGeofencePublisherMock(), clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), cfg.OffTrailThreshold, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
 
 	// Create a trail first
 	trailName := randomString(10)
@@ -129,3 +136,779 @@ func TestZoneService_GetTrailByID(t *testing.T) {
 	assert.Equal(t, trailName, retrievedTrail.TrailName)
 	service.DeleteTrail(trailID)
 }
+
+func TestZoneService_ImportTrail(t *testing.T) {
+	repo := postgres.NewRepository(cfg.Postgres)
+	publisherMock := amqp.NewShelterDistancePublisherMock()
+	service, _ := services.NewZoneService(repo, publisherMock, amqp.NewOffTrailPublisherMock(), amqp.NewShelterReservationPublisherMock(), amqp.NewGeofencePublisherMock(), clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), cfg.OffTrailThreshold, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
+
+	zoneID, err := service.CreateZone(randomString(10))
+	assert.NoError(t, err)
+
+	gpx := `<gpx version="1.1" xmlns="http://www.topografix.com/GPX/1/1"><trk><name>Imported</name><trkseg>
+		<trkpt lat="43.2609" lon="-79.9192"><ele>90</ele></trkpt>
+		<trkpt lat="43.2619" lon="-79.9180"><ele>110</ele></trkpt>
+		<trkpt lat="43.2630" lon="-79.9201"><ele>105</ele></trkpt>
+	</trkseg></trk></gpx>`
+
+	trail, err := service.ImportTrail(zoneID, "", "gpx", []byte(gpx))
+	assert.NoError(t, err)
+	assert.Equal(t, "Imported", trail.TrailName)
+
+	retrievedTrail, err := service.GetTrailByID(trail.TrailID)
+	assert.NoError(t, err)
+	assert.Len(t, retrievedTrail.Path, 3)
+	assert.InDelta(t, trail.Length, retrievedTrail.Length, 1e-9)
+	assert.Equal(t, trail.BoundingBox, retrievedTrail.BoundingBox)
+	assert.Equal(t, 43.2609, retrievedTrail.StartLatitude)
+	assert.Equal(t, -79.9201, retrievedTrail.EndLongitude)
+
+	// The elevation of the points is kept with the climb and drop of the trail
+	assert.Equal(t, 110.0, *retrievedTrail.Path[1].Elevation)
+	assert.Equal(t, 20.0, retrievedTrail.Ascent)
+	assert.Equal(t, 5.0, retrievedTrail.Descent)
+	assert.InDelta(t, trail.MaxGrade, retrievedTrail.MaxGrade, 1e-9)
+	assert.Len(t, retrievedTrail.ElevationProfile(), 2)
+
+	// A file with a single point is not a trail
+	_, err = service.ImportTrail(zoneID, "single", "gpx", []byte(`<gpx><trk><trkseg><trkpt lat="1" lon="1"></trkpt></trkseg></trk></gpx>`))
+	assert.Error(t, err)
+
+	service.DeleteTrail(trail.TrailID)
+	service.DeleteZone(zoneID)
+}
+
+func TestZoneService_UpdateTrailPath(t *testing.T) {
+	repo := postgres.NewRepository(cfg.Postgres)
+	service, _ := services.NewZoneService(repo, amqp.NewShelterDistancePublisherMock(), amqp.NewOffTrailPublisherMock(), amqp.NewShelterReservationPublisherMock(), amqp.NewGeofencePublisherMock(), clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), cfg.OffTrailThreshold, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
+
+	zoneID, err := service.CreateZone(randomString(10))
+	assert.NoError(t, err)
+	gpx := `<gpx version="1.1" xmlns="http://www.topografix.com/GPX/1/1"><trk><name>Edited</name><trkseg>
+		<trkpt lat="43.2609" lon="-79.9192"><ele>90</ele></trkpt>
+		<trkpt lat="43.2619" lon="-79.9180"><ele>110</ele></trkpt>
+		<trkpt lat="43.2630" lon="-79.9201"><ele>105</ele></trkpt>
+	</trkseg></trk></gpx>`
+	trail, err := service.ImportTrail(zoneID, "", "gpx", []byte(gpx))
+	assert.NoError(t, err)
+
+	// Renaming the trail keeps its path and climb
+	name := randomString(10)
+	err = service.UpdateTrail(trail.TrailID, name, zoneID, trail.StartLatitude, trail.StartLongitude, trail.EndLatitude, trail.EndLongitude)
+	assert.NoError(t, err)
+	renamed, err := service.GetTrailByID(trail.TrailID)
+	assert.NoError(t, err)
+	assert.Equal(t, name, renamed.TrailName)
+	assert.Len(t, renamed.Path, 3)
+	assert.InDelta(t, trail.Length, renamed.Length, 1e-9)
+	assert.Equal(t, 20.0, renamed.Ascent)
+
+	// Moving its end moves the end of its path, and the length and bounding box are stored with it
+	err = service.UpdateTrail(trail.TrailID, name, zoneID, 43.2609, -79.9192, 43.2650, -79.9201)
+	assert.NoError(t, err)
+	moved, err := service.GetTrailByID(trail.TrailID)
+	assert.NoError(t, err)
+	expected := *trail
+	expected.MoveEnds(43.2609, -79.9192, 43.2650, -79.9201)
+	assert.Len(t, moved.Path, 3)
+	assert.Equal(t, 43.2650, moved.Path[2].Latitude)
+	assert.Greater(t, moved.Length, trail.Length)
+	assert.InDelta(t, expected.Length, moved.Length, 1e-9)
+	assert.Equal(t, 43.2650, moved.BoundingBox.MaxLatitude)
+	assert.Equal(t, 0.0, moved.Ascent)
+
+	// Unknown trails are not updated
+	assert.Error(t, service.UpdateTrail(uuid.New(), name, zoneID, 0, 0, 1, 1))
+
+	service.DeleteTrail(trail.TrailID)
+	service.DeleteZone(zoneID)
+}
+
+func TestZoneService_CheckOffTrail(t *testing.T) {
+	repo := postgres.NewRepository(cfg.Postgres)
+	publisherMock := amqp.NewShelterDistancePublisherMock()
+	offTrailMock := amqp.NewOffTrailPublisherMock()
+	service, _ := services.NewZoneService(repo, publisherMock, offTrailMock, amqp.NewShelterReservationPublisherMock(), amqp.NewGeofencePublisherMock(), clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), 0.05, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
+
+	zoneID, err := service.CreateZone(randomString(10))
+	assert.NoError(t, err)
+
+	// An L shaped trail, the corner is far from the straight line between its ends
+	gpx := `<gpx version="1.1" xmlns="http://www.topografix.com/GPX/1/1"><trk><trkseg>
+		<trkpt lat="43.2600" lon="-79.9200"></trkpt>
+		<trkpt lat="43.2700" lon="-79.9200"></trkpt>
+		<trkpt lat="43.2700" lon="-79.9000"></trkpt>
+	</trkseg></trk></gpx>`
+	trail, err := service.ImportTrail(zoneID, "L trail", "gpx", []byte(gpx))
+	assert.NoError(t, err)
+
+	closestTrail, err := service.GetClosestTrail(zoneID, -79.9190, 43.2699)
+	assert.NoError(t, err)
+	assert.Equal(t, trail.TrailID, closestTrail)
+
+	workoutID := uuid.New()
+	now := time.Now()
+	offTrailMock.On("PublishOffTrail", workoutID, trail.TrailID, true, mock.Anything, 43.2650, -79.9100, now).Return(nil).Once()
+	offTrailMock.On("PublishOffTrail", workoutID, trail.TrailID, false, mock.Anything, 43.2650, -79.9201, now).Return(nil).Once()
+
+	// On the trail nothing is published
+	offTrail, distance, err := service.CheckOffTrail(workoutID, trail.TrailID, 43.2650, -79.9201, now)
+	assert.NoError(t, err)
+	assert.False(t, offTrail)
+	assert.Less(t, distance, 0.05)
+
+	// Leaving the trail is published once
+	offTrail, _, err = service.CheckOffTrail(workoutID, trail.TrailID, 43.2650, -79.9100, now)
+	assert.NoError(t, err)
+	assert.True(t, offTrail)
+	offTrail, _, err = service.CheckOffTrail(workoutID, trail.TrailID, 43.2650, -79.9100, now)
+	assert.NoError(t, err)
+	assert.True(t, offTrail)
+
+	// Coming back is published
+	offTrail, _, err = service.CheckOffTrail(workoutID, trail.TrailID, 43.2650, -79.9201, now)
+	assert.NoError(t, err)
+	assert.False(t, offTrail)
+
+	offTrailMock.AssertExpectations(t)
+
+	service.DeleteTrail(trail.TrailID)
+	service.DeleteZone(zoneID)
+}
+
+func TestZoneService_ShelterIndexFollowsRepository(t *testing.T) {
+	repo := postgres.NewRepository(cfg.Postgres)
+	publisherMock := amqp.NewShelterDistancePublisherMock()
+	service, err := services.NewZoneService(repo, publisherMock, amqp.NewOffTrailPublisherMock(), amqp.NewShelterReservationPublisherMock(), amqp.NewGeofencePublisherMock(), clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), cfg.OffTrailThreshold, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
+	assert.NoError(t, err)
+
+	// A location in the middle of the ocean, away from the shelters of other tests
+	lat, long := -48.8767, -123.3933
+	shelterID, err := service.CreateShelter(randomString(10), uuid.New(), true, 0, lat, long)
+	assert.NoError(t, err)
+
+	closest, _, err := service.GetClosestShelterInScope(uuid.Nil, uuid.Nil, lat+0.001, long, time.Now())
+	assert.NoError(t, err)
+	if assert.NotNil(t, closest) {
+		assert.Equal(t, shelterID, closest.Shelter.ShelterID)
+		assert.InDelta(t, 0.111, closest.Distance, 0.001)
+	}
+	assert.Len(t, service.GetSheltersWithinRadius(lat, long, 1), 1)
+
+	// Moving the shelter away moves it in the index
+	err = service.UpdateShelter(shelterID, randomString(10), uuid.New(), true, 0, lat+1, long)
+	assert.NoError(t, err)
+	assert.Empty(t, service.GetSheltersWithinRadius(lat, long, 1))
+
+	// A deleted shelter is not found anymore
+	err = service.DeleteShelter(shelterID)
+	assert.NoError(t, err)
+	closest, _, err = service.GetClosestShelterInScope(uuid.Nil, uuid.Nil, lat+1, long, time.Now())
+	assert.NoError(t, err)
+	assert.True(t, closest == nil || closest.Shelter.ShelterID != shelterID)
+}
+
+func TestZoneService_GetClosestShelterInScope(t *testing.T) {
+	repo := postgres.NewRepository(cfg.Postgres)
+	publisherMock := amqp.NewShelterDistancePublisherMock()
+	geofenceMock := amqp.NewGeofencePublisherMock()
+	service, err := services.NewZoneService(repo, publisherMock, amqp.NewOffTrailPublisherMock(), amqp.NewShelterReservationPublisherMock(), geofenceMock, clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), cfg.OffTrailThreshold, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
+	assert.NoError(t, err)
+
+	// Locations in the Southern Ocean, away from the shelters of other tests
+	lat, long := -60.0, 30.0
+	zoneID, _ := service.CreateZone(randomString(10))
+	otherZoneID, _ := service.CreateZone(randomString(10))
+	emptyZoneID, _ := service.CreateZone(randomString(10))
+	trailID, _ := service.CreateTrail(randomString(10), zoneID, lat, long, lat+0.1, long)
+	zoneTrailID, _ := service.CreateTrail(randomString(10), zoneID, lat, long, lat, long+0.1)
+	emptyTrailID, _ := service.CreateTrail(randomString(10), zoneID, lat, long, lat-0.1, long)
+	otherTrailID, _ := service.CreateTrail(randomString(10), otherZoneID, lat, long, lat, long-0.1)
+	lonelyTrailID, _ := service.CreateTrail(randomString(10), emptyZoneID, lat, long, lat, long-0.1)
+
+	// The shelter of the other zone is the closest, the one on the trail the furthest
+	otherShelterID, _ := service.CreateShelter(randomString(10), otherTrailID, true, 0, lat, long+0.001)
+	zoneShelterID, _ := service.CreateShelter(randomString(10), zoneTrailID, true, 0, lat, long+0.01)
+	trailShelterID, _ := service.CreateShelter(randomString(10), trailID, true, 0, lat, long+0.05)
+
+	closest, scope, err := service.GetClosestShelterInScope(trailID, uuid.Nil, lat, long, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, trailShelterID, closest.Shelter.ShelterID)
+	assert.Equal(t, "trail", scope)
+
+	closest, scope, err = service.GetClosestShelterInScope(emptyTrailID, uuid.Nil, lat, long, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, zoneShelterID, closest.Shelter.ShelterID)
+	assert.Equal(t, "zone", scope)
+
+	closest, scope, err = service.GetClosestShelterInScope(lonelyTrailID, emptyZoneID, lat, long, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, otherShelterID, closest.Shelter.ShelterID)
+	assert.Equal(t, "global", scope)
+
+	// The published shelter carries the scope
+	workoutID := uuid.New()
+	publisherMock.On("PublishShelterDistance", workoutID, zoneShelterID, mock.Anything, true, mock.Anything, "zone").Return(nil).Once()
+	geofenceMock.On("PublishGeofenceEvent", mock.Anything).Return(nil)
+	err = service.UpdateCurrentLocation(workoutID, emptyTrailID, lat, long, time.Now())
+	assert.NoError(t, err)
+
+	for _, sId := range []uuid.UUID{otherShelterID, zoneShelterID, trailShelterID} {
+		service.DeleteShelter(sId)
+	}
+	for _, tId := range []uuid.UUID{trailID, zoneTrailID, emptyTrailID, otherTrailID, lonelyTrailID} {
+		service.DeleteTrail(tId)
+	}
+	for _, zId := range []uuid.UUID{zoneID, otherZoneID, emptyZoneID} {
+		service.DeleteZone(zId)
+	}
+}
+
+func TestZoneService_ZoneBoundary(t *testing.T) {
+	repo := postgres.NewRepository(cfg.Postgres)
+	publisherMock := amqp.NewShelterDistancePublisherMock()
+	service, _ := services.NewZoneService(repo, publisherMock, amqp.NewOffTrailPublisherMock(), amqp.NewShelterReservationPublisherMock(), amqp.NewGeofencePublisherMock(), clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), cfg.OffTrailThreshold, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
+
+	zoneID, err := service.CreateZone(randomString(10))
+	assert.NoError(t, err)
+
+	// A square in the Indian Ocean, away from the zones of other tests
+	_, err = service.SetZoneBoundary(zoneID, []byte(`{"type": "LineString", "coordinates": [[70, -30], [71, -30]]}`))
+	assert.ErrorIs(t, err, domain.ErrInvalidBoundary)
+	_, err = service.SetZoneBoundary(zoneID, []byte(`{"type": "Polygon", "coordinates": [[[70, -30], [71, -30], [71, -29], [70, -29], [70, -30]]]}`))
+	assert.NoError(t, err)
+
+	located, err := service.LocateZone(-29.5, 70.5)
+	assert.NoError(t, err)
+	assert.Equal(t, zoneID, located.ZoneID)
+	_, err = service.LocateZone(-28.5, 70.5)
+	assert.ErrorIs(t, err, ports.ErrorZoneNotFound)
+
+	// Trails and shelters have to be inside the zone
+	trailID, err := service.CreateTrail(randomString(10), zoneID, -29.8, 70.2, -29.2, 70.8)
+	assert.NoError(t, err)
+	_, err = service.CreateTrail(randomString(10), zoneID, -29.8, 70.2, -28.2, 70.8)
+	assert.ErrorIs(t, err, domain.ErrOutsideZone)
+	err = service.UpdateTrail(trailID, randomString(10), zoneID, -29.8, 69.2, -29.2, 70.8)
+	assert.ErrorIs(t, err, domain.ErrOutsideZone)
+
+	shelterID, err := service.CreateShelter(randomString(10), trailID, true, 0, -29.5, 70.5)
+	assert.NoError(t, err)
+	_, err = service.CreateShelter(randomString(10), trailID, true, 0, 70.5, -29.5)
+	assert.ErrorIs(t, err, domain.ErrOutsideZone)
+	err = service.UpdateShelter(shelterID, randomString(10), trailID, true, 0, -31.5, 70.5)
+	assert.ErrorIs(t, err, domain.ErrOutsideZone)
+
+	// A boundary leaving out the trail is refused
+	_, err = service.SetZoneBoundary(zoneID, []byte(`{"type": "Polygon", "coordinates": [[[70, -30], [70.5, -30], [70.5, -29.5], [70, -29.5], [70, -30]]]}`))
+	assert.ErrorIs(t, err, domain.ErrOutsideZone)
+
+	service.DeleteShelter(shelterID)
+	service.DeleteTrail(trailID)
+	service.DeleteZone(zoneID)
+}
+
+func TestZoneService_ZoneGeoJSON(t *testing.T) {
+	repo := postgres.NewRepository(cfg.Postgres)
+	publisherMock := amqp.NewShelterDistancePublisherMock()
+	service, _ := services.NewZoneService(repo, publisherMock, amqp.NewOffTrailPublisherMock(), amqp.NewShelterReservationPublisherMock(), amqp.NewGeofencePublisherMock(), clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), cfg.OffTrailThreshold, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
+
+	zoneID, err := service.CreateZone(randomString(10))
+	assert.NoError(t, err)
+	existingTrailID, err := service.CreateTrail(randomString(10), zoneID, -39.8, 70.2, -39.7, 70.3)
+	assert.NoError(t, err)
+
+	// A square in the Indian Ocean, away from the zones of other tests
+	trailID := uuid.New()
+	collection := `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[70, -40], [71, -40], [71, -39], [70, -39], [70, -40]]]}},
+		{"type": "Feature", "properties": {"trail_id": "` + trailID.String() + `", "name": "` + randomString(10) + `"},
+			"geometry": {"type": "LineString", "coordinates": [[70.1, -39.9], [70.5, -39.5], [70.9, -39.1]]}},
+		{"type": "Feature", "properties": {"trail_id": "` + trailID.String() + `", "name": "` + randomString(10) + `", "availability": false},
+			"geometry": {"type": "Point", "coordinates": [70.5, -39.5]}}
+	]}`
+
+	// Features referring to trails of other zones and a boundary leaving out the existing trail are refused
+	otherZoneID, _ := service.CreateZone(randomString(10))
+	otherTrailID, _ := service.CreateTrail(randomString(10), otherZoneID, -39.8, 70.2, -39.7, 70.3)
+	invalid := `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[70.5, -40], [71, -40], [71, -39], [70.5, -39], [70.5, -40]]]}},
+		{"type": "Feature", "properties": {"trail_id": "` + otherTrailID.String() + `", "name": "moved"},
+			"geometry": {"type": "LineString", "coordinates": [[70.6, -39.9], [70.9, -39.1]]}},
+		{"type": "Feature", "properties": {"trail_id": "` + uuid.NewString() + `", "name": "lost"},
+			"geometry": {"type": "Point", "coordinates": [70.7, -39.5]}}
+	]}`
+	_, featureErrors, err := service.ImportZoneGeoJSON(zoneID, []byte(invalid))
+	assert.ErrorIs(t, err, domain.ErrInvalidFeatures)
+	if assert.Len(t, featureErrors, 3) {
+		assert.ErrorIs(t, featureErrors[0], domain.ErrOutsideZone)
+		assert.ErrorIs(t, featureErrors[1], ports.ErrorTrailInOtherZone)
+		assert.ErrorIs(t, featureErrors[2], ports.ErrorShelterTrailNotInZone)
+	}
+	otherTrail, err := service.GetTrailByID(otherTrailID)
+	assert.NoError(t, err)
+	assert.Equal(t, otherZoneID, otherTrail.ZoneID)
+
+	features, featureErrors, err := service.ImportZoneGeoJSON(zoneID, []byte(collection))
+	assert.NoError(t, err)
+	assert.Empty(t, featureErrors)
+	shelterID := features.Shelters[0].ShelterID
+
+	trail, err := service.GetTrailByID(trailID)
+	assert.NoError(t, err)
+	assert.Equal(t, zoneID, trail.ZoneID)
+	assert.Len(t, trail.Path, 3)
+	shelter, err := service.GetShelterByID(shelterID)
+	assert.NoError(t, err)
+	assert.False(t, shelter.ShelterAvailability)
+
+	closest, scope, err := service.GetClosestShelterInScope(trailID, uuid.Nil, -39.5, 70.5, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ShelterScopeTrail, scope)
+	assert.Equal(t, shelterID, closest.Shelter.ShelterID)
+
+	// Importing the export again updates the same trails and shelters
+	exported, err := service.ExportZoneGeoJSON(zoneID)
+	assert.NoError(t, err)
+	assert.Len(t, exported.Features, 4)
+	data, _ := json.Marshal(exported)
+	again, _, err := service.ImportZoneGeoJSON(zoneID, data)
+	assert.NoError(t, err)
+	assert.Len(t, again.Trails, 2)
+	assert.Len(t, again.Shelters, 1)
+	assert.Equal(t, shelterID, again.Shelters[0].ShelterID)
+
+	service.DeleteShelter(shelterID)
+	service.DeleteTrail(trailID)
+	service.DeleteTrail(existingTrailID)
+	service.DeleteTrail(otherTrailID)
+	service.DeleteZone(zoneID)
+	service.DeleteZone(otherZoneID)
+}
+
+func TestZoneService_ZoneGeoJSON_KeepsOccupancy(t *testing.T) {
+	repo := postgres.NewRepository(cfg.Postgres)
+	reservationMock := amqp.NewShelterReservationPublisherMock()
+	service, _ := services.NewZoneService(repo, amqp.NewShelterDistancePublisherMock(), amqp.NewOffTrailPublisherMock(), reservationMock, amqp.NewGeofencePublisherMock(), clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), cfg.OffTrailThreshold, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
+
+	// A shelter of two places in the Indian Ocean, away from the shelters of other tests
+	lat, long := -35.0, 100.0
+	zoneID, _ := service.CreateZone(randomString(10))
+	trailID, _ := service.CreateTrail(randomString(10), zoneID, lat, long, lat, long+0.1)
+	shelterID, err := service.CreateShelter(randomString(10), trailID, true, 2, lat, long+0.05)
+	assert.NoError(t, err)
+
+	firstWorkout, secondWorkout := uuid.New(), uuid.New()
+	reservationMock.On("PublishShelterReservation", mock.Anything, shelterID, true, "", mock.Anything).Return(nil)
+	_, err = service.ReserveShelter(firstWorkout, shelterID)
+	assert.NoError(t, err)
+
+	// Importing the shelter with a single place leaves it full, though the file has it available
+	collection := `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {"shelter_id": "` + shelterID.String() + `", "trail_id": "` + trailID.String() + `", "name": "` + randomString(10) + `", "availability": true, "capacity": 1},
+			"geometry": {"type": "Point", "coordinates": [100.05, -35.0]}}
+	]}`
+	features, _, err := service.ImportZoneGeoJSON(zoneID, []byte(collection))
+	assert.NoError(t, err)
+	if assert.Len(t, features.Shelters, 1) {
+		assert.Equal(t, uint16(1), features.Shelters[0].Occupancy)
+	}
+
+	closest, _, err := service.GetClosestShelterInScope(trailID, zoneID, lat, long+0.05, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, shelterID, closest.Shelter.ShelterID)
+	assert.Equal(t, uint16(1), closest.Shelter.Occupancy)
+	assert.False(t, closest.Shelter.ShelterAvailability)
+
+	reservationMock.On("PublishShelterReservation", secondWorkout, shelterID, false, domain.ReservationReasonUnavailable, mock.Anything).Return(nil).Once()
+	_, err = service.ReserveShelter(secondWorkout, shelterID)
+	assert.ErrorIs(t, err, domain.ErrShelterUnavailable)
+	assert.NoError(t, service.ReleaseShelter(firstWorkout))
+	reservationMock.AssertExpectations(t)
+
+	service.DeleteShelter(shelterID)
+	service.DeleteTrail(trailID)
+	service.DeleteZone(zoneID)
+}
+
+func TestZoneService_ZoneGeoJSON_KeepsSchedule(t *testing.T) {
+	repo := postgres.NewRepository(cfg.Postgres)
+	service, _ := services.NewZoneService(repo, amqp.NewShelterDistancePublisherMock(), amqp.NewOffTrailPublisherMock(), amqp.NewShelterReservationPublisherMock(), amqp.NewGeofencePublisherMock(), clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), cfg.OffTrailThreshold, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
+
+	// The closest shelter is closed for the night, the other one is always open
+	lat, long := -36.0, 101.0
+	zoneID, _ := service.CreateZone(randomString(10))
+	trailID, _ := service.CreateTrail(randomString(10), zoneID, lat, long, lat, long+0.1)
+	nightShelterID, _ := service.CreateShelter(randomString(10), trailID, true, 0, lat, long+0.001)
+	otherShelterID, _ := service.CreateShelter(randomString(10), trailID, true, 0, lat, long+0.05)
+	evening := time.Date(2023, 11, 6, 22, 0, 0, 0, time.UTC)
+	closure, _ := domain.NewShelterClosure(evening, evening.Add(8*time.Hour), "night")
+	_, err := service.AddShelterClosure(nightShelterID, closure)
+	assert.NoError(t, err)
+
+	// Importing the shelter again, without a schedule in the file, keeps it closed at night
+	collection := `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {"shelter_id": "` + nightShelterID.String() + `", "trail_id": "` + trailID.String() + `", "name": "` + randomString(10) + `"},
+			"geometry": {"type": "Point", "coordinates": [101.001, -36.0]}}
+	]}`
+	features, _, err := service.ImportZoneGeoJSON(zoneID, []byte(collection))
+	assert.NoError(t, err)
+	if assert.Len(t, features.Shelters, 1) {
+		assert.Len(t, features.Shelters[0].Closures, 1)
+	}
+
+	night := evening.Add(4 * time.Hour)
+	closest, _, err := service.GetClosestShelterInScope(trailID, zoneID, lat, long, night)
+	assert.NoError(t, err)
+	assert.Equal(t, otherShelterID, closest.Shelter.ShelterID)
+
+	// It opens again in the morning
+	closest, _, err = service.GetClosestShelterInScope(trailID, zoneID, lat, long, evening.Add(9*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, nightShelterID, closest.Shelter.ShelterID)
+
+	service.DeleteShelter(nightShelterID)
+	service.DeleteShelter(otherShelterID)
+	service.DeleteTrail(trailID)
+	service.DeleteZone(zoneID)
+}
+
+func TestZoneService_ShelterReservation(t *testing.T) {
+	repo := postgres.NewRepository(cfg.Postgres)
+	publisherMock := amqp.NewShelterDistancePublisherMock()
+	reservationMock := amqp.NewShelterReservationPublisherMock()
+	service, _ := services.NewZoneService(repo, publisherMock, amqp.NewOffTrailPublisherMock(), reservationMock, amqp.NewGeofencePublisherMock(), clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), cfg.OffTrailThreshold, time.Minute, domain.DefaultGeofenceRadius)
+
+	zoneID, _ := service.CreateZone(randomString(10))
+	trailID, _ := service.CreateTrail(randomString(10), zoneID, -49.0, 60.0, -49.1, 60.1)
+	shelterID, err := service.CreateShelter(randomString(10), trailID, true, 1, -49.05, 60.05)
+	assert.NoError(t, err)
+
+	firstWorkout, secondWorkout := uuid.New(), uuid.New()
+	reservationMock.On("PublishShelterReservation", firstWorkout, shelterID, true, "", mock.Anything).Return(nil)
+	reservationMock.On("PublishShelterReservation", secondWorkout, shelterID, false, domain.ReservationReasonUnavailable, mock.Anything).Return(nil).Once()
+	reservationMock.On("PublishShelterReservation", secondWorkout, shelterID, true, "", mock.Anything).Return(nil).Once()
+	reservationMock.On("PublishShelterReservation", secondWorkout, shelterID, false, domain.ReservationReasonExpired, mock.Anything).Return(nil).Once()
+	reservationMock.On("PublishShelterReservation", firstWorkout, mock.Anything, false, domain.ReservationReasonNotFound, mock.Anything).Return(nil).Once()
+
+	// The single place is taken, the shelter is full for the next workout
+	_, err = service.ReserveShelter(firstWorkout, shelterID)
+	assert.NoError(t, err)
+	_, err = service.ReserveShelter(firstWorkout, shelterID)
+	assert.NoError(t, err)
+	_, err = service.ReserveShelter(secondWorkout, shelterID)
+	assert.ErrorIs(t, err, domain.ErrShelterUnavailable)
+
+	shelter, _ := service.GetShelterByID(shelterID)
+	assert.Equal(t, uint16(1), shelter.Occupancy)
+	assert.False(t, shelter.ShelterAvailability)
+	closest, _, err := service.GetClosestShelterInScope(trailID, zoneID, -49.05, 60.05, time.Now())
+	assert.NoError(t, err)
+	assert.False(t, closest.Shelter.ShelterAvailability)
+
+	// Releasing gives the place to the next workout, whose reservation expires
+	assert.NoError(t, service.ReleaseShelter(firstWorkout))
+	assert.NoError(t, service.ReleaseShelter(firstWorkout))
+	reservation, err := service.ReserveShelter(secondWorkout, shelterID)
+	assert.NoError(t, err)
+
+	expired, err := service.ExpireShelterReservations(reservation.ExpiresAt.Add(-time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, expired)
+	expired, err = service.ExpireShelterReservations(reservation.ExpiresAt.Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	shelter, _ = service.GetShelterByID(shelterID)
+	assert.Equal(t, uint16(0), shelter.Occupancy)
+	assert.True(t, shelter.ShelterAvailability)
+
+	// Unknown shelters are refused
+	_, err = service.ReserveShelter(firstWorkout, uuid.New())
+	assert.Error(t, err)
+
+	reservationMock.AssertExpectations(t)
+
+	service.DeleteShelter(shelterID)
+	service.DeleteTrail(trailID)
+	service.DeleteZone(zoneID)
+}
+
+func TestZoneService_ShelterSchedule(t *testing.T) {
+	repo := postgres.NewRepository(cfg.Postgres)
+	reservationMock := amqp.NewShelterReservationPublisherMock()
+	service, _ := services.NewZoneService(repo, amqp.NewShelterDistancePublisherMock(), amqp.NewOffTrailPublisherMock(), reservationMock, amqp.NewGeofencePublisherMock(), clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), cfg.OffTrailThreshold, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
+
+	zoneID, _ := service.CreateZone(randomString(10))
+	assert.ErrorIs(t, service.SetZoneTimeZone(zoneID, "Mars/Olympus"), domain.ErrInvalidTimeZone)
+	assert.NoError(t, service.SetZoneTimeZone(zoneID, "Asia/Tokyo"))
+	tokyo, _ := domain.LoadTimeZone("Asia/Tokyo")
+
+	// The closest shelter is only open on Monday mornings in Tokyo, the other one is always open
+	lat, long := -30.0, 90.0
+	trailID, _ := service.CreateTrail(randomString(10), zoneID, lat, long, lat, long+0.1)
+	morningShelterID, _ := service.CreateShelter(randomString(10), trailID, true, 0, lat, long+0.001)
+	otherShelterID, _ := service.CreateShelter(randomString(10), trailID, true, 0, lat, long+0.05)
+	mornings, _ := domain.NewOpeningHours(time.Monday, 8*60, 10*60)
+	_, err := service.SetShelterSchedule(morningShelterID, []domain.OpeningHours{mornings}, nil)
+	assert.NoError(t, err)
+
+	// 2023-11-06 is a Monday
+	mondayMorning := time.Date(2023, 11, 6, 9, 0, 0, 0, tokyo)
+	closest, _, err := service.GetClosestShelterInScope(trailID, zoneID, lat, long, mondayMorning)
+	assert.NoError(t, err)
+	assert.Equal(t, morningShelterID, closest.Shelter.ShelterID)
+
+	// The same time in UTC is the afternoon in Tokyo
+	mondayUTC := time.Date(2023, 11, 6, 9, 0, 0, 0, time.UTC)
+	closest, _, err = service.GetClosestShelterInScope(trailID, zoneID, lat, long, mondayUTC)
+	assert.NoError(t, err)
+	assert.Equal(t, otherShelterID, closest.Shelter.ShelterID)
+	assert.True(t, closest.Shelter.ShelterAvailability)
+
+	// With the other shelter closed for maintenance the closest one is flagged as unavailable
+	closure, _ := domain.NewShelterClosure(mondayUTC.Add(-time.Hour), mondayUTC.Add(time.Hour), "maintenance")
+	shelter, err := service.AddShelterClosure(otherShelterID, closure)
+	assert.NoError(t, err)
+	assert.Len(t, shelter.Closures, 1)
+	closest, _, err = service.GetClosestShelterInScope(trailID, zoneID, lat, long, mondayUTC)
+	assert.NoError(t, err)
+	assert.Equal(t, morningShelterID, closest.Shelter.ShelterID)
+	assert.False(t, closest.Shelter.ShelterAvailability)
+
+	// The schedule is stored, and removing the closure opens the shelter again
+	shelter, err = service.GetShelterByID(morningShelterID)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.OpeningHours{mornings}, shelter.OpeningHours)
+	_, err = service.RemoveShelterClosure(otherShelterID, uuid.New())
+	assert.ErrorIs(t, err, domain.ErrClosureNotFound)
+	_, err = service.RemoveShelterClosure(otherShelterID, closure.ClosureID)
+	assert.NoError(t, err)
+	closest, _, err = service.GetClosestShelterInScope(trailID, zoneID, lat, long, mondayUTC)
+	assert.NoError(t, err)
+	assert.Equal(t, otherShelterID, closest.Shelter.ShelterID)
+
+	// A closed shelter cannot be reserved, unless its schedule is removed. It opens two hours a week so
+	// it is closed unless the test runs on a Monday morning in Tokyo
+	workoutID := uuid.New()
+	if !service.IsShelterOpen(shelter, time.Now()) {
+		reservationMock.On("PublishShelterReservation", workoutID, morningShelterID, false, domain.ReservationReasonClosed, mock.Anything).Return(nil).Once()
+		_, err = service.ReserveShelter(workoutID, morningShelterID)
+		assert.ErrorIs(t, err, domain.ErrShelterClosed)
+	}
+	_, err = service.SetShelterSchedule(morningShelterID, nil, nil)
+	assert.NoError(t, err)
+	reservationMock.On("PublishShelterReservation", workoutID, morningShelterID, true, "", mock.Anything).Return(nil).Once()
+	_, err = service.ReserveShelter(workoutID, morningShelterID)
+	assert.NoError(t, err)
+	assert.NoError(t, service.ReleaseShelter(workoutID))
+	reservationMock.AssertExpectations(t)
+
+	service.DeleteShelter(morningShelterID)
+	service.DeleteShelter(otherShelterID)
+	service.DeleteTrail(trailID)
+	service.DeleteZone(zoneID)
+}
+
+func TestZoneService_RouteToShelter(t *testing.T) {
+	repo := postgres.NewRepository(cfg.Postgres)
+	service, _ := services.NewZoneService(repo, amqp.NewShelterDistancePublisherMock(), amqp.NewOffTrailPublisherMock(), amqp.NewShelterReservationPublisherMock(), amqp.NewGeofencePublisherMock(), clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), cfg.OffTrailThreshold, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
+
+	// Two trails joined at a corner, the shelter is at the end of the second one
+	lat, long := -10.0, 100.0
+	zoneID, _ := service.CreateZone(randomString(10))
+	firstTrailID, _ := service.CreateTrail(randomString(10), zoneID, lat, long, lat, long+0.01)
+	secondTrailID, _ := service.CreateTrail(randomString(10), zoneID, lat, long+0.01, lat+0.01, long+0.01)
+	shelterID, _ := service.CreateShelter(randomString(10), secondTrailID, true, 0, lat+0.01, long+0.01)
+
+	// The walk goes around the corner, longer than the straight line
+	route, err := service.RouteToShelter(zoneID, lat, long, shelterID)
+	assert.NoError(t, err)
+	straight := haversineDistance(lat, long, lat+0.01, long+0.01)
+	assert.Greater(t, route.Distance, straight)
+	assert.InDelta(t, haversineDistance(lat, long, lat, long+0.01)+haversineDistance(lat, long+0.01, lat+0.01, long+0.01), route.Distance, 0.001)
+	assert.Equal(t, domain.TrailPoint{Latitude: lat, Longitude: long + 0.01}, route.Path[1])
+
+	// A shortcut trail gives a shorter route once added
+	shortcutTrailID, _ := service.CreateTrail(randomString(10), zoneID, lat, long, lat+0.01, long+0.01)
+	route, err = service.RouteToShelter(zoneID, lat, long, shelterID)
+	assert.NoError(t, err)
+	assert.InDelta(t, straight, route.Distance, 0.001)
+
+	// The shelter must belong to the zone
+	otherZoneID, _ := service.CreateZone(randomString(10))
+	_, err = service.RouteToShelter(otherZoneID, lat, long, shelterID)
+	assert.ErrorIs(t, err, ports.ErrorShelterInOtherZone)
+	_, err = service.RouteToShelter(zoneID, lat, long, uuid.New())
+	assert.Error(t, err)
+
+	service.DeleteShelter(shelterID)
+	service.DeleteTrail(shortcutTrailID)
+	service.DeleteTrail(secondTrailID)
+	service.DeleteTrail(firstTrailID)
+	service.DeleteZone(otherZoneID)
+	service.DeleteZone(zoneID)
+}
+
+func TestZoneService_RecommendTrails(t *testing.T) {
+	repo := postgres.NewRepository(cfg.Postgres)
+	userMock := clients.NewUserServiceClientMock()
+	workoutMock := clients.NewWorkoutServiceClientMock()
+	service, _ := services.NewZoneService(repo, amqp.NewShelterDistancePublisherMock(), amqp.NewOffTrailPublisherMock(), amqp.NewShelterReservationPublisherMock(), amqp.NewGeofencePublisherMock(), userMock, workoutMock, cfg.OffTrailThreshold, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
+
+	// A flat trail of about 5 km with shelters and a flat trail of about 20 km without any
+	lat, long := -20.0, 120.0
+	zoneID, _ := service.CreateZone(randomString(10))
+	shortTrailID, _ := service.CreateTrail(randomString(10), zoneID, lat, long, lat, long+0.045)
+	longTrailID, _ := service.CreateTrail(randomString(10), zoneID, lat+0.1, long, lat+0.1, long+0.19)
+	shelterID, _ := service.CreateShelter(randomString(10), shortTrailID, true, 0, lat, long+0.02)
+
+	shortTrail, _ := service.GetTrailByID(shortTrailID)
+	longTrail, _ := service.GetTrailByID(longTrailID)
+	shortDifficulty, err := service.GetTrailDifficulty(shortTrail)
+	assert.NoError(t, err)
+	longDifficulty, err := service.GetTrailDifficulty(longTrail)
+	assert.NoError(t, err)
+	assert.Less(t, shortDifficulty, longDifficulty)
+
+	// A cardio player who usually runs about 18 km gets the long trail first
+	playerID := uuid.New()
+	userMock.On("GetPlayer", playerID).Return("cardio", 30, nil)
+	workoutMock.On("GetDistanceCovered", playerID, mock.Anything, mock.Anything).Return(36.0, 2, nil).Once()
+	recommendations, err := service.RecommendTrails(zoneID, playerID)
+	assert.NoError(t, err)
+	assert.Len(t, recommendations, 2)
+	assert.Equal(t, longTrailID, recommendations[0].Trail.TrailID)
+	assert.InDelta(t, longDifficulty, recommendations[0].Difficulty, 0.0001)
+
+	// Without past workouts, or when the workout service is down, a 5 km run is assumed
+	workoutMock.On("GetDistanceCovered", playerID, mock.Anything, mock.Anything).Return(0.0, 0, errors.New("connection refused")).Once()
+	recommendations, err = service.RecommendTrails(zoneID, playerID)
+	assert.NoError(t, err)
+	assert.Equal(t, shortTrailID, recommendations[0].Trail.TrailID)
+	workoutMock.AssertExpectations(t)
+
+	// Unknown players get no recommendations
+	unknownPlayerID := uuid.New()
+	userMock.On("GetPlayer", unknownPlayerID).Return("", 0, ports.ErrorPlayerNotFound)
+	_, err = service.RecommendTrails(zoneID, unknownPlayerID)
+	assert.ErrorIs(t, err, ports.ErrorPlayerNotFound)
+
+	service.DeleteShelter(shelterID)
+	service.DeleteTrail(shortTrailID)
+	service.DeleteTrail(longTrailID)
+	service.DeleteZone(zoneID)
+}
+
+func TestZoneService_Geofences(t *testing.T) {
+	repo := postgres.NewRepository(cfg.Postgres)
+	geofenceMock := amqp.NewGeofencePublisherMock()
+	service, _ := services.NewZoneService(repo, amqp.NewShelterDistancePublisherMock(), amqp.NewOffTrailPublisherMock(), amqp.NewShelterReservationPublisherMock(), geofenceMock, clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), cfg.OffTrailThreshold, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
+
+	// A trail of about 1 km with a shelter at its end
+	lat, long := -30.0, 140.0
+	zoneID, _ := service.CreateZone(randomString(10))
+	trailID, _ := service.CreateTrail(randomString(10), zoneID, lat, long, lat, long+0.01)
+	shelterID, _ := service.CreateShelter(randomString(10), trailID, true, 0, lat, long+0.01)
+	workoutID := uuid.New()
+	published := func(eventType string, sId uuid.UUID) interface{} {
+		return mock.MatchedBy(func(event *domain.GeofenceEvent) bool {
+			return event.Type == eventType && event.WorkoutID == workoutID && event.ShelterID == sId
+		})
+	}
+
+	// Entering the start of the trail is published once
+	geofenceMock.On("PublishGeofenceEvent", published(domain.GeofenceTrailStartEntered, uuid.Nil)).Return(nil).Once()
+	events, err := service.CheckGeofences(workoutID, trailID, lat, long, time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	events, err = service.CheckGeofences(workoutID, trailID, lat, long+0.0001, time.Now())
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	// Nothing happens along the trail
+	events, _ = service.CheckGeofences(workoutID, trailID, lat, long+0.005, time.Now())
+	assert.Empty(t, events)
+
+	// The end of the trail is also where the shelter is
+	geofenceMock.On("PublishGeofenceEvent", published(domain.GeofenceTrailEndReached, uuid.Nil)).Return(nil).Once()
+	geofenceMock.On("PublishGeofenceEvent", published(domain.GeofenceShelterArrived, shelterID)).Return(nil).Once()
+	events, _ = service.CheckGeofences(workoutID, trailID, lat, long+0.0099, time.Now())
+	assert.Len(t, events, 2)
+
+	// Walking away from the shelter
+	geofenceMock.On("PublishGeofenceEvent", published(domain.GeofenceShelterLeft, shelterID)).Return(nil).Once()
+	events, _ = service.CheckGeofences(workoutID, trailID, lat, long+0.005, time.Now())
+	assert.Len(t, events, 1)
+	geofenceMock.AssertExpectations(t)
+
+	// Unknown trails are refused
+	_, err = service.CheckGeofences(workoutID, uuid.New(), lat, long, time.Now())
+	assert.Error(t, err)
+
+	service.DeleteShelter(shelterID)
+	service.DeleteTrail(trailID)
+	service.DeleteZone(zoneID)
+}
+
+func TestZoneService_ZoneManagerSession(t *testing.T) {
+	repo := postgres.NewRepository(cfg.Postgres)
+	publisherMock := amqp.NewShelterDistancePublisherMock()
+	geofenceMock := amqp.NewGeofencePublisherMock()
+	service, _ := services.NewZoneService(repo, publisherMock, amqp.NewOffTrailPublisherMock(), amqp.NewShelterReservationPublisherMock(), geofenceMock, clients.NewUserServiceClientMock(), clients.NewWorkoutServiceClientMock(), cfg.OffTrailThreshold, cfg.ShelterReservationTTL, domain.DefaultGeofenceRadius)
+
+	// A trail in the Indian Ocean with a shelter along it
+	lat, long := -40.0, 80.0
+	zoneID, _ := service.CreateZone(randomString(10))
+	trailID, _ := service.CreateTrail(randomString(10), zoneID, lat, long, lat, long+0.1)
+	shelterID, _ := service.CreateShelter(randomString(10), trailID, true, 0, lat, long+0.05)
+	workoutID := uuid.New()
+	geofenceMock.On("PublishGeofenceEvent", mock.Anything).Return(nil)
+
+	// There is no session before the first location
+	_, err := service.GetZoneManager(workoutID)
+	assert.ErrorIs(t, err, ports.ErrorZoneManagerlNotFound)
+
+	// The first location opens the session on the trail and records the published shelter
+	publisherMock.On("PublishShelterDistance", workoutID, shelterID, mock.Anything, true, mock.Anything, "trail").Return(nil)
+	err = service.UpdateCurrentLocation(workoutID, trailID, lat, long, time.Now())
+	assert.NoError(t, err)
+	session, err := service.GetZoneManager(workoutID)
+	assert.NoError(t, err)
+	assert.Equal(t, trailID, session.CurrentTrailID)
+	assert.Equal(t, zoneID, session.ZoneID)
+	assert.Equal(t, shelterID, session.ShelterID)
+	assert.Equal(t, "trail", session.ShelterScope)
+	assert.False(t, session.IsClosed())
+
+	// The next location moves the same session
+	err = service.UpdateCurrentLocation(workoutID, trailID, lat, long+0.01, time.Now())
+	assert.NoError(t, err)
+	moved, _ := service.GetZoneManager(workoutID)
+	assert.Equal(t, session.ZoneManagerID, moved.ZoneManagerID)
+	assert.InDelta(t, long+0.01, moved.CurrentLongitude, 1e-9)
+
+	// Stopping the workout closes the session, later locations are dropped
+	endedAt := time.Now()
+	err = service.CloseZoneManager(workoutID, endedAt)
+	assert.NoError(t, err)
+	err = service.UpdateCurrentLocation(workoutID, trailID, lat, long+0.02, time.Now())
+	assert.NoError(t, err)
+	closed, _ := service.GetZoneManager(workoutID)
+	assert.True(t, closed.IsClosed())
+	assert.InDelta(t, long+0.01, closed.CurrentLongitude, 1e-9)
+	publisherMock.AssertNumberOfCalls(t, "PublishShelterDistance", 2)
+
+	// A workout without a session cannot be closed
+	err = service.CloseZoneManager(uuid.New(), endedAt)
+	assert.ErrorIs(t, err, ports.ErrorZoneManagerlNotFound)
+
+	service.DeleteShelter(shelterID)
+	service.DeleteTrail(trailID)
+	service.DeleteZone(zoneID)
+}
+
+func haversineDistance(fromLat, fromLon, toLat, toLon float64) float64 {
+	_, km := haversine.Distance(haversine.Coord{Lat: fromLat, Lon: fromLon}, haversine.Coord{Lat: toLat, Lon: toLon})
+	return km
+}